@@ -1,14 +1,20 @@
 package cmd
 
 import (
-	"github.com/jjtimmons/repp/internal/repp"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jjtimmons/repp/pkg/repp"
 	"github.com/spf13/cobra"
 )
 
 // annotateCmd is for finding features or enzymes by their name.
 var annotateCmd = &cobra.Command{
 	Use:                        "annotate [seq]",
-	Run:                        repp.Annotate,
+	Run:                        runAnnotate,
 	Short:                      "Annotate a plasmid using features",
 	SuggestionsMinimumDistance: 3,
 	Long: `Accepts a sequence file as input and runs alignment against the
@@ -26,13 +32,62 @@ func init() {
 	annotateCmd.Flags().StringP("in", "i", "", "input file name")
 	annotateCmd.Flags().StringP("out", "o", "", "output file name")
 	annotateCmd.Flags().StringP("exclude", "x", "", "keywords for excluding features")
-	annotateCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	annotateCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	annotateCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU repository")
 	annotateCmd.Flags().StringP("dbs", "d", "", "comma separated list of local fragment databases to consider as features")
 	annotateCmd.Flags().IntP("identity", "p", 96, "match %-identity threshold (see 'blastn -help')")
 	annotateCmd.Flags().BoolP("cull", "c", true, "remove features enclosed in others")
 	annotateCmd.Flags().BoolP("names", "n", false, "log feature names to the console")
+	databaseFlags(annotateCmd)
 
 	RootCmd.AddCommand(annotateCmd)
 }
+
+// runAnnotate annotates the plasmid passed as an argument or in the input file. If an
+// output path is provided, the annotated plasmid is writen to that file. Otherwise,
+// the feature matches are written to stdout.
+func runAnnotate(cmd *cobra.Command, args []string) {
+	req := repp.AnnotateRequest{Databases: databases(cmd, false)}
+	if len(args) > 0 {
+		req.Seq = args[0]
+	} else if req.In, _ = cmd.Flags().GetString("in"); req.In == "" {
+		cmd.Help()
+		fatalf("must pass a file with a plasmid sequence or the plasmid sequence as an argument")
+	}
+
+	exclude, _ := cmd.Flags().GetString("exclude")
+	cull, _ := cmd.Flags().GetBool("cull")
+	namesOnly, _ := cmd.Flags().GetBool("names")
+	req.Exclude = commaList(exclude)
+	req.Identity, _ = cmd.Flags().GetInt("identity")
+	req.Cull = &cull
+	if !namesOnly {
+		req.Out, _ = cmd.Flags().GetString("out")
+	}
+
+	annotated, err := repp.Annotate(context.Background(), req)
+	if err != nil {
+		fatal(err)
+	}
+
+	if namesOnly {
+		featuresNames := []string{}
+		for _, feature := range annotated.Features {
+			dir := ""
+			if !feature.Forward {
+				dir += ":rev"
+			}
+			featuresNames = append(featuresNames, feature.Name+dir)
+		}
+		fmt.Println(strings.Join(featuresNames, ", "))
+	} else if req.Out == "" {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+		fmt.Fprintf(tw, "\nfeatures (%d)\tstart\tend\tdirection\t\n", len(annotated.Features))
+		for _, feat := range annotated.Features {
+			dir := "FWD"
+			if !feat.Forward {
+				dir = "REV"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t\n", feat.Name, feat.Start+1, feat.End+1, dir)
+		}
+		tw.Flush()
+	}
+}
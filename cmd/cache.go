@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd is for managing the cache of results between designs
+var cacheCmd = &cobra.Command{
+	Use:                        "cache [clear]",
+	Short:                      "Manage the cache of results between designs",
+	SuggestionsMinimumDistance: 2,
+	Long: `Manage the cache of BLAST, primer and off-target results in ~/.repp/cache.
+
+Results are reused by later designs until a database or the settings they
+depend on change. The least recently used results are removed when the cache
+is larger than 'cache-max-size', in MB, in the settings file.`,
+}
+
+// cacheClearCmd is for removing every result in the cache
+var cacheClearCmd = &cobra.Command{
+	Use:                        "clear",
+	Short:                      "Remove every result in the cache",
+	Run:                        runCacheClear,
+	Args:                       cobra.NoArgs,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp cache clear",
+	Long:                       `Remove every BLAST, primer and off-target result in the cache.`,
+}
+
+// set flags
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	RootCmd.AddCommand(cacheCmd)
+}
+
+// runCacheClear removes every result in the cache.
+func runCacheClear(cmd *cobra.Command, args []string) {
+	size, err := repp.ClearCache()
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("cleared %.1f MB from the cache\n", float64(size)/(1<<20))
+}
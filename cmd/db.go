@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// dbCmd is for making and managing BLAST databases of fragments
+var dbCmd = &cobra.Command{
+	Use:                        "db [create,add,remove,list,info]",
+	Short:                      "Make and manage fragment databases",
+	SuggestionsMinimumDistance: 2,
+	Long: `Make BLAST databases of fragments from FASTA and Genbank files.
+
+Databases are registered by name, so they can be passed to --dbs without
+their paths, eg: 'repp make sequence --in plasmid.fa --dbs mylab'. Circular
+entries, from Genbank LOCUS lines or FASTA headers with "circular", are
+marked as circular. Their titles, from Genbank DEFINITION lines or the rest
+of FASTA headers, are matched by the --exclude flag.`,
+	Aliases: []string{"database"},
+}
+
+// dbCreateCmd is for making a new database
+var dbCreateCmd = &cobra.Command{
+	Use:                        "create [name] [files...]",
+	Short:                      "Make a database from FASTA and Genbank files",
+	Run:                        runDBCreate,
+	Args:                       cobra.MinimumNArgs(2),
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp db create mylab backbones.fa inserts.gb",
+	Long:                       `Make a database, with a name, from the sequences in FASTA and Genbank files.`,
+}
+
+// dbAddCmd is for adding entries to a database
+var dbAddCmd = &cobra.Command{
+	Use:                        "add [name] [files...]",
+	Short:                      "Add FASTA and Genbank files to a database",
+	Run:                        runDBAdd,
+	Args:                       cobra.MinimumNArgs(2),
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp db add mylab pSB1C3.gb",
+	Long: `Add the sequences in FASTA and Genbank files to a database. Sequences
+with the same ID as an entry in the database replace it.`,
+}
+
+// dbRemoveCmd is for removing entries from a database, or a whole database
+var dbRemoveCmd = &cobra.Command{
+	Use:                        "remove [name] [ids...]",
+	Short:                      "Remove entries from a database or a whole database",
+	Run:                        runDBRemove,
+	Args:                       cobra.MinimumNArgs(1),
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp db remove mylab pSB1C3\n  repp db remove mylab",
+	Long:                       `Remove entries, by ID, from a database. The whole database is removed if no IDs are passed.`,
+	Aliases:                    []string{"rm", "delete"},
+}
+
+// dbListCmd is for listing the databases
+var dbListCmd = &cobra.Command{
+	Use:                        "list",
+	Short:                      "List the databases",
+	Run:                        runDBList,
+	Args:                       cobra.NoArgs,
+	SuggestionsMinimumDistance: 2,
+	Long:                       `List the databases, with their number of entries and paths.`,
+	Aliases:                    []string{"ls"},
+}
+
+// dbInfoCmd is for logging a database's entries
+var dbInfoCmd = &cobra.Command{
+	Use:                        "info [name]",
+	Short:                      "Log the entries in a database",
+	Run:                        runDBInfo,
+	Args:                       cobra.ExactArgs(1),
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp db info mylab",
+	Long:                       `Log a database's path, inventory manifest and entries, with their lengths, topologies and titles.`,
+}
+
+// set flags
+func init() {
+	dbCmd.AddCommand(dbCreateCmd)
+	dbCmd.AddCommand(dbAddCmd)
+	dbCmd.AddCommand(dbRemoveCmd)
+	dbCmd.AddCommand(dbListCmd)
+	dbCmd.AddCommand(dbInfoCmd)
+
+	RootCmd.AddCommand(dbCmd)
+}
+
+// runDBCreate makes a database from the files.
+func runDBCreate(cmd *cobra.Command, args []string) {
+	db, err := repp.CreateDB(args[0], args[1:])
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("created %s with %d entries at %s\n", db.Name, len(db.Entries), db.Path)
+}
+
+// runDBAdd adds the files to a database.
+func runDBAdd(cmd *cobra.Command, args []string) {
+	db, err := repp.AddToDB(args[0], args[1:])
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("%s has %d entries\n", db.Name, len(db.Entries))
+}
+
+// runDBRemove removes entries from a database or the whole database.
+func runDBRemove(cmd *cobra.Command, args []string) {
+	db, err := repp.RemoveFromDB(args[0], args[1:])
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(args) == 1 {
+		fmt.Printf("removed %s\n", db.Name)
+		return
+	}
+	fmt.Printf("%s has %d entries\n", db.Name, len(db.Entries))
+}
+
+// runDBList logs the databases.
+func runDBList(cmd *cobra.Command, args []string) {
+	dbs, err := repp.ListDBs()
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(dbs) == 0 {
+		fmt.Println("there are no databases, make one with 'repp db create'")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintf(w, "name\tentries\tpath\n")
+	for _, db := range dbs {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", db.Name, len(db.Entries), db.Path)
+	}
+	w.Flush()
+}
+
+// runDBInfo logs a database's entries.
+func runDBInfo(cmd *cobra.Command, args []string) {
+	db, err := repp.ReadDB(args[0])
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("name: %s\npath: %s\nentries: %d\n", db.Name, db.Path, len(db.Entries))
+	if db.Inventory != "" {
+		fmt.Printf("inventory: %s\n", db.Inventory)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintf(w, "id\tlength\ttopology\ttitle\n")
+	for _, entry := range db.Entries {
+		topology := "linear"
+		if entry.Circular {
+			topology = "circular"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", entry.ID, len(entry.Seq), topology, entry.Title)
+	}
+	w.Flush()
+}
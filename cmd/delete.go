@@ -6,10 +6,10 @@ import (
 
 // deleteCmd is for finding features or enzymes by their name
 var deleteCmd = &cobra.Command{
-	Use:                        "delete [feature]",
-	Short:                      "Delete a feature",
+	Use:                        "delete [feature,primer]",
+	Short:                      "Delete a feature or primer",
 	SuggestionsMinimumDistance: 2,
-	Long:                       `Delete a feature, by name, from the embedded feature database.`,
+	Long:                       `Delete a feature, by name, from the embedded feature database or a primer from the primer inventory.`,
 	Aliases:                    []string{"rm", "remove"},
 }
 
@@ -17,16 +17,27 @@ var deleteCmd = &cobra.Command{
 var featuresDeleteCmd = &cobra.Command{
 	Use:                        "feature [name]",
 	Short:                      "Delete a feature from the features database",
-	Run:                        featureDB.DeleteCmd,
+	RunE:                       runFeatureDelete,
 	SuggestionsMinimumDistance: 2,
 	Example:                    "  repp delete feature \"T7 terminator\"",
 	Long: `Delete a feature from the features database by its name.
 If no such feature name exists in the database, an error is logged to stderr.`,
 }
 
+// primerDeleteCmd is for deleting primers from the primer inventory
+var primerDeleteCmd = &cobra.Command{
+	Use:                        "primer [name]",
+	Short:                      "Delete a primer from the primer inventory",
+	RunE:                       runPrimerDelete,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp delete primer oJT101",
+	Long:                       `Delete a primer from the primer inventory by its name.`,
+}
+
 // set flags
 func init() {
 	deleteCmd.AddCommand(featuresDeleteCmd)
+	deleteCmd.AddCommand(primerDeleteCmd)
 
 	RootCmd.AddCommand(deleteCmd)
 }
@@ -1,16 +1,21 @@
 package cmd
 
 import (
-	"github.com/jjtimmons/repp/internal/repp"
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jjtimmons/repp/pkg/repp"
 	"github.com/spf13/cobra"
 )
 
 // findCmd is for finding features or enzymes by their name.
 var findCmd = &cobra.Command{
 	Use:                        "find",
-	Short:                      "Find features or enzymes",
+	Short:                      "Find features, enzymes or primers",
 	SuggestionsMinimumDistance: 2,
-	Long: `Find features or enzymes by name.
+	Long: `Find features, enzymes or primers by name.
 If there is no exact match, similar entries are returned`,
 	Aliases: []string{"ls", "list", "get"},
 }
@@ -19,7 +24,7 @@ If there is no exact match, similar entries are returned`,
 var featureFindCmd = &cobra.Command{
 	Use:                        "feature [name]",
 	Short:                      "Find features in the features database",
-	Run:                        featureDB.ReadCmd,
+	RunE:                       runFeatureRead,
 	SuggestionsMinimumDistance: 2,
 	Example:                    "  repp find feature terminator",
 	Long: `Find features in the features database that are similar to [name].
@@ -34,7 +39,7 @@ Otherwise, all features with names similar to the feature name are writen to std
 var enzymeFindCmd = &cobra.Command{
 	Use:                        "enzyme [name]",
 	Short:                      "Find enzymes available for linearizing backbones",
-	Run:                        enzymeDB.ReadCmd,
+	RunE:                       runEnzymeRead,
 	Example:                    "  repp find enzyme EcoRI",
 	SuggestionsMinimumDistance: 2,
 	Long: `List out all the enzymes with the same or a similar a similar name as the argument.
@@ -43,12 +48,26 @@ var enzymeFindCmd = &cobra.Command{
 	Aliases: []string{"enzymes"},
 }
 
+// primerFindCmd is for finding primers in the primer inventory by their name or sequence.
+var primerFindCmd = &cobra.Command{
+	Use:                        "primer [name]",
+	Short:                      "Find primers in the primer inventory",
+	RunE:                       runPrimerRead,
+	Example:                    "  repp find primer oJT101",
+	SuggestionsMinimumDistance: 2,
+	Long: `Find primers in the primer inventory with the same or a similar name as the
+argument. A primer's sequence can also be passed to find its name.
+
+'repp find primer' without any arguments logs all the primers in the inventory.`,
+	Aliases: []string{"primers"},
+}
+
 // fragmentFindCmd is for finding a fragment by its name
 var fragmentFindCmd = &cobra.Command{
 	Use:                        "fragment [name]",
 	Short:                      "Find a fragment in the databases",
 	Example:                    "  repp find fragment pSB1C3 --igem",
-	Run:                        repp.FragmentFindCmd,
+	Run:                        runFragmentFind,
 	SuggestionsMinimumDistance: 2,
 	Long:                       `Find a fragment with a given name in the databases requested.`,
 }
@@ -57,7 +76,7 @@ var fragmentFindCmd = &cobra.Command{
 var sequenceFindCmd = &cobra.Command{
 	Use:                        "sequence [seq]",
 	Short:                      "Find a sequence in the databases",
-	Run:                        repp.SequenceFindCmd,
+	Run:                        runSequenceFind,
 	Example:                    "  repp find sequence GTTGACAATTAATCATCGGCATAGTATATCGGCATAGTATAATACGAC --igem",
 	SuggestionsMinimumDistance: 2,
 	Long:                       `Find a sequence's BLAST matches among databases.`,
@@ -67,21 +86,64 @@ var sequenceFindCmd = &cobra.Command{
 // set flags
 func init() {
 	fragmentFindCmd.Flags().StringP("dbs", "d", "", "comma separated list of local fragment databases")
-	fragmentFindCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	fragmentFindCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	fragmentFindCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU respository")
 
 	sequenceFindCmd.Flags().StringP("dbs", "d", "", "comma separated list of local fragment databases")
-	sequenceFindCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	sequenceFindCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	sequenceFindCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU respository")
 	sequenceFindCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
 	sequenceFindCmd.Flags().IntP("identity", "t", 100, "match %-identity threshold (see 'blastn -help')")
+	databaseFlags(fragmentFindCmd)
+	databaseFlags(sequenceFindCmd)
 
 	findCmd.AddCommand(featureFindCmd)
 	findCmd.AddCommand(enzymeFindCmd)
 	findCmd.AddCommand(fragmentFindCmd)
 	findCmd.AddCommand(sequenceFindCmd)
+	findCmd.AddCommand(primerFindCmd)
 
 	RootCmd.AddCommand(findCmd)
 }
+
+// runFragmentFind logs the building fragment with the name passed.
+func runFragmentFind(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.Help()
+		fatalf("no fragment name passed")
+	}
+
+	frag, err := repp.FindFragment(context.Background(), repp.FindFragmentRequest{
+		Databases: databases(cmd, true),
+		Name:      args[0],
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("%s\t%s\n%s\n", frag.ID, frag.DB, frag.Seq)
+}
+
+// runSequenceFind logs the BLAST matches of the sequence passed in the dbs.
+func runSequenceFind(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.Help()
+		fatalf("no sequence passed")
+	}
+
+	exclude, _ := cmd.Flags().GetString("exclude")
+	identity, _ := cmd.Flags().GetInt("identity")
+
+	matches, err := repp.FindSequence(context.Background(), repp.FindSequenceRequest{
+		Databases: databases(cmd, true),
+		Seq:       args[0],
+		Exclude:   commaList(exclude),
+		Identity:  identity,
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(writer, "entry\tqstart\tqend\tsstart\tsend\tdatabase\tURL\t\n")
+	for _, m := range matches {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", m.Entry, m.QueryStart, m.QueryEnd, m.SubjectStart, m.SubjectEnd, m.DB, m.URL)
+	}
+	writer.Flush()
+}
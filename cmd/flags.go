@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// registry is the registry of fragment databases, each of which is a flag
+var registry = config.Registry()
+
+// databaseFlags adds a flag for each database in the registry to the command, eg
+// --addgene. Databases with the name or shorthand of another flag are skipped, so
+// they're added after the command's other flags.
+func databaseFlags(cmd *cobra.Command) {
+	for _, d := range registry {
+		if cmd.Flags().Lookup(d.Name) != nil {
+			continue
+		}
+
+		shorthand := d.Shorthand
+		if len(shorthand) != 1 || cmd.Flags().ShorthandLookup(shorthand) != nil {
+			shorthand = ""
+		}
+		cmd.Flags().BoolP(d.Name, shorthand, false, fmt.Sprintf("use the %s database", d.Name))
+	}
+}
+
+// databases parses the database flags. If none are set and useDefault is true,
+// the databases in the registry that aren't internal are used.
+func databases(cmd *cobra.Command, useDefault bool) repp.Databases {
+	dbString, _ := cmd.Flags().GetString("dbs")
+	dbs := commaList(dbString)
+
+	for _, d := range registry {
+		if use, err := cmd.Flags().GetBool(d.Name); err == nil && use {
+			dbs = append(dbs, d.Name)
+		}
+	}
+
+	if useDefault && len(dbs) == 0 {
+		for _, d := range registry {
+			if !d.Internal {
+				dbs = append(dbs, d.Name)
+			}
+		}
+		logger.Info("no fragment databases chosen, using the defaults", "dbs", strings.Join(dbs, ", "))
+	}
+
+	return repp.Databases{Dbs: dbs}
+}
+
+// commaList converts a comma separated list of strings into a list of strings.
+func commaList(commaList string) (list []string) {
+	for _, item := range strings.Split(commaList, ",") {
+		if item = strings.Trim(item, " ,"); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// inputFile returns the path to the input file from the "in" flag. If it's unset, the
+// first FASTA file in the current directory is used.
+func inputFile(cmd *cobra.Command) string {
+	if in, err := cmd.Flags().GetString("in"); err == nil && in != "" {
+		return in
+	}
+
+	in, err := guessInput()
+	if err != nil {
+		cmd.Help()
+		fatal(err)
+	}
+
+	return in
+}
+
+// outputFile returns the path to the output file from the "out" flag. If it's
+// unset, it's guessed from the input file's path.
+func outputFile(cmd *cobra.Command, in string) string {
+	if out, err := cmd.Flags().GetString("out"); err == nil && out != "" {
+		return out
+	}
+
+	return guessOutput(in)
+}
+
+// guessInput returns the first fasta file in the current directory. Is used
+// if the user hasn't specified an input file.
+func guessInput() (in string, err error) {
+	dir, _ := filepath.Abs(".")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if ext == ".fa" || ext == ".fasta" {
+			return file.Name(), nil
+		}
+	}
+
+	return "", fmt.Errorf("failed: no input argument set and no fasta file found in %s", dir)
+}
+
+// guessOutput gets an outpath path from an input path (if no output path is
+// specified). It uses the same name as the input path to create an output.
+func guessOutput(in string) (out string) {
+	ext := filepath.Ext(in)
+	noExt := in[0 : len(in)-len(ext)]
+	return noExt + ".output.json"
+}
+
+// featureNames splits the arguments to the features command into feature names.
+// Features are comma separated if any argument has a comma and space separated otherwise.
+func featureNames(args []string) (names []string) {
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, ",") {
+		return strings.Fields(joined)
+	}
+
+	for _, name := range strings.Split(joined, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_guessOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantOut string
+	}{
+		{
+			"parse relative path to neighboring output path",
+			"./test_file.fa",
+			"./test_file.output.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotOut := guessOutput(tt.in); gotOut != tt.wantOut {
+				t.Errorf("guessOutput() = %v, want %v", gotOut, tt.wantOut)
+			}
+		})
+	}
+}
+
+func Test_featureNames(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			"comma separated features with spaces",
+			[]string{"p10", "promoter,", "mEGFP,T7", "terminator"},
+			[]string{"p10 promoter", "mEGFP", "T7 terminator"},
+		},
+		{
+			"space separated features",
+			[]string{"BBa_R0062", "BBa_B0034"},
+			[]string{"BBa_R0062", "BBa_B0034"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := featureNames(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("featureNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
 	"github.com/jjtimmons/repp/config"
-	"github.com/jjtimmons/repp/internal/repp"
+	"github.com/jjtimmons/repp/pkg/repp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -11,6 +19,23 @@ var (
 	backboneHelp = `backbone to insert the fragments into. Can either be an entry 
 in one of the dbs or a file on the local filesystem.`
 
+	formatHelp = `output format: json, genbank or sbol. With genbank, each solution
+is written to its own Genbank file beside the output path. With sbol,
+an SBOL3 document is written as JSON-LD beside the output path.`
+
+	explainHelp = `path to write a report of every candidate assembly to, with its
+estimated cost and why it failed or was pruned. HTML if the path
+ends with .html and JSON otherwise.`
+
+	objectiveHelp = `objective that the solutions with each fragment count are ranked by:
+cost, synthesis (fewest synthesized bp), pcr (fewest PCR reactions),
+repositories (fewest to order from), inventory (most in-house fragments),
+turnaround (fewest days to the plasmid) or weighted, by the
+objective-weights in the settings. The objective in the settings if unset.`
+
+	timeoutHelp = `maximum time to design for, eg 10m. When it runs out, or on Ctrl-C,
+the best solutions found so far are written. No limit if 0.`
+
 	enzymeHelp = `comma separated list of enzymes to linearize the backbone with.
 The backbone must be specified. 'repp ls enzymes' prints a list of
 recognized enzymes.`
@@ -30,7 +55,7 @@ against a list of consituent fragment, feature, or a target sequence.`,
 var fragmentsCmd = &cobra.Command{
 	Use:                        "fragments",
 	Short:                      "Build a plasmid from its constituent fragments",
-	Run:                        repp.FragmentsCmd,
+	Run:                        runFragments,
 	SuggestionsMinimumDistance: 3,
 	Long: `Prepare a list of fragments for assembly via Gibson Assembly. Fragments are
 checked for existing homology with their neighbors and are prepared for
@@ -41,7 +66,7 @@ assembly with PCR.`,
 var featuresCmd = &cobra.Command{
 	Use:                        "features \"[feature],...[featureN]\"",
 	Short:                      "Find or build a plasmid from its constituent features",
-	Run:                        repp.FeaturesCmd,
+	Run:                        runFeatures,
 	SuggestionsMinimumDistance: 3,
 	Example:                    `repp make features "BBa_R0062,BBa_B0034,BBa_C0040,BBa_B0010,BBa_B0012" --backbone pSB1C3 --enzymes "EcoRI,PstI" --igem`,
 }
@@ -50,12 +75,32 @@ var featuresCmd = &cobra.Command{
 var sequenceCmd = &cobra.Command{
 	Use:                        "sequence",
 	Short:                      "Find or build a plasmid from its target sequence",
-	Run:                        repp.SequenceCmd,
+	Run:                        runSequence,
 	SuggestionsMinimumDistance: 2,
 	Long: `Build up a plasmid from its target sequence using a combination of existing and
 synthesized fragments.
 
-Solutions have either a minimum fragment count or assembly cost (or both).`,
+Solutions have either a minimum fragment count or assembly cost (or both).
+With '--objective' solutions are ranked by synthesized bp, PCR reactions, the
+repositories ordered from, in-house fragments, days to the plasmid or a weighted
+sum, rather than by cost. With '--solutions' that many are returned for each
+fragment count. Each solution has an estimate of the days from ordering its
+fragments and primers to a verified plasmid.
+
+Fragments are assembled via Gibson Assembly by default. With '--method goldengate'
+fragments are instead flanked by Type IIS sites and joined by unique 4bp overhangs.
+
+With '--format genbank' each solution is written to a Genbank file, annotated with
+its fragments and their sources, primer binding sites, the junctions between
+fragments, and the backbone's cut sites.
+With '--format sbol' the target, solutions, fragments and primers are written
+as SBOL3 Components, with the fragments' sources as their provenance.
+
+With '--batch' every sequence in the input file is designed, '--parallel' at a time.
+The designs are written to a single output file or, with '--split', to a file per
+target. The targets are planned together as a library: each target's solution is
+chosen so that plasmids, primers, PCR products and synthetic fragments are shared
+between targets, and paid for once, where possible.`,
 	Aliases: []string{"seq", "plasmid"},
 	Example: `repp make sequence -i "./target_plasmid.fa --addgene --dbs "part_library.fa"`,
 }
@@ -63,37 +108,46 @@ Solutions have either a minimum fragment count or assembly cost (or both).`,
 // set flags
 func init() {
 	// Flags for specifying the paths to the input file, input fragment files, and output file
-	fragmentsCmd.Flags().StringP("in", "i", "", "input file name (FASTA or Genbank)")
+	fragmentsCmd.Flags().StringP("in", "i", "", "input file name (FASTA, Genbank or SBOL)")
 	fragmentsCmd.Flags().StringP("out", "o", "", "output file name (FASTA)")
+	fragmentsCmd.Flags().StringP("format", "f", "json", formatHelp)
 	fragmentsCmd.Flags().StringP("dbs", "d", "", "comma separated list of local fragment databases")
-	fragmentsCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	fragmentsCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	fragmentsCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU repository")
 	fragmentsCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	fragmentsCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 
 	// Flags for specifying the paths to the input file, input fragment files, and output file
 	featuresCmd.Flags().StringP("out", "o", "", "output file name")
+	featuresCmd.Flags().StringP("format", "f", "json", formatHelp)
 	featuresCmd.Flags().StringP("dbs", "d", "", "comma separated list of local fragment databases")
-	featuresCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	featuresCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	featuresCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU repository")
 	featuresCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	featuresCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 	featuresCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
 	featuresCmd.Flags().IntP("identity", "p", 98, "%-identity threshold (see 'blastn -help')")
+	featuresCmd.Flags().String("explain", "", explainHelp)
+	featuresCmd.Flags().String("objective", "", objectiveHelp)
+	featuresCmd.Flags().Int("solutions", 1, "number of solutions to return for each fragment count")
 
 	// Flags for specifying the paths to the input file, input fragment files, and output file
-	sequenceCmd.Flags().StringP("in", "i", "", "input file name (FASTA or Genbank)")
+	sequenceCmd.Flags().StringP("in", "i", "", "input file name (FASTA, Genbank or SBOL)")
 	sequenceCmd.Flags().StringP("out", "o", "", "output file name")
+	sequenceCmd.Flags().StringP("format", "f", "json", formatHelp)
 	sequenceCmd.Flags().StringP("dbs", "d", "", "list of local fragment databases")
-	sequenceCmd.Flags().BoolP("addgene", "a", false, "use the Addgene repository")
-	sequenceCmd.Flags().BoolP("igem", "g", false, "use the iGEM repository")
-	sequenceCmd.Flags().BoolP("dnasu", "u", false, "use the DNASU repository")
 	sequenceCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	sequenceCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 	sequenceCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
 	sequenceCmd.Flags().IntP("identity", "p", 98, "%-identity threshold (see 'blastn -help')")
+	sequenceCmd.Flags().StringP("method", "m", "gibson", "assembly method: gibson or goldengate")
+	sequenceCmd.Flags().Bool("batch", false, "design every sequence in the input file")
+	sequenceCmd.Flags().IntP("parallel", "j", 4, "number of sequences to design at a time with --batch")
+	sequenceCmd.Flags().Bool("split", false, "write each sequence's design to its own file with --batch")
+	sequenceCmd.Flags().String("explain", "", explainHelp)
+	sequenceCmd.Flags().String("objective", "", objectiveHelp)
+	sequenceCmd.Flags().Int("solutions", 1, "number of solutions to return for each fragment count")
+
+	// Flags for the databases in the registry, eg --addgene
+	databaseFlags(fragmentsCmd)
+	databaseFlags(featuresCmd)
+	databaseFlags(sequenceCmd)
 
 	makeCmd.AddCommand(fragmentsCmd)
 	makeCmd.AddCommand(featuresCmd)
@@ -101,9 +155,231 @@ func init() {
 
 	// settings is an optional parameter for a settings file (that overrides the fields in BaseSettingsFile)
 	makeCmd.PersistentFlags().StringP("settings", "s", config.RootSettingsFile, "build settings")
-	makeCmd.PersistentFlags().BoolP("verbose", "v", false, "whether to log results to stdout")
+	makeCmd.PersistentFlags().BoolP("verbose", "v", false, "whether to log debug messages to stderr")
+	makeCmd.PersistentFlags().Duration("timeout", 0, timeoutHelp)
 	viper.BindPFlag("settings", makeCmd.PersistentFlags().Lookup("settings"))
 	viper.BindPFlag("verbose", makeCmd.PersistentFlags().Lookup("verbose"))
 
 	RootCmd.AddCommand(makeCmd)
 }
+
+// runFragments assembles the fragments in the input file, in order.
+func runFragments(cmd *cobra.Command, args []string) {
+	in := inputFile(cmd)
+	backbone, _ := cmd.Flags().GetString("backbone")
+	enzymes, _ := cmd.Flags().GetString("enzymes")
+
+	format, _ := cmd.Flags().GetString("format")
+
+	ctx, stop := designContext(cmd)
+
+	_, err := repp.Fragments(ctx, repp.FragmentsRequest{
+		Databases: databases(cmd, true),
+		In:        in,
+		Out:       outputFile(cmd, in),
+		Format:    format,
+		Backbone:  backbone,
+		Enzymes:   commaList(enzymes),
+	}, config.New())
+	stop()
+	checkDesign(cmd, err, false)
+}
+
+// runFeatures builds a plasmid with the features passed as arguments.
+func runFeatures(cmd *cobra.Command, args []string) {
+	names := featureNames(args)
+	if len(names) < 1 {
+		cmd.Help()
+		fatalf("no features passed")
+	}
+
+	backbone, _ := cmd.Flags().GetString("backbone")
+	enzymes, _ := cmd.Flags().GetString("enzymes")
+	exclude, _ := cmd.Flags().GetString("exclude")
+	identity, _ := cmd.Flags().GetInt("identity")
+	format, _ := cmd.Flags().GetString("format")
+	explain, _ := cmd.Flags().GetString("explain")
+	objective, _ := cmd.Flags().GetString("objective")
+	solutions, _ := cmd.Flags().GetInt("solutions")
+
+	ctx, stop := designContext(cmd)
+
+	out, err := repp.Features(ctx, repp.FeaturesRequest{
+		Databases: databases(cmd, true),
+		Features:  names,
+		Out:       outputFile(cmd, strings.Join(names, ",")),
+		Format:    format,
+		Explain:   explain,
+		Objective: objective,
+		Solutions: solutions,
+		Backbone:  backbone,
+		Enzymes:   commaList(enzymes),
+		Exclude:   commaList(exclude),
+		Identity:  identity,
+	}, config.New())
+	stop()
+	checkDesign(cmd, err, out != nil)
+}
+
+// runSequence builds a plasmid from the target sequence in the input file or, if
+// there is one, the argument.
+func runSequence(cmd *cobra.Command, args []string) {
+	if batch, _ := cmd.Flags().GetBool("batch"); batch {
+		runSequenceBatch(cmd)
+		return
+	}
+
+	req := repp.SequenceRequest{Databases: databases(cmd, true)}
+	if in, _ := cmd.Flags().GetString("in"); in == "" && len(args) > 0 {
+		req.Name = "target_sequence"
+		req.Seq = args[0]
+		req.Out = outputFile(cmd, req.Name)
+	} else {
+		req.In = inputFile(cmd)
+		req.Out = outputFile(cmd, req.In)
+	}
+
+	enzymes, _ := cmd.Flags().GetString("enzymes")
+	exclude, _ := cmd.Flags().GetString("exclude")
+	req.Backbone, _ = cmd.Flags().GetString("backbone")
+	req.Enzymes = commaList(enzymes)
+	req.Exclude = commaList(exclude)
+	req.Identity, _ = cmd.Flags().GetInt("identity")
+	req.Method, _ = cmd.Flags().GetString("method")
+	req.Format, _ = cmd.Flags().GetString("format")
+	req.Explain, _ = cmd.Flags().GetString("explain")
+	req.Objective, _ = cmd.Flags().GetString("objective")
+	req.Solutions, _ = cmd.Flags().GetInt("solutions")
+
+	ctx, stop := designContext(cmd)
+
+	out, err := repp.Sequence(ctx, req, config.New())
+	stop()
+	checkDesign(cmd, err, out != nil)
+}
+
+// runSequenceBatch builds a plasmid for every target sequence in the input file and
+// logs a summary of each target's cheapest design.
+func runSequenceBatch(cmd *cobra.Command) {
+	req := repp.SequenceBatchRequest{Databases: databases(cmd, true)}
+	req.In = inputFile(cmd)
+	req.Out = outputFile(cmd, req.In)
+
+	enzymes, _ := cmd.Flags().GetString("enzymes")
+	exclude, _ := cmd.Flags().GetString("exclude")
+	req.Backbone, _ = cmd.Flags().GetString("backbone")
+	req.Enzymes = commaList(enzymes)
+	req.Exclude = commaList(exclude)
+	req.Identity, _ = cmd.Flags().GetInt("identity")
+	req.Method, _ = cmd.Flags().GetString("method")
+	req.Parallel, _ = cmd.Flags().GetInt("parallel")
+	req.Split, _ = cmd.Flags().GetBool("split")
+	req.Format, _ = cmd.Flags().GetString("format")
+	req.Objective, _ = cmd.Flags().GetString("objective")
+	req.Solutions, _ = cmd.Flags().GetInt("solutions")
+
+	ctx, stop := designContext(cmd)
+
+	batch, err := repp.SequenceBatch(ctx, req, config.New())
+	stop()
+	checkDesign(cmd, err, batch != nil)
+
+	failed := 0
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(writer, "target\tsolution\tfragments\tcost\tshared\terror\t\n")
+	for _, t := range batch.Targets {
+		if t.Error != "" {
+			failed++
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%.2f\t%d\t%s\n", t.Target, t.Solution+1, t.Count, t.Cost, t.Shared, t.Error)
+	}
+	writer.Flush()
+	fmt.Printf(
+		"\n%d targets, %d shared items, $%.2f library cost ($%.2f if designed separately)\n",
+		len(batch.Targets),
+		len(batch.Shared),
+		batch.Cost,
+		batch.IsolatedCost,
+	)
+
+	if failed == len(batch.Targets) {
+		fatalf("failed to design any of the targets")
+	}
+}
+
+// designContext returns the context of a design. It's cancelled on Ctrl-C or when the
+// timeout runs out. On a terminal, and without --verbose, the design's progress is
+// shown until stop is called. A second Ctrl-C exits immediately.
+func designContext(cmd *cobra.Command) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelTimeout := func() {}
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+			signal.Stop(interrupt)
+		case <-ctx.Done():
+		}
+	}()
+
+	clear := func() {}
+	if verbose, _ := cmd.Flags().GetBool("verbose"); !verbose && isTerminal(os.Stderr) {
+		ctx = repp.WithProgress(ctx, printProgress)
+		clear = func() { fmt.Fprint(os.Stderr, "\r\033[K") }
+	}
+
+	return ctx, func() {
+		cancelTimeout()
+		cancel()
+		signal.Stop(interrupt)
+		clear()
+	}
+}
+
+// printProgress writes a design's progress over the last line of stderr.
+func printProgress(p repp.Progress) {
+	line := "searching the databases"
+	switch p.Stage {
+	case "build":
+		line = fmt.Sprintf("%d fragments found, building assemblies", p.Fragments)
+	case "fill":
+		line = fmt.Sprintf("%d fragments found, %d assemblies built, %d filled", p.Fragments, p.Assemblies, p.Filled)
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+}
+
+// checkDesign exits with a design's error. If the design was cancelled or timed out
+// after solutions were found, they were written and it only warns.
+func checkDesign(cmd *cobra.Command, err error, found bool) {
+	if err == nil {
+		return
+	}
+
+	reason := ""
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		reason = fmt.Sprintf("timed out after %s", timeout)
+	case errors.Is(err, context.Canceled):
+		reason = "interrupted"
+	default:
+		fatal(err)
+	}
+
+	if !found {
+		fatal(fmt.Errorf("%s before a solution was found: %w", reason, err))
+	}
+	logger.Warn(reason + ", the best solutions found so far were written")
+}
+
+// isTerminal returns whether the file is a terminal rather than a pipe or regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
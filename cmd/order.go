@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// orderCmd is for writing vendor order sheets for a design's primers and synthetic fragments.
+var orderCmd = &cobra.Command{
+	Use:                        "order [output.json]",
+	Short:                      "Write order sheets for primers and synthetic fragments",
+	Run:                        runOrder,
+	Args:                       cobra.ExactArgs(1),
+	SuggestionsMinimumDistance: 3,
+	Example:                    "  repp order plasmid.output.json --format xlsx --layout plate",
+	Long: `Accepts the JSON output of a design, or of a batch, and writes order
+sheets for its primers and synthetic fragments. Batches order the chosen
+solution of every target.
+
+Identical oligos and fragments are ordered once. They're named with the
+order-primer-name and order-synthetic-name templates in the settings file,
+where {target} is the first plasmid to use them and {n} is their number.
+Scales and purifications are also read from the settings file.
+
+As CSV, the primers and synthetic fragments are written to separate sheets,
+eg "plasmid.output.order.primers.csv" and "plasmid.output.order.synthetics.csv".
+As XLSX, they're written to a single workbook with a sheet for each.`,
+}
+
+// set flags
+func init() {
+	orderCmd.Flags().IntP("solution", "s", 1, "solution to order, 1-indexed. Batches order their chosen solutions")
+	orderCmd.Flags().StringP("out", "o", "", "output file name stem, beside the input if empty")
+	orderCmd.Flags().StringP("format", "f", "", "output format: csv or xlsx, order-format in the settings if empty")
+	orderCmd.Flags().StringP("layout", "l", "", "oligos in tubes or 96-well plates: tube or plate, order-layout in the settings if empty")
+	orderCmd.Flags().String("settings", config.RootSettingsFile, "settings file with the order names, scales and purifications")
+
+	RootCmd.AddCommand(orderCmd)
+}
+
+// runOrder writes the order sheets for the output file passed as an argument.
+func runOrder(cmd *cobra.Command, args []string) {
+	req := repp.OrderRequest{In: args[0]}
+	req.Solution, _ = cmd.Flags().GetInt("solution")
+	req.Out, _ = cmd.Flags().GetString("out")
+	req.Format, _ = cmd.Flags().GetString("format")
+	req.Layout, _ = cmd.Flags().GetString("layout")
+	settings, _ := cmd.Flags().GetString("settings")
+
+	conf, err := config.Load(settings)
+	if err != nil {
+		fatal(err)
+	}
+
+	order, err := repp.WriteOrder(context.Background(), req, conf)
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("%d primers and %d synthetic fragments\n", len(order.Primers), len(order.Synthetics))
+	for _, file := range order.Files {
+		fmt.Println(file)
+	}
+}
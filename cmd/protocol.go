@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// protocolCmd is for writing the bench protocol of a design's solution.
+var protocolCmd = &cobra.Command{
+	Use:                        "protocol [output.json]",
+	Short:                      "Write a step-by-step protocol for a solution",
+	Run:                        runProtocol,
+	Args:                       cobra.ExactArgs(1),
+	SuggestionsMinimumDistance: 3,
+	Example:                    "  repp protocol plasmid.output.json --solution 2 --format csv --out protocol.csv",
+	Long: `Accepts the JSON output of a design and writes the protocol for one of
+its solutions:
+
+  1. the repository plasmids to order
+  2. the primers to order
+  3. the synthetic fragments to order
+  4. the digestion of the backbone with its enzymes
+  5. the PCR reactions, with their templates, primers, product sizes
+     and annealing temperatures
+  6. the assembly, with the amount of each fragment
+
+The protocol is written as Markdown or as CSV.`,
+}
+
+// set flags
+func init() {
+	protocolCmd.Flags().IntP("solution", "s", 1, "solution to write the protocol for, 1-indexed")
+	protocolCmd.Flags().StringP("format", "f", "markdown", "output format: markdown or csv")
+	protocolCmd.Flags().StringP("out", "o", "", "output file name, stdout if empty")
+
+	RootCmd.AddCommand(protocolCmd)
+}
+
+// runProtocol writes the protocol for a solution in the output file passed as an argument.
+func runProtocol(cmd *cobra.Command, args []string) {
+	solution, _ := cmd.Flags().GetInt("solution")
+	format, _ := cmd.Flags().GetString("format")
+	outPath, _ := cmd.Flags().GetString("out")
+
+	out, err := repp.ReadOutput(args[0])
+	if err != nil {
+		fatal(err)
+	}
+
+	protocol, err := repp.NewProtocol(out, solution)
+	if err != nil {
+		fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "markdown", "md":
+		err = protocol.Markdown(w)
+	case "csv":
+		err = protocol.CSV(w)
+	default:
+		err = fmt.Errorf("unknown protocol format %s, expecting markdown or csv", format)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
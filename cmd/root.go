@@ -2,16 +2,28 @@
 package cmd
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/jjtimmons/repp/internal/repp"
 	"github.com/spf13/cobra"
 )
 
 var (
-	featureDB = repp.NewFeatureDB()
+	// logger is for logging warnings to stderr, it's set up by the root command
+	logger = slog.Default()
 
-	enzymeDB = repp.NewEnzymeDB()
+	runFeatureRead   = featureCmd((*repp.FeatureDB).ReadCmd)
+	runFeatureSet    = featureCmd((*repp.FeatureDB).SetCmd)
+	runFeatureDelete = featureCmd((*repp.FeatureDB).DeleteCmd)
+
+	runEnzymeRead = enzymeCmd((*repp.EnzymeDB).ReadCmd)
+	runEnzymeSet  = enzymeCmd((*repp.EnzymeDB).SetCmd)
+
+	runPrimerRead   = primerCmd((*repp.PrimerDB).ReadCmd)
+	runPrimerSet    = primerCmd((*repp.PrimerDB).SetCmd)
+	runPrimerDelete = primerCmd((*repp.PrimerDB).DeleteCmd)
 )
 
 // RootCmd represents the base command when called without any subcommands.
@@ -21,13 +33,82 @@ var RootCmd = &cobra.Command{
 	
 Repository-based plasmid design. Specify and build plasmids using
 their sequence, features, or fragments`,
-	Version: "0.1.0",
+	Version:           "0.1.0",
+	PersistentPreRunE: setupLogging,
+	SilenceErrors:     true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		log.Fatalf("%v", err)
+		fatal(err)
+	}
+}
+
+// set flags
+func init() {
+	RootCmd.PersistentFlags().String("log-format", "text", "format of the logs written to stderr: text or json")
+}
+
+// setupLogging logs to stderr in the format of the --log-format flag. Debug messages
+// are logged with --verbose. The usage isn't printed for errors after this point,
+// they're from running the command rather than from its flags.
+func setupLogging(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("log-format")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	l, err := repp.NewLogger(os.Stderr, format, verbose)
+	if err != nil {
+		return err
+	}
+	repp.SetLogger(l)
+	logger = l
+
+	cmd.SilenceUsage = true
+	return nil
+}
+
+// fatal logs the error, with its code, and exits.
+func fatal(err error) {
+	repp.LogError(err)
+	os.Exit(1)
+}
+
+// fatalf logs a formatted error and exits.
+func fatalf(format string, a ...interface{}) {
+	fatal(fmt.Errorf(format, a...))
+}
+
+// featureCmd returns a command's run function that reads the features database first.
+func featureCmd(run func(*repp.FeatureDB, *cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		db, err := repp.LoadFeatureDB()
+		if err != nil {
+			return err
+		}
+		return run(db, cmd, args)
+	}
+}
+
+// enzymeCmd returns a command's run function that reads the enzymes database first.
+func enzymeCmd(run func(*repp.EnzymeDB, *cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		db, err := repp.LoadEnzymeDB()
+		if err != nil {
+			return err
+		}
+		return run(db, cmd, args)
+	}
+}
+
+// primerCmd returns a command's run function that reads the primer inventory first.
+func primerCmd(run func(*repp.PrimerDB, *cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		db, err := repp.LoadPrimerDB()
+		if err != nil {
+			return err
+		}
+		return run(db, cmd, args)
 	}
 }
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/internal/server"
+	"github.com/jjtimmons/repp/pkg/repp"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd is for serving designs over HTTP.
+var serveCmd = &cobra.Command{
+	Use:                        "serve",
+	Short:                      "Serve designs as JSON over HTTP",
+	Run:                        runServe,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp serve --port 8080",
+	Long: `Start a long-running server with JSON endpoints for making plasmids,
+annotating them, and finding fragments, sequences, features, and enzymes.
+
+The configuration and the feature and enzyme databases are loaded once, at
+startup. Designs run as background jobs, one at a time, and their status
+and output are read from /jobs/{id}:
+
+  POST   /make/sequence
+  POST   /make/features
+  POST   /make/fragments
+  GET    /jobs/{id}
+  DELETE /jobs/{id}
+  POST   /annotate
+  POST   /find/fragment
+  POST   /find/sequence
+  GET    /find/feature?name=
+  GET    /find/enzyme?name=
+
+Request bodies mirror the flags of the matching command, for example:
+
+  curl -X POST localhost:8080/make/sequence -d '{"seq": "ATGC...", "addgene": true}'
+
+Outputs are only returned in responses, so "out" and "explain" paths are
+rejected. Sequences are passed in requests unless the server has an
+--input-dir, in which case "in" paths, and files named as features,
+backbones or fragments, are read from it. Databases are passed by name.`,
+}
+
+// set flags
+func init() {
+	serveCmd.Flags().StringP("host", "n", "localhost", "host to listen on")
+	serveCmd.Flags().IntP("port", "p", 8080, "port to listen on")
+	serveCmd.Flags().String("input-dir", "", "directory that requests' input files are read from")
+
+	RootCmd.AddCommand(serveCmd)
+}
+
+// runServe serves designs until it's interrupted.
+func runServe(cmd *cobra.Command, args []string) {
+	host, _ := cmd.Flags().GetString("host")
+	port, _ := cmd.Flags().GetInt("port")
+	inputDir, _ := cmd.Flags().GetString("input-dir")
+
+	designer, err := repp.NewDesigner(config.New())
+	if err != nil {
+		fatal(err)
+	}
+
+	s := server.New(designer, inputDir)
+	httpServer := &http.Server{Addr: fmt.Sprintf("%s:%d", host, port), Handler: s}
+
+	// stop accepting requests and cancel jobs on an interrupt
+	done := make(chan struct{})
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		<-interrupt
+
+		s.Close()
+		httpServer.Shutdown(context.Background())
+		close(done)
+	}()
+
+	fmt.Printf("serving on %s\n", httpServer.Addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		fatal(err)
+	}
+	<-done
+}
@@ -7,12 +7,13 @@ import (
 // setCmd is for piecing together a list of input fragments into a plasmid
 // and preparing the fragments to make into that plasmid
 var setCmd = &cobra.Command{
-	Use:                        "set [feature,enzyme]",
-	Short:                      "Set a feature or enzyme",
+	Use:                        "set [feature,enzyme,primer]",
+	Short:                      "Set a feature, enzyme or primer",
 	SuggestionsMinimumDistance: 1,
 	Long: `Create/update a feature or enzyme with its name and sequence/recognition-site.
 Set features can be passed to the 'repp build features' command and enzymes can
-be passed to the --enzyme flag`,
+be passed to the --enzyme flag. Set primers are in the primer inventory, used
+in designs before new primers are made`,
 	Aliases: []string{"add", "update"},
 }
 
@@ -20,7 +21,7 @@ be passed to the --enzyme flag`,
 var featureCreateCmd = &cobra.Command{
 	Use:                        "feature [name] [sequence]",
 	Short:                      "Add a feature to the features database",
-	Run:                        featureDB.SetCmd,
+	RunE:                       runFeatureSet,
 	SuggestionsMinimumDistance: 2,
 	Long:                       "\nSet a feature in the features database so it can be use used in 'repp builde features'",
 	Aliases:                    []string{"add", "update"},
@@ -31,7 +32,7 @@ var featureCreateCmd = &cobra.Command{
 var enzymeCreateCmd = &cobra.Command{
 	Use:                        "enzyme [name] [sequence]",
 	Short:                      "Add an enzyme to the enzymes database",
-	Run:                        enzymeDB.SetCmd,
+	RunE:                       runEnzymeSet,
 	SuggestionsMinimumDistance: 2,
 	Long: `
 Set an enzyme in the enzymes database so it can be used to linearize backbones.
@@ -43,9 +44,29 @@ a cut site in the complement sequence: "_". Use 'repp ls enzyme' for examples`,
 	Example: "  repp set enzyme BbvCI CC^TCA_GC",
 }
 
+// primerCreateCmd is for adding a primer to the primer inventory
+var primerCreateCmd = &cobra.Command{
+	Use:                        "primer [name] [sequence]",
+	Short:                      "Add a primer to the primer inventory",
+	RunE:                       runPrimerSet,
+	SuggestionsMinimumDistance: 2,
+	Long: `
+Set a primer in the primer inventory of primers in stock. Before making new
+primers, designs check whether primers in the inventory bind the template where
+the PCR needs to start or end. Those that do are used, at no cost, and named
+in the output by their name in the inventory.
+
+Many primers can be set at once from a FASTA file with the --in flag.`,
+	Aliases: []string{"add", "update"},
+	Example: "  repp set primer oJT101 GTTGACAATTAATCATCGGCATAG\n  repp set primer --in primers.fa",
+}
+
 func init() {
+	primerCreateCmd.Flags().StringP("in", "i", "", "FASTA file of primers to set, by their IDs")
+
 	setCmd.AddCommand(featureCreateCmd)
 	setCmd.AddCommand(enzymeCreateCmd)
+	setCmd.AddCommand(primerCreateCmd)
 
 	RootCmd.AddCommand(setCmd)
 }
@@ -2,12 +2,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/mitchellh/mapstructure"
@@ -27,22 +29,101 @@ var (
 	// Primer3Config is the path to the embedded primer3 config directory
 	Primer3Config = filepath.Join(reppDir, "primer3_config") + string(os.PathSeparator)
 
-	// IGEMDB is the path to the iGEM db
+	// IGEMDB is the default path to the iGEM db
 	IGEMDB = filepath.Join(reppDir, "igem")
 
-	// AddgeneDB is the path to the Addgene db
+	// AddgeneDB is the default path to the Addgene db
 	AddgeneDB = filepath.Join(reppDir, "addgene")
 
-	// DNASUDB is the path to the DNASU db
+	// DNASUDB is the default path to the DNASU db
 	DNASUDB = filepath.Join(reppDir, "dnasu")
 
+	// DBsDir is the directory of the BLAST dbs made with 'repp db create'
+	DBsDir = filepath.Join(reppDir, "dbs")
+
+	// CacheDir is the directory of results cached between designs
+	CacheDir = filepath.Join(reppDir, "cache")
+
 	// FeatureDB is the path to the features db
 	FeatureDB = filepath.Join(reppDir, "features.tsv")
 
 	// EnzymeDB is the path to the enzymes db file
 	EnzymeDB = filepath.Join(reppDir, "enzymes.tsv")
+
+	// PrimerDB is the path to the inventory of primers in stock
+	PrimerDB = filepath.Join(reppDir, "primers.tsv")
 )
 
+// defaultDatabases is the registry of databases used if a settings file doesn't have one
+var defaultDatabases = []Database{
+	Database{
+		Name:      "addgene",
+		Path:      AddgeneDB,
+		URL:       "https://www.addgene.org/{plasmid}/",
+		Cost:      65.0,
+		LeadTime:  7.0,
+		Shorthand: "a",
+	},
+	Database{
+		Name:      "igem",
+		Path:      IGEMDB,
+		URL:       "http://parts.igem.org/Part:{id}",
+		Cost:      0.0,
+		LeadTime:  14.0,
+		Shorthand: "g",
+	},
+	Database{
+		Name:      "dnasu",
+		Path:      DNASUDB,
+		URL:       "http://dnasu.org/DNASU/GetCloneDetail.do?cloneid={id}",
+		Cost:      55.0,
+		LeadTime:  10.0,
+		Shorthand: "u",
+	},
+}
+
+// Database is a BLAST database of building fragments in the registry of databases.
+// Each database in the registry is a flag of the design commands, eg --addgene, and
+// can be passed to --dbs by its name.
+type Database struct {
+	// Name of the database, and of its flag
+	Name string `mapstructure:"name"`
+
+	// Path to the BLAST database. Relative to the repp directory if it isn't absolute
+	Path string `mapstructure:"path"`
+
+	// URL is the template of links to the database's entries. {id} is replaced by an
+	// entry's ID and {plasmid} by its ID up to the first period, eg 1234 of 1234.1
+	URL string `mapstructure:"url"`
+
+	// Cost of procuring an entry from the database
+	Cost float64 `mapstructure:"cost"`
+
+	// LeadTime is the number of days it takes to receive an entry from the database.
+	// Entries of internal databases are on hand
+	LeadTime float64 `mapstructure:"lead-time"`
+
+	// Internal is whether the database's entries are on hand, rather than
+	// procured from a repository
+	Internal bool `mapstructure:"internal"`
+
+	// Shorthand is a single letter shorthand of the database's flag, eg "a" for -a
+	Shorthand string `mapstructure:"shorthand"`
+}
+
+// EntryURL returns the URL of an entry in the database. It's empty if the database
+// doesn't have a URL template.
+func (d Database) EntryURL(entry string) string {
+	if d.URL == "" {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{id}", entry,
+		"{plasmid}", strings.Split(entry, ".")[0],
+	).Replace(d.URL)
+}
+
 // SynthCost contains data of the cost of synthesizing DNA up to a certain
 // size. Can be fixed (ie everything beneath that limit is the same amount)
 // or not (pay by the bp)
@@ -54,21 +135,122 @@ type SynthCost struct {
 	Cost float64 `mapstructure:"cost"`
 }
 
+// Vendor is a provider of synthetic fragments, with its own prices, length limits and
+// rules on the sequences it synthesizes. Rules that are 0 aren't checked.
+type Vendor struct {
+	// Name of the vendor, eg idt
+	Name string `mapstructure:"name"`
+
+	// FragmentCost is the cost of synthetic fragments (as a step function)
+	FragmentCost map[int]SynthCost `mapstructure:"fragment-cost"`
+
+	// PlasmidCost is the cost of synthesized clonal DNA (delivered in a plasmid)
+	PlasmidCost map[int]SynthCost `mapstructure:"plasmid-cost"`
+
+	// MinLength is the minimum length of a synthetic fragment
+	MinLength int `mapstructure:"min-length"`
+
+	// MaxLength is the maximum length of a synthetic fragment
+	MaxLength int `mapstructure:"max-length"`
+
+	// LeadTime is the number of days it takes to receive a synthetic fragment
+	LeadTime float64 `mapstructure:"lead-time"`
+
+	// MinGC is the minimum GC content, as a percentage, of each window of a fragment
+	MinGC float64 `mapstructure:"min-gc"`
+
+	// MaxGC is the maximum GC content, as a percentage, of each window of a fragment
+	MaxGC float64 `mapstructure:"max-gc"`
+
+	// GCWindow is the length of the windows that GC content is checked in. The whole
+	// fragment if 0
+	GCWindow int `mapstructure:"gc-window"`
+
+	// MaxHomopolymer is the longest run of a single base in a fragment
+	MaxHomopolymer int `mapstructure:"max-homopolymer"`
+
+	// MaxRepeat is the longest sequence that's repeated within a fragment
+	MaxRepeat int `mapstructure:"max-repeat"`
+}
+
+// SynthFragmentCost returns the cost of synthesizing a fragment from the vendor. It's
+// extremely large if the fragment's too short or too long for the vendor.
+func (v Vendor) SynthFragmentCost(fragLength int) float64 {
+	if fragLength < v.MinLength || (v.MaxLength > 0 && fragLength > v.MaxLength) {
+		return math.MaxInt32
+	}
+	cost := synthCost(fragLength, v.FragmentCost)
+	if cost.Fixed {
+		return cost.Cost
+	}
+
+	return float64(fragLength) * cost.Cost
+}
+
+// SynthPlasmidCost returns the cost of synthesizing the insert and having it delivered
+// in a plasmid by the vendor. It's extremely large if the insert's too long for the vendor.
+func (v Vendor) SynthPlasmidCost(insertLength int) float64 {
+	if v.MaxLength > 0 && insertLength > v.MaxLength {
+		return math.MaxInt32
+	}
+	cost := synthCost(insertLength, v.PlasmidCost)
+	if cost.Fixed {
+		return cost.Cost
+	}
+
+	return float64(insertLength) * cost.Cost
+}
+
+// ObjectiveWeights are the weights of each objective in the weighted objective. A
+// solution's weighted score is the sum of its score by each objective times its weight.
+// In-house fragments are maximized, so their weight is subtracted.
+type ObjectiveWeights struct {
+	// Cost is the weight of each dollar of a solution's cost
+	Cost float64 `mapstructure:"cost"`
+
+	// Synthesis is the weight of each synthesized bp
+	Synthesis float64 `mapstructure:"synthesis"`
+
+	// PCR is the weight of each PCR reaction
+	PCR float64 `mapstructure:"pcr"`
+
+	// Repositories is the weight of each repository fragments are ordered from
+	Repositories float64 `mapstructure:"repositories"`
+
+	// Inventory is the weight of each in-house fragment
+	Inventory float64 `mapstructure:"inventory"`
+
+	// Turnaround is the weight of each day to the plasmid
+	Turnaround float64 `mapstructure:"turnaround"`
+}
+
 // Config is the Root-level settings struct and is a mix
 // of settings available in config.yaml and those
 // available from the command line
 type Config struct {
-	// Vebose is whether to log debug messages to the stdout
+	// Vebose is whether to log debug messages to stderr
 	Verbose bool
 
-	// the cost of a single Addgene plasmid
-	CostAddgene float64 `mapstructure:"addgene-cost"`
+	// Databases is the registry of fragment databases
+	Databases []Database `mapstructure:"databases"`
+
+	// Aligner finds fragments in databases: blastn, from BLAST+, or native, in Go
+	Aligner string `mapstructure:"aligner"`
+
+	// Thermo calculates hairpins and dimers and picks primers: native, in Go, or primer3,
+	// with ntthal and primer3_core
+	Thermo string `mapstructure:"thermo"`
 
-	// the cost of a single part from the iGEM registry
-	CostIGEM float64 `mapstructure:"igem-cost"`
+	// CacheMaxSize is the size, in MB, that the cache of BLAST, primer and off-target
+	// results is kept beneath. The cache is off if it's 0
+	CacheMaxSize int `mapstructure:"cache-max-size"`
 
-	// the per plasmid cost of DNASU plasmids
-	CostDNASU float64 `mapstructure:"dnasu-cost"`
+	// Workers is the number of assemblies filled at once. It's the number of CPUs if 0
+	Workers int `mapstructure:"workers"`
+
+	// BlastThreads is the number of threads split between the blastn searches of a query,
+	// one for each database. It's one less than the number of CPUs if 0
+	BlastThreads int `mapstructure:"blast-threads"`
 
 	// the cost per bp of primer DNA
 	CostBP float64 `mapstructure:"pcr-bp-cost"`
@@ -85,12 +267,44 @@ type Config struct {
 	// the cost of time for each Gibson Assembly
 	CostTimeGibson float64 `mapstructure:"gibson-assembly-time-cost"`
 
+	// the cost of each Golden Gate Assembly
+	CostGoldenGate float64 `mapstructure:"golden-gate-assembly-cost"`
+
+	// DaysPCR is the bench time, in days, of a round of PCR, including its cleanup
+	DaysPCR float64 `mapstructure:"pcr-days"`
+
+	// PCRMaxParallel is the number of PCR reactions run at once. There's no limit if 0
+	PCRMaxParallel int `mapstructure:"pcr-max-parallel"`
+
+	// LeadTimePrimer is the number of days it takes to receive primers that aren't in the inventory
+	LeadTimePrimer float64 `mapstructure:"pcr-primer-lead-time"`
+
+	// DaysGibson is the bench time, in days, from a Gibson Assembly to a verified plasmid
+	DaysGibson float64 `mapstructure:"gibson-assembly-days"`
+
+	// DaysGoldenGate is the bench time, in days, from a Golden Gate Assembly to a verified plasmid
+	DaysGoldenGate float64 `mapstructure:"golden-gate-assembly-days"`
+
+	// LeadTimeSyntheticFragment is the number of days it takes to receive a synthetic fragment
+	LeadTimeSyntheticFragment float64 `mapstructure:"synthetic-fragment-lead-time"`
+
 	// the cost per bp of synthesized DNA as a fragment (as a step function)
 	CostSyntheticFragment map[int]SynthCost `mapstructure:"synthetic-fragment-cost"`
 
 	// the cost per bp of synthesized clonal DNA  (delivered in a plasmid)
 	CostSynthPlasmid map[int]SynthCost `mapstructure:"synthetic-plasmid-cost"`
 
+	// Vendors of synthetic fragments. Without any, the synthetic fragment and plasmid
+	// costs, lengths and lead time are of a single vendor
+	Vendors []Vendor `mapstructure:"synthetic-vendors"`
+
+	// Objective ranks the solutions with each fragment count: cost, synthesis, pcr,
+	// repositories, inventory, turnaround or weighted, by ObjectiveWeights
+	Objective string `mapstructure:"objective"`
+
+	// ObjectiveWeights are the weights of the weighted objective
+	ObjectiveWeights ObjectiveWeights `mapstructure:"objective-weights"`
+
 	// the maximum number of fragments in the final assembly
 	FragmentsMaxCount int `mapstructure:"fragments-max-count"`
 
@@ -115,6 +329,9 @@ type Config struct {
 	// PCRMaxOfftargetTm is the maximum tm of an offtarget, above which PCR is abandoned
 	PCRMaxOfftargetTm float64 `mapstructure:"pcr-primer-max-ectopic-tm"`
 
+	// PCRInventoryMinTm is the minimum tm of the annealing portion of a primer from the inventory
+	PCRInventoryMinTm float64 `mapstructure:"pcr-primer-inventory-min-tm"`
+
 	// PCRBufferLength is the length of buffer from the ends of a match in which
 	// to allow Primer3 to look for a primer
 	PCRBufferLength int `mapstructure:"pcr-buffer-length"`
@@ -124,6 +341,30 @@ type Config struct {
 
 	// minimum length of a synthesized piece of DNA
 	SyntheticMinLength int `mapstructure:"synthetic-min-length"`
+
+	// OrderFormat is the format of primer and synthetic fragment order sheets: csv or xlsx
+	OrderFormat string `mapstructure:"order-format"`
+
+	// OrderLayout is whether to order oligos and fragments in tubes or 96-well plates
+	OrderLayout string `mapstructure:"order-layout"`
+
+	// OrderPrimerName is the template of primer names in order sheets
+	OrderPrimerName string `mapstructure:"order-primer-name"`
+
+	// OrderPrimerScale is the synthesis scale of primers, eg 25nm
+	OrderPrimerScale string `mapstructure:"order-primer-scale"`
+
+	// OrderPrimerPurification is the purification of primers, eg STD
+	OrderPrimerPurification string `mapstructure:"order-primer-purification"`
+
+	// OrderSyntheticName is the template of synthetic fragment names in order sheets
+	OrderSyntheticName string `mapstructure:"order-synthetic-name"`
+
+	// OrderSyntheticScale is the scale of synthetic fragments, eg 500ng
+	OrderSyntheticScale string `mapstructure:"order-synthetic-scale"`
+
+	// OrderSyntheticPurification is the purification of synthetic fragments
+	OrderSyntheticPurification string `mapstructure:"order-synthetic-purification"`
 }
 
 // New returns a new Config struct populated by settings from
@@ -133,87 +374,235 @@ type Config struct {
 // TODO: check for and error out on nonsense config values
 // TODO: add back the config file path setting
 func New() *Config {
-	// read in the default/base settings file first
-	viper.SetConfigType("yaml")
-	viper.SetConfigFile(RootSettingsFile)
-	if err := viper.ReadInConfig(); err != nil {
+	config, err := load(viper.GetViper(), viper.GetString("settings"))
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	if userSettings := viper.GetString("settings"); userSettings != "" && userSettings != RootSettingsFile {
-		viper.SetConfigFile(userSettings)             // user has specified a new path for a settings file
-		if err := viper.MergeInConfig(); err != nil { // read in user defined settings file
-			log.Fatal(err)
+	return config
+}
+
+// Load returns a new Config struct populated by settings from config.yaml and,
+// optionally, a user settings file that overrides them. Unlike New, it returns
+// errors rather than exiting and it ignores the command line flags.
+func Load(settings string) (*Config, error) {
+	return load(viper.New(), settings)
+}
+
+// load reads the root and user settings files into a Config using the viper instance
+func load(v *viper.Viper, userSettings string) (*Config, error) {
+	// read in the default/base settings file first
+	v.SetConfigType("yaml")
+	v.SetConfigFile(RootSettingsFile)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	if userSettings != "" && userSettings != RootSettingsFile {
+		v.SetConfigFile(userSettings)             // user has specified a new path for a settings file
+		if err := v.MergeInConfig(); err != nil { // read in user defined settings file
+			return nil, err
+		}
+
+		file, err := os.Open(userSettings)
+		if err != nil {
+			return nil, err
 		}
+		defer file.Close()
 
-		file, _ := os.Open(userSettings)
 		userData := make(map[string]interface{})
 		if err := yaml.NewDecoder(file).Decode(userData); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		userConfig := &Config{}
 		if err := mapstructure.Decode(userData, userConfig); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		if userConfig.CostSyntheticFragment != nil {
-			viper.Set("synthetic-fragment-cost", userConfig.CostSyntheticFragment)
+			v.Set("synthetic-fragment-cost", userConfig.CostSyntheticFragment)
 		}
 		if userConfig.CostSynthPlasmid != nil {
-			viper.Set("synthetic-plasmid-cost", userConfig.CostSynthPlasmid)
+			v.Set("synthetic-plasmid-cost", userConfig.CostSynthPlasmid)
 		}
 	}
 
 	// make sure all depedencies are available (may belong elsewhere)
-	if _, err := exec.LookPath("blastn"); err != nil {
-		log.Fatal("no blastn executable available in PATH, try `make install`")
+	var deps []string
+	if v.GetString("aligner") != "native" {
+		deps = append(deps, "blastn", "blastdbcmd")
+	}
+	if v.GetString("thermo") == "primer3" {
+		deps = append(deps, "primer3_core", "ntthal")
+	}
+	for _, dep := range deps {
+		if _, err := exec.LookPath(dep); err != nil {
+			return nil, fmt.Errorf("no %s executable available in PATH, try `make install`", dep)
+		}
 	}
 
-	if _, err := exec.LookPath("blastdbcmd"); err != nil {
-		log.Fatal("no blastdbcmd executable available in PATH, try `make install`")
+	// build Config
+	config := &Config{}
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode settings file %s: %v", v.ConfigFileUsed(), err)
 	}
+	config.Databases = registry(config.Databases)
 
-	if _, err := exec.LookPath("primer3_core"); err != nil {
-		log.Fatal("no primer3_core executable available in PATH, try `make install`")
+	return config, nil
+}
+
+// Registry returns the registry of fragment databases in the root settings file.
+// Unlike Load, it doesn't check for dependencies, so it's used to make the
+// database flags of commands.
+func Registry() []Database {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetConfigFile(RootSettingsFile)
+	if err := v.ReadInConfig(); err != nil {
+		return registry(nil)
 	}
 
-	if _, err := exec.LookPath("ntthal"); err != nil {
-		log.Fatal("no ntthal executable available in PATH, try `make install`")
+	var databases []Database
+	if err := v.UnmarshalKey("databases", &databases); err != nil {
+		return registry(nil)
 	}
 
-	// build Config
-	config := &Config{}
-	if err := viper.Unmarshal(&config); err != nil {
-		log.Fatalf("failed to decode settings file %s: %v", viper.ConfigFileUsed(), err)
+	return registry(databases)
+}
+
+// registry resolves the paths of the databases, relative to the repp directory,
+// or returns the default registry if there are no databases.
+func registry(databases []Database) []Database {
+	if len(databases) == 0 {
+		databases = append([]Database{}, defaultDatabases...)
 	}
 
-	return config
+	for i, d := range databases {
+		if d.Path == "" {
+			d.Path = d.Name
+		}
+		if !filepath.IsAbs(d.Path) {
+			databases[i].Path = filepath.Join(reppDir, d.Path)
+		}
+	}
+
+	return databases
+}
+
+// Database returns the database in the registry with the name or path db. If
+// there isn't one, and url is set, the database with a URL template that it
+// matches is returned.
+func (c *Config) Database(db, url string) (Database, bool) {
+	if c == nil {
+		return Database{}, false
+	}
+
+	for _, d := range c.Databases {
+		if db != "" && (d.Name == db || d.Path == db) {
+			return d, true
+		}
+	}
+
+	if db == "" && url != "" {
+		for _, d := range c.Databases {
+			if prefix := strings.Split(d.URL, "{")[0]; prefix != "" && strings.HasPrefix(url, prefix) {
+				return d, true
+			}
+		}
+	}
+
+	return Database{}, false
+}
+
+// SynthVendors returns the vendors of synthetic fragments. Without any in the settings,
+// it's a single unnamed vendor with the synthetic fragment and plasmid costs, lengths and
+// lead time of the settings.
+func (c Config) SynthVendors() []Vendor {
+	if len(c.Vendors) > 0 {
+		return c.Vendors
+	}
+
+	return []Vendor{
+		Vendor{
+			FragmentCost: c.CostSyntheticFragment,
+			PlasmidCost:  c.CostSynthPlasmid,
+			MinLength:    c.SyntheticMinLength,
+			MaxLength:    c.SyntheticMaxLength,
+			LeadTime:     c.LeadTimeSyntheticFragment,
+		},
+	}
+}
+
+// SynthVendor returns the vendor of synthetic fragments with the name.
+func (c Config) SynthVendor(name string) (Vendor, bool) {
+	for _, v := range c.SynthVendors() {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return Vendor{}, false
 }
 
-// SynthFragmentCost returns the cost of synthesizing a linear stretch of DNA
+// SynthMaxLength returns the length of the longest fragment that any vendor synthesizes.
+// Longer stretches of DNA are split into fragments of at most this length. It's the
+// synthetic max length if a vendor doesn't have one.
+func (c Config) SynthMaxLength() int {
+	longest := 0
+	for _, v := range c.SynthVendors() {
+		if v.MaxLength <= 0 {
+			return c.SyntheticMaxLength
+		}
+		if v.MaxLength > longest {
+			longest = v.MaxLength
+		}
+	}
+	return longest
+}
+
+// SynthMinLength returns the length of the shortest fragment that any vendor synthesizes.
+// Shorter fragments are extended to it.
+func (c Config) SynthMinLength() int {
+	shortest := math.MaxInt32
+	for _, v := range c.SynthVendors() {
+		if v.MinLength < shortest {
+			shortest = v.MinLength
+		}
+	}
+	return shortest
+}
+
+// SynthFragmentCost returns the cost of synthesizing a linear stretch of DNA, from the
+// cheapest vendor that can make each of its fragments. Stretches shorter than any vendor
+// synthesizes are extended to the shortest they do, as synthetic fragments are.
 func (c Config) SynthFragmentCost(fragLength int) float64 {
+	if minLength := c.SynthMinLength(); fragLength < minLength {
+		fragLength = minLength
+	}
+
 	// by default, we try to synthesize the whole thing in one piece
 	// we may optionally need to split it into multiple
-	fragCount := math.Ceil(float64(fragLength) / float64(c.SyntheticMaxLength))
+	fragCount := math.Ceil(float64(fragLength) / float64(c.SynthMaxLength()))
 	fragLength = int(math.Floor(float64(fragLength) / float64(fragCount)))
 
-	cost := synthCost(fragLength, c.CostSyntheticFragment)
-	if cost.Fixed {
-		return fragCount * cost.Cost
+	cost := float64(math.MaxInt32)
+	for _, v := range c.SynthVendors() {
+		cost = math.Min(cost, v.SynthFragmentCost(fragLength))
 	}
 
-	return fragCount * float64(fragLength) * cost.Cost
+	return fragCount * cost
 }
 
-// SynthPlasmidCost returns the cost of synthesizing the insert and having it delivered in a plasmid
+// SynthPlasmidCost returns the cost of synthesizing the insert and having it delivered in a plasmid,
+// from the cheapest vendor
 func (c Config) SynthPlasmidCost(insertLength int) float64 {
-	cost := synthCost(insertLength, c.CostSynthPlasmid)
-	if cost.Fixed {
-		return cost.Cost
+	cost := float64(math.MaxInt32)
+	for _, v := range c.SynthVendors() {
+		cost = math.Min(cost, v.SynthPlasmidCost(insertLength))
 	}
 
-	return float64(insertLength) * cost.Cost
+	return cost
 }
 
 // synthCost returns the cost of synthesizing a piece of DNA
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"math"
+	"path/filepath"
 	"testing"
 )
 
@@ -74,3 +76,107 @@ func TestConfig_SynthCost(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad(t *testing.T) {
+	if _, err := Load(filepath.Join(reppDir, "missing-settings.yaml")); err == nil {
+		t.Error("Load() expected an error for a missing settings file")
+	}
+}
+
+func TestConfig_Database(t *testing.T) {
+	c := &Config{Databases: registry([]Database{
+		Database{Name: "addgene", URL: "https://www.addgene.org/{plasmid}/", Cost: 65},
+		Database{Name: "acme", Path: "/data/acme", URL: "https://acme.example.com/{id}", Internal: true},
+	})}
+
+	if c.Databases[0].Path != filepath.Join(reppDir, "addgene") {
+		t.Errorf("registry() path = %s, want it in %s", c.Databases[0].Path, reppDir)
+	}
+
+	if d, ok := c.Database("/data/acme", ""); !ok || d.Name != "acme" {
+		t.Errorf("Database() by path = %+v, want acme", d)
+	}
+	if d, ok := c.Database("", "https://www.addgene.org/1234/"); !ok || d.Cost != 65 {
+		t.Errorf("Database() by url = %+v, want addgene", d)
+	}
+	if _, ok := c.Database("missing", ""); ok {
+		t.Error("Database() found a database that isn't in the registry")
+	}
+	if _, ok := (*Config)(nil).Database("addgene", ""); ok {
+		t.Error("Database() found a database without a config")
+	}
+
+	if url := c.Databases[0].EntryURL("1234.1"); url != "https://www.addgene.org/1234/" {
+		t.Errorf("EntryURL() = %s, want https://www.addgene.org/1234/", url)
+	}
+	if url := c.Databases[1].EntryURL("pSB1C3"); url != "https://acme.example.com/pSB1C3" {
+		t.Errorf("EntryURL() = %s, want https://acme.example.com/pSB1C3", url)
+	}
+
+	if defaults := registry(nil); len(defaults) != 3 {
+		t.Errorf("registry() = %+v, want the default databases", defaults)
+	}
+}
+
+func TestConfig_SynthVendors(t *testing.T) {
+	c := Config{
+		SyntheticMaxLength: 3000,
+		Vendors: []Vendor{
+			Vendor{
+				Name:         "idt",
+				MaxLength:    3000,
+				FragmentCost: map[int]SynthCost{3000: SynthCost{Fixed: true, Cost: 100}},
+			},
+			Vendor{
+				Name:         "twist",
+				MinLength:    300,
+				MaxLength:    1800,
+				FragmentCost: map[int]SynthCost{1800: SynthCost{Fixed: false, Cost: 0.1}},
+			},
+		},
+	}
+
+	// twist is cheaper up to 1000bp and can't make fragments under 300bp or over 1800bp
+	costs := map[int]float64{100: 100, 500: 50, 1500: 100, 2000: 100}
+	for length, want := range costs {
+		if got := c.SynthFragmentCost(length); got != want {
+			t.Errorf("SynthFragmentCost(%d) = %v, want %v", length, got, want)
+		}
+	}
+
+	if v, ok := c.SynthVendor("twist"); !ok || v.MinLength != 300 {
+		t.Errorf("SynthVendor() = %+v, want twist", v)
+	}
+	if twist := c.Vendors[1]; twist.SynthFragmentCost(100) != math.MaxInt32 || twist.SynthPlasmidCost(2000) != math.MaxInt32 {
+		t.Error("Vendor costs are not extremely large outside of the vendor's lengths")
+	}
+
+	// stretches shorter than any vendor makes are priced as the shortest they make
+	twistOnly := Config{Vendors: c.Vendors[1:]}
+	if got := twistOnly.SynthFragmentCost(100); got != 30 {
+		t.Errorf("SynthFragmentCost(100) = %v, want 30 for a 300bp fragment", got)
+	}
+	if _, ok := c.SynthVendor("genscript"); ok {
+		t.Error("SynthVendor() found a vendor that isn't in the settings")
+	}
+
+	if c.SynthMaxLength() != 3000 || c.SynthMinLength() != 0 {
+		t.Errorf("SynthMaxLength(), SynthMinLength() = %d, %d, want 3000, 0", c.SynthMaxLength(), c.SynthMinLength())
+	}
+
+	// a vendor that makes longer fragments than the synthetic max length makes them in one piece
+	c.Vendors[0].MaxLength = 5000
+	c.Vendors[0].FragmentCost = map[int]SynthCost{5000: SynthCost{Fixed: true, Cost: 100}}
+	if got := c.SynthFragmentCost(4000); got != 100 {
+		t.Errorf("SynthFragmentCost(4000) = %v, want 100 from a 5000bp vendor", got)
+	}
+
+	// without vendors, the synthetic settings are a single unnamed vendor
+	single := Config{SyntheticMaxLength: 500, LeadTimeSyntheticFragment: 5}
+	if vendors := single.SynthVendors(); len(vendors) != 1 || vendors[0].Name != "" || vendors[0].MaxLength != 500 || vendors[0].LeadTime != 5 {
+		t.Errorf("SynthVendors() = %+v, want the synthetic settings", vendors)
+	}
+	if single.SynthMaxLength() != 500 {
+		t.Errorf("SynthMaxLength() = %d, want the synthetic max length", single.SynthMaxLength())
+	}
+}
@@ -151,6 +151,14 @@ var metaMap = map[string]meta{
 		"find",
 		"repp",
 	},
+	"repp_find_primer": meta{
+		grandchild,
+		"primer",
+		4,
+		false,
+		"find",
+		"repp",
+	},
 	"repp_set": meta{
 		childParent,
 		"set",
@@ -175,6 +183,14 @@ var metaMap = map[string]meta{
 		"set",
 		"repp",
 	},
+	"repp_set_primer": meta{
+		grandchild,
+		"primer",
+		2,
+		false,
+		"set",
+		"repp",
+	},
 	"repp_delete": meta{
 		childParent,
 		"delete",
@@ -191,6 +207,14 @@ var metaMap = map[string]meta{
 		"delete",
 		"repp",
 	},
+	"repp_delete_primer": meta{
+		grandchild,
+		"primer",
+		1,
+		false,
+		"delete",
+		"repp",
+	},
 	"repp_annotate": meta{
 		child,
 		"annotate",
@@ -199,6 +223,94 @@ var metaMap = map[string]meta{
 		"repp",
 		"",
 	},
+	"repp_serve": meta{
+		child,
+		"serve",
+		5,
+		false,
+		"repp",
+		"",
+	},
+	"repp_protocol": meta{
+		child,
+		"protocol",
+		6,
+		false,
+		"repp",
+		"",
+	},
+	"repp_order": meta{
+		child,
+		"order",
+		7,
+		false,
+		"repp",
+		"",
+	},
+	"repp_db": meta{
+		childParent,
+		"db",
+		8,
+		true,
+		"repp",
+		"",
+	},
+	"repp_db_create": meta{
+		grandchild,
+		"create",
+		0,
+		false,
+		"db",
+		"repp",
+	},
+	"repp_db_add": meta{
+		grandchild,
+		"add",
+		1,
+		false,
+		"db",
+		"repp",
+	},
+	"repp_db_remove": meta{
+		grandchild,
+		"remove",
+		2,
+		false,
+		"db",
+		"repp",
+	},
+	"repp_db_list": meta{
+		grandchild,
+		"list",
+		3,
+		false,
+		"db",
+		"repp",
+	},
+	"repp_db_info": meta{
+		grandchild,
+		"info",
+		4,
+		false,
+		"db",
+		"repp",
+	},
+	"repp_cache": meta{
+		childParent,
+		"cache",
+		9,
+		true,
+		"repp",
+		"",
+	},
+	"repp_cache_clear": meta{
+		grandchild,
+		"clear",
+		0,
+		false,
+		"cache",
+		"repp",
+	},
 }
 
 // makeDocs parses the custom commands and outputs Markdown documentation files
@@ -0,0 +1,439 @@
+package repp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// aligner finds query sequences in fragment databases, or subject FASTA files, and
+// queries entries out of the databases.
+type aligner interface {
+	// align returns the matches of the query in its db or, if set, its subject file
+	align(ctx context.Context, q *alignQuery, filters []string) ([]match, error)
+
+	// entry writes an entry of a db to a FASTA file and returns it with the entry's sequence
+	entry(ctx context.Context, entry, db string) (*os.File, string, error)
+}
+
+// newAligner returns the aligner in the settings. blastn is the default.
+func newAligner(conf *config.Config) aligner {
+	if conf != nil && conf.Aligner == "native" {
+		return native{}
+	}
+	return blastn{}
+}
+
+// native is an aligner in Go, without BLAST+. It seeds matches on exact k-mers of
+// the query and extends them, without gaps, like blastn's ungapped extension. That's
+// enough for the near-exact searches of designs, but it's less sensitive than blastn
+// to distant and gapped matches. Its databases are the FASTA files at the paths of
+// the BLAST dbs.
+type native struct{}
+
+// nativeXDrop is how far the score of an extension can fall below its best before it stops
+const nativeXDrop = 20
+
+// align finds the query in each sequence of the db or subject file, on both strands.
+func (native) align(_ context.Context, q *alignQuery, filters []string) ([]match, error) {
+	path := q.db
+	if q.subject != "" {
+		path = q.subject
+	}
+
+	query := strings.ToUpper(q.seq)
+	if q.circular {
+		query += query
+	}
+
+	k := seedLength(q.identity, len(query))
+	seeds := kmers(query, k)
+	threshold := float64(q.identity)/100.0 - 0.0001
+
+	// reward and penalty, from blastn's scores for each identity, see blastExec.run
+	penalty := 1
+	if q.identity > 99 {
+		penalty = 5
+	} else if q.identity >= 98 {
+		penalty = 3
+	} else if q.identity >= 90 {
+		penalty = 2
+	}
+
+	var matches []match
+	err := eachFasta(path, func(header, seq string) {
+		entry, title := header, ""
+		if fields := strings.Fields(header); len(fields) > 0 {
+			entry, title = fields[0], strings.Join(fields[1:], " ")
+		}
+
+		seq = strings.ToUpper(seq)
+		for _, forward := range []bool{true, false} {
+			subject := seq
+			if !forward {
+				subject = reverseComplement(seq)
+			}
+
+			for _, h := range extendSeeds(query, subject, seeds, k, penalty) {
+				if float64(h.length-h.mismatching)/float64(h.length) < threshold {
+					continue
+				}
+
+				m := match{
+					entry:        entry,
+					title:        title,
+					queryStart:   h.queryStart,
+					queryEnd:     h.queryStart + h.length - 1,
+					seq:          subject[h.subjectStart : h.subjectStart+h.length],
+					subjectStart: h.subjectStart,
+					subjectEnd:   h.subjectStart + h.length - 1,
+					mismatching:  h.mismatching,
+					forward:      forward,
+				}
+
+				// report the reverse strand's match on the forward strand, like blastn
+				if !forward {
+					m.subjectStart, m.subjectEnd = len(seq)-1-m.subjectEnd, len(seq)-1-m.subjectStart
+				}
+
+				if m, keep := q.hit(m, filters); keep {
+					matches = append(matches, m)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to align %s against %s: %v", q.name, path, err)
+	}
+
+	return matches, nil
+}
+
+// entry reads an entry from the db's FASTA file with its index of entries.
+func (native) entry(_ context.Context, entry, db string) (*os.File, string, error) {
+	offset, found, err := fastaOffset(db, entry)
+	if err != nil {
+		return nil, "", fmt.Errorf("warning: failed to query %s from %s\n\t%s", entry, db, err.Error())
+	}
+	if !found {
+		return nil, "", fmt.Errorf("warning: failed to query %s from %s", entry, db)
+	}
+
+	file, err := os.Open(db)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	var header, seq string
+	if err := scanFasta(file, func(h, s string) bool {
+		header, seq = h, s
+		return false // just the one entry
+	}); err != nil {
+		return nil, "", err
+	}
+
+	output, err := ioutil.TempFile("", "native-entry-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer output.Close()
+
+	if _, err := output.WriteString(fmt.Sprintf(">%s\n%s\n", header, seq)); err != nil {
+		return nil, "", fmt.Errorf("failed to write %s to %s: %v", entry, output.Name(), err)
+	}
+
+	return output, seq, nil
+}
+
+// seedLength returns the length of exact k-mers to seed matches with. Matches above
+// 95% identity are found with long seeds and few random hits. Primers' off-targets
+// need short ones.
+func seedLength(identity, queryLength int) int {
+	k := 7
+	if identity >= 95 {
+		k = 16
+	} else if identity >= 80 {
+		k = 11
+	}
+
+	if k > queryLength {
+		k = queryLength
+	}
+	return k
+}
+
+// baseCode returns the 2-bit encoding of a base and false if it isn't A, T, G or C.
+func baseCode(b byte) (uint32, bool) {
+	switch b {
+	case 'A':
+		return 0, true
+	case 'C':
+		return 1, true
+	case 'G':
+		return 2, true
+	case 'T':
+		return 3, true
+	}
+	return 0, false
+}
+
+// eachKmer calls fn with each k-mer of the seq, up to 16 bp, and its start index.
+// k-mers with bases other than A, T, G and C are skipped.
+func eachKmer(seq string, k int, fn func(key uint32, start int)) {
+	if k < 1 {
+		return
+	}
+
+	mask := uint32(1<<(2*uint(k))) - 1
+	if k == 16 {
+		mask = ^uint32(0)
+	}
+
+	var key uint32
+	valid := 0 // number of valid bases at the end of the key
+	for i := 0; i < len(seq); i++ {
+		code, ok := baseCode(seq[i])
+		if !ok {
+			valid = 0
+			continue
+		}
+
+		key = (key<<2 | code) & mask
+		if valid++; valid >= k {
+			fn(key, i-k+1)
+		}
+	}
+}
+
+// kmers returns an index of the query's k-mers to their start indexes.
+func kmers(query string, k int) map[uint32][]int {
+	index := make(map[uint32][]int)
+	eachKmer(query, k, func(key uint32, start int) {
+		index[key] = append(index[key], start)
+	})
+	return index
+}
+
+// nativeHit is an ungapped alignment between a query and subject.
+type nativeHit struct {
+	queryStart   int
+	subjectStart int
+	length       int
+	mismatching  int
+}
+
+// extendSeeds finds the query's k-mers in the subject and extends each, in both
+// directions, until its score drops nativeXDrop below its best. Each diagonal is
+// only extended once through a stretch of the subject.
+func extendSeeds(query, subject string, seeds map[uint32][]int, k, penalty int) (hits []nativeHit) {
+	extended := make(map[int]int) // diagonal to the end of its last hit in the subject
+
+	eachKmer(subject, k, func(key uint32, s int) {
+		for _, q := range seeds[key] {
+			diagonal := s - q
+			if end, seen := extended[diagonal]; seen && s < end {
+				continue
+			}
+
+			h := extend(query, subject, q, s, k, penalty)
+			extended[diagonal] = h.subjectStart + h.length
+			hits = append(hits, h)
+		}
+	})
+
+	return hits
+}
+
+// extend extends a seed between the query at q and the subject at s without gaps.
+func extend(query, subject string, q, s, k, penalty int) nativeHit {
+	// to the left of the seed
+	left, score, best := 0, 0, 0
+	for i := 1; q-i >= 0 && s-i >= 0; i++ {
+		if query[q-i] == subject[s-i] {
+			score++
+		} else {
+			score -= penalty
+		}
+
+		if score > best {
+			left, best = i, score
+		} else if best-score > nativeXDrop {
+			break
+		}
+	}
+
+	// to the right of the seed
+	right, score, best := 0, 0, 0
+	for i := k; q+i < len(query) && s+i < len(subject); i++ {
+		if query[q+i] == subject[s+i] {
+			score++
+		} else {
+			score -= penalty
+		}
+
+		if score > best {
+			right, best = i-k+1, score
+		} else if best-score > nativeXDrop {
+			break
+		}
+	}
+
+	h := nativeHit{
+		queryStart:   q - left,
+		subjectStart: s - left,
+		length:       left + k + right,
+	}
+	for i := 0; i < h.length; i++ {
+		if query[h.queryStart+i] != subject[h.subjectStart+i] {
+			h.mismatching++
+		}
+	}
+
+	return h
+}
+
+// eachFasta calls fn with the header, without its '>', and sequence of each entry
+// in a FASTA file. The file is streamed, so large dbs aren't read into memory.
+func eachFasta(path string, fn func(header, seq string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return scanFasta(file, func(header, seq string) bool {
+		fn(header, seq)
+		return true
+	})
+}
+
+// scanFasta reads FASTA entries from r until fn returns false or r is empty.
+func scanFasta(r io.Reader, fn func(header, seq string) bool) error {
+	reader := bufio.NewReaderSize(r, 1<<16)
+
+	header, hasHeader := "", false
+	var seq strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ">") {
+			if hasHeader && !fn(header, seq.String()) {
+				return nil
+			}
+			header, hasHeader = strings.TrimSpace(line[1:]), true
+			seq.Reset()
+		} else if hasHeader && !strings.HasPrefix(line, ";") {
+			seq.WriteString(line)
+		} else if line != "" && !hasHeader {
+			return fmt.Errorf("not a FASTA file, the native aligner needs FASTA files at the paths of databases")
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if hasHeader {
+		fn(header, seq.String())
+	}
+	return nil
+}
+
+// fastaIndexes are the offsets of entries in FASTA files, keyed by the files' paths.
+// An index is made again if its file has changed.
+var fastaIndexes = struct {
+	sync.Mutex
+	files map[string]fastaIndex
+}{files: make(map[string]fastaIndex)}
+
+// fastaIndex is the byte offsets of the entries in a FASTA file by their IDs.
+type fastaIndex struct {
+	modTime time.Time
+	offsets map[string]int64
+}
+
+// fastaOffset returns the offset of an entry's header in a FASTA file. Entries
+// are found by the first word of their headers or, for IDs like gnl|addgene|1234,
+// the ID after the last '|'.
+func fastaOffset(path, entry string) (int64, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false, err
+	}
+
+	fastaIndexes.Lock()
+	defer fastaIndexes.Unlock()
+
+	index, indexed := fastaIndexes.files[path]
+	if !indexed || !index.modTime.Equal(info.ModTime()) {
+		offsets, err := indexFasta(path)
+		if err != nil {
+			return 0, false, err
+		}
+		index = fastaIndex{modTime: info.ModTime(), offsets: offsets}
+		fastaIndexes.files[path] = index
+	}
+
+	offset, found := index.offsets[entry]
+	return offset, found, nil
+}
+
+// indexFasta returns the offsets of the headers of entries in a FASTA file.
+func indexFasta(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	offsets := make(map[string]int64)
+	short := make(map[string]int64)
+	reader := bufio.NewReaderSize(file, 1<<16)
+	var offset int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if strings.HasPrefix(line, ">") {
+			if fields := strings.Fields(line[1:]); len(fields) > 0 {
+				id := fields[0]
+				offsets[id] = offset
+				if i := strings.LastIndex(id, "|"); i >= 0 && i < len(id)-1 {
+					short[id[i+1:]] = offset
+				}
+			}
+		}
+		offset += int64(len(line))
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	for id, offset := range short {
+		if _, exists := offsets[id]; !exists {
+			offsets[id] = offset
+		}
+	}
+
+	return offsets, nil
+}
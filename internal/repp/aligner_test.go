@@ -0,0 +1,81 @@
+package repp
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func Test_native_align(t *testing.T) {
+	testDB, _ := filepath.Abs(path.Join("..", "..", "test", "db", "db"))
+
+	frags, err := read(testDB, false)
+	if err != nil || len(frags) < 2 {
+		t.Fatalf("failed to read the test db: %v", err)
+	}
+
+	// the start of the first entry, with a mismatch, and the reverse complement of part of the second.
+	// They're separated by Ns so the matches don't extend into one another
+	first := []byte(frags[0].Seq[:200])
+	first[100] = 'A'
+	if frags[0].Seq[100] == 'A' {
+		first[100] = 'T'
+	}
+	second := reverseComplement(frags[1].Seq[300:500])
+	seq := string(first) + "NNNNNNNNNN" + second
+
+	q := &alignQuery{name: "test_target", seq: seq, db: testDB, identity: 98, internal: true}
+	matches, err := native{}.align(context.Background(), q, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(want match) {
+		for _, m := range matches {
+			if m.entry == want.entry && m.queryStart == want.queryStart && m.queryEnd == want.queryEnd &&
+				m.subjectStart == want.subjectStart && m.subjectEnd == want.subjectEnd &&
+				m.forward == want.forward && m.mismatching == want.mismatching {
+				if !m.internal || m.db != testDB || m.querySeq != seq[m.queryStart:m.queryEnd+1] {
+					t.Errorf("align() match = %+v", m)
+				}
+				return
+			}
+		}
+		t.Errorf("align() failed to find %+v in %+v", want, matches)
+	}
+
+	find(match{entry: "gnl|addgene|107006", queryStart: 0, queryEnd: 199, subjectStart: 0, subjectEnd: 199, forward: true, mismatching: 1})
+	find(match{entry: "gnl|addgene|85039.1", queryStart: 210, queryEnd: 409, subjectStart: 300, subjectEnd: 499, forward: false})
+
+	// entries that match a filter are skipped
+	if filtered, _ := (native{}).align(context.Background(), q, []string{"107006"}); len(filtered) >= len(matches) {
+		t.Errorf("align() with a filter = %d matches, want fewer than %d", len(filtered), len(matches))
+	}
+}
+
+func Test_native_entry(t *testing.T) {
+	testDB, _ := filepath.Abs(path.Join("..", "..", "test", "db", "db"))
+
+	frags, err := read(testDB, false)
+	if err != nil || len(frags) < 1 {
+		t.Fatalf("failed to read the test db: %v", err)
+	}
+
+	for _, entry := range []string{"gnl|addgene|107006", "107006"} {
+		file, seq, err := native{}.entry(context.Background(), entry, testDB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(file.Name())
+
+		if seq != frags[0].Seq {
+			t.Errorf("entry(%s) = %s, want %s", entry, seq, frags[0].Seq)
+		}
+	}
+
+	if _, _, err := (native{}).entry(context.Background(), "missing", testDB); err == nil {
+		t.Error("entry() found an entry that isn't in the db")
+	}
+}
@@ -1,106 +1,84 @@
 package repp
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 
-	"github.com/spf13/cobra"
+	"github.com/jjtimmons/repp/config"
 )
 
-// Annotate is for annotating a plasmid sequence given the features in the feature database.
-// If an output path is provided, the annotated plasmid is writen to that file. Otherwise,
-// the feature matches are written to stdout.
-func Annotate(cmd *cobra.Command, args []string) {
-	output, _ := cmd.Flags().GetString("out")
+// Feature is a feature found in a sequence by Annotate.
+type Feature struct {
+	// Name of the feature in the features database (or the entry in a BLAST db)
+	Name string `json:"name"`
 
-	identity, err := cmd.Flags().GetInt("identity")
-	if err != nil {
-		identity = 96 // might be something other than `repp plasmid`
-	}
+	// Start index of the feature on the sequence (0-indexed)
+	Start int `json:"start"`
 
-	p := inputParser{}
-	filters, err := cmd.Flags().GetString("exclude")
-	excludeFilters := []string{}
-	if err == nil {
-		excludeFilters = p.getFilters(filters)
-	}
+	// End index of the feature on the sequence (0-indexed)
+	End int `json:"end"`
 
-	name := ""
-	query := "" // the plasmid sequence that we're querying
-	if len(args) > 0 {
-		query = args[0]
-	} else {
-		in, err := cmd.Flags().GetString("in")
-		if in == "" || err != nil {
-			cmd.Help()
-			stderr.Fatalln("must pass a file with a plasmid sequence or the plasmid sequence as an argument.")
-		}
+	// Forward is whether the feature is on the top strand of the sequence
+	Forward bool `json:"forward"`
+}
 
-		frags, err := read(in, false)
-		if err != nil {
-			stderr.Fatalln(err)
-		}
-		name = frags[0].ID
-		query = frags[0].Seq
+// Annotate is for annotating a plasmid sequence given the features in the feature database.
+// If dbs are set in the flags, their entries are used as features instead. If toCull is true,
+// features that are enclosed in others are removed.
+func Annotate(ctx context.Context, name, seq string, flags *Flags, toCull bool) ([]Feature, error) {
+	if seq == "" {
+		return nil, fmt.Errorf("must pass a file with a plasmid sequence or the plasmid sequence as an argument")
 	}
 
-	toCull, _ := cmd.Flags().GetBool("cull")
-	namesOnly, _ := cmd.Flags().GetBool("names")
-
-	addgene, err := cmd.Flags().GetBool("addgene") // use addgene db?
-	if err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse addgene flag: %v", err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	igem, err := cmd.Flags().GetBool("igem") // use igem db?
+	fDB, err := flags.features()
 	if err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse igem flag: %v", err)
+		return nil, err
 	}
 
-	dnasu, err := cmd.Flags().GetBool("dnasu") // use dnasu db?
+	matches, err := annotate(ctx, fDB, name, seq, flags.identity, flags.dbs, flags.filters, toCull, flags.conf)
 	if err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse dnasu flag: %v", err)
+		return nil, err
 	}
 
-	dbString, err := cmd.Flags().GetString("dbs")
-	if err != nil && !addgene {
-		cmd.Help()
-		stderr.Fatalf("failed to parse building fragments: %v", err)
+	var features []Feature
+	for _, m := range matches {
+		features = append(features, Feature{
+			Name:    m.entry,
+			Start:   m.queryStart,
+			End:     m.queryEnd,
+			Forward: m.forward,
+		})
 	}
 
-	dbs, err := p.parseDBs(dbString, addgene, igem, dnasu)
-	if err != nil {
-		stderr.Fatalf("failed to find any fragment databases: %v", err)
-	}
-
-	annotate(name, query, output, identity, dbs, excludeFilters, toCull, namesOnly)
+	return features, nil
 }
 
-// annotate is for executing blast against the query sequence.
-func annotate(name, seq, output string, identity int, dbs, filters []string, toCull, namesOnly bool) {
-	handleErr := func(err error) {
-		if err != nil {
-			stderr.Fatalln(err)
-		}
+// WriteGenbank writes a sequence, annotated with its features, to a Genbank file.
+func WriteGenbank(filename, name, seq string, features []Feature) error {
+	var feats []match
+	for _, f := range features {
+		feats = append(feats, match{
+			entry:      f.Name,
+			queryStart: f.Start,
+			queryEnd:   f.End,
+			forward:    f.Forward,
+		})
 	}
 
-	in, err := ioutil.TempFile("", "annotate-in-*")
-	handleErr(err)
-	defer os.Remove(in.Name())
-
-	out, err := ioutil.TempFile("", "annotate-out-*")
-	handleErr(err)
-	defer os.Remove(out.Name())
+	return writeGenbank(filename, annotatedRecord(name, seq, feats))
+}
 
+// annotate is for executing blast against the query sequence.
+func annotate(ctx context.Context, fDB *FeatureDB, name, seq string, identity int, dbs, filters []string, toCull bool, conf *config.Config) ([]match, error) {
 	// create a subject file with all the blast features
-	fDB := NewFeatureDB()
 	featIndex := 0
 	var featureSubjects strings.Builder
 	indexToFeature := make(map[int]string)
@@ -110,15 +88,16 @@ func annotate(name, seq, output string, identity int, dbs, filters []string, toC
 		featIndex++
 	}
 	subjectFile, err := ioutil.TempFile("", "features-*")
-	handleErr(err)
+	if err != nil {
+		return nil, err
+	}
 	defer os.Remove(subjectFile.Name())
 
-	_, err = subjectFile.WriteString(featureSubjects.String())
-	handleErr(err)
+	if _, err = subjectFile.WriteString(featureSubjects.String()); err != nil {
+		return nil, err
+	}
 
-	b := &blastExec{
-		in:       in,
-		out:      out,
+	q := &alignQuery{
 		name:     name,
 		subject:  subjectFile.Name(),
 		seq:      seq,
@@ -129,10 +108,9 @@ func annotate(name, seq, output string, identity int, dbs, filters []string, toC
 	features := []match{}
 	if len(dbs) < 1 {
 		// if the user selected another db, don't use the internal one
-		handleErr(b.input())
-		handleErr(b.runAgainst())
-		features, err = b.parse(filters)
-		handleErr(err)
+		if features, err = newAligner(conf).align(ctx, q, filters); err != nil {
+			return nil, err
+		}
 
 		// get rid of features that start past the zero index, wrap that those that go around it
 		// get rid of features matches that aren't 100% of the feature in the feature database
@@ -157,12 +135,13 @@ func annotate(name, seq, output string, identity int, dbs, filters []string, toC
 		}
 		features = cleanedFeatures
 	} else {
-		features, err = blast(name, seq, false, dbs, filters, identity, blastWriter())
-		handleErr(err)
+		if features, err = blast(ctx, name, seq, false, dbs, filters, identity, conf); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(features) < 1 {
-		stderr.Fatal("no features found")
+		return nil, fmt.Errorf("no features found")
 	}
 
 	sortMatches(features)
@@ -173,28 +152,5 @@ func annotate(name, seq, output string, identity int, dbs, filters []string, toC
 		// }
 	}
 
-	if namesOnly {
-		featuresNames := []string{}
-		for _, feature := range features {
-			dir := ""
-			if !feature.forward {
-				dir += ":rev"
-			}
-			featuresNames = append(featuresNames, feature.entry+dir)
-		}
-		fmt.Println(strings.Join(featuresNames, ", "))
-	} else if output != "" {
-		writeGenbank(output, name, seq, []*Frag{}, features)
-	} else {
-		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
-		fmt.Fprintf(tw, "\nfeatures (%d)\tstart\tend\tdirection\t\n", len(features))
-		for _, feat := range features {
-			dir := "FWD"
-			if !feat.forward {
-				dir = "REV"
-			}
-			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t\n", feat.entry, feat.queryStart+1, feat.queryEnd+1, dir)
-		}
-		tw.Flush()
-	}
+	return features, nil
 }
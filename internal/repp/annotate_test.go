@@ -1,12 +1,14 @@
 package repp
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func Test_annotate(t *testing.T) {
 	type args struct {
 		name     string
 		seq      string
-		output   string
 		identity int
 		dbs      []string
 		filters  []string
@@ -21,7 +23,6 @@ func Test_annotate(t *testing.T) {
 			args{
 				"BBa_E0610",
 				"TTTACGGCTAGCTCAGTCCTAGGTACAATGCTAGCTACTAGATGAAGTACCTGCTGCCGACCGCGGCGGCGGGTCTGCTGCTGCTGGCGGCGCAGCCGGCGATGGCGGACGATGACGATGACATGAACTTCCCGCGTGCGAGCCGTCTGATGCAGGCGGCGGTGCTGGGTGGCCTGATGGCGGTTAGCGCGGCGGCGACCGCGCAAACCAACCCGTATGCGCGTGGTCCGAACCCGACCGCGGCGAGCCTGGAGGCGAGCGCGGGTCCGTTCACCGTGCGTAGCTTTACCGTTAGCCGTCCGAGCGGTTACGGTGCGGGTACCGTGTACTATCCGACCAACGCGGGTGGCACCGTGGGTGCGATCGCGATTGTTCCGGGTTATACCGCGCGTCAGAGCAGCATCAAATGGTGGGGTCCGCGTCTGGCGAGCCACGGTTTTGTGGTTATCACCATTGATACCAACAGCACCCTGGACCAGCCGAGCAGCCGTAGCAGCCAGCAAATGGCGGCGCTGCGTCAAGTTGCGAGCCTGAACGGTACCAGCAGCAGCCCGATCTACGGCAAGGTGGATACCGCGCGTATGGGCGTTATGGGTTGGAGCATGGGTGGCGGTGGCAGCCTGATTAGCGCGGCGAACAACCCGAGCCTGAAAGCTGCGGCGCCGCAAGCGCCGTGGGACAGCAGCACCAACTTCAGCAGCGTGACCGTTCCGACCCTGATCTTTGCGTGCGAGAACGATAGCATTGCGCCGGTGAACAGCAGCGCGCTGCCGATCTACGACAGCATGAGCCGTAACGCGAAGCAGTTCCTGGAAATTAACGGTGGCAGCCACAGCTGCGCGAACAGCGGTAACAGCAACCAAGCGCTGATTGGCAAGAAAGGTGTGGCGTGGATGAAACGTTTCATGGATAACGACACCCGTTATAGCACCTTTGCGTGCGAAAACCCGAACAGCACCCGTGTTAGCGATTTTCGTACCGCGAATTGCAGCTAATAATACTAGAGAAAGAGGAGAAATACTAGATGAGTGTGATCGCTAAACAAATGACCTACAAGGTTTATATGTCAGGCACGGTCAATGGACACTACTTTGAGGTCGAAGGCGATGGAAAAGGTAAGCCCTACGAGGGGGAGCAGACGGTAAAGCTCACTGTCACCAAGGGCGGACCTCTGCCATTTGCTTGGGATATTTTATCACCACAGTGTCAGTACGGAAGCATACCATTCACCAAGTACCCTGAAGACATCCCTGACTATGTAAAGCAGTCATTCCCGGAGGGCTATACATGGGAGAGGATCATGAACTTTGAAGATGGTGCAGTGTGTACTGTCAGCAATGATTCCAGCATCCAAGGCAACTGTTTCATCTACCATGTCAAGTTCTCTGGTTTGAACTTTCCTCCCAATGGACCTGTCATGCAGAAGAAGACACAGGGCTGGGAACCCAACACTGAGCGTCTCTTTGCACGAGATGGAATGCTGCTAGGAAACAACTTTATGGCTCTGAAGTTAGAAGGAGGCGGTCACTATTTGTGTGAATTTAAAACTACTTACAAGGCAAAGAAGCCTGTGAAGATGCCAGGGTATCACTATGTTGACCGCAAACTGGATGTAACCAATCACAACAAGGATTACACTTCGGTTGAGCAGTGTGAAATTTCCATTGCACGCAAACCTGTGGTCGCCTAATAATACTAGAGCCAGGCATCAAATAAAACGAAAGGCTCAGTCGAAAGACTGGGCCTTTCGTTTTATCTGTTGTTTGTCGGTGAACGCTCTCTACTAGAGTCACACTGGCTCACCTTCGGGTGGGCCTTTCTGCGTTTATACGCGGCCGCTTCTAGAGTACTAGTAGCGGCCGCTGCAGTCCGGCAAAAAAGGGCAAGGTGTCACCACCCTGCCCTTTTTCTTTAAAACCGAAAAGATTACTTCGCGTTATGCAGGCTTCCTCGCTCACTGACTCGCTGCGCTCGGTCGTTCGGCTGCGGCGAGCGGTATCAGCTCACTCAAAGGCGGTAATACGGTTATCCACAGAATCAGGGGATAACGCAGGAAAGAACATGTGAGCAAAAGGCCAGCAAAAGGCCAGGAACCGTAAAAAGGCCGCGTTGCTGGCGTTTTTCCACAGGCTCCGCCCCCCTGACGAGCATCACAAAAATCGACGCTCAAGTCAGAGGTGGCGAAACCCGACAGGACTATAAAGATACCAGGCGTTTCCCCCTGGAAGCTCCCTCGTGCGCTCTCCTGTTCCGACCCTGCCGCTTACCGGATACCTGTCCGCCTTTCTCCCTTCGGGAAGCGTGGCGCTTTCTCATAGCTCACGCTGTAGGTATCTCAGTTCGGTGTAGGTCGTTCGCTCCAAGCTGGGCTGTGTGCACGAACCCCCCGTTCAGCCCGACCGCTGCGCCTTATCCGGTAACTATCGTCTTGAGTCCAACCCGGTAAGACACGACTTATCGCCACTGGCAGCAGCCACTGGTAACAGGATTAGCAGAGCGAGGTATGTAGGCGGTGCTACAGAGTTCTTGAAGTGGTGGCCTAACTACGGCTACACTAGAAGAACAGTATTTGGTATCTGCGCTCTGCTGAAGCCAGTTACCTTCGGAAAAAGAGTTGGTAGCTCTTGATCCGGCAAACAAACCACCGCTGGTAGCGGTGGTTTTTTTGTTTGCAAGCAGCAGATTACGCGCAGAAAAAAAGGATCTCAAGAAGATCCTTTGATCTTTTCTACGGGGTCTGACGCTCAGTGGAACGAAAACTCACGTTAAGGGATTTTGGTCATGAGATTATCAAAAAGGATCTTCACCTAGATCCTTTTAAATTAAAAATGAAGTTTTAAATCAATCTAAAGTATATATGAGTAAACTTGGTCTGACAGCTCGAGGCTTGGATTCTCACCAATAAAAAACGCCCGGCGGCAACCGAGCGTTCTGAACAAATCCAGATGGAGTTCTGAGGTCATTACTGGATCTATCAACAGGAGTCCAAGCGAGCTCGATATCAAATTACGCCCCGCCCTGCCACTCATCGCAGTACTGTTGTAATTCATTAAGCATTCTGCCGACATGGAAGCCATCACAAACGGCATGATGAACCTGAATCGCCAGCGGCATCAGCACCTTGTCGCCTTGCGTATAATATTTGCCCATGGTGAAAACGGGGGCGAAGAAGTTGTCCATATTGGCCACGTTTAAATCAAAACTGGTGAAACTCACCCAGGGATTGGCTGAGACGAAAAACATATTCTCAATAAACCCTTTAGGGAAATAGGCCAGGTTTTCACCGTAACACGCCACATCTTGCGAATATATGTGTAGAAACTGCCGGAAATCGTCGTGGTATTCACTCCAGAGCGATGAAAACGTTTCAGTTTGCTCATGGAAAACGGTGTAACAAGGGTGAACACTATCCCATATCACCAGCTCACCGTCTTTCATTGCCATACGAAATTCCGGATGAGCATTCATCAGGCGGGCAAGAATGTGAATAAAGGCCGGATAAAACTTGTGCTTATTTTTCTTTACGGTCTTTAAAAAGGCCGTAATATCCAGCTGAACGGTCTGGTTATAGGTACATTGAGCAACTGACTGAAATGCCTCAAAATGTTCTTTACGATGCCATTGGGATATATCAACGGTGGTATATCCAGTGATTTTTTTCTCCATTTTAGCTTCCTTAGCTCCTGAAAATCTCGATAACTCAAAAAATACGCCCGGTAGTGATCTTATTTCATTATGGTGAAAGTTGGAACCTCTTACGTGCCCGATCAACTCGAGTGCCACCTGACGTCTAAGAAACCATTATTATCATGACATTAACCTATAAAAATAGGCGTATCACGAGGCAGAATTTCAGATAAAAAAAATCCTTAGCTTTCGCTAAGGATGATTTCTGG",
-				"",
 				100,
 				[]string{},
 				[]string{},
@@ -29,9 +30,16 @@ func Test_annotate(t *testing.T) {
 			},
 		},
 	}
+	featureDB, err := LoadFeatureDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			annotate(tt.args.name, tt.args.seq, tt.args.output, tt.args.identity, tt.args.dbs, tt.args.filters, tt.args.enclosed, false)
+			if _, err := annotate(context.Background(), featureDB, tt.args.name, tt.args.seq, tt.args.identity, tt.args.dbs, tt.args.filters, tt.args.enclosed, nil); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
@@ -1,8 +1,10 @@
 package repp
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"math"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -22,8 +24,9 @@ type assembly struct {
 	synths int
 }
 
-// add Frag to the end of an assembly. Return a new assembly and whether it circularized
-func (a *assembly) add(f *Frag, maxCount, targetLength int, features bool) (newAssembly assembly, created, circularized bool) {
+// add Frag to the end of an assembly. Return a new assembly and whether it circularized.
+// Its cost is estimated for Golden Gate assembly if gg is not nil
+func (a *assembly) add(f *Frag, maxCount, targetLength int, features bool, gg *goldenGate) (newAssembly assembly, created, circularized bool) {
 	firstStart := a.frags[0].start
 	start := f.start
 	end := f.end
@@ -64,7 +67,7 @@ func (a *assembly) add(f *Frag, maxCount, targetLength int, features bool) (newA
 	created = true
 
 	// calc the estimated dollar cost of getting to the next Frag
-	annealCost := last.costTo(f)
+	annealCost := last.costTo(f, gg)
 	if selfAnnealing && synths == 0 {
 		annealCost = 0 // does not cost extra to anneal to the first fragment
 	}
@@ -107,7 +110,7 @@ func (a *assembly) len() int {
 	return len(a.frags) + a.synths
 }
 
-// log logs a description of the assembly (the entires in it and its cost).
+// log logs a description of the assembly (the entires in it and its cost) at the debug level.
 func (a *assembly) log() {
 	logString := ""
 	if len(a.frags) >= 1 {
@@ -116,12 +119,12 @@ func (a *assembly) log() {
 		}
 	}
 
-	fmt.Println(fmt.Sprintf("%s- $%.2f", logString, a.cost))
+	logger.Debug("assembly", "frags", strings.TrimSpace(logString), "cost", a.cost)
 }
 
 // fill traverses frags in an assembly and adds primers or makes syntheic fragments where necessary.
 // It can fail. For example, a PCR Frag may have off-targets in the parent plasmid.
-func (a *assembly) fill(target string, conf *config.Config) (frags []*Frag, err error) {
+func (a *assembly) fill(ctx context.Context, target string, conf *config.Config) (frags []*Frag, err error) {
 	min := conf.FragmentsMinHomology
 	max := conf.FragmentsMaxHomology
 
@@ -138,11 +141,13 @@ func (a *assembly) fill(target string, conf *config.Config) (frags []*Frag, err
 
 		return []*Frag{
 			&Frag{
-				ID:       f.ID,
-				Seq:      strings.ToUpper(f.Seq)[0:len(target)], // it may be longer
-				fragType: circular,
-				URL:      f.URL,
-				conf:     conf,
+				ID:        f.ID,
+				Seq:       strings.ToUpper(f.Seq)[0:len(target)], // it may be longer
+				fragType:  circular,
+				URL:       f.URL,
+				db:        f.db,
+				inventory: f.inventory,
+				conf:      conf,
 			},
 		}, nil
 	}
@@ -180,8 +185,8 @@ func (a *assembly) fill(target string, conf *config.Config) (frags []*Frag, err
 
 		// if the Frag has a full target from upload or
 		if needsPCR {
-			if err := f.setPrimers(last, next, target, conf); err != nil || len(f.Primers) < 2 {
-				return nil, fmt.Errorf("failed to pcr %s: %v", f.ID, err)
+			if err := f.setPrimers(ctx, last, next, target, conf); err != nil || len(f.Primers) < 2 {
+				return nil, &PrimerError{Fragment: f.ID, Err: err}
 			}
 			f.fragType = pcr // is now a pcr type
 		}
@@ -199,9 +204,11 @@ func (a *assembly) fill(target string, conf *config.Config) (frags []*Frag, err
 
 		// add synthesized fragments between this Frag and the next (if necessary)
 		next := a.mockNext(frags, i, target, conf)
-		if synthedFrags := f.synthTo(next, target); synthedFrags != nil {
-			fragsWithSynth = append(fragsWithSynth, synthedFrags...)
+		synthedFrags, err := f.synthTo(ctx, next, target)
+		if err != nil {
+			return nil, err
 		}
+		fragsWithSynth = append(fragsWithSynth, synthedFrags...)
 	}
 	frags = fragsWithSynth
 
@@ -261,7 +268,10 @@ func (a *assembly) duplicates(frags []*Frag, min, max int) (isDup bool, first, s
 //   foreach otherFragment that fragment overlaps with + reachSynthCount more:
 //	   foreach assembly on fragment:
 //       add otherFragment to the assembly to create a new assembly, store on otherFragment
-func createAssemblies(frags []*Frag, target string, targetLength int, features bool, conf *config.Config) (assemblies []assembly) {
+//
+// Costs are estimated for Golden Gate assembly if gg is not nil. It returns the context's
+// error if it's cancelled.
+func createAssemblies(ctx context.Context, frags []*Frag, target string, targetLength int, features bool, gg *goldenGate, conf *config.Config) (assemblies []assembly, err error) {
 	// number of additional frags try synthesizing to, in addition to those that
 	// already have enough homology for overlap without any modifications for each Frag
 	maxNodes := conf.FragmentsMaxCount
@@ -281,23 +291,27 @@ func createAssemblies(frags []*Frag, target string, targetLength int, features b
 					frags:  []*Frag{f.copy()},
 					synths: 0,
 				},
-			}
+			}, nil
 		}
 
 		// create a starting assembly for each fragment containing just it
 		frags[i].assemblies = []assembly{
 			assembly{
 				frags:  []*Frag{f.copy()}, // just self
-				cost:   f.costTo(f),       // just PCR,
+				cost:   f.costTo(f, gg),   // just PCR,
 				synths: 0,                 // no synthetic frags at start
 			},
 		}
 	}
 
 	for i, f := range frags { // for every Frag in the list of increasing start index frags
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		for _, j := range f.reach(frags, i, features) { // for every overlapping fragment + reach more
 			for _, a := range f.assemblies { // for every assembly on the reaching fragment
-				newAssembly, created, circularized := a.add(frags[j], maxNodes, targetLength, features)
+				newAssembly, created, circularized := a.add(frags[j], maxNodes, targetLength, features, gg)
 
 				if !created { // if a new assembly wasn't created, move on
 					continue
@@ -317,18 +331,23 @@ func createAssemblies(frags []*Frag, target string, targetLength int, features b
 	// in case all other plasmid designs fail
 	mockStart := &Frag{start: conf.FragmentsMinHomology, end: conf.FragmentsMinHomology, conf: conf}
 	mockEnd := &Frag{start: len(target), end: len(target), conf: conf}
-	synths := mockStart.synthTo(mockEnd, target)
-	assemblies = append(assemblies, assembly{
-		frags:  synths,
-		cost:   mockStart.costTo(mockEnd),
-		synths: len(synths),
-	})
-
-	if conf.Verbose {
-		fmt.Printf("%d assemblies made\n", len(assemblies))
+	synths, err := mockStart.synthTo(ctx, mockEnd, target)
+	var synthErr *SynthesisError
+	if errors.As(err, &synthErr) {
+		logger.Debug("skipping the fully synthetic assembly", "error", err)
+	} else if err != nil {
+		return nil, err
+	} else {
+		assemblies = append(assemblies, assembly{
+			frags:  synths,
+			cost:   mockStart.costTo(mockEnd, gg),
+			synths: len(synths),
+		})
 	}
 
-	return assemblies
+	logger.Debug("assemblies made", "count", len(assemblies))
+
+	return assemblies, nil
 }
 
 // groupAssembliesByCount returns a map from the number of fragments in a build
@@ -358,55 +377,249 @@ func groupAssembliesByCount(assemblies []assembly) ([]int, map[int][]assembly) {
 	return counts, countToAssemblies
 }
 
-// fillAssemblies fills in assemblies and returns the pareto optimal solutions.
-func fillAssemblies(target string, counts []int, countToAssemblies map[int][]assembly, conf *config.Config) (solutions [][]*Frag) {
-	// append a fully synthetic solution at first, nothing added should cost more than this (single plasmid)
-	filled := make(map[int][]*Frag)
-	minCostAssembly := math.MaxFloat64
-
+// fillAssemblies fills in assemblies and returns the pareto optimal solutions: the best,
+// by the objective, of each fragment count that are better than those with fewer fragments.
+// The objective's number of solutions are kept for each fragment count, rather than just the best,
+// and an assembly is pruned once that many with as many or fewer fragments are better.
+// The solutions are sorted by fragment count and then score.
+// Assemblies are filled for Golden Gate assembly if gg is not nil and for Gibson Assembly otherwise.
+// It stops early, returning the solutions filled so far, if the context is cancelled.
+//
+// Assemblies are filled in parallel by a pool of workers, a few ahead of the one being
+// considered. They're considered in order, by count and then cost, so the solutions are
+// the same as if they were filled one at a time.
+//
+// If the design is being explained, each assembly is recorded as a candidate with the
+// reason it failed or was pruned.
+func fillAssemblies(ctx context.Context, target string, counts []int, countToAssemblies map[int][]assembly, gg *goldenGate, obj objective, conf *config.Config) (solutions [][]*Frag) {
+	// the assemblies, and their counts, in the order they're considered
+	var toFill []assembly
+	var toFillCounts []int
 	for _, count := range counts {
-		for _, assemblyToFill := range countToAssemblies[count] {
-			if assemblyToFill.cost > minCostAssembly {
-				// skip this and the rest with this count, there's another
-				// cheaper option with the same number or fewer fragments (estimated)
-				break
+		for _, a := range countToAssemblies[count] {
+			toFill = append(toFill, a)
+			toFillCounts = append(toFillCounts, count)
+		}
+	}
+
+	// the candidates of the explanation, one per assembly, if the design is being explained
+	var candidates []Candidate
+	if explanation := explanationOf(ctx); explanation != nil {
+		candidates = make([]Candidate, len(toFill))
+		for i, a := range toFill {
+			candidates[i] = newCandidate(a)
+		}
+		defer func() {
+			explanation.Candidates = append(explanation.Candidates, candidates...)
+		}()
+	}
+	record := func(i int, outcome, reason string, a ...interface{}) {
+		if candidates != nil {
+			candidates[i].Outcome = outcome
+			candidates[i].Reason = fmt.Sprintf(reason, a...)
+		}
+	}
+
+	// filled are the solutions so far, sorted from best to worst by the objective
+	type filledAssembly struct {
+		frags     []*Frag
+		cost      float64
+		score     float64
+		candidate int // the index of the assembly it was filled from
+	}
+	var filled []filledAssembly
+	better := func(a, b filledAssembly) bool {
+		return a.score < b.score || (a.score == b.score && a.cost < b.cost)
+	}
+	keep := obj.keep()
+
+	// duplicate returns whether the filled fragments are the same as those of a solution
+	duplicate := func(frags []*Frag) bool {
+		key := fragsKey(frags)
+		for _, f := range filled {
+			if fragsKey(f.frags) == key {
+				return true
 			}
+		}
+		return false
+	}
 
-			filledFragments, err := assemblyToFill.fill(target, conf)
-			if err != nil || filledFragments == nil {
-				// assemblyToFill.log()
-				// fmt.Println("error", err.Error())
-				// stderr.Fatal(err)
-				continue
+	// bound is the filled assembly that a new one has to be better than, the worst of
+	// the best few. Nothing added should cost more than it if ranking by cost
+	bound := func() (filledAssembly, bool) {
+		if len(filled) < keep {
+			return filledAssembly{}, false
+		}
+		return filled[keep-1], true
+	}
+
+	// skippedCounts are counts whose remaining assemblies are skipped
+	skippedCounts := make(map[int]bool)
+	skipped := func(i int) bool {
+		if skippedCounts[toFillCounts[i]] {
+			return true
+		}
+		b, bounded := bound()
+		return obj.costOnly() && bounded && toFill[i].cost > b.cost
+	}
+
+	// start the workers. Each sends the filled fragments of an assembly to its result
+	type fillResult struct {
+		frags []*Frag
+		err   error
+	}
+	poolSize := workers(conf)
+	jobs := make(chan int, 2*poolSize)
+	results := make([]chan fillResult, len(toFill))
+	defer close(jobs)
+	for w := 0; w < poolSize; w++ {
+		go func() {
+			for i := range jobs {
+				var r fillResult
+				if ctx.Err() != nil {
+					r.err = ctx.Err()
+				} else if gg != nil {
+					r.frags, r.err = toFill[i].fillGoldenGate(ctx, target, gg, conf)
+				} else {
+					r.frags, r.err = toFill[i].fill(ctx, target, conf)
+				}
+				results[i] <- r
 			}
+		}()
+	}
 
-			newAssemblyCost := fragsCost(filledFragments)
+	queued := 0 // assemblies before this have been queued or skipped
+	for i := range toFill {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if skipped(i) {
+			// skip this and the rest with this count, there are enough other
+			// cheaper options with the same number or fewer fragments (estimated)
+			skippedCounts[toFillCounts[i]] = true
+			b, _ := bound()
+			record(i, outcomePruned, "its estimated cost, $%.2f, is more than $%.2f, the cost of a filled assembly with %d fragments", toFill[i].cost, b.cost, len(b.frags))
+			continue
+		}
 
-			if newAssemblyCost >= minCostAssembly || len(filledFragments) > conf.FragmentsMaxCount {
-				continue // wasn't actually cheaper, keep trying
+		// queue this and the next few assemblies that won't be skipped
+		for ; queued < len(toFill) && queued < i+cap(jobs); queued++ {
+			if !skipped(queued) {
+				results[queued] = make(chan fillResult, 1)
+				jobs <- queued
 			}
-			minCostAssembly = newAssemblyCost // store this as the new cheapest assembly
+		}
 
-			// delete all assemblies with more fragments that cost more
-			for filledCount, existingFilledFragments := range filled {
-				if filledCount < len(filledFragments) {
-					continue
-				}
+		var r fillResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		reportProgress(ctx, func(p *Progress) { p.Filled++ })
 
-				existingCost := fragsCost(existingFilledFragments)
-				if existingCost >= newAssemblyCost {
-					delete(filled, filledCount)
+		filledFragments, err := r.frags, r.err
+		if err != nil {
+			logger.Debug("failed to fill assembly", "error", err)
+			record(i, outcomeFailed, "%v", err)
+			continue
+		}
+		if filledFragments == nil {
+			record(i, outcomeFailed, "no fragments after filling")
+			continue
+		}
+
+		newAssembly := filledAssembly{frags: filledFragments, cost: fragsCost(filledFragments), candidate: i}
+		newAssembly.score = obj.score(newAssembly.cost, newScores(filledFragments, gg, conf))
+		if candidates != nil {
+			candidates[i].Cost = newAssembly.cost
+		}
+
+		if duplicate(filledFragments) {
+			// another assembly was filled to the same fragments
+			record(i, outcomePruned, "it's the same, once filled, as another filled assembly")
+			continue
+		}
+		if b, bounded := bound(); bounded && !better(newAssembly, b) {
+			// wasn't actually better, keep trying
+			record(i, outcomePruned, "its %s, %s, isn't better than %s, that of a filled assembly with %d fragments", obj.label(), obj.format(newAssembly.score), obj.format(b.score), len(b.frags))
+			continue
+		}
+		if len(filledFragments) > conf.FragmentsMaxCount {
+			record(i, outcomeFailed, "it has %d fragments after filling, more than the max of %d", len(filledFragments), conf.FragmentsMaxCount)
+			continue
+		}
+
+		// add this in order and prune those with as many or more fragments that are
+		// worse than enough others with as many or fewer fragments
+		at := sort.Search(len(filled), func(j int) bool { return better(newAssembly, filled[j]) })
+		filled = append(filled[:at], append([]filledAssembly{newAssembly}, filled[at:]...)...)
+
+		var kept []filledAssembly
+		for _, f := range filled {
+			// the better assemblies kept with as many or fewer fragments, and the
+			// keep-th of them that outranks this one
+			fewer := 0
+			var worst filledAssembly
+			for _, k := range kept {
+				if len(k.frags) <= len(f.frags) {
+					if fewer++; fewer == keep {
+						worst = k
+					}
 				}
 			}
-
-			// set this is as the new cheapest of this length
-			filled[len(filledFragments)] = filledFragments
+			if fewer >= keep {
+				record(f.candidate, outcomePruned, "its %s, %s, isn't better than %s, that of a filled assembly with %d fragments", obj.label(), obj.format(f.score), obj.format(worst.score), len(worst.frags))
+				continue
+			}
+			kept = append(kept, f)
 		}
+		filled = kept
 	}
 
-	for _, frags := range filled {
-		solutions = append(solutions, frags) // flatten
+	// sort the solutions by their fragment count and then by the objective
+	sort.SliceStable(filled, func(i, j int) bool {
+		return len(filled[i].frags) < len(filled[j].frags)
+	})
+	for _, f := range filled {
+		solutions = append(solutions, f.frags)
+		record(f.candidate, outcomeSolution, "")
+	}
+	if ctx.Err() != nil {
+		for i := range candidates {
+			if candidates[i].Outcome == outcomeUnfilled {
+				record(i, outcomeUnfilled, "the design stopped before it was filled")
+			}
+		}
 	}
 
 	return solutions
 }
+
+// fragsKey returns a key of filled fragments that's the same for assemblies filled to
+// the same fragments, primers and synthetic sequences, even if they start at another
+// fragment of the plasmid.
+func fragsKey(frags []*Frag) string {
+	var keys []string
+	for _, f := range frags {
+		key := fmt.Sprintf("%s|%s|%s", f.ID, f.fragType, f.Seq)
+		for _, p := range f.Primers {
+			key += "|" + p.Seq
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// workers returns the number of assemblies to fill at once
+func workers(conf *config.Config) int {
+	if conf.Workers > 0 {
+		return conf.Workers
+	}
+	return runtime.NumCPU()
+}
@@ -1,6 +1,8 @@
 package repp
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -13,6 +15,7 @@ func Test_assembly_add(t *testing.T) {
 	c.FragmentsMaxCount = 5
 	c.PCRMaxEmbedLength = 0
 	c.PCRMinLength = 0
+	c.SyntheticMinLength = 0
 	c.SyntheticMaxLength = 100
 	c.CostSyntheticFragment = map[int]config.SynthCost{
 		100000: {
@@ -78,7 +81,7 @@ func Test_assembly_add(t *testing.T) {
 			},
 			assembly{
 				frags:  []*Frag{n1, n2},
-				cost:   n1.costTo(n2),
+				cost:   n1.costTo(n2, nil),
 				synths: 0,
 			},
 			true,
@@ -96,7 +99,7 @@ func Test_assembly_add(t *testing.T) {
 			},
 			assembly{
 				frags:  []*Frag{n1, n3},
-				cost:   10.0 + n1.costTo(n3),
+				cost:   10.0 + n1.costTo(n3, nil),
 				synths: 1,
 			},
 			true,
@@ -166,7 +169,7 @@ func Test_assembly_add(t *testing.T) {
 				cost:   tt.fields.cost,
 				synths: tt.fields.synths,
 			}
-			gotNewAssembly, gotCreated, gotComplete := a.add(tt.args.n, 5, sl, false)
+			gotNewAssembly, gotCreated, gotComplete := a.add(tt.args.n, 5, sl, false, nil)
 			if !reflect.DeepEqual(gotNewAssembly, tt.wantNewAssembly) {
 				t.Errorf("assembly.add() gotNewAssembly = %v, want %v", gotNewAssembly, tt.wantNewAssembly)
 			}
@@ -453,3 +456,62 @@ func Test_assembly_duplicates(t *testing.T) {
 		})
 	}
 }
+
+func Test_fillAssemblies(t *testing.T) {
+	c := config.New()
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	// assemblies of a single fragment spanning the target. They all cost the same
+	// so the first is the only solution, however many are filled at once
+	var assemblies []assembly
+	for i := 0; i < 20; i++ {
+		f := &Frag{ID: fmt.Sprintf("frag%d", i), Seq: target, fragType: circular, conf: c}
+		assemblies = append(assemblies, assembly{frags: []*Frag{f}, cost: float64(i)})
+	}
+	counts, countToAssemblies := groupAssembliesByCount(assemblies)
+
+	for _, workers := range []int{1, 2, 8} {
+		var progress Progress
+		ctx := WithProgress(context.Background(), func(p Progress) { progress = p })
+
+		c.Workers = workers
+		solutions := fillAssemblies(ctx, target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 1}, c)
+		if len(solutions) != 1 || len(solutions[0]) != 1 || solutions[0][0].ID != "frag0" {
+			t.Errorf("fillAssemblies() with %d workers = %v, want frag0", workers, solutions)
+		}
+		if progress.Filled < 1 {
+			t.Errorf("fillAssemblies() with %d workers reported %d filled", workers, progress.Filled)
+		}
+	}
+
+	// nothing is filled after the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if solutions := fillAssemblies(ctx, target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 1}, c); len(solutions) != 0 {
+		t.Errorf("fillAssemblies() after cancel = %v, want none", solutions)
+	}
+}
+
+func Test_fillAssemblies_duplicates(t *testing.T) {
+	c := config.New()
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	// the first two assemblies are filled to the same fragment
+	var assemblies []assembly
+	for i, id := range []string{"frag0", "frag0", "frag1"} {
+		f := &Frag{ID: id, Seq: target, fragType: circular, conf: c}
+		assemblies = append(assemblies, assembly{frags: []*Frag{f}, cost: float64(i)})
+	}
+	counts, countToAssemblies := groupAssembliesByCount(assemblies)
+
+	// the same fragments starting at another one of them
+	a := []*Frag{&Frag{ID: "a", Seq: "ATGC", fragType: pcr}, &Frag{ID: "b", Seq: "GGCC", fragType: synthetic}}
+	if fragsKey(a) != fragsKey([]*Frag{a[1], a[0]}) {
+		t.Error("fragsKey() differs for the same fragments starting at another one")
+	}
+
+	solutions := fillAssemblies(context.Background(), target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 3}, c)
+	if len(solutions) != 2 || solutions[0][0].ID != "frag0" || solutions[1][0].ID != "frag1" {
+		t.Errorf("fillAssemblies() = %v, want frag0 and frag1", solutions)
+	}
+}
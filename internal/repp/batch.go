@@ -0,0 +1,292 @@
+package repp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// Batch is the designs of every target sequence in an input file, planned together
+// as a library.
+type Batch struct {
+	// Targets are the designs, in the order of the input file
+	Targets []BatchTarget `json:"targets"`
+
+	// Shared are the plasmids, primers, PCR products and synthetic fragments used by
+	// multiple targets
+	Shared []SharedItem `json:"shared,omitempty"`
+
+	// Cost of the library: the chosen solutions of every target with shared items paid for once
+	Cost float64 `json:"cost"`
+
+	// IsolatedCost is the cost of the cheapest solution of every target, each paid for in isolation
+	IsolatedCost float64 `json:"isolatedCost"`
+
+	// Execution is the number of seconds it took to design every target
+	Execution float64 `json:"execution"`
+}
+
+// BatchTarget is the design of a single target in a Batch, summarized by the
+// solution chosen for the library.
+type BatchTarget struct {
+	// Target's name
+	Target string `json:"target"`
+
+	// Solution is the index of the chosen solution in the target's output
+	Solution int `json:"solution"`
+
+	// Count is the number of fragments in the chosen solution
+	Count int `json:"count"`
+
+	// Cost of the chosen solution, on its own
+	Cost float64 `json:"cost"`
+
+	// Shared is the number of items in the chosen solution that other targets use
+	Shared int `json:"shared"`
+
+	// Error is why the target failed to be designed
+	Error string `json:"error,omitempty"`
+
+	// ErrorCode is the kind of the Error, eg "missing_database", if it has one
+	ErrorCode string `json:"errorCode,omitempty"`
+
+	// File the target's output was written to, if outputs were written separately
+	File string `json:"file,omitempty"`
+
+	// Output is the target's design, if outputs weren't written separately
+	Output *Output `json:"output,omitempty"`
+}
+
+// SharedItem is something used by multiple targets in a Batch. It's procured,
+// or prepared, once.
+type SharedItem struct {
+	// Kind of item: plasmid, primer, pcr or synthetic
+	Kind string `json:"kind"`
+
+	// Name of the item: a plasmid's URL, a primer's sequence, or a fragment's source or ID
+	Name string `json:"name"`
+
+	// Cost of the item
+	Cost float64 `json:"cost"`
+
+	// Targets that use the item
+	Targets []string `json:"targets"`
+}
+
+// SequenceBatch designs a plasmid for every target sequence in the input, up to
+// parallel at a time. Targets that fail to design are recorded with their error
+// rather than stopping the others. If split is true, each target's output is
+// written to its own file beside the flags' out path, which gets the summary. In
+// the Genbank and SBOL formats, each target is always written to its own file.
+//
+// If the context is cancelled or times out, targets that were being designed keep the
+// best solutions found so far, those that weren't started fail, and the batch is written
+// and returned with the context's error.
+func SequenceBatch(ctx context.Context, flags *Flags, conf *config.Config, parallel int, split bool) (*Batch, error) {
+	start := time.Now()
+
+	targets, err := flags.read(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target sequences from %s: %v", flags.in, err)
+	}
+
+	// the enzymes are read once, rather than by each target's design
+	if flags.method == methodGoldenGate {
+		if _, err := flags.enzymes(); err != nil {
+			return nil, err
+		}
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// targets are designed at once, so there's no single design to report the progress of
+	targetCtx := withoutProgress(ctx)
+
+	outputs := make([]*Output, len(targets))
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *Frag) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+
+			// each target gets its own copy of the backbone, it's modified during the design
+			targetFlags := *flags
+			targetFlags.frags = []*Frag{target}
+			targetFlags.backbone = flags.backbone.copy()
+			targetFlags.out = ""
+
+			if outputs[i], errs[i] = Sequence(targetCtx, &targetFlags, conf); outputs[i] != nil {
+				errs[i] = nil // stopped early, with the best solutions found so far
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	batch := newBatch(targets, outputs, errs)
+	batch.Execution = time.Since(start).Seconds()
+
+	if flags.out != "" {
+		// Genbank and SBOL files are per target, so they're always written separately
+		if split || flags.format != formatJSON {
+			if err := writeSplit(flags.out, flags.format, batch); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := writeJSON(flags.out, batch); err != nil {
+			return nil, err
+		}
+	}
+
+	return batch, ctx.Err()
+}
+
+// newBatch plans the targets' designs as a library and summarizes them by the
+// solutions chosen for it.
+func newBatch(targets []*Frag, outputs []*Output, errs []error) *Batch {
+	batch := &Batch{}
+	solutions := make([][]Solution, len(targets))
+	for i, target := range targets {
+		bt := BatchTarget{Target: target.ID, Solution: -1, Output: outputs[i]}
+		if errs[i] != nil {
+			bt.Error = strings.TrimSpace(errs[i].Error())
+			bt.ErrorCode = ErrorCode(errs[i])
+		} else if len(outputs[i].Solutions) == 0 {
+			bt.Error = "no solutions found"
+		} else {
+			solutions[i] = outputs[i].Solutions
+		}
+
+		batch.Targets = append(batch.Targets, bt)
+	}
+
+	choices, cost := planLibrary(solutions)
+	batch.Cost = roundCost(cost)
+
+	// find the items used by multiple targets, counting each once per target
+	var keys []string
+	items := make(map[string]libraryItem)
+	usedBy := make(map[string][]int)
+	for i, choice := range choices {
+		if choice < 0 {
+			continue
+		}
+
+		cheapest := solutions[i][0].Cost
+		for _, s := range solutions[i] {
+			if s.Cost < cheapest {
+				cheapest = s.Cost
+			}
+		}
+		batch.IsolatedCost += cheapest
+
+		chosen := solutions[i][choice]
+		batch.Targets[i].Solution = choice
+		batch.Targets[i].Count = chosen.Count
+		batch.Targets[i].Cost = chosen.Cost
+
+		for _, item := range newLibraryOption(chosen).items {
+			if _, exists := items[item.key]; !exists {
+				keys = append(keys, item.key)
+				items[item.key] = item
+			}
+			usedBy[item.key] = append(usedBy[item.key], i)
+		}
+	}
+	batch.IsolatedCost = roundCost(batch.IsolatedCost)
+
+	for _, key := range keys {
+		users := usedBy[key]
+		if len(users) < 2 {
+			continue
+		}
+
+		item := items[key]
+		shared := SharedItem{Kind: item.kind, Name: item.name, Cost: roundCost(item.cost)}
+		for _, i := range users {
+			shared.Targets = append(shared.Targets, targets[i].ID)
+			batch.Targets[i].Shared++
+		}
+		batch.Shared = append(batch.Shared, shared)
+	}
+
+	return batch
+}
+
+// roundCost rounds a cost to the cent.
+func roundCost(cost float64) float64 {
+	rounded, _ := strconv.ParseFloat(fmt.Sprintf("%.2f", cost), 64)
+	return rounded
+}
+
+// unsafeFilename matches characters that shouldn't be in a target's output filename
+var unsafeFilename = regexp.MustCompile(`[^\w.-]+`)
+
+// writeSplit writes each target's output to its own file beside the out path, eg
+// "designs.output.json" to "designs.output.target_1.json", and removes the outputs
+// from the batch. In the Genbank format, it's the target's chosen solution that's
+// written, eg to "designs.output.target_1.gb". In the SBOL format, it's an SBOL
+// document of the target's output, eg "designs.output.target_1.jsonld".
+func writeSplit(out, format string, batch *Batch) error {
+	ext := filepath.Ext(out)
+	stem := strings.TrimSuffix(out, ext)
+	switch format {
+	case formatGenbank:
+		ext = ".gb"
+	case formatSBOL:
+		ext = ".jsonld"
+	}
+
+	used := make(map[string]int)
+	for i, bt := range batch.Targets {
+		if bt.Output == nil || (format == formatGenbank && bt.Solution < 0) {
+			continue
+		}
+
+		name := unsafeFilename.ReplaceAllString(bt.Target, "_")
+		if used[name]++; used[name] > 1 {
+			name += "_" + strconv.Itoa(used[name])
+		}
+
+		filename := stem + "." + name + ext
+		switch format {
+		case formatGenbank:
+			record := solutionRecord(bt.Output, bt.Solution, bt.Output.Solutions[bt.Solution])
+			if err := writeGenbank(filename, record); err != nil {
+				return err
+			}
+		case formatSBOL:
+			if err := writeJSON(filename, sbolOutput(bt.Output)); err != nil {
+				return err
+			}
+		default:
+			if err := writeJSON(filename, bt.Output); err != nil {
+				return err
+			}
+		}
+
+		batch.Targets[i].File = filename
+		batch.Targets[i].Output = nil
+	}
+
+	return nil
+}
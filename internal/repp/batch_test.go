@@ -0,0 +1,108 @@
+package repp
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_newBatch(t *testing.T) {
+	conf := testLibraryConfig()
+	targets := []*Frag{&Frag{ID: "a"}, &Frag{ID: "b"}, &Frag{ID: "c"}}
+	outputs := []*Output{
+		&Output{Solutions: []Solution{
+			Solution{Count: 1, Cost: 60, Fragments: []*Frag{testSynthetic("a-1", "GGCC", conf)}},
+			Solution{Count: 1, Cost: 95, Fragments: []*Frag{testPCR("a-2", conf)}},
+		}},
+		&Output{Solutions: []Solution{
+			Solution{Count: 1, Cost: 95, Fragments: []*Frag{testPCR("b-1", conf)}},
+		}},
+		nil,
+	}
+	errs := []error{nil, nil, errors.New("failed to blast c")}
+
+	batch := newBatch(targets, outputs, errs)
+
+	if len(batch.Targets) != 3 {
+		t.Fatalf("newBatch() has %d targets, want 3", len(batch.Targets))
+	}
+
+	// a's PCR product is chosen because b already needs it
+	if a := batch.Targets[0]; a.Solution != 1 || a.Cost != 95 || a.Count != 1 || a.Shared != 4 {
+		t.Errorf("newBatch() target a = %+v, want its second solution with 4 shared items", a)
+	}
+	if c := batch.Targets[2]; c.Error != "failed to blast c" || c.Solution != -1 {
+		t.Errorf("newBatch() target c = %+v, want its error", c)
+	}
+
+	// the plasmid, both primers and the PCR product are shared
+	if len(batch.Shared) != 4 {
+		t.Errorf("newBatch() shared %d items, want 4", len(batch.Shared))
+	}
+	if batch.Cost != 95 || batch.IsolatedCost != 155 {
+		t.Errorf("newBatch() cost = %.2f, isolated cost = %.2f, want 95 and 155", batch.Cost, batch.IsolatedCost)
+	}
+}
+
+func Test_writeSplit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	batch := &Batch{Targets: []BatchTarget{
+		BatchTarget{Target: "target 1", Output: &Output{Target: "target 1"}},
+		BatchTarget{Target: "target/1", Output: &Output{Target: "target/1"}},
+		BatchTarget{Target: "failed", Error: "failed"},
+	}}
+
+	if err := writeSplit(filepath.Join(dir, "designs.output.json"), formatJSON, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "designs.output.target_1.json"),
+		filepath.Join(dir, "designs.output.target_1_2.json"),
+		"",
+	}
+	for i, bt := range batch.Targets {
+		if bt.File != want[i] {
+			t.Errorf("writeSplit() file = %s, want %s", bt.File, want[i])
+		}
+		if bt.Output != nil {
+			t.Errorf("writeSplit() left the output of %s in the batch", bt.Target)
+		}
+		if bt.File != "" {
+			if _, err := os.Stat(bt.File); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func Test_primerCache(t *testing.T) {
+	c := &primerCache{primers: make(map[string][]Primer), errs: make(map[string]error)}
+
+	if _, _, made := c.get("a"); made {
+		t.Error("primerCache.get() found primers that weren't made")
+	}
+
+	c.set("a", []Primer{Primer{Seq: "ATGC"}}, nil)
+	c.set("b", nil, errors.New("failed"))
+
+	if primers, err, made := c.get("a"); !made || err != nil || primers[0].Seq != "ATGC" {
+		t.Errorf("primerCache.get() = %v, %v, %v", primers, err, made)
+	}
+	if _, err, made := c.get("b"); !made || err == nil {
+		t.Errorf("primerCache.get() = %v, %v, want the error", err, made)
+	}
+
+	// the same PCR run on a different target isn't shared
+	last, f, next := &Frag{end: 10}, &Frag{uniqueID: "frag", start: 5, end: 100}, &Frag{start: 95}
+	if primerHash(last, f, next, "ATGC") == primerHash(last, f, next, "GGCC") {
+		t.Error("primerHash() is the same for different targets")
+	}
+}
@@ -1,6 +1,7 @@
 package repp
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,13 +10,39 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"text/tabwriter"
+	"sync"
 
 	"github.com/jjtimmons/repp/config"
 )
 
-// mismatchResults is a map from primer key to mismatch check results
-var mismatchResults = make(map[string]mismatchResult)
+// mismatchResults are the results of prior off-target checks
+var mismatchResults = &mismatchCache{results: make(map[string]mismatchResult)}
+
+// mismatchCache is a store of off-target check results, keyed by offtargetKey. It's
+// safe for concurrent use, so assemblies filled in parallel can share it.
+type mismatchCache struct {
+	mu sync.Mutex
+
+	// results of off-target checks
+	results map[string]mismatchResult
+}
+
+// get returns the result of a prior off-target check and whether there was one.
+func (c *mismatchCache) get(key string) (mismatchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, checked := c.results[key]
+	return result, checked
+}
+
+// set stores the result of an off-target check.
+func (c *mismatchCache) set(key string, result mismatchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[key] = result
+}
 
 // match is a blast "hit" in the blastdb.
 type match struct {
@@ -62,8 +89,8 @@ type match struct {
 	forward bool
 }
 
-// blastExec is a small utility object for executing BLAST.
-type blastExec struct {
+// alignQuery is a sequence to find, with an aligner, in a database or a subject FASTA file.
+type alignQuery struct {
 	// the name of the query
 	name string
 
@@ -76,12 +103,6 @@ type blastExec struct {
 	// the path to the database we're BLASTing against
 	db string
 
-	// the input BLAST file
-	in *os.File
-
-	// the output BLAST file
-	out *os.File
-
 	// optional path to a FASTA file with a subject FASTA sequence
 	subject string
 
@@ -96,6 +117,20 @@ type blastExec struct {
 
 	// the expect value of a BLAST query (defaults to 10)
 	evalue int
+
+	// the number of threads blastn searches with. One if it's not set
+	threads int
+}
+
+// blastExec is a small utility object for executing BLAST.
+type blastExec struct {
+	*alignQuery
+
+	// the input BLAST file
+	in *os.File
+
+	// the output BLAST file
+	out *os.File
 }
 
 // mismatchResults are the results of a seqMismatch check. saved
@@ -135,86 +170,125 @@ func (m *match) copyWithQueryRange(start, end int) match {
 	}
 }
 
-// log the match in string format
+// log the match at the debug level
 func (m *match) log() {
-	fmt.Printf("%s %d %d\n", m.entry, m.queryStart, m.queryEnd)
+	logger.Debug("match", "entry", m.entry, "start", m.queryStart, "end", m.queryEnd)
 }
 
-// blast the seq against all dbs and acculate matches.
+// blast the seq against all dbs and acculate matches. The dbs are searched at once,
+// splitting the settings' thread budget between them.
 func blast(
+	ctx context.Context,
 	name, seq string,
 	circular bool,
 	dbs, filters []string,
 	identity int,
-	tw *tabwriter.Writer,
+	conf *config.Config,
 ) ([]match, error) {
-	in, err := ioutil.TempFile("", "blast-in-*")
-	if err != nil {
-		return nil, err
+	al := newAligner(conf)
+
+	// make sure the dbs exist
+	for _, db := range dbs {
+		if _, err := os.Stat(db); os.IsNotExist(err) {
+			return nil, &MissingDatabaseError{Path: db}
+		}
 	}
-	defer os.Remove(in.Name())
 
-	out, err := ioutil.TempFile("", "blast-out-*")
-	if err != nil {
-		return nil, err
+	threads := 1
+	if len(dbs) > 0 {
+		threads = blastThreads(conf) / len(dbs)
+	}
+	if threads < 1 {
+		threads = 1
 	}
-	defer os.Remove(out.Name())
 
-	matches := []match{}
-	for _, db := range dbs {
+	dbMatches := make([][]match, len(dbs))
+	dbErrs := make([]error, len(dbs))
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		// dbs outside the registry are local, their fragments are on hand
 		internal := true
-		if strings.Contains(db, "addgene") || strings.Contains(db, "igem") || strings.Contains(db, "dnasu") {
-			internal = false
+		if d, registered := conf.Database(db, ""); registered {
+			internal = d.Internal
 		}
 
-		b := &blastExec{
+		q := &alignQuery{
 			name:     name,
 			seq:      seq,
 			circular: circular,
 			db:       db,
-			in:       in,
-			out:      out,
 			internal: internal,
 			identity: identity,
+			threads:  threads,
 		}
 
-		// make sure the db exists
-		if _, err := os.Stat(db); os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to find a BLAST database at %s", db)
-		}
-
-		// create the input file
-		if err := b.input(); err != nil {
-			return nil, fmt.Errorf("failed to write a BLAST input file at %s: %v", b.in.Name(), err)
-		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
 
-		// execute BLAST
-		if err := b.run(); err != nil {
-			return nil, fmt.Errorf("failed executing BLAST: %v", err)
-		}
+			var cached bool
+			if dbMatches[i], cached = loadMatches(q, filters, conf); cached {
+				return
+			}
+			if dbMatches[i], dbErrs[i] = al.align(ctx, q, filters); dbErrs[i] == nil {
+				storeMatches(q, filters, dbMatches[i], conf)
+			}
+		}(i)
+	}
+	wg.Wait()
 
-		// parse the output file to Matches against the Frag
-		dbMatches, err := b.parse(filters)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse BLAST output: %v", err)
+	// accumulate the matches in the order of the dbs
+	matches := []match{}
+	for i, db := range dbs {
+		if dbErrs[i] != nil {
+			return nil, dbErrs[i]
 		}
 
-		fmt.Fprintf(tw, "%s\t%d\t%s\n", name, len(dbMatches), db)
-
-		// add these matches against the growing list of matches
-		matches = append(matches, dbMatches...)
+		logger.Debug("blasted", "entry", name, "matches", len(dbMatches[i]), "database", db)
+		matches = append(matches, dbMatches[i]...)
 	}
 
 	return matches, nil
 }
 
+// blastThreads returns the number of threads shared by the blastn searches of a query.
+func blastThreads(conf *config.Config) int {
+	if conf != nil && conf.BlastThreads > 0 {
+		return conf.BlastThreads
+	}
+	return runtime.NumCPU() - 1
+}
+
 // blast an entry against a pre-made subject database
 func blastAgainst(
+	ctx context.Context,
 	name, seq, subject string,
 	circular bool,
 	identity int,
-	tw *tabwriter.Writer,
+	conf *config.Config,
 ) (matches []match, err error) {
+	q := &alignQuery{
+		name:     name,
+		seq:      seq,
+		circular: circular,
+		subject:  subject,
+		internal: true,
+		identity: identity,
+	}
+
+	// make sure the subject file exists
+	if _, err := os.Stat(subject); os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to find a BLAST subject at %s", subject)
+	}
+
+	return newAligner(conf).align(ctx, q, []string{})
+}
+
+// blastn is the aligner that executes blastn and blastdbcmd from BLAST+.
+type blastn struct{}
+
+// align BLASTs the query against its db or subject file and parses the output into matches.
+func (blastn) align(ctx context.Context, q *alignQuery, filters []string) ([]match, error) {
 	in, err := ioutil.TempFile("", "blast-in-*")
 	if err != nil {
 		return nil, err
@@ -227,26 +301,7 @@ func blastAgainst(
 	}
 	defer os.Remove(out.Name())
 
-	internal := true
-	if strings.Contains(name, "addgene") || strings.Contains(name, "igem") || strings.Contains(name, "dnasu") {
-		internal = false
-	}
-
-	b := &blastExec{
-		name:     name,
-		seq:      seq,
-		circular: circular,
-		subject:  subject,
-		in:       in,
-		out:      out,
-		internal: internal,
-		identity: identity,
-	}
-
-	// make sure the subject file exists
-	if _, err := os.Stat(subject); os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to find a BLAST subject at %s", subject)
-	}
+	b := &blastExec{alignQuery: q, in: in, out: out}
 
 	// create the input file
 	if err := b.input(); err != nil {
@@ -254,24 +309,27 @@ func blastAgainst(
 	}
 
 	// execute BLAST
-	if err := b.runAgainst(); err != nil {
+	if q.subject != "" {
+		err = b.runAgainst(ctx)
+	} else {
+		err = b.run(ctx)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed executing BLAST: %v", err)
 	}
 
 	// parse the output file to Matches against the Frag
-	if matches, err = b.parse([]string{}); err != nil {
+	matches, err := b.parse(filters)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse BLAST output: %v", err)
 	}
 
 	return matches, nil
 }
 
-// blastWriter returns a new tabwriter specifically for blast database calls.
-func blastWriter() *tabwriter.Writer {
-	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
-	fmt.Fprintf(tw, "entry\tmatches\tdatabase\t\n")
-
-	return tw
+// entry queries an entry from a BLAST db with blastdbcmd.
+func (blastn) entry(ctx context.Context, entry, db string) (*os.File, string, error) {
+	return blastdbcmd(ctx, entry, db)
 }
 
 // input creates an input query file (FASTA) for blastn.
@@ -290,8 +348,8 @@ func (b *blastExec) input() error {
 }
 
 // run calls the external blastn binary on the input file.
-func (b *blastExec) run() (err error) {
-	threads := runtime.NumCPU() - 1
+func (b *blastExec) run(ctx context.Context) (err error) {
+	threads := b.threads
 	if threads < 1 {
 		threads = 1
 	}
@@ -350,7 +408,7 @@ func (b *blastExec) run() (err error) {
 	}
 
 	// https://www.ncbi.nlm.nih.gov/books/NBK279682/
-	blastCmd := exec.Command("blastn", flags...)
+	blastCmd := exec.CommandContext(ctx, "blastn", flags...)
 
 	// execute BLAST and wait on it to finish
 	if output, err := blastCmd.CombinedOutput(); err != nil {
@@ -369,7 +427,6 @@ func (b *blastExec) parse(filters []string) (matches []match, err error) {
 	}
 	fileS := string(file)
 
-	fullQuery := b.seq + b.seq
 	identityThreshold := float64(b.identity)/100.0 - 0.0001
 
 	// read it into Matches
@@ -427,48 +484,53 @@ func (b *blastExec) parse(filters []string) (matches []match, err error) {
 			forward = !forward
 		}
 
-		// filter on titles
-		matchesFilter := false
-		titles += entry
-		titles = strings.ToUpper(titles)
-		for _, f := range filters {
-			if strings.Contains(titles, f) {
-				matchesFilter = true
-				break
-			}
-		}
-		if matchesFilter {
-			continue // has been filtered out because of the "exclude" CLI flag
-		}
-
-		// get a unique identifier to distinguish this match/fragment from the others
-		uniqueID := entry + strconv.Itoa(queryStart%len(b.seq))
-
-		// gather the query sequence
-		querySeq := fullQuery[queryStart : queryEnd+1]
-
-		// create and append the new match
-		ms = append(ms, match{
+		m, keep := b.hit(match{
 			entry:        entry,
-			uniqueID:     uniqueID,
-			querySeq:     querySeq,
 			queryStart:   queryStart,
 			queryEnd:     queryEnd,
 			seq:          seq,
 			subjectStart: subjectStart,
 			subjectEnd:   subjectEnd,
-			circular:     strings.Contains(entry+titles, "CIRCULAR"),
 			mismatching:  mismatching + gaps,
-			internal:     b.internal,
-			db:           b.db,
 			title:        titles,
 			forward:      forward,
-		})
+		}, filters)
+		if keep {
+			ms = append(ms, m)
+		}
 	}
 
 	return ms, nil
 }
 
+// hit completes a match of the query found by an aligner. It returns false if the
+// match should be skipped: its entry matches a filter or is out of stock.
+func (q *alignQuery) hit(m match, filters []string) (match, bool) {
+	// filter on titles
+	titles := strings.ToUpper(m.title + m.entry)
+	for _, f := range filters {
+		if strings.Contains(titles, f) {
+			return m, false // has been filtered out because of the "exclude" CLI flag
+		}
+	}
+
+	// skip entries that are out of stock in the db's inventory
+	if e := inventoryLookup(q.db, m.entry); e != nil && !e.inStock() {
+		return m, false
+	}
+
+	fullQuery := q.seq + q.seq
+
+	m.uniqueID = m.entry + strconv.Itoa(m.queryStart%len(q.seq)) // distinguishes this match/fragment from the others
+	m.querySeq = fullQuery[m.queryStart : m.queryEnd+1]
+	m.circular = strings.Contains(m.entry+titles, "CIRCULAR")
+	m.internal = q.internal
+	m.db = q.db
+	m.title = titles
+
+	return m, true
+}
+
 // culling removes matches that are engulfed in others
 //
 // culling fragment matches means removing those that are completely
@@ -566,7 +628,7 @@ func sortMatches(matches []match) {
 }
 
 // queryDatabases is for finding a fragment/plasmid with the entry name in one of the dbs
-func queryDatabases(entry string, dbs []string) (f *Frag, err error) {
+func queryDatabases(ctx context.Context, entry string, dbs []string, conf *config.Config) (f *Frag, err error) {
 	// first try to get the entry out of a local file
 	if frags, err := read(entry, false); err == nil && len(frags) > 0 {
 		return frags[0], nil // it was a local file
@@ -577,10 +639,11 @@ func queryDatabases(entry string, dbs []string) (f *Frag, err error) {
 	dbSourceCh := make(chan string, len(dbs))
 
 	// move through each db and see if it contains the entry
+	al := newAligner(conf)
 	for _, db := range dbs {
 		go func(db string) {
 			// if outFile is defined here we managed to query the entry from the db
-			outFile, _, err := blastdbcmd(entry, db)
+			outFile, _, err := al.entry(ctx, entry, db)
 			if err == nil && outFile != nil {
 				outFileCh <- outFile.Name() // "" if not found
 				dbSourceCh <- db
@@ -610,6 +673,7 @@ func queryDatabases(entry string, dbs []string) (f *Frag, err error) {
 			}
 
 			targetFrag.db = dbSource
+			targetFrag.inventory = inventoryLookup(dbSource, targetFrag.ID)
 			return targetFrag, nil
 		}
 
@@ -627,7 +691,7 @@ func queryDatabases(entry string, dbs []string) (f *Frag, err error) {
 // seqMismatch queries for any mismatching primer locations in the parent sequence
 // unlike parentMismatch, it doesn't first find the parent fragment from the db it came from
 // the sequence is passed directly as parentSeq
-func seqMismatch(primers []Primer, parentID, parentSeq string, conf *config.Config) mismatchResult {
+func seqMismatch(ctx context.Context, primers []Primer, parentID, parentSeq string, conf *config.Config) mismatchResult {
 	parentFile, err := ioutil.TempFile("", "parent-*")
 	if err != nil {
 		return mismatchResult{false, match{}, err}
@@ -644,7 +708,7 @@ func seqMismatch(primers []Primer, parentID, parentSeq string, conf *config.Conf
 
 	// check each primer for mismatches
 	for _, primer := range primers {
-		wasMismatch, m, err := mismatch(primer.Seq, parentFile, conf)
+		wasMismatch, m, err := mismatch(ctx, primer.Seq, parentFile, conf)
 		if wasMismatch || err != nil {
 			return mismatchResult{wasMismatch, m, err}
 		}
@@ -655,16 +719,16 @@ func seqMismatch(primers []Primer, parentID, parentSeq string, conf *config.Conf
 
 // parentMismatch both searches for a the parent fragment in its source DB and queries for
 // any mismatches in the seq before returning
-func parentMismatch(primers []Primer, parent, db string, conf *config.Config) mismatchResult {
+func parentMismatch(ctx context.Context, primers []Primer, parent, db string, conf *config.Config) mismatchResult {
 	// try and query for the parent in the source DB and write to a file
-	parentFile, parentSeq, err := blastdbcmd(parent, db)
+	parentFile, parentSeq, err := newAligner(conf).entry(ctx, parent, db)
 
 	// ugly check here for whether we just failed to get the parent entry from a db
 	// which isn't a huge deal (shouldn't be flagged as a mismatch)
 	// this is similar to what io.IsNotExist does
 	if err != nil {
 		if strings.Contains(err.Error(), "failed to query") {
-			stderr.Println(err) // just write the error
+			logger.Warn(err.Error()) // just log the error
 			// TODO: if we fail to find the parent, query the fullSeq as it was sent
 			return mismatchResult{false, match{}, nil}
 		}
@@ -687,7 +751,7 @@ func parentMismatch(primers []Primer, parent, db string, conf *config.Config) mi
 			}
 
 			// check for a mismatch in the parent sequence
-			wasMismatch, m, err := mismatch(primer.Seq, parentFile, conf)
+			wasMismatch, m, err := mismatch(ctx, primer.Seq, parentFile, conf)
 			if wasMismatch || err != nil {
 				return mismatchResult{wasMismatch, m, err}
 			}
@@ -701,7 +765,7 @@ func parentMismatch(primers []Primer, parent, db string, conf *config.Config) mi
 // results to a temporary file (to be BLAST'ed against)
 //
 // entry here is the ID that's associated with the fragment in its source DB (db)
-func blastdbcmd(entry, db string) (output *os.File, parentSeq string, err error) {
+func blastdbcmd(ctx context.Context, entry, db string) (output *os.File, parentSeq string, err error) {
 	// path to the entry batch file to hold the entry accession
 	entryFile, err := ioutil.TempFile("", "blastcmd-in-*")
 	if err != nil {
@@ -724,7 +788,8 @@ func blastdbcmd(entry, db string) (output *os.File, parentSeq string, err error)
 	}
 
 	// make a blastdbcmd command (for querying a DB, very different from blastn)
-	queryCmd := exec.Command(
+	queryCmd := exec.CommandContext(
+		ctx,
 		"blastdbcmd",
 		"-db", db,
 		"-dbtype", "nucl",
@@ -754,46 +819,20 @@ func blastdbcmd(entry, db string) (output *os.File, parentSeq string, err error)
 // the parent sequence (in the parent file)
 //
 // The fragment to query against is stored in parentFile
-func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch bool, m match, err error) {
-	// path to the entry batch file to hold the entry accession
-	in, err := ioutil.TempFile("", "primer3-in-*")
-	if err != nil {
-		return false, match{}, err
-	}
-	defer os.Remove(in.Name())
-
-	// path to the output sequence file from querying the entry's sequence from the BLAST db
-	out, err := ioutil.TempFile("", "primer3-out-*")
-	if err != nil {
-		return false, match{}, err
-	}
-	defer os.Remove(out.Name())
-
-	// create input file
-	inContent := fmt.Sprintf(">primer\n%s\n", primer)
-	if _, err = in.WriteString(inContent); err != nil {
-		return false, m, fmt.Errorf("failed to write primer sequence to query FASTA file: %v", err)
-	}
-
+func mismatch(ctx context.Context, primer string, parentFile *os.File, c *config.Config) (wasMismatch bool, m match, err error) {
 	// BLAST the query sequence against the parentFile sequence
-	b := blastExec{
-		in:       in,
-		out:      out,
+	q := &alignQuery{
+		name:     "primer",
 		subject:  parentFile.Name(),
 		seq:      primer,
 		identity: 65,    // see Primer-BLAST https://www.ncbi.nlm.nih.gov/pmc/articles/PMC3412702/
 		evalue:   30000, // see Primer-BLAST
 	}
 
-	// execute BLAST
-	if err = b.runAgainst(); err != nil {
-		return false, m, fmt.Errorf("failed to run blast against parent: %v", err)
-	}
-
 	// get the BLAST matches
-	matches, err := b.parse([]string{})
+	matches, err := newAligner(c).align(ctx, q, []string{})
 	if err != nil {
-		return false, match{}, fmt.Errorf("failed to parse matches from %s: %v", out.Name(), err)
+		return false, match{}, fmt.Errorf("failed to align primer against parent: %v", err)
 	}
 
 	// parse the results and check whether any are cause for concern (by Tm)
@@ -810,7 +849,7 @@ func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch
 	}
 
 	for _, m := range matches {
-		if isMismatch(primer, m, c) {
+		if isMismatch(ctx, primer, m, c) {
 			primerCount--
 		}
 
@@ -823,10 +862,11 @@ func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch
 }
 
 // runs blast on the query file against another subject file (rather than blastdb)
-func (b *blastExec) runAgainst() (err error) {
+func (b *blastExec) runAgainst(ctx context.Context) (err error) {
 	// create the blast command
 	// https://www.ncbi.nlm.nih.gov/books/NBK279682/
-	blastCmd := exec.Command(
+	blastCmd := exec.CommandContext(
+		ctx,
 		"blastn",
 		"-task", "blastn",
 		"-query", b.in.Name(),
@@ -846,35 +886,22 @@ func (b *blastExec) runAgainst() (err error) {
 // isMismatch returns whether the match constitutes a mismatch
 // between it and the would be primer sequence
 //
-// estimate the ntthal and check against the max offtarget tm
+// estimate the dimer's tm and check against the max offtarget tm
 // from the settings
-func isMismatch(primer string, m match, c *config.Config) bool {
+func isMismatch(ctx context.Context, primer string, m match, c *config.Config) bool {
 	// we want the reverse complement of one to the other
 	ectopic := m.seq
 	if m.forward {
 		ectopic = reverseComplement(ectopic)
 	}
 
-	ntthalCmd := exec.Command(
-		"ntthal",
-		"-a", "END1", // end of primer sequence
-		"-s1", primer,
-		"-s2", ectopic,
-		"-path", config.Primer3Config,
-		"-r", // temperature only
-	)
-
-	ntthalOut, err := ntthalCmd.CombinedOutput()
+	temp, err := newThermo(c).dimer(ctx, primer, ectopic)
 	if err != nil {
-		stderr.Printf("failed to execute ntthal: %s", strings.Join(ntthalCmd.Args, ","))
+		if ctx.Err() == nil {
+			logger.Warn(err.Error())
+		}
 		return true
 	}
 
-	ntthalOutString := string(ntthalOut)
-	temp, err := strconv.ParseFloat(strings.TrimSpace(ntthalOutString), 64)
-	if err != nil {
-		stderr.Fatalln(err)
-	}
-
 	return temp > c.PCRMaxOfftargetTm
 }
@@ -1,6 +1,9 @@
 package repp
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -22,7 +25,7 @@ func Test_BLAST(t *testing.T) {
 	seq := "GGCCGCAATAAAATATCTTTATTTTCATTACATCTGTGTGTTGGTTTTTTGTGTGAATCGATAGTACTAACATGACCACCTTGATCTTCATGGTCTGGGTGCCCTCGTAGGGCTTGCCTTCGCCCTCGGATGTGCACTTGAAGTGGTGGTTGTTCACGGTGCCCTCCATGTACAGCTTCATGTGCATGTTCTCCTTGATCAGCTCGCTCATAGGTCCAGGGTTCTCCTCCACGTCTCCAGCCTGCTTCAGCAGGCTGAAGTTAGTAGCTCCGCTTCCGGATCCCCCGGGGAGCATGTCAAGGTCAAAATCGTCAAGAGCGTCAGCAGGCAGCATATCAAGGTCAAAGTCGTCAAGGGCATCGGCTGGGAgCATGTCTAAgTCAAAATCGTCAAGGGCGTCGGCCGGCCCGCCGCTTTcgcacGCCCTGGCAATCGAGATGCTGGACAGGCATCATACCCACTTCTGCCCCCTGGAAGGCGAGTCATGGCAAGACTTTCTGCGGAACAACGCCAAGTCATTCCGCTGTGCTCTCCTCTCACATCGCGACGGGGCTAAAGTGCATCTCGGCACCCGCCCAACAGAGAAACAGTACGAAACCCTGGAAAATCAGCTCGCGTTCCTGTGTCAGCAAGGCTTCTCCCTGGAGAACGCACTGTACGCTCTGTCCGCCGTGGGCCACTTTACACTGGGCTGCGTATTGGAGGATCAGGAGCATCAAGTAGCAAAAGAGGAAAGAGAGACACCTACCACCGATTCTATGCCTGACTGTGGCGGGTGAGCTTAGGGGGCCTCCGCTCCAGCTCGACACCGGGCAGCTGCTGAAGATCGCGAAGAGAGGGGGAGTAACAGCGGTAGAGGCAGTGCACGCCTGGCGCAATGCGCTCACCGGGGCCCCCTTGAACCTGACCCCAGACCAGGTAGTCGCAATCGCGAACAATAATGGGGGAAAGCAAGCCCTGGAAACCGTGCAAAGGTTGTTGCCGGTCCTTTGTCAAGACCACGGCCTTACACCGGAGCAAGTCGTGGCCATTGCAAGCAATGGGGGTGGCAAACAGGCTCTTGAGACGGTTCAGAGACTTCTCCCAGTTCTCTGTCAAGCCGTTGGAGTCCACGTTCTTTAATAGTGGACTCTTGTTCCAAACTGGAACAACACTCAACCCTATCTCGGTCTATTCTTTTGATTTATAAGGGATTTTGCCGATTTCGGCCTATTGGTTAAAAAATGAGCTGATTTAACAAAAATTTAACGCGAATTTTAACAAAATATTAACGCTTACAATTTAGGTGGCACTTTTCGGGGAAATGTGCGCGGAACCCCTATTTGTTTATTTTTCTAAATACATTCAAATATGTATCCGCTCATGAGACAATAACCCTGATAAATGCTTCAATAATATTGAAAAAGGAAGAGTATGAGTATTCAACATTTCCGTGTCGCCCTTATTCCCTTTTTTGCGGCATTTTGCCTTCCTGTTTTTGCTCACCCAGAAACGCTGGTGAAAGTAAAAGATGCTGAAGATCAGTTGGGTGCACGAGTGGGTTACATCGAACTGGATCTCAACAGCGGTAAGATCCTTGAGAGTTTTCGCCCCGAAGAACGTTTTCCAATGATGAGCACTTTTAAAGTTCTGCTATGTGGCGCGGTATTATCCCGTATTGACGCCGGGCAAGAGCAACTCGGTCGCCGCATACACTATTCTCAGAATGACTTGGTTGAGTACTCACCAGTCACAGAAAAGCATCTTACGGATGGCATGACAGTAAGAGAATTATGCAGTGCTGCCATAACCATGAGTGATAACACTGCGGCCAACTTACTTCTGACAACGATCGGAGGACCGAAGGAGCTAACCGCTTTTTTGCACAACATGGGGGATCATGTAACTCGCCTTGATCGTTGGGAACCGGAGCTGAATGAAGCCATACCAAACGACGAGCGTGACACCACGATGCCTGTAGCAATGGCAACAACGTTGCGCAAACTATTAACTGGCGAACTACTTACTCTAGCTTCCCGGCAACAATTAATAGACTGGATGGAGGCGGATAAAGTTGCAGGACCACTTCTGCGCTCGGCCCTTCCGGCTGGCTGGTTTATTGCTGATAAATCTGGAGCCGGTGAGCGTGGGTCTCGCGGTATCATTGCAGCACTGGGGCCAGATGGTAAGCCCTCCCGTATCGTAGTTATCTACACGACGGGGAGTCAGGCAACTATGGATGAACGAAATAGACAGATCGCTGAGATAGGTGCCTCACTGATTAAGCATTGGTAACTGTCAGACCAAGTTTACTCATATATACTTTAGATTGATTTAAAACTTCATTTTTAATTTAAAAGGATCTAGGTGAAGATCCTTTTTGATAATCTCATGACCAAAATCCCTTAACGTGAGTTTTCGTTCCACTGAGCGTCAGACCCCGTAGAA"
 
 	// run blast
-	matches, err := blast(id, seq, true, []string{testDB}, []string{}, 10, blastWriter()) // any match over 10 bp
+	matches, err := blast(context.Background(), id, seq, true, []string{testDB}, []string{}, 10, nil) // any match over 10 bp
 
 	// check if it fails
 	if err != nil {
@@ -55,6 +58,47 @@ func Test_BLAST(t *testing.T) {
 
 // test that we can filter out overlapping regions from blast results
 // and those that are up against the edge of the fragment
+// dbs are searched at once but their matches and counts are in the order of the dbs
+func Test_blast_dbs(t *testing.T) {
+	testDB, _ := filepath.Abs(path.Join("..", "..", "test", "db", "db"))
+	frags, err := read(testDB, false)
+	if err != nil || len(frags) < 2 {
+		t.Fatalf("failed to read the test db: %v", err)
+	}
+
+	// a second db with only the second entry
+	dir, err := ioutil.TempDir("", "repp-db-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	otherDB := filepath.Join(dir, "other")
+	ioutil.WriteFile(otherDB, []byte(">"+frags[1].ID+"\n"+frags[1].Seq+"\n"), 0644)
+
+	conf := &config.Config{Aligner: "native", BlastThreads: 3}
+	seq := frags[0].Seq[:300] + frags[1].Seq[:300]
+
+	logs := tempLogger(t)
+	matches, err := blast(context.Background(), "test_target", seq, false, []string{testDB, otherDB}, []string{}, 98, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dbs []string
+	for _, l := range logs.records() {
+		if l["msg"] == "blasted" {
+			dbs = append(dbs, l["database"].(string))
+		}
+	}
+	if !reflect.DeepEqual(dbs, []string{testDB, otherDB}) {
+		t.Errorf("blast() logged the dbs %v, want each db in order", dbs)
+	}
+
+	if len(matches) < 3 || matches[0].db != testDB || matches[len(matches)-1].db != otherDB {
+		t.Errorf("blast() = %+v, want the matches of %s then %s", matches, testDB, otherDB)
+	}
+}
+
 func Test_cull(t *testing.T) {
 	// test fragment with 3 matches that should be removed
 	matches := []match{
@@ -140,7 +184,7 @@ func Test_isMismatch(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isMismatch(tt.args.sequence, tt.args.match, c); got != tt.want {
+			if got := isMismatch(context.Background(), tt.args.sequence, tt.args.match, c); got != tt.want {
 				t.Errorf("isMismatch() = %v, want %v", got, tt.want)
 			}
 		})
@@ -196,7 +240,7 @@ func Test_parentMismatch(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mismatchResult := parentMismatch([]Primer{Primer{Seq: tt.args.primer}}, tt.args.parent, testDB, conf)
+			mismatchResult := parentMismatch(context.Background(), []Primer{Primer{Seq: tt.args.primer}}, tt.args.parent, testDB, conf)
 			gotMismatch := mismatchResult.wasMismatch
 			gotMatch := mismatchResult.m
 			err := mismatchResult.err
@@ -258,7 +302,7 @@ func Test_queryDatabases(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotF, err := queryDatabases(tt.args.entry, tt.args.dbs)
+			gotF, err := queryDatabases(context.Background(), tt.args.entry, tt.args.dbs, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("queryDatabases() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -293,7 +337,7 @@ func Test_blastdbcmd(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := blastdbcmd(tt.args.entry, tt.args.db)
+			_, _, err := blastdbcmd(context.Background(), tt.args.entry, tt.args.db)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("blastdbcmd() error = %v, wantErr %v", err, tt.wantErr)
 				return
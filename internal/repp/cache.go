@@ -0,0 +1,416 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// cacheVersion is in every cache key. Bumping it invalidates results cached in an old format
+const cacheVersion = 1
+
+// resultCache is the on-disk cache of BLAST, primer and off-target results
+var resultCache = &diskCache{dir: config.CacheDir, written: -1}
+
+// diskCache is a store of results from prior designs as JSON files, one per key. The
+// files least recently used are removed when the cache is larger than its maximum size.
+type diskCache struct {
+	// dir is the root directory of the cache
+	dir string
+
+	// mu guards written
+	mu sync.Mutex
+
+	// written is the number of bytes written since the last eviction, or -1 before the first
+	written int64
+}
+
+// cacheKey returns a key from a kind of result, eg "matches", and everything it depends on.
+func cacheKey(kind string, parts ...interface{}) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d", cacheVersion)
+	for _, part := range parts {
+		fmt.Fprintf(h, "\x00%v", part)
+	}
+	return fmt.Sprintf("%s/%x", kind, h.Sum(nil))
+}
+
+// path returns the path to the file of a key.
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key)+".json")
+}
+
+// get reads the result of a key into v and returns whether it was in the cache.
+func (c *diskCache) get(key string, v interface{}, conf *config.Config) bool {
+	if conf.CacheMaxSize <= 0 {
+		return false
+	}
+
+	path := c.path(key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false
+	}
+
+	// mark it as recently used
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return true
+}
+
+// set writes the result of a key. Results that can't be written aren't cached.
+func (c *diskCache) set(key string, v interface{}, conf *config.Config) {
+	if conf.CacheMaxSize <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	// write to a temporary file and move it in place so no one reads it half-written
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	tmp.Close()
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	// evict on the first write and then every tenth of the maximum size that's written
+	maxBytes := int64(conf.CacheMaxSize) << 20
+	c.mu.Lock()
+	evict := c.written < 0 || c.written > maxBytes/10
+	if evict {
+		c.written = 0
+	}
+	c.written += int64(len(data))
+	c.mu.Unlock()
+
+	if evict {
+		c.evict(maxBytes)
+	}
+}
+
+// evict removes the least recently used files until the cache is under 90% of its maximum size.
+func (c *diskCache) evict(maxBytes int64) {
+	var files []os.FileInfo
+	var paths []string
+	var size int64
+	filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, info)
+			paths = append(paths, path)
+			size += info.Size()
+		}
+		return nil
+	})
+	if size <= maxBytes {
+		return
+	}
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return files[order[i]].ModTime().Before(files[order[j]].ModTime())
+	})
+
+	for _, i := range order {
+		if size <= maxBytes*9/10 {
+			break
+		}
+		if os.Remove(paths[i]) == nil {
+			size -= files[i].Size()
+		}
+	}
+}
+
+// clear removes every result in the cache and returns the number of bytes removed.
+func (c *diskCache) clear() (int64, error) {
+	var size int64
+	filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	if err := os.RemoveAll(c.dir); err != nil {
+		return 0, fmt.Errorf("failed to clear the cache at %s: %v", c.dir, err)
+	}
+
+	return size, nil
+}
+
+// ClearCache removes the results cached between designs and returns the number of bytes removed.
+func ClearCache() (int64, error) {
+	return resultCache.clear()
+}
+
+// fileChecksum returns a checksum of the files of a database, or another file, from
+// their names, sizes and modification times. It changes whenever one of them changes.
+func fileChecksum(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	// BLAST dbs are split across files with the db's path as their prefix
+	paths, _ := filepath.Glob(path + ".*")
+	paths = append(paths, path)
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// primerSettings are the settings that primers and their off-target checks depend on.
+func primerSettings(conf *config.Config) string {
+	return fmt.Sprint(
+		conf.Aligner,
+		conf.Thermo,
+		conf.FragmentsMinHomology,
+		conf.FragmentsMaxHomology,
+		conf.FragmentsMaxHairpinMelt,
+		conf.PCRMinLength,
+		conf.PCRMaxPenalty,
+		conf.PCRMaxEmbedLength,
+		conf.PCRMaxOfftargetTm,
+		conf.PCRInventoryMinTm,
+		conf.PCRBufferLength,
+		fileChecksum(config.PrimerDB),
+	)
+}
+
+// cachedPrimer is a Primer with its range, as it's cached.
+type cachedPrimer struct {
+	Primer
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Added int `json:"added,omitempty"`
+}
+
+// cachedPrimers are the primers, or the error from making them, of a PCR run.
+type cachedPrimers struct {
+	Primers []cachedPrimer `json:"primers"`
+	Err     string         `json:"err,omitempty"`
+}
+
+// primerCacheKey returns the key of the primers of a PCR run, keyed by its primerHash,
+// on a Frag. Primers are checked for off-targets in the Frag's parent, so it's in the key.
+func primerCacheKey(pHash string, f *Frag, conf *config.Config) string {
+	return cacheKey("primers", pHash, primerSettings(conf), f.ID, fileChecksum(f.db), seqHash(f.fullSeq))
+}
+
+// loadPrimers returns the primers, or the error from making them, of a prior PCR run
+// and whether there was one. They're from madePrimers or the disk cache.
+func loadPrimers(pHash string, f *Frag, conf *config.Config) ([]Primer, error, bool) {
+	if primers, err, made := madePrimers.get(pHash); made {
+		return primers, err, true
+	}
+
+	var cached cachedPrimers
+	if !resultCache.get(primerCacheKey(pHash, f, conf), &cached, conf) {
+		return nil, nil, false
+	}
+
+	if cached.Err != "" {
+		err := errors.New(cached.Err)
+		madePrimers.set(pHash, nil, err)
+		return nil, err, true
+	}
+
+	primers := make([]Primer, len(cached.Primers))
+	for i, p := range cached.Primers {
+		primers[i] = p.Primer
+		primers[i].Range = ranged{start: p.Start, end: p.End}
+		primers[i].added = p.Added
+	}
+	madePrimers.set(pHash, primers, nil)
+	return primers, nil, true
+}
+
+// storePrimers stores the primers, or the error from making them, of a PCR run in
+// madePrimers and, if persist, the disk cache.
+func storePrimers(pHash string, f *Frag, primers []Primer, err error, persist bool, conf *config.Config) {
+	madePrimers.set(pHash, primers, err)
+	if !persist {
+		return
+	}
+
+	cached := cachedPrimers{}
+	if err != nil {
+		cached.Err = err.Error()
+	}
+	for _, p := range primers {
+		cached.Primers = append(cached.Primers, cachedPrimer{Primer: p, Start: p.Range.start, End: p.Range.end, Added: p.added})
+	}
+	resultCache.set(primerCacheKey(pHash, f, conf), cached, conf)
+}
+
+// cachedMatch is a match as it's cached.
+type cachedMatch struct {
+	Entry        string `json:"entry"`
+	UniqueID     string `json:"uniqueID"`
+	QuerySeq     string `json:"querySeq"`
+	QueryStart   int    `json:"queryStart"`
+	QueryEnd     int    `json:"queryEnd"`
+	Seq          string `json:"seq"`
+	SubjectStart int    `json:"subjectStart"`
+	SubjectEnd   int    `json:"subjectEnd"`
+	DB           string `json:"db"`
+	Title        string `json:"title"`
+	Circular     bool   `json:"circular"`
+	Mismatching  int    `json:"mismatching"`
+	Internal     bool   `json:"internal"`
+	Forward      bool   `json:"forward"`
+}
+
+// matchesCacheKey returns the key of the matches of a query against its db.
+func matchesCacheKey(q *alignQuery, filters []string, conf *config.Config) string {
+	return cacheKey(
+		"matches",
+		conf.Aligner,
+		q.seq,
+		q.circular,
+		q.db,
+		fileChecksum(q.db),
+		q.internal,
+		q.task,
+		q.identity,
+		q.evalue,
+		filters,
+	)
+}
+
+// loadMatches returns the matches of a query against its db from the disk cache and
+// whether they were in it.
+func loadMatches(q *alignQuery, filters []string, conf *config.Config) ([]match, bool) {
+	if conf == nil {
+		return nil, false // the cache is off without settings
+	}
+
+	var cached []cachedMatch
+	if !resultCache.get(matchesCacheKey(q, filters, conf), &cached, conf) {
+		return nil, false
+	}
+
+	matches := make([]match, len(cached))
+	for i, m := range cached {
+		matches[i] = match{
+			entry:        m.Entry,
+			uniqueID:     m.UniqueID,
+			querySeq:     m.QuerySeq,
+			queryStart:   m.QueryStart,
+			queryEnd:     m.QueryEnd,
+			seq:          m.Seq,
+			subjectStart: m.SubjectStart,
+			subjectEnd:   m.SubjectEnd,
+			db:           m.DB,
+			title:        m.Title,
+			circular:     m.Circular,
+			mismatching:  m.Mismatching,
+			internal:     m.Internal,
+			forward:      m.Forward,
+		}
+	}
+	return matches, true
+}
+
+// storeMatches stores the matches of a query against its db in the disk cache.
+func storeMatches(q *alignQuery, filters []string, matches []match, conf *config.Config) {
+	if conf == nil {
+		return
+	}
+
+	cached := make([]cachedMatch, len(matches))
+	for i, m := range matches {
+		cached[i] = cachedMatch{
+			Entry:        m.entry,
+			UniqueID:     m.uniqueID,
+			QuerySeq:     m.querySeq,
+			QueryStart:   m.queryStart,
+			QueryEnd:     m.queryEnd,
+			Seq:          m.seq,
+			SubjectStart: m.subjectStart,
+			SubjectEnd:   m.subjectEnd,
+			DB:           m.db,
+			Title:        m.title,
+			Circular:     m.circular,
+			Mismatching:  m.mismatching,
+			Internal:     m.internal,
+			Forward:      m.forward,
+		}
+	}
+	resultCache.set(matchesCacheKey(q, filters, conf), cached, conf)
+}
+
+// cachedMismatch is a mismatchResult as it's cached. Only the sequence of its match is used.
+type cachedMismatch struct {
+	WasMismatch bool   `json:"wasMismatch"`
+	Seq         string `json:"seq,omitempty"`
+}
+
+// offtargetKey returns the key of an off-target check of primers in a Frag's parent.
+func offtargetKey(primers []Primer, f *Frag, conf *config.Config) string {
+	var seqs []string
+	for _, p := range primers {
+		seqs = append(seqs, p.Seq)
+	}
+	return cacheKey("offtargets", seqs, f.ID, seqHash(f.fullSeq), f.db, fileChecksum(f.db), primerSettings(conf))
+}
+
+// loadMismatch returns the result of an off-target check from mismatchResults or the
+// disk cache and whether it was in either.
+func loadMismatch(key string, conf *config.Config) (mismatchResult, bool) {
+	if result, checked := mismatchResults.get(key); checked {
+		return result, true
+	}
+
+	var cached cachedMismatch
+	if !resultCache.get(key, &cached, conf) {
+		return mismatchResult{}, false
+	}
+
+	result := mismatchResult{wasMismatch: cached.WasMismatch, m: match{seq: cached.Seq}}
+	mismatchResults.set(key, result)
+	return result, true
+}
+
+// storeMismatch stores the result of an off-target check in mismatchResults and, if the
+// check didn't fail, the disk cache.
+func storeMismatch(key string, result mismatchResult, conf *config.Config) {
+	mismatchResults.set(key, result)
+	if result.err != nil {
+		return
+	}
+
+	resultCache.set(key, cachedMismatch{WasMismatch: result.wasMismatch, Seq: result.m.seq}, conf)
+}
@@ -0,0 +1,172 @@
+package repp
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// tempCache swaps resultCache for one in a temporary directory until the test ends
+func tempCache(t *testing.T) *diskCache {
+	dir, err := ioutil.TempDir("", "repp-cache-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := resultCache
+	resultCache = &diskCache{dir: dir, written: -1}
+	t.Cleanup(func() {
+		resultCache = old
+		os.RemoveAll(dir)
+	})
+
+	return resultCache
+}
+
+func Test_diskCache(t *testing.T) {
+	c := tempCache(t)
+	conf := &config.Config{CacheMaxSize: 1}
+
+	var got []string
+	if c.get("test/a", &got, conf) {
+		t.Error("diskCache.get() found a result that wasn't set")
+	}
+
+	c.set("test/a", []string{"ATGC"}, conf)
+	if !c.get("test/a", &got, conf) || !reflect.DeepEqual(got, []string{"ATGC"}) {
+		t.Errorf("diskCache.get() = %v, want [ATGC]", got)
+	}
+
+	// the cache is off without a size
+	if c.get("test/a", &got, &config.Config{}) {
+		t.Error("diskCache.get() found a result with the cache off")
+	}
+
+	size, err := c.clear()
+	if err != nil || size == 0 {
+		t.Errorf("diskCache.clear() = %d, %v", size, err)
+	}
+	if c.get("test/a", &got, conf) {
+		t.Error("diskCache.get() found a result after clear")
+	}
+}
+
+func Test_diskCache_evict(t *testing.T) {
+	c := tempCache(t)
+	conf := &config.Config{CacheMaxSize: 1}
+
+	// three results of 0.4 MB, the least recently used is evicted
+	os.MkdirAll(filepath.Join(c.dir, "test"), 0755)
+	for i, key := range []string{"test/a", "test/b", "test/c"} {
+		ioutil.WriteFile(c.path(key), []byte(`"`+strings.Repeat("A", 400<<10)+`"`), 0644)
+		past := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(c.path(key), past, past)
+	}
+
+	var got string
+	c.get("test/a", &got, conf) // used most recently
+	c.evict(1 << 20)
+
+	if _, err := os.Stat(c.path("test/b")); !os.IsNotExist(err) {
+		t.Error("diskCache.evict() kept the least recently used result")
+	}
+	for _, key := range []string{"test/a", "test/c"} {
+		if _, err := os.Stat(c.path(key)); err != nil {
+			t.Errorf("diskCache.evict() removed %s: %v", key, err)
+		}
+	}
+}
+
+func Test_fileChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repp-db-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := filepath.Join(dir, "db")
+	ioutil.WriteFile(db, []byte(">a\nATGC\n"), 0644)
+	ioutil.WriteFile(db+".nsq", []byte("ATGC"), 0644)
+
+	checksum := fileChecksum(db)
+	if checksum != fileChecksum(db) {
+		t.Error("fileChecksum() changed without a change to the db")
+	}
+
+	ioutil.WriteFile(db+".nsq", []byte("ATGCATGC"), 0644)
+	if checksum == fileChecksum(db) {
+		t.Error("fileChecksum() is the same after the db changed")
+	}
+}
+
+func Test_loadPrimers(t *testing.T) {
+	tempCache(t)
+	conf := config.New()
+	conf.CacheMaxSize = 1
+
+	f := &Frag{ID: "frag", uniqueID: "frag0"}
+	primers := []Primer{
+		{Seq: "ATGCATGCATGCATGCATGC", Strand: true, Tm: 60.1, Range: ranged{start: 10, end: 30}},
+		{Seq: "GCATGCATGCATGCATGCAT", Tm: 59.8, Range: ranged{start: 200, end: 220}},
+	}
+
+	storePrimers("a", f, primers, nil, true, conf)
+	storePrimers("b", f, nil, errors.New("failed"), true, conf)
+	storePrimers("c", f, primers, nil, false, conf)
+
+	// clear the primers in memory, they're read from disk
+	madePrimers.mu.Lock()
+	for _, key := range []string{"a", "b", "c"} {
+		delete(madePrimers.primers, key)
+		delete(madePrimers.errs, key)
+	}
+	madePrimers.mu.Unlock()
+
+	if got, err, made := loadPrimers("a", f, conf); !made || err != nil || !reflect.DeepEqual(got, primers) {
+		t.Errorf("loadPrimers() = %v, %v, %v, want %v", got, err, made, primers)
+	}
+	if _, err, made := loadPrimers("b", f, conf); !made || err == nil || err.Error() != "failed" {
+		t.Errorf("loadPrimers() = %v, %v, want the error", err, made)
+	}
+	if _, _, made := loadPrimers("c", f, conf); made {
+		t.Error("loadPrimers() found primers that weren't persisted")
+	}
+
+	// primers aren't shared after a setting they depend on changes
+	conf.PCRMaxPenalty++
+	madePrimers.mu.Lock()
+	delete(madePrimers.primers, "a")
+	madePrimers.mu.Unlock()
+	if _, _, made := loadPrimers("a", f, conf); made {
+		t.Error("loadPrimers() found primers made with other settings")
+	}
+}
+
+func Test_loadMatches(t *testing.T) {
+	tempCache(t)
+	conf := &config.Config{Aligner: "blastn", CacheMaxSize: 1}
+
+	q := &alignQuery{seq: "ATGCATGC", db: "db", identity: 100}
+	matches := []match{{entry: "a", uniqueID: "a0", queryStart: 1, queryEnd: 50, seq: "ATGC", db: "db", circular: true, forward: true}}
+
+	if _, cached := loadMatches(q, nil, conf); cached {
+		t.Error("loadMatches() found matches that weren't stored")
+	}
+
+	storeMatches(q, nil, matches, conf)
+	if got, cached := loadMatches(q, nil, conf); !cached || !reflect.DeepEqual(got, matches) {
+		t.Errorf("loadMatches() = %v, %v, want %v", got, cached, matches)
+	}
+
+	// other filters aren't the same query
+	if _, cached := loadMatches(q, []string{"2018"}, conf); cached {
+		t.Error("loadMatches() found matches with other filters")
+	}
+}
@@ -0,0 +1,349 @@
+package repp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// validDBName is the pattern of names for databases made by repp
+var validDBName = regexp.MustCompile(`^[\w-]+$`)
+
+// DB is a BLAST database of fragments made by repp from FASTA and Genbank files.
+// It's registered by its name, so it can be passed to --dbs without its path.
+type DB struct {
+	// Name of the database
+	Name string `json:"name"`
+
+	// Path to the database's FASTA file. The BLAST db files are beside it
+	Path string `json:"path"`
+
+	// Entries in the database
+	Entries []DBEntry `json:"entries"`
+
+	// Inventory is the path to the database's inventory manifest. Empty if it doesn't have one
+	Inventory string `json:"inventory,omitempty"`
+}
+
+// DBEntry is a single plasmid or fragment in a DB.
+type DBEntry struct {
+	// ID of the entry, used by the backbone and fragment flags
+	ID string `json:"id"`
+
+	// Title of the entry, eg its Genbank definition. It's matched by --exclude filters
+	Title string `json:"title,omitempty"`
+
+	// Circular is whether the entry is circular, eg a plasmid
+	Circular bool `json:"circular"`
+
+	// Seq of the entry
+	Seq string `json:"seq"`
+}
+
+// dbPath returns the path to a database's FASTA file by its name.
+func dbPath(name string) string {
+	return filepath.Join(config.DBsDir, name)
+}
+
+// registeredDB returns the path to a database made by repp and whether there is one
+// with the name.
+func registeredDB(name string) (string, bool) {
+	if !validDBName.MatchString(name) {
+		return "", false
+	}
+
+	path := dbPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// ListDBs returns the databases made by repp, sorted by name, with their entries.
+func ListDBs() ([]*DB, error) {
+	files, err := ioutil.ReadDir(config.DBsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the databases in %s: %v", config.DBsDir, err)
+	}
+
+	var dbs []*DB
+	for _, file := range files {
+		if file.IsDir() || !validDBName.MatchString(file.Name()) {
+			continue // BLAST db files and inventories have extensions
+		}
+
+		db, err := ReadDB(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+
+	sort.Slice(dbs, func(i, j int) bool { return dbs[i].Name < dbs[j].Name })
+	return dbs, nil
+}
+
+// ReadDB returns a database made by repp by its name.
+func ReadDB(name string) (*DB, error) {
+	path, exists := registeredDB(name)
+	if !exists {
+		return nil, fmt.Errorf("failed to find a database named %s in %s", name, config.DBsDir)
+	}
+
+	frags, err := read(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database %s: %v", name, err)
+	}
+
+	db := &DB{Name: name, Path: path}
+	for _, f := range frags {
+		header := strings.Fields(f.ID)
+		if len(header) < 1 {
+			continue
+		}
+
+		entry := DBEntry{ID: header[0], Circular: f.fragType == circular, Seq: f.Seq}
+		if entry.Circular {
+			entry.Seq = entry.Seq[:len(entry.Seq)/2] // undo the doubling of circular entries
+		}
+		if len(header) > 1 {
+			entry.Title = strings.Join(header[1:], " ")
+		}
+		db.Entries = append(db.Entries, entry)
+	}
+
+	if _, err := os.Stat(inventoryPath(path)); err == nil {
+		db.Inventory = inventoryPath(path)
+	}
+
+	return db, nil
+}
+
+// CreateDB makes a new database from the sequences in FASTA and Genbank files.
+func CreateDB(name string, files []string) (*DB, error) {
+	if !validDBName.MatchString(name) {
+		return nil, fmt.Errorf("invalid database name %s, expecting only letters, numbers, underscores and dashes", name)
+	}
+	if _, exists := registeredDB(name); exists {
+		return nil, fmt.Errorf("failed to create %s, there's already a database with its name. Add to it with 'repp db add'", name)
+	}
+
+	entries, err := dbEntries(files)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Name: name, Path: dbPath(name), Entries: entries}
+	return db, db.write()
+}
+
+// AddToDB adds the sequences in FASTA and Genbank files to a database. Entries with
+// the same ID as one in the database replace it.
+func AddToDB(name string, files []string) (*DB, error) {
+	db, err := ReadDB(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := dbEntries(files)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		db.set(entry)
+	}
+
+	return db, db.write()
+}
+
+// RemoveFromDB removes the entries, by ID, from a database. The whole database is
+// removed if no IDs are passed.
+func RemoveFromDB(name string, ids []string) (*DB, error) {
+	db, err := ReadDB(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		files, err := filepath.Glob(db.Path + ".*")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range append(files, db.Path) {
+			if err := os.Remove(file); err != nil {
+				return nil, fmt.Errorf("failed to remove database %s: %v", name, err)
+			}
+		}
+		return db, nil
+	}
+
+	remove := make(map[string]bool)
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	var kept []DBEntry
+	for _, entry := range db.Entries {
+		if remove[entry.ID] {
+			delete(remove, entry.ID)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	if len(remove) > 0 {
+		var missing []string
+		for id := range remove {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("failed to find %s in database %s", strings.Join(missing, ", "), name)
+	}
+
+	db.Entries = kept
+	return db, db.write()
+}
+
+// dbEntries returns the entries for a database from the sequences in FASTA and Genbank
+// files. IDs can't have whitespace, so it's replaced with underscores.
+func dbEntries(files []string) (entries []DBEntry, err error) {
+	if len(files) < 1 {
+		return nil, fmt.Errorf("no FASTA or Genbank files passed")
+	}
+
+	whitespace := regexp.MustCompile(`\s+`)
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if lower := strings.ToLower(file); strings.HasSuffix(lower, "gb") ||
+			strings.HasSuffix(lower, "gbk") ||
+			strings.HasSuffix(lower, "genbank") {
+			records, err := parseGenbank(string(contents))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+			}
+			for _, record := range records {
+				entries = append(entries, DBEntry{
+					ID:       whitespace.ReplaceAllString(record.name, "_"),
+					Title:    record.definition,
+					Circular: record.circular,
+					Seq:      record.seq,
+				})
+			}
+			continue
+		}
+
+		frags, err := read(file, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range frags {
+			header := strings.Fields(f.ID)
+			if len(header) < 1 {
+				return nil, fmt.Errorf("failed to parse an ID for a sequence in %s", file)
+			}
+			entries = append(entries, DBEntry{
+				ID:       header[0],
+				Title:    strings.Join(header[1:], " "),
+				Circular: f.fragType == circular,
+				Seq:      f.Seq,
+			})
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Seq == "" {
+			return nil, fmt.Errorf("failed to parse a sequence for %s", entry.ID)
+		}
+	}
+
+	return entries, nil
+}
+
+// set adds an entry to the database or replaces the one with the same ID.
+func (db *DB) set(entry DBEntry) {
+	for i, e := range db.Entries {
+		if e.ID == entry.ID {
+			db.Entries[i] = entry
+			return
+		}
+	}
+	db.Entries = append(db.Entries, entry)
+}
+
+// title returns the title of an entry in its FASTA header. BLAST reports the
+// first word of the title, which is checked for "circular" and matched by
+// --exclude filters, so the title's words are joined by dashes.
+func (e DBEntry) title() string {
+	words := strings.Fields(e.Title)
+	if e.Circular && !strings.Contains(strings.ToLower(e.Title), "circular") {
+		words = append([]string{"circular"}, words...)
+	}
+	return strings.Join(words, "-")
+}
+
+// fasta returns the database's entries as a multi-FASTA file. Like the other databases,
+// the sequences of circular entries are doubled so matches can cross their zero index.
+func (db *DB) fasta() string {
+	var fasta strings.Builder
+	for _, entry := range db.Entries {
+		header := entry.ID
+		if title := entry.title(); title != "" {
+			header += " " + title
+		}
+		seq := entry.Seq
+		if entry.Circular {
+			seq += entry.Seq
+		}
+		fasta.WriteString(fmt.Sprintf(">%s\n%s\n", header, seq))
+	}
+	return fasta.String()
+}
+
+// write writes the database's FASTA file and makes a BLAST db from it with makeblastdb.
+func (db *DB) write() error {
+	if len(db.Entries) < 1 {
+		return fmt.Errorf("failed to write database %s, it has no entries", db.Name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(db.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create the database directory: %v", err)
+	}
+
+	if err := ioutil.WriteFile(db.Path, []byte(db.fasta()), 0644); err != nil {
+		return fmt.Errorf("failed to write database %s: %v", db.Name, err)
+	}
+
+	// the native aligner only needs the FASTA file
+	if _, err := exec.LookPath("makeblastdb"); err != nil {
+		logger.Warn("no makeblastdb executable available in PATH, the database can only be used by the native aligner", "db", db.Name)
+		return nil
+	}
+
+	makeCmd := exec.Command(
+		"makeblastdb",
+		"-in", db.Path,
+		"-dbtype", "nucl",
+		"-parse_seqids",
+		"-title", db.Name,
+		"-out", db.Path,
+	)
+	if output, err := makeCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to make a BLAST db for %s with makeblastdb: %v\n%s", db.Name, err, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package repp
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_dbEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fasta := filepath.Join(dir, "parts.fa")
+	genbank := filepath.Join(dir, "plasmids.gb")
+	if err := ioutil.WriteFile(fasta, []byte(">part_1 strong promoter\nATGCATGC\n>pBackbone circular\nGGGGCCCC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(genbank, []byte(testGenbank), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := dbEntries([]string{fasta, genbank})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DBEntry{
+		DBEntry{ID: "part_1", Title: "strong promoter", Seq: "ATGCATGC"},
+		DBEntry{ID: "pBackbone", Title: "circular", Circular: true, Seq: "GGGGCCCC"},
+		DBEntry{ID: "first", Title: "a circular plasmid with features across two lines.", Circular: true, Seq: "ATGCAAAACCCCGGGGTTTT"},
+		DBEntry{ID: "second", Seq: "AAAATTTT"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("dbEntries() = %+v, want %+v", entries, want)
+	}
+
+	if _, err := dbEntries(nil); err == nil {
+		t.Error("dbEntries() without files should fail")
+	}
+}
+
+func Test_DB_fasta(t *testing.T) {
+	db := &DB{Entries: []DBEntry{
+		DBEntry{ID: "part_1", Title: "strong promoter", Seq: "ATGC"},
+		DBEntry{ID: "pBackbone", Title: "lab backbone", Circular: true, Seq: "GGCC"},
+		DBEntry{ID: "untitled", Seq: "TTAA"},
+	}}
+	db.set(DBEntry{ID: "untitled", Seq: "AATT"})
+
+	want := ">part_1 strong-promoter\nATGC\n>pBackbone circular-lab-backbone\nGGCCGGCC\n>untitled\nAATT\n"
+	if got := db.fasta(); got != want {
+		t.Errorf("fasta() = %q, want %q", got, want)
+	}
+
+	// read back, the entries keep their topologies
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbsDir := config.DBsDir
+	config.DBsDir = dir
+	defer func() { config.DBsDir = dbsDir }()
+
+	if err := ioutil.WriteFile(dbPath("mylab"), []byte(want), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, exists := registeredDB("../mylab"); exists {
+		t.Error("registeredDB() found a database outside the databases directory")
+	}
+
+	read, err := ReadDB("mylab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Entries) != 3 || !read.Entries[1].Circular || read.Entries[1].Seq != "GGCC" || read.Entries[0].Circular || read.Entries[0].Title != "strong-promoter" {
+		t.Errorf("ReadDB() = %+v", read.Entries)
+	}
+
+	dbs, err := ListDBs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dbs) != 1 || dbs[0].Name != "mylab" {
+		t.Errorf("ListDBs() = %+v, want mylab", dbs)
+	}
+
+	if _, err := RemoveFromDB("mylab", []string{"missing"}); err == nil {
+		t.Error("RemoveFromDB() should fail to remove a missing entry")
+	}
+}
+
+func Test_DB_fasta_circular(t *testing.T) {
+	testDB, _ := filepath.Abs(path.Join("..", "..", "test", "db", "db"))
+	frags, err := read(testDB, false)
+	if err != nil || len(frags) < 1 {
+		t.Fatalf("failed to read the test db: %v", err)
+	}
+	plasmid := frags[0].Seq[:300]
+
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := &DB{Path: filepath.Join(dir, "mylab"), Entries: []DBEntry{DBEntry{ID: "pLab", Circular: true, Seq: plasmid}}}
+	if err := ioutil.WriteFile(db.Path, []byte(db.fasta()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a sequence across the plasmid's zero index
+	seq := plasmid[250:] + plasmid[:50]
+	q := &alignQuery{name: "test_target", seq: seq, db: db.Path, identity: 100}
+	matches, err := native{}.align(context.Background(), q, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range matches {
+		if m.entry == "pLab" && m.queryStart == 0 && m.queryEnd == len(seq)-1 && m.subjectStart == 250 {
+			return
+		}
+	}
+	t.Errorf("align() = %+v, want a match across the zero index of pLab", matches)
+}
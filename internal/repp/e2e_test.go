@@ -1,6 +1,7 @@
 package repp
 
 import (
+	"context"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -138,14 +139,22 @@ func Test_sequence_e2e(test *testing.T) {
 	}
 
 	for _, t := range tests {
-		sols := Sequence(NewFlags(t.in, t.out, t.backbone, t.filters, t.enzymes, t.dbs, t.addgene, t.igem, false))
+		fs, conf, err := NewFlags(t.in, t.out, t.backbone, t.filters, t.enzymes, t.dbs, t.addgene, t.igem, false)
+		if err != nil {
+			test.Fatal(err)
+		}
+		out, err := Sequence(context.Background(), fs, conf)
+		if err != nil {
+			test.Error(err)
+			continue
+		}
 
-		if len(sols) < 1 {
+		if len(out.Solutions) < 1 {
 			test.Errorf("no solutions for %s", t.in)
 		}
 
-		for _, s := range sols {
-			e := validateJunctions(s, c)
+		for _, s := range out.Solutions {
+			e := validateJunctions(s.Fragments, c)
 			if e != nil {
 				test.Logf("failed making %s\n", t.in)
 				test.Error(e)
@@ -155,7 +164,7 @@ func Test_sequence_e2e(test *testing.T) {
 }
 
 func Test_features(t *testing.T) {
-	test1, conf := NewFlags(
+	test1, conf, err := NewFlags(
 		"p10 promoter, mEGFP, T7 terminator",
 		filepath.Join("..", "..", "test", "output", "features.json"),
 		"pSB1A3",
@@ -166,8 +175,11 @@ func Test_features(t *testing.T) {
 		true,
 		false,
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	test2, _ := NewFlags(
+	test2, _, err := NewFlags(
 		"BBa_R0062,BBa_B0034,BBa_C0040,BBa_B0010,BBa_B0012",
 		filepath.Join("..", "..", "test", "output", "igem.features.json"),
 		"pSB1C3",
@@ -178,6 +190,9 @@ func Test_features(t *testing.T) {
 		true,
 		false,
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	type args struct {
 		flags *Flags
@@ -204,14 +219,17 @@ func Test_features(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sols := Features(tt.args.flags, tt.args.conf)
+			out, err := Features(context.Background(), tt.args.flags, tt.args.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-			if len(sols) < 1 {
+			if len(out.Solutions) < 1 {
 				t.Failed()
 			}
 
-			for _, s := range sols {
-				e := validateJunctions(s, conf)
+			for _, s := range out.Solutions {
+				e := validateJunctions(s.Fragments, conf)
 				if e != nil {
 					t.Error(e)
 				}
@@ -276,7 +294,7 @@ func Test_fragments(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotTargetPlasmid, gotFragments := fragments(tt.args.inputFragments, tt.args.conf)
+			gotTargetPlasmid, gotFragments, _ := fragments(context.Background(), tt.args.inputFragments, tt.args.conf)
 
 			if !reflect.DeepEqual(gotTargetPlasmid.Seq, tt.wantTargetPlasmid.Seq) {
 				t.Errorf("fragments() gotTargetPlasmid = %v, want %v", gotTargetPlasmid, tt.wantTargetPlasmid)
@@ -303,7 +321,7 @@ func Test_fragments(t *testing.T) {
 // if an input fragment being built is exactly the same as one in a DB, it should be used
 // as is and without PCR or any preparation
 func Test_plasmid_single_plasmid(t *testing.T) {
-	fs, c := NewFlags(
+	fs, c, err := NewFlags(
 		path.Join("..", "..", "test", "input", "109049.addgene.fa"),
 		path.Join("..", "..", "test", "output", "109049.output.json"),
 		"",
@@ -314,10 +332,16 @@ func Test_plasmid_single_plasmid(t *testing.T) {
 		false,
 		false,
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	assemblies := Sequence(fs, c) // use addgene database
+	out, err := Sequence(context.Background(), fs, c) // use addgene database
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if !strings.Contains(assemblies[0][0].URL, "109049") {
+	if !strings.Contains(out.Solutions[0].Fragments[0].URL, "109049") {
 		t.Fatal("failed to use 109049 to build the plasmid")
 	}
 }
@@ -98,7 +98,7 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 		for _, enzyme := range enzymes {
 			enzymeNames = append(enzymeNames, enzyme.name)
 		}
-		return &Frag{}, &Backbone{}, fmt.Errorf("no %s cutsites found in %s", strings.Join(enzymeNames, ","), frag.ID)
+		return &Frag{}, &Backbone{}, &NoCutsiteError{Backbone: frag.ID, Enzymes: enzymeNames}
 	}
 
 	// only one cutsite
@@ -120,12 +120,13 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 		return &Frag{
 				ID:       frag.ID,
 				uniqueID: "backbone",
+				Backbone: true,
 				Seq:      digestedSeq,
 				fragType: linear,
 				db:       frag.db,
 			},
 			&Backbone{
-				URL:      parseURL(frag.ID, frag.db),
+				URL:      parseURL(frag.ID, frag.db, frag.conf),
 				Seq:      frag.Seq,
 				Enzymes:  []string{cut.enzyme.name},
 				Cutsites: []int{cut.index},
@@ -166,12 +167,13 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 	return &Frag{
 			ID:       frag.ID,
 			uniqueID: "backbone",
+			Backbone: true,
 			Seq:      digestedSeq,
 			fragType: linear,
 			db:       frag.db,
 		},
 		&Backbone{
-			URL:      parseURL(frag.ID, frag.db),
+			URL:      parseURL(frag.ID, frag.db, frag.conf),
 			Seq:      frag.Seq,
 			Enzymes:  []string{cut1.enzyme.name, cut2.enzyme.name},
 			Cutsites: []int{cut1Index, cut2Index},
@@ -257,11 +259,11 @@ type EnzymeDB struct {
 	enzymes map[string]string
 }
 
-// NewEnzymeDB returns a new copy of the enzymes db.
-func NewEnzymeDB() *EnzymeDB {
+// LoadEnzymeDB returns a new copy of the enzymes db or an error if it can't be read.
+func LoadEnzymeDB() (*EnzymeDB, error) {
 	enzymeFile, err := os.Open(config.EnzymeDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return nil, err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -273,16 +275,16 @@ func NewEnzymeDB() *EnzymeDB {
 	}
 
 	if err := enzymeFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return nil, err
 	}
 
-	return &EnzymeDB{enzymes: enzymes}
+	return &EnzymeDB{enzymes: enzymes}, nil
 }
 
 // ReadCmd returns enzymes that are similar in name to the enzyme name requested.
 // if multiple enzyme names include the enzyme name, they are all returned.
 // otherwise a list of enzyme names are returned (those beneath a levenshtein distance cutoff).
-func (f *EnzymeDB) ReadCmd(cmd *cobra.Command, args []string) {
+func (f *EnzymeDB) ReadCmd(cmd *cobra.Command, args []string) error {
 	// from https://golang.org/pkg/text/tabwriter/
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
 
@@ -299,49 +301,51 @@ func (f *EnzymeDB) ReadCmd(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(w, "%s\t%s\n", name, f.enzymes[name])
 		}
 		w.Flush()
-		return
+		return nil
 	}
 
 	name := args[0]
+	writeMatches(w, f.Find(name), fmt.Sprintf("failed to find any enzymes for %s", name))
+	return w.Flush()
+}
 
-	// if there's an exact match, just log that one
+// Find returns the enzymes, mapped to their recognition sequences, with names similar
+// to name. An exact match is returned alone. Otherwise the enzymes containing the name
+// are returned or, if there are fewer than three, they're returned with those beneath
+// a levenshtein distance cutoff.
+func (f *EnzymeDB) Find(name string) map[string]string {
+	// if there's an exact match, just return that one
 	if seq, exists := f.enzymes[name]; exists {
-		fmt.Printf("%s	%s\n", name, seq)
-		return
+		return map[string]string{name: seq}
 	}
 
 	ldCutoff := 2
-	containing := []string{}
-	lowDistance := []string{}
+	containing := make(map[string]string)
+	lowDistance := make(map[string]string)
 
 	for fName, fSeq := range f.enzymes {
 		if strings.Contains(fName, name) {
-			containing = append(containing, fName+"\t"+fSeq)
+			containing[fName] = fSeq
 		} else if len(fName) > ldCutoff && ld(name, fName, true) <= ldCutoff {
-			lowDistance = append(lowDistance, fName+"\t"+fSeq)
+			lowDistance[fName] = fSeq
 		}
 	}
 
 	if len(containing) < 3 {
-		lowDistance = append(lowDistance, containing...)
-		containing = []string{} // clear
-	}
-	if len(containing) > 0 {
-		fmt.Fprintf(w, strings.Join(containing, "\n"))
-	} else if len(lowDistance) > 0 {
-		fmt.Fprintf(w, strings.Join(lowDistance, "\n"))
-	} else {
-		fmt.Fprintf(w, fmt.Sprintf("failed to find any enzymes for %s", name))
+		for fName, fSeq := range containing {
+			lowDistance[fName] = fSeq
+		}
+		return lowDistance
 	}
-	w.Write([]byte("\n"))
-	w.Flush()
+
+	return containing
 }
 
 // SetCmd the enzyme's seq in the database (or create if it isn't in the enzyme db).
-func (f *EnzymeDB) SetCmd(cmd *cobra.Command, args []string) {
+func (f *EnzymeDB) SetCmd(cmd *cobra.Command, args []string) error {
 	if len(args) < 2 {
 		cmd.Help()
-		stderr.Fatalln("expecting two args: a name and recognition sequence.")
+		return fmt.Errorf("expecting two args: a name and recognition sequence")
 	}
 
 	name := args[0]
@@ -356,12 +360,12 @@ func (f *EnzymeDB) SetCmd(cmd *cobra.Command, args []string) {
 	seq = invalidChars.ReplaceAllString(seq, "")
 
 	if strings.Count(seq, "^") != 1 || strings.Count(seq, "_") != 1 {
-		stderr.Fatalf("%s is not a valid enzyme recognition sequence. see 'repp find enzyme --help'\n", seq)
+		return fmt.Errorf("%s is not a valid enzyme recognition sequence. see 'repp find enzyme --help'", seq)
 	}
 
 	enzymeFile, err := os.Open(config.EnzymeDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -384,11 +388,11 @@ func (f *EnzymeDB) SetCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if err := enzymeFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if err := ioutil.WriteFile(config.EnzymeDB, []byte(output.String()), 0644); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if updated {
@@ -397,13 +401,15 @@ func (f *EnzymeDB) SetCmd(cmd *cobra.Command, args []string) {
 
 	// update in memory
 	f.enzymes[name] = seq
+
+	return nil
 }
 
 // DeleteCmd the enzyme from the database
-func (f *EnzymeDB) DeleteCmd(cmd *cobra.Command, args []string) {
+func (f *EnzymeDB) DeleteCmd(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		cmd.Help()
-		stderr.Fatalf("\nexpecting an enzymes name.")
+		return fmt.Errorf("expecting an enzymes name")
 	}
 
 	name := args[0]
@@ -412,12 +418,12 @@ func (f *EnzymeDB) DeleteCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if _, contained := f.enzymes[name]; !contained {
-		fmt.Printf("failed to find %s in the enzymes database\n", name)
+		return fmt.Errorf("failed to find %s in the enzymes database", name)
 	}
 
 	enzymeFile, err := os.Open(config.EnzymeDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -434,11 +440,11 @@ func (f *EnzymeDB) DeleteCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if err := enzymeFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if err := ioutil.WriteFile(config.EnzymeDB, []byte(output.String()), 0644); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// delete from memory
@@ -446,7 +452,7 @@ func (f *EnzymeDB) DeleteCmd(cmd *cobra.Command, args []string) {
 
 	if deleted {
 		fmt.Printf("deleted %s from the enzymes database\n", name)
-	} else {
-		fmt.Printf("failed to find %s in the enzymes database\n", name)
 	}
+
+	return nil
 }
@@ -0,0 +1,106 @@
+package repp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MissingDatabaseError is returned when a fragment database isn't in the filesystem.
+type MissingDatabaseError struct {
+	// Path of the missing database
+	Path string
+}
+
+func (e *MissingDatabaseError) Error() string {
+	return fmt.Sprintf("failed to find a BLAST database at %s", e.Path)
+}
+
+// NoCutsiteError is returned when a backbone isn't cut by the enzymes it's linearized with.
+type NoCutsiteError struct {
+	// Backbone is the ID of the backbone
+	Backbone string
+
+	// Enzymes are the names of the enzymes
+	Enzymes []string
+}
+
+func (e *NoCutsiteError) Error() string {
+	return fmt.Sprintf("no %s cutsites found in %s", strings.Join(e.Enzymes, ","), e.Backbone)
+}
+
+// PrimerError is returned when primers can't be made to PCR a fragment.
+type PrimerError struct {
+	// Fragment is the ID of the fragment
+	Fragment string
+
+	// Err is why the primers failed
+	Err error
+}
+
+func (e *PrimerError) Error() string {
+	return fmt.Sprintf("failed to pcr %s: %v", e.Fragment, e.Err)
+}
+
+func (e *PrimerError) Unwrap() error {
+	return e.Err
+}
+
+// SynthesisError is returned when no vendor can synthesize a fragment.
+type SynthesisError struct {
+	// Fragment is the ID of the fragment
+	Fragment string
+
+	// Err is why each vendor can't synthesize it
+	Err error
+}
+
+func (e *SynthesisError) Error() string {
+	return fmt.Sprintf("failed to synthesize %s: %v", e.Fragment, e.Err)
+}
+
+func (e *SynthesisError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownEnzymeError is returned for an enzyme name that isn't in the enzymes database.
+type UnknownEnzymeError struct {
+	// Name of the enzyme
+	Name string
+}
+
+func (e *UnknownEnzymeError) Error() string {
+	return fmt.Sprintf(`failed to find enzyme with name %s use "repp enzymes" for a list of recognized enzymes`, e.Name)
+}
+
+// ErrorCode returns a short code for the kind of an error, for scripts and logs.
+// It's empty for errors without a kind.
+func ErrorCode(err error) string {
+	var (
+		missingDatabase *MissingDatabaseError
+		noCutsite       *NoCutsiteError
+		primer          *PrimerError
+		synthesis       *SynthesisError
+		unknownEnzyme   *UnknownEnzymeError
+	)
+
+	switch {
+	case errors.As(err, &missingDatabase):
+		return "missing_database"
+	case errors.As(err, &noCutsite):
+		return "no_cutsite"
+	case errors.As(err, &unknownEnzyme):
+		return "unknown_enzyme"
+	case errors.As(err, &primer):
+		return "primer_failure"
+	case errors.As(err, &synthesis):
+		return "synthesis_failure"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+
+	return ""
+}
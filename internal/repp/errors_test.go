@@ -0,0 +1,71 @@
+package repp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_ErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"missing database",
+			&MissingDatabaseError{Path: "/dbs/addgene"},
+			"missing_database",
+		},
+		{
+			"wrapped no cutsite",
+			fmt.Errorf("failed to digest: %w", &NoCutsiteError{Backbone: "pSB1A3", Enzymes: []string{"EcoRI"}}),
+			"no_cutsite",
+		},
+		{
+			"primer failure",
+			&PrimerError{Fragment: "frag", Err: errors.New("off-target")},
+			"primer_failure",
+		},
+		{
+			"synthesis failure",
+			&SynthesisError{Fragment: "frag", Err: errors.New("idt: too long")},
+			"synthesis_failure",
+		},
+		{
+			"unknown enzyme",
+			&UnknownEnzymeError{Name: "EcoRX"},
+			"unknown_enzyme",
+		},
+		{
+			"timeout",
+			context.DeadlineExceeded,
+			"timeout",
+		},
+		{
+			"other",
+			errors.New("failed"),
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.want {
+				t.Errorf("ErrorCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PrimerError(t *testing.T) {
+	cause := errors.New("off-target")
+	err := error(&PrimerError{Fragment: "frag", Err: cause})
+
+	if err.Error() != "failed to pcr frag: off-target" {
+		t.Errorf("PrimerError.Error() = %s", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("PrimerError doesn't unwrap to its cause")
+	}
+}
@@ -0,0 +1,163 @@
+package repp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// outcomeSolution is a candidate that's one of the design's solutions
+	outcomeSolution = "solution"
+
+	// outcomeFailed is a candidate that failed to be filled, eg its primers had off-targets
+	outcomeFailed = "failed"
+
+	// outcomePruned is a candidate dominated by others with fewer or as many fragments
+	// that are better by the design's objective
+	outcomePruned = "pruned"
+
+	// outcomeUnfilled is a candidate that wasn't considered before the design stopped
+	outcomeUnfilled = "unfilled"
+)
+
+// Explanation is a report of every candidate assembly considered in a design and why
+// it was, or wasn't, one of the solutions.
+type Explanation struct {
+	// Target is the ID of the target plasmid
+	Target string `json:"target"`
+
+	// Candidates in the order they were considered: by fragment count and then estimated cost
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Candidate is an assembly considered in a design.
+type Candidate struct {
+	// Fragments are the IDs of the assembly's fragments, before it's filled
+	Fragments []string `json:"fragments"`
+
+	// Synthetic is the estimated number of synthetic fragments
+	Synthetic int `json:"synthetic"`
+
+	// Count is the estimated number of fragments, including the synthetic fragments
+	Count int `json:"count"`
+
+	// EstimatedCost is the cost estimated before the assembly was filled
+	EstimatedCost float64 `json:"estimatedCost"`
+
+	// Cost of the filled assembly. Zero if it wasn't filled
+	Cost float64 `json:"cost,omitempty"`
+
+	// Outcome of the candidate: solution, failed, pruned or unfilled
+	Outcome string `json:"outcome"`
+
+	// Reason it failed, was pruned or wasn't filled
+	Reason string `json:"reason,omitempty"`
+}
+
+// explanationKey is the key of a design's explanation in its context.
+type explanationKey struct{}
+
+// withExplanation returns a context that records the candidates of the design it's passed to.
+func withExplanation(ctx context.Context, e *Explanation) context.Context {
+	return context.WithValue(ctx, explanationKey{}, e)
+}
+
+// explanationOf returns the explanation recorded for a design. It's nil if the design
+// isn't being explained.
+func explanationOf(ctx context.Context) *Explanation {
+	e, _ := ctx.Value(explanationKey{}).(*Explanation)
+	return e
+}
+
+// explain returns a context that records the design's candidates, if flags has a path
+// to write them to, along with the explanation they're recorded to.
+func explain(ctx context.Context, flags *Flags) (context.Context, *Explanation) {
+	if flags.explain == "" {
+		return ctx, nil
+	}
+
+	e := &Explanation{Candidates: []Candidate{}}
+	return withExplanation(ctx, e), e
+}
+
+// newCandidate returns a candidate for an assembly that's not yet filled.
+func newCandidate(a assembly) Candidate {
+	c := Candidate{
+		Fragments:     []string{},
+		Synthetic:     a.synths,
+		Count:         a.len(),
+		EstimatedCost: a.cost,
+		Outcome:       outcomeUnfilled,
+	}
+	for _, f := range a.frags {
+		if f.fragType != synthetic {
+			c.Fragments = append(c.Fragments, f.ID)
+		}
+	}
+	return c
+}
+
+// write writes the explanation to a file, as HTML if it ends with .html and as JSON otherwise.
+// Nothing's written if the explanation is nil.
+func (e *Explanation) write(path string) error {
+	if e == nil {
+		return nil
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".html" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create the explanation file %s: %v", path, err)
+		}
+		defer f.Close()
+
+		if err = explanationTemplate.Execute(f, e); err != nil {
+			return fmt.Errorf("failed to write the explanation to %s: %v", path, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize the explanation: %v", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the explanation to %s: %v", path, err)
+	}
+	return nil
+}
+
+// explanationTemplate is an HTML page with a table of the candidates.
+var explanationTemplate = template.Must(template.New("explanation").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Target}} candidates</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+tr.solution { background: #e6f4ea; }
+tr.failed { background: #fce8e6; }
+tr.unfilled { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Target}}</h1>
+<p>{{len .Candidates}} candidate assemblies, in the order they were considered.</p>
+<table>
+<tr><th>#</th><th>fragments</th><th>synthetic</th><th>count</th><th>estimated cost</th><th>cost</th><th>outcome</th><th>reason</th></tr>
+{{range $i, $c := .Candidates}}<tr class="{{$c.Outcome}}"><td>{{$i}}</td><td>{{join $c.Fragments ", "}}</td><td>{{$c.Synthetic}}</td><td>{{$c.Count}}</td><td>${{printf "%.2f" $c.EstimatedCost}}</td><td>{{if $c.Cost}}${{printf "%.2f" $c.Cost}}{{end}}</td><td>{{$c.Outcome}}</td><td>{{$c.Reason}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,128 @@
+package repp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_fillAssemblies_explain(t *testing.T) {
+	c := config.New()
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	var assemblies []assembly
+	for i := 0; i < 5; i++ {
+		f := &Frag{ID: fmt.Sprintf("frag%d", i), Seq: target, fragType: circular, conf: c}
+		assemblies = append(assemblies, assembly{frags: []*Frag{f}, cost: float64(i)})
+	}
+	counts, countToAssemblies := groupAssembliesByCount(assemblies)
+
+	e := &Explanation{}
+	fillAssemblies(withExplanation(context.Background(), e), target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 1}, c)
+
+	if len(e.Candidates) != len(assemblies) {
+		t.Fatalf("fillAssemblies() explained %d candidates, want %d", len(e.Candidates), len(assemblies))
+	}
+	if got := e.Candidates[0]; got.Outcome != outcomeSolution || !reflect.DeepEqual(got.Fragments, []string{"frag0"}) {
+		t.Errorf("fillAssemblies() explained %+v, want frag0 as the solution", got)
+	}
+	for _, got := range e.Candidates[1:] {
+		if got.Outcome == outcomeSolution || got.Outcome == outcomeUnfilled || got.Reason == "" {
+			t.Errorf("fillAssemblies() explained %+v, want why it isn't a solution", got)
+		}
+	}
+
+	// candidates aren't filled after the context is cancelled
+	e = &Explanation{}
+	ctx, cancel := context.WithCancel(withExplanation(context.Background(), e))
+	cancel()
+	fillAssemblies(ctx, target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 1}, c)
+	for _, got := range e.Candidates {
+		if got.Outcome != outcomeUnfilled || got.Reason == "" {
+			t.Errorf("fillAssemblies() after cancel explained %+v, want it unfilled", got)
+		}
+	}
+}
+
+func Test_fillAssemblies_explainPruned(t *testing.T) {
+	c := config.New()
+	c.Workers = 1
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	// filled in the order of their estimates, the last is the cheapest and the
+	// second, then the worst of the two best, is pruned by the first
+	var assemblies []assembly
+	for i, cost := range []float64{10, 20, 5} {
+		f := &Frag{
+			ID:        fmt.Sprintf("frag%d", i),
+			Seq:       target,
+			fragType:  circular,
+			inventory: &inventoryEntry{cost: cost, hasCost: true},
+			conf:      c,
+		}
+		assemblies = append(assemblies, assembly{frags: []*Frag{f}, cost: float64(i)})
+	}
+	counts, countToAssemblies := groupAssembliesByCount(assemblies)
+
+	e := &Explanation{}
+	fillAssemblies(withExplanation(context.Background(), e), target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 2}, c)
+
+	got := e.Candidates[1]
+	if got.Outcome != outcomePruned || !strings.Contains(got.Reason, fmt.Sprintf("than %s,", objective{name: objectiveCost}.format(10))) {
+		t.Errorf("fillAssemblies() explained %+v, want it pruned by frag0", got)
+	}
+}
+
+func Test_Explanation_write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repp-explain-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := &Explanation{
+		Target: "target",
+		Candidates: []Candidate{
+			{Fragments: []string{"a", "b"}, Count: 2, EstimatedCost: 120.5, Cost: 130.25, Outcome: outcomeSolution},
+			{Fragments: []string{"c"}, Synthetic: 1, Count: 2, EstimatedCost: 90, Outcome: outcomeFailed, Reason: "off-target <in c>"},
+		},
+	}
+
+	jsonPath := filepath.Join(dir, "explain.json")
+	if err := e.write(jsonPath); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(jsonPath)
+	var got Explanation
+	if err := json.Unmarshal(data, &got); err != nil || !reflect.DeepEqual(&got, e) {
+		t.Errorf("Explanation.write() = %s, want %+v", data, e)
+	}
+
+	htmlPath := filepath.Join(dir, "explain.html")
+	if err := e.write(htmlPath); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = ioutil.ReadFile(htmlPath)
+	for _, want := range []string{"<h1>target</h1>", "a, b", "$130.25", "off-target &lt;in c&gt;"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Explanation.write() HTML is missing %q", want)
+		}
+	}
+
+	// nothing is written without an explanation
+	var none *Explanation
+	if err := none.write(filepath.Join(dir, "none.json")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "none.json")); !os.IsNotExist(err) {
+		t.Error("Explanation.write() wrote a nil explanation")
+	}
+}
@@ -2,7 +2,9 @@ package repp
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -25,35 +27,59 @@ type featureMatch struct {
 	match        match
 }
 
-// FeaturesCmd accepts a cobra commands and assembles a plasmid containing all the features
-func FeaturesCmd(cmd *cobra.Command, args []string) {
-	Features(parseCmdFlags(cmd, args, true))
-}
-
 // Features assembles a plasmid with all the Features requested with the 'repp Features [feature ...]' command
 // repp assemble Features p10 promoter, mEGFP, T7 terminator
-func Features(flags *Flags, conf *config.Config) [][]*Frag {
+// The output is written to the out path of the flags, if set.
+//
+// If the context is cancelled or times out while assemblies are being filled, the best
+// solutions found so far are written and returned with the context's error. Like Sequence,
+// a report of the candidate assemblies is written to the explain path of the flags, if set.
+func Features(ctx context.Context, flags *Flags, conf *config.Config) (*Output, error) {
 	start := time.Now()
 
 	// turn feature names into sequences
-	insertFeats, bbFeat := queryFeatures(flags)
+	insertFeats, bbFeat, err := queryFeatures(ctx, flags)
+	if err != nil {
+		return nil, err
+	}
 	feats := insertFeats
 	if len(bbFeat) > 0 {
 		feats = append(feats, bbFeat)
 	}
 
 	// find matches in the databases
-	featureMatches := blastFeatures(flags, feats, conf)
+	reportProgress(ctx, func(p *Progress) { p.Stage = "blast" })
+	featureMatches, err := blastFeatures(ctx, flags, feats, conf)
+	if ctx.Err() != nil {
+		return nil, ctx.Err() // any error was from a search that was cut short
+	}
+	if err != nil {
+		return nil, err
+	}
 	if len(featureMatches) == 0 {
 		featNames := []string{}
 		for _, feat := range insertFeats {
 			featNames = append(featNames, feat[0])
 		}
-		stderr.Fatalf("failed to find fragments with the specified features: %s", strings.Join(featNames, ", "))
+		return nil, fmt.Errorf("failed to find fragments with the specified features: %s", strings.Join(featNames, ", "))
 	}
 
 	// build assemblies containing the matched fragments
-	target, solutions := featureSolutions(feats, featureMatches, flags, conf)
+	ctx, explanation := explain(ctx, flags)
+	target, solutions, err := featureSolutions(ctx, feats, featureMatches, flags, conf)
+	if explanation != nil {
+		explanation.Target = flags.in
+		if err := explanation.write(flags.explain); err != nil {
+			return nil, err
+		}
+	}
+	stopped := ctx.Err()
+	if stopped != nil && len(solutions) == 0 {
+		return nil, stopped // any error was from a step that was cut short
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	// write the output file
 	insertLength := 0
@@ -61,30 +87,40 @@ func Features(flags *Flags, conf *config.Config) [][]*Frag {
 		insertLength += len(f[1])
 	}
 
-	writeJSON(
-		flags.out,
+	out, err := newOutput(
 		flags.in,
 		target,
 		solutions,
 		insertLength,
 		time.Since(start).Seconds(),
 		flags.backboneMeta,
+		nil,
 		conf,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.out != "" {
+		if err := writeOutput(flags, out); err != nil {
+			return nil, err
+		}
+	}
 
-	return solutions
+	return out, stopped
 }
 
 // queryFeatures takes the list of feature names and finds them in the available databases
-func queryFeatures(flags *Flags) ([][]string, []string) {
+func queryFeatures(ctx context.Context, flags *Flags) ([][]string, []string, error) {
 	var insertFeats [][]string // slice of tuples [feature name, feature sequence]
-	if readFeatures, err := read(flags.in, true); err == nil {
+	if readFeatures, err := flags.read(true); err == nil {
 		// see if the features are in a file (multi-FASTA or features in a Genbank)
 		seenFeatures := make(map[string]string) // map feature name to sequence
 		for _, f := range readFeatures {
-			if seq := seenFeatures[f.ID]; seq != f.Seq {
-				stderr.Fatalf("failed to parse features, %s has two different sequences:\n\t%s\n\t%s\n", f.ID, f.Seq, seq)
+			if seq, seen := seenFeatures[f.ID]; seen && seq != f.Seq {
+				return nil, nil, fmt.Errorf("failed to parse features, %s has two different sequences:\n\t%s\n\t%s", f.ID, f.Seq, seq)
 			}
+			seenFeatures[f.ID] = f.Seq
 			insertFeats = append(insertFeats, []string{f.ID, f.Seq})
 		}
 	} else {
@@ -98,10 +134,14 @@ func queryFeatures(flags *Flags) ([][]string, []string) {
 		}
 
 		if len(featureNames) < 1 {
-			stderr.Fatal("no features chosen. see 'repp make features --help'")
+			return nil, nil, fmt.Errorf("no features chosen. see 'repp make features --help'")
+		}
+
+		featureDB, err := flags.features()
+		if err != nil {
+			return nil, nil, err
 		}
 
-		featureDB := NewFeatureDB()
 		for _, f := range featureNames {
 			fwd := true
 			if strings.Contains(f, ":") {
@@ -116,7 +156,7 @@ func queryFeatures(flags *Flags) ([][]string, []string) {
 					seq = reverseComplement(seq)
 				}
 				insertFeats = append(insertFeats, []string{f, seq})
-			} else if dbFrag, err := queryDatabases(f, flags.dbs); err == nil {
+			} else if dbFrag, err := queryDatabases(ctx, f, flags.dbs, flags.conf); err == nil {
 				f = strings.Replace(f, ":", "|", -1)
 				if !fwd {
 					dbFrag.Seq = reverseComplement(dbFrag.Seq)
@@ -124,7 +164,7 @@ func queryFeatures(flags *Flags) ([][]string, []string) {
 				insertFeats = append(insertFeats, []string{f, dbFrag.Seq})
 			} else {
 				sep := "\n\t"
-				stderr.Fatalf(
+				return nil, nil, fmt.Errorf(
 					"failed to find '%s' in the features database (%s) or any of:"+
 						"%s\ncheck features database with 'repp features find [feature name]'",
 					f,
@@ -141,17 +181,17 @@ func queryFeatures(flags *Flags) ([][]string, []string) {
 		bbFeat = []string{flags.backbone.ID, flags.backbone.Seq}
 	}
 
-	return insertFeats, bbFeat
+	return insertFeats, bbFeat, nil
 }
 
 // blastFeatures returns matches between the target features and entries in the databases with those features
-func blastFeatures(flags *Flags, feats [][]string, conf *config.Config) map[string][]featureMatch {
+func blastFeatures(ctx context.Context, flags *Flags, feats [][]string, conf *config.Config) (map[string][]featureMatch, error) {
 	featureMatches := make(map[string][]featureMatch) // a map from from each entry (by id) to its list of matched features
 	for i, target := range feats {
 		targetFeature := target[1]
-		matches, err := blast(target[0], targetFeature, false, flags.dbs, flags.filters, flags.identity, blastWriter())
+		matches, err := blast(ctx, target[0], targetFeature, false, flags.dbs, flags.filters, flags.identity, conf)
 		if err != nil {
-			stderr.Fatalln(err)
+			return nil, err
 		}
 
 		for _, m := range matches {
@@ -175,29 +215,31 @@ func blastFeatures(flags *Flags, feats [][]string, conf *config.Config) map[stri
 		}
 	}
 
-	return featureMatches
+	return featureMatches, nil
 }
 
 // featureSolutions creates and fills the assemblies using the matched fragments
-func featureSolutions(feats [][]string, featureMatches map[string][]featureMatch, flags *Flags, conf *config.Config) (string, [][]*Frag) {
+func featureSolutions(ctx context.Context, feats [][]string, featureMatches map[string][]featureMatch, flags *Flags, conf *config.Config) (string, [][]*Frag, error) {
 	// merge matches into one another if they can combine to cover a range
 	extendedMatches := extendMatches(feats, featureMatches)
 
 	// filter out matches that are completely contained in others or too short
-	if conf.Verbose {
-		fmt.Printf("%d matched fragments\n", len(featureMatches))
-		fmt.Printf("%d matches before culling\n", len(extendedMatches))
-	}
+	logger.Debug("matched features", "fragments", len(featureMatches), "matches", len(extendedMatches))
 
 	// remove extended matches fully enclosed by others
 	extendedMatches = cull(extendedMatches, len(feats), 1, 4)
 
 	// create a subject file from the matches' source fragments
-	subjectDB, frags := subjectDatabase(extendedMatches, flags.dbs)
+	subjectDB, frags, err := subjectDatabase(ctx, extendedMatches, flags.dbs, conf)
+	if err != nil {
+		return "", nil, err
+	}
 	defer os.Remove(subjectDB)
 
 	// re-BLAST the features against the new subject database
-	featureMatches = reblastFeatures(flags, feats, conf, subjectDB, frags)
+	if featureMatches, err = reblastFeatures(ctx, flags, feats, conf, subjectDB, frags); err != nil {
+		return "", nil, err
+	}
 
 	// merge matches into one another if they can combine to cover a range
 	extendedMatches = extendMatches(feats, featureMatches)
@@ -205,9 +247,7 @@ func featureSolutions(feats [][]string, featureMatches map[string][]featureMatch
 	// remove extended matches fully enclosed by others
 	extendedMatches = cull(extendedMatches, len(feats), 1, 4)
 
-	if conf.Verbose {
-		fmt.Printf("%d matches after culling\n", len(extendedMatches))
-	}
+	logger.Debug("culled matches", "matches", len(extendedMatches))
 
 	// get the full plasmid length as if just synthesizing each feature next to one another
 	var targetBuilder strings.Builder
@@ -227,9 +267,9 @@ func featureSolutions(feats [][]string, featureMatches map[string][]featureMatch
 		}
 		seenMatches[m.uniqueID] = true
 
-		frag, err := queryDatabases(m.entry, flags.dbs)
+		frag, err := queryDatabases(ctx, m.entry, flags.dbs, conf)
 		if err != nil {
-			stderr.Fatalln(err)
+			return "", nil, err
 		}
 
 		frag.ID = m.entry
@@ -263,20 +303,31 @@ func featureSolutions(feats [][]string, featureMatches map[string][]featureMatch
 	}
 
 	// traverse the fragments, accumulate assemblies that span all the features
-	assemblies := createAssemblies(frags, target, len(feats), true, conf)
+	reportProgress(ctx, func(p *Progress) {
+		p.Stage = "build"
+		p.Fragments = len(frags)
+	})
+	assemblies, err := createAssemblies(ctx, frags, target, len(feats), true, nil, conf)
+	if err != nil {
+		return "", nil, err
+	}
 
 	// build up a map from fragment count to a sorted list of assemblies with that number
 	assemblyCounts, countToAssemblies := groupAssembliesByCount(assemblies)
 
 	// fill each assembly and accumulate the pareto optimal solutions
-	solutions := fillAssemblies(target, assemblyCounts, countToAssemblies, conf)
+	reportProgress(ctx, func(p *Progress) {
+		p.Stage = "fill"
+		p.Assemblies = len(assemblies)
+	})
+	solutions := fillAssemblies(ctx, target, assemblyCounts, countToAssemblies, nil, flags.objective, conf)
 
 	// update the target to the first filled assembly
 	if len(solutions) > 0 {
 		target = annealFragments(conf.FragmentsMinHomology, conf.FragmentsMaxHomology, solutions[0])
 	}
 
-	return target, solutions
+	return target, solutions, nil
 }
 
 // extendMatches groups and extends matches against the subject sequence
@@ -330,12 +381,12 @@ func extendMatches(feats [][]string, featureMatches map[string][]featureMatch) (
 // create a subject database to query specifically for all
 // features. Needed because the first BLAST may not return
 // all feature matches on each fragment
-func subjectDatabase(extendedMatches []match, dbs []string) (filename string, frags []*Frag) {
+func subjectDatabase(ctx context.Context, extendedMatches []match, dbs []string, conf *config.Config) (filename string, frags []*Frag, err error) {
 	subject := ""
 	for _, m := range extendedMatches {
-		frag, err := queryDatabases(m.entry, dbs)
+		frag, err := queryDatabases(ctx, m.entry, dbs, conf)
 		if err != nil {
-			stderr.Fatalln(err)
+			return "", nil, err
 		}
 		subject += fmt.Sprintf(">%s\n%s\n", frag.ID, frag.Seq)
 		frags = append(frags, frag)
@@ -343,22 +394,25 @@ func subjectDatabase(extendedMatches []match, dbs []string) (filename string, fr
 
 	in, err := ioutil.TempFile("", "feature-subject-*")
 	if err != nil {
-		stderr.Fatal(err)
+		return "", nil, err
 	}
+	defer in.Close()
 
-	in.WriteString(subject)
+	if _, err = in.WriteString(subject); err != nil {
+		return "", nil, err
+	}
 
-	return in.Name(), frags
+	return in.Name(), frags, nil
 }
 
 // reblastFeatures returns matches between the target features and entries in the databases with those features
-func reblastFeatures(flags *Flags, feats [][]string, conf *config.Config, subjectDB string, frags []*Frag) map[string][]featureMatch {
+func reblastFeatures(ctx context.Context, flags *Flags, feats [][]string, conf *config.Config, subjectDB string, frags []*Frag) (map[string][]featureMatch, error) {
 	featureMatches := make(map[string][]featureMatch) // a map from from each entry (by id) to its list of matched features
 	for i, target := range feats {
 		targetFeature := target[1]
-		matches, err := blastAgainst(target[0], targetFeature, subjectDB, false, flags.identity, blastWriter())
+		matches, err := blastAgainst(ctx, target[0], targetFeature, subjectDB, false, flags.identity, conf)
 		if err != nil {
-			stderr.Fatalln(err)
+			return nil, err
 		}
 
 		for _, m := range matches {
@@ -416,16 +470,16 @@ func reblastFeatures(flags *Flags, feats [][]string, conf *config.Config, subjec
 		}
 	}
 
-	return featureMatches
+	return featureMatches, nil
 }
 
-// NewFeatureDB returns a new copy of the features db
-func NewFeatureDB() *FeatureDB {
+// LoadFeatureDB returns a new copy of the features db or an error if it can't be read
+func LoadFeatureDB() (*FeatureDB, error) {
 	features := make(map[string]string)
 
 	featureFile, err := os.Open(config.FeatureDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return nil, err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -436,16 +490,16 @@ func NewFeatureDB() *FeatureDB {
 	}
 
 	if err := featureFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return nil, err
 	}
 
-	return &FeatureDB{features: features}
+	return &FeatureDB{features: features}, nil
 }
 
 // ReadCmd returns features that are similar in name to the feature name requested.
 // if multiple feature names include the feature name, they are all returned.
 // otherwise a list of feature names are returned (those beneath a levenshtein distance cutoff)
-func (f *FeatureDB) ReadCmd(cmd *cobra.Command, args []string) {
+func (f *FeatureDB) ReadCmd(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		// no feature name passed, log all of them
 		featNames := []string{}
@@ -472,7 +526,7 @@ func (f *FeatureDB) ReadCmd(cmd *cobra.Command, args []string) {
 
 		w.Flush()
 
-		return
+		return nil
 	}
 
 	name := args[0]
@@ -480,52 +534,71 @@ func (f *FeatureDB) ReadCmd(cmd *cobra.Command, args []string) {
 		name = strings.Join(args, " ")
 	}
 
+	// from https://golang.org/pkg/text/tabwriter/
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+	writeMatches(w, f.Find(name), fmt.Sprintf("failed to find any features for %s", name))
+	return w.Flush()
+}
+
+// Find returns the features, mapped to their sequences, with names similar to name.
+// An exact match is returned alone unless other features contain the name. Otherwise
+// the features containing the name are returned or, if there are fewer than three,
+// they're returned with those beneath a levenshtein distance cutoff.
+func (f *FeatureDB) Find(name string) map[string]string {
 	ldCutoff := len(name) / 3
 	if 1 > ldCutoff {
 		ldCutoff = 1
 	}
-	containing := []string{}
-	lowDistance := []string{}
+	containing := make(map[string]string)
+	lowDistance := make(map[string]string)
 
 	for fName, fSeq := range f.features {
 		if strings.Contains(fName, name) {
-			containing = append(containing, fName+"\t"+fSeq)
+			containing[fName] = fSeq
 		} else if len(fName) > ldCutoff && ld(name, fName, true) <= ldCutoff {
-			lowDistance = append(lowDistance, fName+"\t"+fSeq)
+			lowDistance[fName] = fSeq
 		}
 	}
 
 	// check for an exact match
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
-	matchedFeature, exactMatch := f.features[name]
-	if exactMatch && len(containing) < 2 {
-		fmt.Fprintf(w, name+"\t"+matchedFeature)
-		w.Write([]byte("\n"))
-		w.Flush()
-		return
+	if seq, exactMatch := f.features[name]; exactMatch && len(containing) < 2 {
+		return map[string]string{name: seq}
 	}
 
-	// from https://golang.org/pkg/text/tabwriter/
 	if len(containing) < 3 {
-		lowDistance = append(lowDistance, containing...)
-		containing = []string{} // clear
+		for fName, fSeq := range containing {
+			lowDistance[fName] = fSeq
+		}
+		return lowDistance
 	}
-	if len(containing) > 0 {
-		fmt.Fprintf(w, strings.Join(containing, "\n"))
-	} else if len(lowDistance) > 0 {
-		fmt.Fprintf(w, strings.Join(lowDistance, "\n"))
-	} else {
-		fmt.Fprintf(w, fmt.Sprintf("failed to find any features for %s", name))
+
+	return containing
+}
+
+// writeMatches writes names and their sequences, sorted by name, to w. notFound
+// is written if there are no matches.
+func writeMatches(w io.Writer, matches map[string]string, notFound string) {
+	if len(matches) < 1 {
+		fmt.Fprintln(w, notFound)
+		return
+	}
+
+	names := []string{}
+	for name := range matches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, matches[name])
 	}
-	w.Write([]byte("\n"))
-	w.Flush()
 }
 
 // SetCmd the feature's seq in the database (or create if it isn't in the feature db)
-func (f *FeatureDB) SetCmd(cmd *cobra.Command, args []string) {
+func (f *FeatureDB) SetCmd(cmd *cobra.Command, args []string) error {
 	if len(args) < 2 {
 		cmd.Help()
-		stderr.Fatalln("\nexpecting two args: a features name and sequence.")
+		return fmt.Errorf("expecting two args: a features name and sequence")
 	}
 
 	name := args[0]
@@ -538,7 +611,7 @@ func (f *FeatureDB) SetCmd(cmd *cobra.Command, args []string) {
 
 	featureFile, err := os.Open(config.FeatureDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -561,11 +634,11 @@ func (f *FeatureDB) SetCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if err := featureFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if err := ioutil.WriteFile(config.FeatureDB, []byte(output.String()), 0644); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if updated {
@@ -574,13 +647,15 @@ func (f *FeatureDB) SetCmd(cmd *cobra.Command, args []string) {
 
 	// update in memory
 	f.features[name] = seq
+
+	return nil
 }
 
 // DeleteCmd the feature from the database
-func (f *FeatureDB) DeleteCmd(cmd *cobra.Command, args []string) {
+func (f *FeatureDB) DeleteCmd(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		cmd.Help()
-		stderr.Fatalln("\nno features name passed.")
+		return fmt.Errorf("no features name passed")
 	}
 
 	name := args[0]
@@ -589,12 +664,12 @@ func (f *FeatureDB) DeleteCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if _, contained := f.features[name]; !contained {
-		fmt.Printf("failed to find %s in the features database\n", name)
+		return fmt.Errorf("failed to find %s in the features database", name)
 	}
 
 	featureFile, err := os.Open(config.FeatureDB)
 	if err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// https://golang.org/pkg/bufio/#example_Scanner_lines
@@ -611,11 +686,11 @@ func (f *FeatureDB) DeleteCmd(cmd *cobra.Command, args []string) {
 	}
 
 	if err := featureFile.Close(); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	if err := ioutil.WriteFile(config.FeatureDB, []byte(output.String()), 0644); err != nil {
-		stderr.Fatal(err)
+		return err
 	}
 
 	// delete from memory
@@ -623,9 +698,9 @@ func (f *FeatureDB) DeleteCmd(cmd *cobra.Command, args []string) {
 
 	if deleted {
 		fmt.Printf("deleted %s from the features database\n", name)
-	} else {
-		fmt.Printf("failed to find %s in the features database\n", name)
 	}
+
+	return nil
 }
 
 // ld compares two strings and returns the levenshtein distance between them.
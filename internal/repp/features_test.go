@@ -1,20 +1,48 @@
 package repp
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	"github.com/jjtimmons/repp/config"
 )
 
-func TestNewFeatureDB(t *testing.T) {
-	db := NewFeatureDB()
+func TestLoadFeatureDB(t *testing.T) {
+	db, err := LoadFeatureDB()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(db.features) < 1 {
 		t.Fail()
 	}
 }
 
+func TestFeatureDB_Find(t *testing.T) {
+	db := &FeatureDB{features: map[string]string{
+		"T7 promoter":   "TAATACGACTCACTATAG",
+		"T7 terminator": "CTAGCATAACCCCTTGGGGCCTCTAAACGGGTCTTGAGGGGTTTTTTG",
+		"lac promoter":  "TTTACACTTTATGCTTCCGGCTCGTATGTTG",
+	}}
+
+	if got := db.Find("T7 promoter"); len(got) != 1 || got["T7 promoter"] == "" {
+		t.Errorf("Find() = %v, want the exact match", got)
+	}
+
+	if got := db.Find("T7"); len(got) != 2 {
+		t.Errorf("Find() = %v, want both T7 features", got)
+	}
+
+	if got := db.Find("lac promotor"); got["lac promoter"] == "" {
+		t.Errorf("Find() = %v, want the misspelled lac promoter", got)
+	}
+
+	if got := db.Find("GFP"); len(got) != 0 {
+		t.Errorf("Find() = %v, want no features", got)
+	}
+}
+
 func Test_queryFeatures(t *testing.T) {
 	type args struct {
 		flags *Flags
@@ -55,7 +83,7 @@ func Test_queryFeatures(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := queryFeatures(tt.args.flags); !reflect.DeepEqual(got, tt.want) {
+			if got, _, _ := queryFeatures(context.Background(), tt.args.flags); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("queryFeatures() = %v, want %v", got, tt.want)
 			}
 		})
@@ -87,7 +115,7 @@ func Test_blastFeatures(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := blastFeatures(tt.args.flags, tt.args.targetFeatures, config.New())
+			got, _ := blastFeatures(context.Background(), tt.args.flags, tt.args.targetFeatures, config.New())
 
 			matches := []match{}
 			for _, ms := range got {
@@ -1,22 +1,59 @@
 package repp
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"math"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/jinzhu/copier"
 	"github.com/jjtimmons/repp/config"
 )
 
-var (
-	// madePrimers, formerly made primers
-	madePrimers = make(map[string][]Primer)
+// madePrimers, formerly made primers and the errors found during prior builds
+var madePrimers = &primerCache{
+	primers: make(map[string][]Primer),
+	errs:    make(map[string]error),
+}
 
-	// primerErrs, errors found during prior builds
-	primerErrs = make(map[string]error)
-)
+// primerCache is a store of primers made for PCR runs, keyed by primerHash. It's
+// safe for concurrent use, so designs of multiple targets can share it.
+type primerCache struct {
+	mu sync.Mutex
+
+	// primers made for a PCR run
+	primers map[string][]Primer
+
+	// errs from failing to make primers for a PCR run
+	errs map[string]error
+}
+
+// get returns the primers or error from a prior PCR run and whether there was one.
+func (c *primerCache) get(key string) ([]Primer, error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err, failed := c.errs[key]; failed {
+		return nil, err, true
+	}
+
+	primers, made := c.primers[key]
+	return primers, nil, made
+}
+
+// set stores the primers, or the error if it's not nil, of a PCR run.
+func (c *primerCache) set(key string, primers []Primer, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.errs[key] = err
+	} else {
+		c.primers[key] = primers
+	}
+}
 
 // fragType is the Frag building type to be used in the assembly
 type fragType int
@@ -49,6 +86,18 @@ type Frag struct {
 	// URL, eg link to a plasmid's addgene page
 	URL string `json:"url,omitempty"`
 
+	// Location of the fragment's source in a local inventory, eg a freezer box
+	Location string `json:"location,omitempty"`
+
+	// Concentration of the fragment's source in a local inventory
+	Concentration string `json:"concentration,omitempty"`
+
+	// Vendor that a synthetic fragment is ordered from
+	Vendor string `json:"vendor,omitempty"`
+
+	// Backbone is whether the fragment is the user's linearized backbone
+	Backbone bool `json:"backbone,omitempty"`
+
 	// fragment/plasmid's sequence
 	Seq string `json:"seq,omitempty"`
 
@@ -71,6 +120,9 @@ type Frag struct {
 	// db that the frag came from
 	db string
 
+	// inventory entry of the frag in its db's inventory. nil if it isn't in one
+	inventory *inventoryEntry
+
 	// start of this Frag on the target plasmid
 	start int
 
@@ -86,6 +138,9 @@ type Frag struct {
 	// assemblies that span from this Frag to the end of the plasmid
 	assemblies []assembly
 
+	// overhang ligated to the next Frag in a Golden Gate assembly
+	overhang string
+
 	// build configuration
 	conf *config.Config
 }
@@ -119,8 +174,15 @@ type Primer struct {
 	// GC % max
 	GC float64 `json:"gc"`
 
+	// Inventory is the name of the primer in the primer inventory. Empty if it's a new primer
+	Inventory string `json:"inventory,omitempty"`
+
 	// Range that the primer spans on the fragment
 	Range ranged `json:"-"`
+
+	// added is the number of bp added to the primer's 5' end, beyond its range from primer3,
+	// for homology with the neighboring fragment
+	added int
 }
 
 // newFrag creates a Frag from a match
@@ -131,33 +193,24 @@ func newFrag(m match, conf *config.Config) *Frag {
 	}
 
 	return &Frag{
-		ID:       m.entry,
-		uniqueID: m.uniqueID,
-		Seq:      strings.ToUpper(m.seq),
-		start:    m.queryStart,
-		end:      m.queryEnd,
-		db:       m.db,
-		URL:      parseURL(m.entry, m.db),
-		conf:     conf,
-		fragType: fType,
+		ID:        m.entry,
+		uniqueID:  m.uniqueID,
+		Seq:       strings.ToUpper(m.seq),
+		start:     m.queryStart,
+		end:       m.queryEnd,
+		db:        m.db,
+		inventory: inventoryLookup(m.db, m.entry),
+		URL:       parseURL(m.entry, m.db, conf),
+		conf:      conf,
+		fragType:  fType,
 	}
 }
 
-// parseURL turns a fragment identifier into a URL to its repository
-func parseURL(entry, db string) string {
-	if strings.Contains(db, "addgene") {
-		// create a link to the source Addgene page
-		entries := strings.Split(entry, ".")
-		return fmt.Sprintf("https://www.addgene.org/%s/", entries[0])
-	}
-
-	if strings.Contains(db, "igem") {
-		// create a source to the source iGEM page
-		return fmt.Sprintf("http://parts.igem.org/Part:%s", entry)
-	}
-
-	if strings.Contains(db, "dnasu") {
-		return fmt.Sprintf("http://dnasu.org/DNASU/GetCloneDetail.do?cloneid=%s", entry)
+// parseURL turns a fragment identifier into a URL to its repository, from the
+// URL template of its db in the registry of databases
+func parseURL(entry, db string, conf *config.Config) string {
+	if d, registered := conf.Database(db, ""); registered {
+		return d.EntryURL(entry)
 	}
 
 	return ""
@@ -200,26 +253,58 @@ func (f *Frag) copy() (newFrag *Frag) {
 // cost returns the estimated cost of a fragment. Combination of source and preparation
 func (f *Frag) cost(procure bool) (c float64) {
 	if procure {
-		if strings.Contains(f.URL, "addgene") {
-			c += f.conf.CostAddgene
-		} else if strings.Contains(f.URL, "igem") {
-			c += f.conf.CostIGEM
-		} else if strings.Contains(f.URL, "dnasu") {
-			c += f.conf.CostDNASU
-		}
+		c += f.procurementCost()
 	}
 
 	if f.fragType == pcr && f.Primers != nil {
-		// cost of primers plus the cost of a single PCR reaction
-		c += float64(len(f.Primers[0].Seq)+len(f.Primers[1].Seq)) * f.conf.CostBP
+		// cost of new primers plus the cost of a single PCR reaction. Primers from
+		// the inventory are free
+		for _, p := range f.Primers[:2] {
+			if p.Inventory == "" {
+				c += float64(len(p.Seq)) * f.conf.CostBP
+			}
+		}
 		c += f.conf.CostPCR
 	} else if f.fragType == synthetic {
-		c += f.conf.SynthFragmentCost(len(f.Seq))
+		if v, picked := f.conf.SynthVendor(f.Vendor); picked {
+			c += v.SynthFragmentCost(len(f.Seq))
+		} else {
+			c += f.conf.SynthFragmentCost(len(f.Seq))
+		}
 	}
 
 	return
 }
 
+// procurementCost returns the cost of ordering the fragment's source from its repository.
+// A cost in the inventory of the fragment's db takes precedence.
+func (f *Frag) procurementCost() float64 {
+	if f.inventory != nil && f.inventory.hasCost {
+		return f.inventory.cost
+	}
+
+	if d, registered := f.conf.Database(f.db, f.URL); registered {
+		return d.Cost
+	}
+
+	return 0
+}
+
+// leadTime returns the number of days it takes to receive the fragment's source from its
+// repository. It's on hand if it's in an inventory or from an internal database or a
+// database outside the registry.
+func (f *Frag) leadTime() float64 {
+	if f.inventory != nil {
+		return 0
+	}
+
+	if d, registered := f.conf.Database(f.db, f.URL); registered && !d.Internal {
+		return d.LeadTime
+	}
+
+	return 0
+}
+
 // distTo returns the distance between the start of this Frag and the end of the other.
 // assumes that this Frag starts before the other
 // will return a negative number if this Frag overlaps with the other and positive otherwise
@@ -254,7 +339,7 @@ func (f *Frag) synthDist(other *Frag) (synthCount int) {
 	floatDist := math.Max(1.0, float64(dist))
 
 	// split up the distance between them by the max synthesized fragment size
-	return int(math.Ceil(floatDist / float64(f.conf.SyntheticMaxLength)))
+	return int(math.Ceil(floatDist / float64(f.conf.SynthMaxLength())))
 }
 
 // costTo estimates the $ amount needed to get from this fragment
@@ -268,10 +353,21 @@ func (f *Frag) synthDist(other *Frag) (synthCount int) {
 //
 // This does not add in the cost of procurement, which is added to the assembly cost
 // in assembly.add()
-func (f *Frag) costTo(other *Frag) (cost float64) {
+//
+// The junctions are those of a Golden Gate assembly if gg is not nil, where each end
+// gets a recognition site, spacer and overhang, and of a Gibson Assembly otherwise
+func (f *Frag) costTo(other *Frag, gg *goldenGate) (cost float64) {
 	needsPCR := f.fragType == pcr || f.fragType == circular
 	pcrNoHomology := 50.0 * f.conf.CostBP // pcr no homology
 	pcrHomology := (50.0 + float64(f.conf.FragmentsMinHomology)) * f.conf.CostBP
+	junction := f.conf.FragmentsMinHomology // bp added to each end of a synthetic fragment
+	if gg != nil {
+		// both primers are flanked by recognition sites, and extended to an overhang
+		// if the fragments don't already overlap
+		pcrNoHomology = (50.0 + 2*float64(len(gg.flank()))) * f.conf.CostBP
+		pcrHomology = pcrNoHomology + 2*overhangLength*f.conf.CostBP
+		junction = len(gg.flank()) + overhangLength
+	}
 
 	if other == f {
 		if needsPCR {
@@ -294,9 +390,9 @@ func (f *Frag) costTo(other *Frag) (cost float64) {
 
 	// we need to create a new synthetic fragment to get from this fragment to the next
 	// to account for both the bps between them as well as the additional bps we need to add
-	// for homology between the two
+	// for the junctions on either end
 	dist := f.distTo(other)
-	dist += f.conf.FragmentsMinHomology * 2
+	dist += junction * 2
 	synthCost := f.conf.SynthFragmentCost(dist)
 
 	// also account for whether this frag will require PCR
@@ -391,22 +487,22 @@ func (f *Frag) junction(other *Frag, minHomology, maxHomology int) (junction str
 // one another and are within the upper and lower synthesis bounds.
 // target is the plasmid's full sequence. We need it to build up the target
 // plasmid's sequence
-func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
+func (f *Frag) synthTo(ctx context.Context, next *Frag, target string) (synths []*Frag, err error) {
 	jL := f.conf.FragmentsMinHomology // junction length
 
 	// check whether we need to make synthetic fragments to get
 	// to the next fragment in the assembly
 	synCount := f.synthDist(next) // fragment count
 	if synCount == 0 {
-		return nil
+		return nil, nil
 	}
 
 	tL := len(target)               // length of the full target plasmid
 	fL := f.distTo(next) / synCount // each fragment's length
 	fL += jL * 2                    // account for homology on either end of each synthetic fragment
-	if f.conf.SyntheticMinLength > fL {
-		// need to synthesize at least Synthesis.MinLength bps
-		fL = f.conf.SyntheticMinLength
+	if minLength := f.conf.SynthMinLength(); minLength > fL {
+		// need to synthesize at least the shortest fragment a vendor makes
+		fL = minLength
 	}
 
 	// add to self to account for sequence across the zero-index (when sequence subselecting)
@@ -423,14 +519,30 @@ func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
 
 		// check for a hairpin in the junction and shift this fragment's synthesis
 		// to the right if a hairpin is found
-		for hairpin(seq[len(seq)-jL:], f.conf) > f.conf.FragmentsMaxHairpinMelt {
+		for {
+			melt, err := hairpin(ctx, seq[len(seq)-jL:], f.conf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find hairpins in %s: %v", f.ID, err)
+			}
+			if melt <= f.conf.FragmentsMaxHairpinMelt {
+				break
+			}
+
 			end += jL / 2
 			seq = target[start:end]
 		}
 
+		// order it from the cheapest vendor that can make it
+		id := fmt.Sprintf("%s-%s-synthesis-%d", f.ID, next.ID, len(synths)+1)
+		vendor, err := pickVendor(seq, f.conf)
+		if err != nil {
+			return nil, &SynthesisError{Fragment: id, Err: err}
+		}
+
 		synths = append(synths, &Frag{
-			ID:       fmt.Sprintf("%s-%s-synthesis-%d", f.ID, next.ID, len(synths)+1),
+			ID:       id,
 			Seq:      seq,
+			Vendor:   vendor.Name,
 			start:    start,
 			end:      end,
 			fragType: synthetic,
@@ -446,17 +558,30 @@ func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
 // setPrimers creates primers against a Frag and returns an error if:
 //	1. the primers have an unacceptably high primer3 penalty score
 //	2. the primers have off-targets in their source plasmid/fragment
-func (f *Frag) setPrimers(last, next *Frag, seq string, conf *config.Config) (err error) {
-	pHash := primerHash(last, f, next)
-	if oldPrimers, contained := madePrimers[pHash]; contained {
+//
+// Primers from the inventory are used if they bind where the PCR needs to start or end.
+// If both do, primer3 isn't run. If one does, primer3 picks the other.
+func (f *Frag) setPrimers(ctx context.Context, last, next *Frag, seq string, conf *config.Config) (err error) {
+	pHash := primerHash(last, f, next, seq)
+	if oldPrimers, oldErr, contained := loadPrimers(pHash, f, conf); contained {
+		if oldErr != nil {
+			return oldErr
+		}
+
 		f.Primers = oldPrimers
 		mutatePrimers(f, seq, 0, 0) // set PCRSeq
 		return nil
 	}
 
-	if oldErr, contained := primerErrs[pHash]; contained {
-		return oldErr
-	}
+	// store the primers, or the reason they couldn't be made, for later builds. Failures
+	// to run primer3 or check off-targets aren't kept between designs, and nothing is
+	// kept if the design was cancelled
+	persist := true
+	defer func() {
+		if ctx.Err() == nil {
+			storePrimers(pHash, f, f.Primers, err, persist, conf)
+		}
+	}()
 
 	psExec := newPrimer3(last, f, next, seq, conf)
 
@@ -471,18 +596,20 @@ func (f *Frag) setPrimers(last, next *Frag, seq string, conf *config.Config) (er
 		conf.PCRBufferLength,
 	)
 	if err != nil {
-		primerErrs[pHash] = err
 		return
 	}
 
-	if err = psExec.run(); err != nil {
-		primerErrs[pHash] = err
-		return
-	}
+	if psExec.left != nil && psExec.right != nil {
+		f.Primers = []Primer{*psExec.left, *psExec.right}
+	} else {
+		if err = psExec.run(ctx); err != nil {
+			persist = false
+			return
+		}
 
-	if err = psExec.parse(seq); err != nil {
-		primerErrs[pHash] = err
-		return
+		if err = psExec.parse(seq); err != nil {
+			return
+		}
 	}
 
 	// update Frag's range, and add additional bp to the left and right primer if it wasn't included in the primer3 output
@@ -497,7 +624,6 @@ func (f *Frag) setPrimers(last, next *Frag, seq string, conf *config.Config) (er
 			conf.PCRMinLength,
 		)
 		f.Primers = nil
-		primerErrs[pHash] = err
 		return
 	}
 
@@ -511,53 +637,54 @@ func (f *Frag) setPrimers(last, next *Frag, seq string, conf *config.Config) (er
 			f.Primers[1],
 		)
 		f.Primers = nil
-		primerErrs[pHash] = err
 		return
 	}
 
 	// 2. check for whether either of the primers have an off-target/mismatch
-	var mismatchExists bool
-	var mm match
-
-	if f.fullSeq != "" {
-		// we have the full sequence (it was included in the forward design)
-		mismatchResult := seqMismatch(f.Primers, f.ID, f.fullSeq, conf)
-		mismatchExists = mismatchResult.wasMismatch
-		mm = mismatchResult.m
-		err = mismatchResult.err
-	} else if f.db != "" {
-		// otherwise, query the fragment from the DB (try to find it) and then check for mismatches
-		mismatchResult := parentMismatch(f.Primers, f.ID, f.db, conf)
-		mismatchExists = mismatchResult.wasMismatch
-		mm = mismatchResult.m
-		err = mismatchResult.err
-	}
-
-	if err != nil {
+	if err = f.offtargets(ctx, f.Primers, conf); err != nil {
+		persist = false
 		f.Primers = nil
-		primerErrs[pHash] = err
-		return err
-	}
-	if mismatchExists {
-		err = fmt.Errorf(
-			"found a mismatching sequence %s for primers: %s, %s",
-			mm.seq,
-			f.Primers[0].Seq,
-			f.Primers[1].Seq,
-		)
-		f.Primers = nil
-		primerErrs[pHash] = err
 		return
 	}
 
 	f.fragType = pcr
 
-	os.Remove(psExec.in.Name()) // delete the temporary input and output files
-	os.Remove(psExec.out.Name())
+	return
+}
 
-	madePrimers[pHash] = f.Primers
+// offtargets checks the primers for off-target binding sites in the Frag's parent
+// sequence, either its full sequence if it's known or the parent in the Frag's db
+func (f *Frag) offtargets(ctx context.Context, primers []Primer, conf *config.Config) error {
+	key := offtargetKey(primers, f, conf)
+	result, checked := loadMismatch(key, conf)
+	if !checked {
+		if f.fullSeq != "" {
+			// we have the full sequence (it was included in the forward design)
+			result = seqMismatch(ctx, primers, f.ID, f.fullSeq, conf)
+		} else if f.db != "" {
+			// otherwise, query the fragment from the DB (try to find it) and then check for mismatches
+			result = parentMismatch(ctx, primers, f.ID, f.db, conf)
+		}
+		if err := ctx.Err(); err != nil {
+			return err // the check was cut short
+		}
+		storeMismatch(key, result, conf)
+	}
 
-	return
+	if result.err != nil {
+		return result.err
+	}
+
+	if result.wasMismatch {
+		return fmt.Errorf(
+			"found a mismatching sequence %s for primers: %s, %s",
+			result.m.seq,
+			primers[0].Seq,
+			primers[1].Seq,
+		)
+	}
+
+	return nil
 }
 
 // mutatePrimers adds additional bp to the sides of a Frag
@@ -573,8 +700,8 @@ func mutatePrimers(f *Frag, seq string, addLeft, addRight int) *Frag {
 
 	// change the Frag's start and end index to match those of the start and end index
 	// of the primers, since the range may have shifted to get better primers
-	f.start = f.Primers[0].Range.start
-	f.end = f.Primers[1].Range.end
+	f.start = f.Primers[0].Range.start + f.Primers[0].added
+	f.end = f.Primers[1].Range.end - f.Primers[1].added
 
 	// update fragment sequence
 	f.Seq = seq[f.start+sl : f.end+sl+1]
@@ -584,6 +711,7 @@ func mutatePrimers(f *Frag, seq string, addLeft, addRight int) *Frag {
 		oldStart := f.Primers[0].Range.start + sl
 		f.Primers[0].Seq = seq[oldStart-addLeft:oldStart] + f.Primers[0].Seq
 		f.Primers[0].Range.start -= addLeft
+		f.Primers[0].added += addLeft
 	}
 
 	// add bp to the right/REV primer to match the fragment to the right
@@ -591,6 +719,7 @@ func mutatePrimers(f *Frag, seq string, addLeft, addRight int) *Frag {
 		oldEnd := f.Primers[1].Range.end + sl
 		f.Primers[1].Seq = reverseComplement(seq[oldEnd+1:oldEnd+addRight+1]) + f.Primers[1].Seq
 		f.Primers[1].Range.end += addRight
+		f.Primers[1].added += addRight
 	}
 
 	// update fragment sequence
@@ -613,7 +742,14 @@ func fragsCost(frags []*Frag) (cost float64) {
 	return
 }
 
-// primerHash returns a unique hash for a PCR run
-func primerHash(last, f, next *Frag) string {
-	return fmt.Sprintf("%s%d%d%d%d", f.uniqueID, last.end, f.start, f.end, next.start)
+// primerHash returns a unique hash for a PCR run on a target sequence
+func primerHash(last, f, next *Frag, seq string) string {
+	return fmt.Sprintf("%s%d%d%d%d%s", f.uniqueID, last.end, f.start, f.end, next.start, seqHash(seq))
+}
+
+// seqHash returns a short hash of a sequence, for keys that are specific to a target
+func seqHash(seq string) string {
+	h := fnv.New64a()
+	h.Write([]byte(seq))
+	return fmt.Sprintf("%x", h.Sum64())
 }
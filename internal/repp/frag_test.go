@@ -1,6 +1,7 @@
 package repp
 
 import (
+	"context"
 	"math"
 	"reflect"
 	"strings"
@@ -149,6 +150,16 @@ func Test_Frag_synthDist(t *testing.T) {
 			}
 		})
 	}
+
+	// stretches are split by the longest fragment the vendors make, not the synthetic max length
+	n := &Frag{start: 0, end: 40, conf: c}
+	other := &Frag{start: 160, end: 200, conf: c}
+	for maxLength, want := range map[int]int{50: 3, 500: 1} {
+		c.Vendors = []config.Vendor{config.Vendor{Name: "vendor", MaxLength: maxLength}}
+		if got := n.synthDist(other); got != want {
+			t.Errorf("Frag.synthDist() with a %dbp vendor max = %d, want %d", maxLength, got, want)
+		}
+	}
 }
 
 func Test_Frag_costTo(t *testing.T) {
@@ -213,7 +224,7 @@ func Test_Frag_costTo(t *testing.T) {
 					conf:  c,
 				},
 			},
-			3.5,
+			6.25, // extended to the synthetic min length
 		},
 		{
 			"cost to self should just be for PCR",
@@ -237,11 +248,22 @@ func Test_Frag_costTo(t *testing.T) {
 				assemblies: tt.fields.assemblies,
 				conf:       c,
 			}
-			if gotCost := n.costTo(tt.args.other); math.Abs(gotCost-tt.wantCost) > 0.1 {
+			if gotCost := n.costTo(tt.args.other, nil); math.Abs(gotCost-tt.wantCost) > 0.1 {
 				t.Errorf("Frag.costTo() = %v, want %v", gotCost, tt.wantCost)
 			}
 		})
 	}
+
+	// Golden Gate primers are flanked by the BsaI site and synthetic fragments end in
+	// its flank and an overhang, rather than homology
+	gg, _ := newTypeIIS(newEnzyme("BsaI", "GGTCTCN^NNNN_N"))
+	n2 := &Frag{start: 0, end: 50, conf: c}
+	if got := n2.costTo(&Frag{start: 20, end: 100, conf: c}, gg); math.Abs(got-2.28) > 0.01 {
+		t.Errorf("Frag.costTo() Golden Gate PCR = %v, want 2.28", got)
+	}
+	if got := n2.costTo(&Frag{start: 200, end: 260, conf: c}, gg); math.Abs(got-9.2) > 0.01 {
+		t.Errorf("Frag.costTo() Golden Gate synthesis = %v, want 9.2", got)
+	}
 }
 
 func Test_Frag_reach(t *testing.T) {
@@ -682,7 +704,7 @@ func Test_setPrimers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.n.setPrimers(tt.args.last, tt.args.next, tt.args.Seq, c)
+			err := tt.n.setPrimers(context.Background(), tt.args.last, tt.args.next, tt.args.Seq, c)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("setPrimers() error = %v, wantErr %v", err, tt.wantErr)
 				return
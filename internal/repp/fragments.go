@@ -1,41 +1,43 @@
 package repp
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/jjtimmons/repp/config"
-	"github.com/spf13/cobra"
 )
 
-// FragmentFindCmd logs the building fragment with the name passed.
-func FragmentFindCmd(cmd *cobra.Command, args []string) {
-	if len(args) < 1 {
-		cmd.Help()
-		stderr.Fatalln("\nno fragment name passed.")
+// FindFragment returns the fragment with the name passed from the dbs. The db it
+// was found in is also returned.
+func FindFragment(ctx context.Context, name string, flags *Flags) (*Frag, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("no fragment name passed")
 	}
-	name := args[0]
 
-	flags, _ := parseCmdFlags(cmd, args, false)
-	frag, err := queryDatabases(name, flags.dbs)
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	frag, err := queryDatabases(ctx, name, flags.dbs, flags.conf)
 	if err != nil {
-		stderr.Fatalln(err)
+		return nil, "", err
 	}
 	if frag.fragType == circular {
 		frag.Seq = frag.Seq[:len(frag.Seq)/2]
 	}
+	frag.URL = parseURL(name, frag.db, flags.conf)
 
-	fmt.Printf("%s\t%s\n%s\n", name, frag.db, frag.Seq)
+	return frag, frag.db, nil
 }
 
-// FragmentsCmd accepts a cobra commands and assembles a list of building fragments in order
-func FragmentsCmd(cmd *cobra.Command, args []string) {
-	flags, conf := parseCmdFlags(cmd, args, true)
-
+// Fragments assembles a list of building fragments in order.
+// The output is written to the out path of the flags, if set.
+func Fragments(ctx context.Context, flags *Flags, conf *config.Config) (*Output, error) {
 	// read in the constituent fragments
-	frags, err := read(flags.in, false)
+	frags, err := flags.read(false)
 	if err != nil {
-		stderr.Fatalln(err)
+		return nil, err
 	}
 
 	// add in the backbone if it was provided
@@ -48,27 +50,45 @@ func FragmentsCmd(cmd *cobra.Command, args []string) {
 		f.conf = conf
 	}
 
-	target, solution := fragments(frags, conf)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	target, solution, err := fragments(ctx, frags, conf)
+	if err != nil {
+		return nil, err
+	}
 
-	// write the single list of fragments as a possible solution to the output file
-	writeJSON(
-		flags.out,
+	// the single list of fragments is the only possible solution
+	out, err := newOutput(
 		flags.in,
 		target.Seq,
 		[][]*Frag{solution},
 		len(target.Seq),
 		0,
 		flags.backboneMeta,
+		nil,
 		conf,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.out != "" {
+		if err := writeOutput(flags, out); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
 }
 
 // fragments pieces together a list of fragments into a single plasmid
 // with the fragments in the order and orientation specified
-func fragments(frags []*Frag, conf *config.Config) (target *Frag, solution []*Frag) {
+func fragments(ctx context.Context, frags []*Frag, conf *config.Config) (target *Frag, solution []*Frag, err error) {
 	// piece together the adjacent fragments
 	if len(frags) < 1 {
-		stderr.Fatalln("failed: no fragments to assemble")
+		return nil, nil, fmt.Errorf("failed: no fragments to assemble")
 	}
 
 	// anneal the fragments together, shift their junctions and create the plasmid sequence
@@ -82,12 +102,11 @@ func fragments(frags []*Frag, conf *config.Config) (target *Frag, solution []*Fr
 
 	// create an assembly out of the frags (to fill/convert to fragments with primers)
 	a := assembly{frags: frags}
-	solution, err := a.fill(target.Seq, conf)
-	if err != nil {
-		stderr.Fatalln(err)
+	if solution, err = a.fill(ctx, target.Seq, conf); err != nil {
+		return nil, nil, err
 	}
 
-	return target, solution
+	return target, solution, nil
 }
 
 // annealFragments shifts the start and end of junctions that overlap one another
@@ -0,0 +1,527 @@
+package repp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// genbankRecord is a single record, from its LOCUS line to its "//", in a Genbank file.
+type genbankRecord struct {
+	// name of the record on its LOCUS line
+	name string
+
+	// circular is whether the LOCUS line's topology is circular
+	circular bool
+
+	// definition is the record's DEFINITION line(s)
+	definition string
+
+	// features in the record's feature table, in order
+	features []genbankFeature
+
+	// seq is the record's sequence from its ORIGIN, upper-case
+	seq string
+}
+
+// genbankFeature is a single feature in a record's feature table.
+type genbankFeature struct {
+	// key of the feature, eg "CDS" or "promoter"
+	key string
+
+	// location as it was written, eg "complement(join(1..10,20..30))"
+	location string
+
+	// spans of the feature, in order of transcription
+	spans []span
+
+	// qualifiers of the feature, in order
+	qualifiers []qualifier
+}
+
+// span is a contiguous range of a feature's location. It's 0-indexed and inclusive.
+// On circular records, a span with a start after its end crosses the origin.
+type span struct {
+	start   int
+	end     int
+	forward bool
+}
+
+// qualifier is a single "/name=value" of a feature. Qualifiers without
+// a value, like "/pseudo", have an empty value.
+type qualifier struct {
+	name  string
+	value string
+}
+
+// qualifier returns the value of the feature's first qualifier with the name.
+func (f genbankFeature) qualifier(name string) (string, bool) {
+	for _, q := range f.qualifiers {
+		if q.name == name {
+			return q.value, true
+		}
+	}
+	return "", false
+}
+
+// name returns a name for the feature from its qualifiers, falling back to its key.
+func (f genbankFeature) name() string {
+	for _, q := range []string{"label", "gene", "product", "locus_tag", "standard_name", "note"} {
+		if value, ok := f.qualifier(q); ok && value != "" {
+			return value
+		}
+	}
+	return f.key
+}
+
+// forward returns whether the feature is on the top strand.
+func (f genbankFeature) forward() bool {
+	return len(f.spans) < 1 || f.spans[0].forward
+}
+
+// sequence returns the feature's sequence from the record's, on the feature's strand.
+func (f genbankFeature) sequence(seq string) string {
+	var featSeq strings.Builder
+	for _, s := range f.spans {
+		if s.start < 0 || s.end >= len(seq) {
+			continue
+		}
+
+		spanSeq := ""
+		if s.start <= s.end {
+			spanSeq = seq[s.start : s.end+1]
+		} else {
+			spanSeq = seq[s.start:] + seq[:s.end+1] // crosses the origin
+		}
+
+		if !s.forward {
+			spanSeq = reverseComplement(spanSeq)
+		}
+		featSeq.WriteString(spanSeq)
+	}
+	return featSeq.String()
+}
+
+// parseGenbank parses every record in the contents of a Genbank file.
+func parseGenbank(contents string) (records []genbankRecord, err error) {
+	contents = strings.TrimPrefix(contents, "\ufeff") // byte order mark
+	contents = strings.Replace(contents, "\r\n", "\n", -1)
+
+	var record *genbankRecord
+	var feature *genbankFeature
+	var seq strings.Builder
+	section := "" // LOCUS, FEATURES or ORIGIN
+	keyword := "" // the last header keyword, for continued lines
+	open := false // whether the last qualifier's quoted value is unterminated
+
+	// finish adds the current record, if there is one
+	finish := func() error {
+		if record == nil {
+			return nil
+		}
+
+		// features with locations that can't be parsed, eg in other records, are skipped
+		record.seq = seq.String()
+		features := record.features[:0]
+		for _, f := range record.features {
+			spans, err := parseLocation(f.location, len(record.seq), record.circular)
+			if err != nil {
+				logger.Warn("skipping a feature with an unsupported location", "feature", f.key, "record", record.name, "error", err)
+				continue
+			}
+			f.spans = spans
+			features = append(features, f)
+		}
+		record.features = features
+
+		records = append(records, *record)
+		record, feature = nil, nil
+		seq.Reset()
+		return nil
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "LOCUS") {
+			if err = finish(); err != nil {
+				return nil, err
+			}
+
+			fields := strings.Fields(line[len("LOCUS"):])
+			if len(fields) < 1 {
+				return nil, fmt.Errorf("failed to parse locus name from: %s", line)
+			}
+
+			record = &genbankRecord{name: fields[0]}
+			for _, field := range fields[1:] {
+				if strings.ToLower(field) == "circular" {
+					record.circular = true
+				}
+			}
+			section, keyword = "LOCUS", "LOCUS"
+			continue
+		}
+
+		if record == nil {
+			continue // text before the first LOCUS
+		}
+
+		if line == "//" {
+			if err = finish(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// a keyword at the start of the line starts a new section of the record
+		if line[0] != ' ' {
+			keyword = strings.Fields(line)[0]
+			switch keyword {
+			case "FEATURES", "ORIGIN":
+				section = keyword
+			case "DEFINITION":
+				section = "LOCUS"
+				record.definition = strings.TrimSpace(line[len(keyword):])
+			default:
+				section = "LOCUS"
+			}
+			continue
+		}
+
+		switch section {
+		case "LOCUS":
+			if keyword == "DEFINITION" {
+				record.definition += " " + strings.TrimSpace(line)
+			}
+		case "FEATURES":
+			content := strings.TrimSpace(line)
+
+			// keys are indented by 5 spaces, qualifiers by 21
+			if indent := len(line) - len(strings.TrimLeft(line, " ")); indent < 21 {
+				fields := strings.Fields(content)
+				record.features = append(record.features, genbankFeature{
+					key:      fields[0],
+					location: strings.TrimSpace(content[len(fields[0]):]),
+				})
+				feature = &record.features[len(record.features)-1]
+				open = false
+				continue
+			}
+
+			if feature == nil {
+				continue
+			}
+
+			switch {
+			case open:
+				// a quoted value that continues from the last line
+				q := &feature.qualifiers[len(feature.qualifiers)-1]
+				if q.name == "translation" {
+					q.value += content
+				} else {
+					q.value += " " + content
+				}
+				if strings.Count(content, `"`)%2 == 1 {
+					open = false
+					q.value = unquote(q.value)
+				}
+			case strings.HasPrefix(content, "/"):
+				q := qualifier{name: content[1:]}
+				if eq := strings.Index(content, "="); eq > 0 {
+					q.name, q.value = content[1:eq], content[eq+1:]
+				}
+				if strings.HasPrefix(q.value, `"`) && strings.Count(q.value, `"`)%2 == 1 {
+					open = true
+				} else {
+					q.value = unquote(q.value)
+				}
+				feature.qualifiers = append(feature.qualifiers, q)
+			case len(feature.qualifiers) < 1:
+				// a location that continues from the last line
+				feature.location += content
+			}
+		case "ORIGIN":
+			// only bases are kept, as they are in FASTA files
+			for _, c := range strings.ToUpper(line) {
+				if c == 'A' || c == 'T' || c == 'G' || c == 'C' {
+					seq.WriteRune(c)
+				}
+			}
+		}
+	}
+
+	// a record without a closing "//"
+	if record != nil && seq.Len() > 0 {
+		if err = finish(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(records) < 1 {
+		return nil, fmt.Errorf("failed to find a LOCUS record")
+	}
+
+	return records, nil
+}
+
+// unquote removes the quotes around a qualifier's value and unescapes doubled quotes.
+func unquote(value string) string {
+	if len(value) > 1 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+	return strings.Replace(value, `""`, `"`, -1)
+}
+
+// parseLocation parses a feature's location to its spans. Supported are ranges ("1..10"),
+// single bases ("5"), sites between bases ("5^6"), partial ends ("<1..>10") and
+// "complement", "join" and "order" of those. On a circular record, a range
+// whose start is after its end crosses the origin.
+func parseLocation(location string, length int, circular bool) ([]span, error) {
+	location = strings.Replace(location, " ", "", -1)
+
+	if inner, ok := operator(location, "complement"); ok {
+		spans, err := parseLocation(inner, length, circular)
+		if err != nil {
+			return nil, err
+		}
+
+		// the complement is read in the other direction
+		complemented := make([]span, len(spans))
+		for i, s := range spans {
+			s.forward = !s.forward
+			complemented[len(spans)-1-i] = s
+		}
+		return complemented, nil
+	}
+
+	for _, op := range []string{"join", "order"} {
+		if inner, ok := operator(location, op); ok {
+			var spans []span
+			for _, part := range splitTopLevel(inner) {
+				partSpans, err := parseLocation(part, length, circular)
+				if err != nil {
+					return nil, err
+				}
+				spans = append(spans, partSpans...)
+			}
+			return spans, nil
+		}
+	}
+
+	if strings.Contains(location, ":") {
+		return nil, fmt.Errorf("unsupported reference to another record: %s", location)
+	}
+
+	location = strings.NewReplacer("<", "", ">", "").Replace(location)
+
+	bounds := []string{location, location}
+	for _, sep := range []string{"..", "^", "."} {
+		if strings.Contains(location, sep) {
+			bounds = strings.SplitN(location, sep, 2)
+			break
+		}
+	}
+
+	start, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid location: %s", location)
+	}
+	end, err := strconv.Atoi(bounds[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid location: %s", location)
+	}
+	if strings.Contains(location, "^") {
+		end = start // a site between two bases
+	}
+
+	if start < 1 || end < 1 || (length > 0 && (start > length || end > length)) {
+		return nil, fmt.Errorf("location %s is outside the sequence", location)
+	}
+	if start > end && !circular {
+		return nil, fmt.Errorf("location %s crosses the origin of a linear sequence", location)
+	}
+
+	return []span{span{start: start - 1, end: end - 1, forward: true}}, nil
+}
+
+// operator returns what's inside a location's operator, eg "1..10" in "complement(1..10)".
+func operator(location, op string) (string, bool) {
+	if strings.HasPrefix(location, op+"(") && strings.HasSuffix(location, ")") {
+		return location[len(op)+1 : len(location)-1], true
+	}
+	return "", false
+}
+
+// splitTopLevel splits a list of locations on the commas outside parentheses.
+func splitTopLevel(locations string) (parts []string) {
+	depth, last := 0, 0
+	for i, c := range locations {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, locations[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, locations[last:])
+}
+
+// unquotedQualifiers are qualifiers whose values are written without quotes
+var unquotedQualifiers = map[string]bool{
+	"codon_start":  true,
+	"transl_table": true,
+	"number":       true,
+	"direction":    true,
+}
+
+// String formats the record as it's written in a Genbank file, ending with "//".
+func (r genbankRecord) String() string {
+	var gb strings.Builder
+
+	// header
+	name := strings.Join(strings.Fields(r.name), "_")
+	if name == "" {
+		name = "plasmid"
+	}
+	topology := "linear"
+	if r.circular {
+		topology = "circular"
+	}
+	date := strings.ToUpper(time.Now().Local().Format("02-Jan-2006"))
+	gb.WriteString(fmt.Sprintf("LOCUS       %-16s %11d bp    DNA     %-8s SYN %s\n", name, len(r.seq), topology, date))
+
+	definition := r.definition
+	if definition == "" {
+		definition = "."
+	}
+	gb.WriteString(wrapGenbank("DEFINITION  ", strings.Repeat(" ", 12), definition, " "))
+	gb.WriteString("ACCESSION   .\nVERSION     .\nKEYWORDS    .\n")
+	gb.WriteString("SOURCE      synthetic DNA construct\n  ORGANISM  synthetic DNA construct\n")
+
+	// features
+	indent := strings.Repeat(" ", 21)
+	gb.WriteString("FEATURES             Location/Qualifiers\n")
+	for _, f := range r.features {
+		location := f.location
+		if len(f.spans) > 0 {
+			location = formatLocation(f.spans, len(r.seq))
+		}
+		gb.WriteString(wrapGenbank(fmt.Sprintf("     %-16s", f.key), indent, location, ","))
+
+		for _, q := range f.qualifiers {
+			qualifier := "/" + q.name
+			if q.value != "" && unquotedQualifiers[q.name] {
+				qualifier += "=" + q.value
+			} else if q.value != "" {
+				qualifier += `="` + strings.Replace(q.value, `"`, `""`, -1) + `"`
+			}
+			gb.WriteString(wrapGenbank(indent, indent, qualifier, " "))
+		}
+	}
+
+	// sequence
+	gb.WriteString("ORIGIN\n")
+	seq := strings.ToLower(r.seq)
+	for i := 0; i < len(seq); i += 60 {
+		gb.WriteString(fmt.Sprintf("%9d", i+1))
+		for s := i; s < i+60 && s < len(seq); s += 10 {
+			e := s + 10
+			if e > len(seq) {
+				e = len(seq)
+			}
+			gb.WriteString(" " + seq[s:e])
+		}
+		gb.WriteString("\n")
+	}
+	gb.WriteString("//\n")
+
+	return gb.String()
+}
+
+// wrapGenbank writes text after a prefix and wraps it at 79 characters, preferring
+// to break after the separator. Continued lines start with the indent.
+func wrapGenbank(prefix, indent, text, sep string) string {
+	const width = 79
+
+	var wrapped strings.Builder
+	line := prefix
+	for len(line)+len(text) > width {
+		room := width - len(line)
+		cut := strings.LastIndex(text[:room], sep)
+		if sep == "," {
+			cut++ // keep the comma on the line
+		}
+		if cut <= 0 {
+			cut = room
+		}
+
+		wrapped.WriteString(line + strings.TrimRight(text[:cut], " ") + "\n")
+		text = strings.TrimLeft(text[cut:], " ")
+		line = indent
+	}
+	wrapped.WriteString(line + text + "\n")
+
+	return wrapped.String()
+}
+
+// formatLocation formats spans as a feature's location on a sequence of the length.
+// It's the reverse of parseLocation. Spans that cross the origin are joined ranges.
+func formatLocation(spans []span, length int) string {
+	// ranges returns the ranges of a span on the top strand, 1-indexed
+	ranges := func(s span) []string {
+		format := func(start, end int) string {
+			if start == end {
+				return strconv.Itoa(start + 1)
+			}
+			return fmt.Sprintf("%d..%d", start+1, end+1)
+		}
+
+		if s.start <= s.end {
+			return []string{format(s.start, s.end)}
+		}
+		return []string{format(s.start, length-1), format(0, s.end)}
+	}
+
+	join := func(parts []string) string {
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return "join(" + strings.Join(parts, ",") + ")"
+	}
+
+	allReverse := true
+	for _, s := range spans {
+		allReverse = allReverse && !s.forward
+	}
+
+	var parts []string
+	if allReverse {
+		// the complement of the ranges in the order of the top strand
+		for i := len(spans) - 1; i >= 0; i-- {
+			parts = append(parts, ranges(spans[i])...)
+		}
+		return "complement(" + join(parts) + ")"
+	}
+
+	for _, s := range spans {
+		spanRanges := ranges(s)
+		if s.forward {
+			parts = append(parts, spanRanges...)
+			continue
+		}
+		for i := len(spanRanges) - 1; i >= 0; i-- {
+			parts = append(parts, "complement("+spanRanges[i]+")")
+		}
+	}
+	return join(parts)
+}
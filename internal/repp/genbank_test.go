@@ -0,0 +1,321 @@
+package repp
+
+import (
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGenbank = `LOCUS       first                     20 bp    DNA     circular SYN 01-JAN-2020
+DEFINITION  a circular plasmid with features
+            across two lines.
+FEATURES             Location/Qualifiers
+     source          1..20
+                     /mol_type="other DNA"
+     promoter        complement(3..6)
+                     /label=prom
+     CDS             join(1..4,
+                     9..12)
+                     /gene="gene"
+                     /note="a note that ""quotes"" and continues
+                     onto the next line"
+                     /pseudo
+     misc_feature    18..3
+                     /note="crosses the origin"
+ORIGIN
+        1 atgcaaaacc ccggggtttt
+//
+LOCUS       second                    8 bp    DNA     linear   SYN 01-JAN-2020
+FEATURES             Location/Qualifiers
+     misc_feature    <2..>5
+ORIGIN
+        1 aaaatttt
+//
+`
+
+func Test_parseGenbank(t *testing.T) {
+	records, err := parseGenbank(testGenbank)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("parseGenbank() parsed %d records, want 2", len(records))
+	}
+
+	first, second := records[0], records[1]
+	if first.name != "first" || !first.circular || first.seq != "ATGCAAAACCCCGGGGTTTT" {
+		t.Errorf("parseGenbank() first record = %+v", first)
+	}
+	if first.definition != "a circular plasmid with features across two lines." {
+		t.Errorf("parseGenbank() definition = %q", first.definition)
+	}
+	if second.name != "second" || second.circular || second.seq != "AAAATTTT" {
+		t.Errorf("parseGenbank() second record = %+v", second)
+	}
+
+	if len(first.features) != 4 {
+		t.Fatalf("parseGenbank() parsed %d features, want 4", len(first.features))
+	}
+
+	cds := first.features[2]
+	if cds.location != "join(1..4,9..12)" {
+		t.Errorf("parseGenbank() location = %s", cds.location)
+	}
+	wantQualifiers := []qualifier{
+		qualifier{"gene", "gene"},
+		qualifier{"note", `a note that "quotes" and continues onto the next line`},
+		qualifier{"pseudo", ""},
+	}
+	if !reflect.DeepEqual(cds.qualifiers, wantQualifiers) {
+		t.Errorf("parseGenbank() qualifiers = %+v, want %+v", cds.qualifiers, wantQualifiers)
+	}
+
+	wantFeatures := []struct {
+		name    string
+		forward bool
+		seq     string
+	}{
+		{"source", true, "ATGCAAAACCCCGGGGTTTT"},
+		{"prom", false, "TTGC"},
+		{"gene", true, "ATGCCCCC"},
+		{"crosses the origin", true, "TTTATG"},
+	}
+	for i, want := range wantFeatures {
+		f := first.features[i]
+		if f.name() != want.name || f.forward() != want.forward || f.sequence(first.seq) != want.seq {
+			t.Errorf("feature %d = %s, %t, %s, want %+v", i, f.name(), f.forward(), f.sequence(first.seq), want)
+		}
+	}
+
+	if partial := second.features[0]; partial.sequence(second.seq) != "AAAT" {
+		t.Errorf("partial feature sequence = %s, want AAAT", partial.sequence(second.seq))
+	}
+}
+
+func Test_parseGenbank_skipped(t *testing.T) {
+	records, err := parseGenbank(`LOCUS       third                     8 bp    DNA     linear   SYN 01-JAN-2020
+FEATURES             Location/Qualifiers
+     misc_feature    acc:1..10
+     misc_feature    2..5
+ORIGIN
+        1 aaaannnntt tt-1
+//
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bases are kept as they are in FASTA files and the feature in another record is skipped
+	if third := records[0]; third.seq != "AAAATTTT" || len(third.features) != 1 || third.features[0].location != "2..5" {
+		t.Errorf("parseGenbank() record = %+v, want AAAATTTT with the feature at 2..5", third)
+	}
+}
+
+func Test_parseLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		circular bool
+		want     []span
+		wantErr  bool
+	}{
+		{
+			"range",
+			"5..10",
+			false,
+			[]span{span{4, 9, true}},
+			false,
+		},
+		{
+			"single base",
+			"7",
+			false,
+			[]span{span{6, 6, true}},
+			false,
+		},
+		{
+			"site between bases",
+			"7^8",
+			false,
+			[]span{span{6, 6, true}},
+			false,
+		},
+		{
+			"complement of a join",
+			"complement(join(1..4,9..12))",
+			false,
+			[]span{span{8, 11, false}, span{0, 3, false}},
+			false,
+		},
+		{
+			"join of complements",
+			"join(complement(9..12),complement(1..4))",
+			false,
+			[]span{span{8, 11, false}, span{0, 3, false}},
+			false,
+		},
+		{
+			"crosses the origin",
+			"18..3",
+			true,
+			[]span{span{17, 2, true}},
+			false,
+		},
+		{
+			"crosses the origin of a linear sequence",
+			"18..3",
+			false,
+			nil,
+			true,
+		},
+		{
+			"outside the sequence",
+			"15..25",
+			false,
+			nil,
+			true,
+		},
+		{
+			"another record",
+			"J00194.1:100..202",
+			false,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLocation(tt.location, 20, tt.circular)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readGenbank(t *testing.T) {
+	frags, err := readGenbank("test.gb", testGenbank, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frags) != 2 || frags[0].ID != "first" || frags[0].fragType != circular || frags[1].fragType != linear {
+		t.Errorf("readGenbank() = %+v, want both records", frags)
+	}
+
+	features, err := readGenbank("test.gb", testGenbank, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 4 || features[0].ID != "prom" || features[0].Seq != "TTGC" {
+		t.Errorf("readGenbank() features = %+v, want four without the source", features)
+	}
+
+	// a SnapGene export with multi-line qualifiers and complemented features
+	contents, err := ioutil.ReadFile(path.Join("..", "..", "test", "input", "addgene-plasmid-111577-sequence-216913.gbk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := parseGenbank(string(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !records[0].circular || len(records[0].seq) != 6714 {
+		t.Errorf("parseGenbank() = %d records, want the circular 6714 bp plasmid", len(records))
+	}
+}
+
+func Test_formatLocation(t *testing.T) {
+	tests := []struct {
+		name  string
+		spans []span
+		want  string
+	}{
+		{
+			"range",
+			[]span{span{4, 9, true}},
+			"5..10",
+		},
+		{
+			"single base",
+			[]span{span{6, 6, true}},
+			"7",
+		},
+		{
+			"complement of a join",
+			[]span{span{8, 11, false}, span{0, 3, false}},
+			"complement(join(1..4,9..12))",
+		},
+		{
+			"mixed strands",
+			[]span{span{0, 3, true}, span{8, 11, false}},
+			"join(1..4,complement(9..12))",
+		},
+		{
+			"crosses the origin",
+			[]span{span{17, 2, true}},
+			"join(18..20,1..3)",
+		},
+		{
+			"complement across the origin",
+			[]span{span{17, 2, false}},
+			"complement(join(18..20,1..3))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatLocation(tt.spans, 20)
+			if got != tt.want {
+				t.Errorf("formatLocation() = %s, want %s", got, tt.want)
+			}
+
+			// the sequence of the location is the same after it's parsed
+			spans, err := parseLocation(got, 20, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seq := "ATGCAAAACCCCGGGGTTTT"
+			if before, after := (genbankFeature{spans: tt.spans}).sequence(seq), (genbankFeature{spans: spans}).sequence(seq); before != after {
+				t.Errorf("parseLocation(formatLocation()) sequence = %s, want %s", after, before)
+			}
+		})
+	}
+}
+
+func Test_genbankRecord_String(t *testing.T) {
+	note := strings.Repeat("a long note ", 10)
+	record := genbankRecord{
+		name:     "a plasmid",
+		circular: true,
+		seq:      "ATGCAAAACCCCGGGGTTTT",
+		features: []genbankFeature{
+			genbankFeature{
+				key:        "CDS",
+				spans:      []span{span{0, 3, true}, span{8, 11, true}},
+				qualifiers: []qualifier{qualifier{"note", note}, qualifier{"codon_start", "1"}, qualifier{"pseudo", ""}},
+			},
+		},
+	}
+
+	gb := record.String()
+	for _, line := range strings.Split(gb, "\n") {
+		if len(line) > 80 {
+			t.Errorf("line is longer than 80 characters: %s", line)
+		}
+	}
+
+	records, err := parseGenbank(gb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []qualifier{qualifier{"note", note}, qualifier{"codon_start", "1"}, qualifier{"pseudo", ""}}
+	if got := records[0].features[0]; got.location != "join(1..4,9..12)" || !reflect.DeepEqual(got.qualifiers, want) {
+		t.Errorf("genbankRecord.String() wrote %+v", got)
+	}
+}
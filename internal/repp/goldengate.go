@@ -0,0 +1,491 @@
+package repp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+const (
+	// methodGibson is assembly via homologous ends between adjacent fragments
+	methodGibson = "gibson"
+
+	// methodGoldenGate is assembly via Type IIS digestion and ligation of overhangs
+	methodGoldenGate = "goldengate"
+
+	// overhangLength is the length of the overhangs left by digestion with a Type IIS enzyme
+	overhangLength = 4
+
+	// maxOverhangCandidates is the most indexes tried per junction when picking overhangs
+	maxOverhangCandidates = 40
+)
+
+var (
+	// goldenGateEnzymes are tried, in order, before the rest of the enzymes db
+	goldenGateEnzymes = []string{"BsaI", "BsmBI", "BbsI", "Esp3I", "PaqCI"}
+
+	// goldenGatePad is added 5' of recognition sites. Type IIS enzymes
+	// cleave inefficiently when their site is at the very end of a fragment
+	goldenGatePad = "GCATCA"
+
+	// goldenGateFillers are repeated outside the recognition sites of synthetic
+	// fragments that are shorter than the minimum synthesis length
+	goldenGateFillers = []string{"ATCG", "TAGC", "ACTG"}
+)
+
+// goldenGate is a Type IIS enzyme used to assemble fragments via Golden Gate.
+type goldenGate struct {
+	// enzyme that cleaves downstream of its recognition site
+	enzyme enzyme
+
+	// site is the enzyme's recognition sequence without its trailing Ns
+	site string
+
+	// spacer is the number of bp between the recognition site and the overhang
+	spacer int
+
+	// siteRegex matches the recognition site
+	siteRegex *regexp.Regexp
+}
+
+// ggJunction is a window on the target sequence in which an overhang can start.
+type ggJunction struct {
+	// lo is the smallest start index of the overhang
+	lo int
+
+	// hi is the largest start index of the overhang
+	hi int
+
+	// ideal is the start index that leaves the most room for both fragments
+	ideal int
+}
+
+// newGoldenGate picks the Type IIS enzyme to use for a Golden Gate assembly of the target.
+// Preferred enzymes are tried first. The enzyme cannot have a recognition site in the target.
+func newGoldenGate(target string, enzymes map[string]string) (*goldenGate, error) {
+	var others []string
+	for name := range enzymes {
+		preferred := false
+		for _, p := range goldenGateEnzymes {
+			preferred = preferred || p == name
+		}
+		if !preferred {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+
+	target = strings.ToUpper(target)
+	for _, name := range append(goldenGateEnzymes, others...) {
+		recog, exists := enzymes[name]
+		if !exists {
+			continue
+		}
+
+		gg, isTypeIIS := newTypeIIS(newEnzyme(name, recog))
+		if !isTypeIIS {
+			continue
+		}
+
+		if len(gg.site) > len(target) {
+			continue
+		}
+
+		// the target is circular, check for sites across the zero index
+		if gg.sites(target+target[:len(gg.site)-1]) > 0 {
+			continue
+		}
+
+		return gg, nil
+	}
+
+	return nil, fmt.Errorf("failed to find a Type IIS enzyme with a %dbp overhang and no recognition sites in the target", overhangLength)
+}
+
+// newTypeIIS returns a goldenGate for the enzyme if it cleaves outside of its
+// recognition site and leaves a 4bp 5' overhang.
+func newTypeIIS(e enzyme) (*goldenGate, bool) {
+	site := strings.TrimRight(e.recog, "N")
+	if site == "" || e.seqCutIndex < len(site) || e.compCutIndex-e.seqCutIndex != overhangLength {
+		return nil, false
+	}
+
+	// Type IIS recognition sites are asymmetric
+	if reverseComplement(site) == site {
+		return nil, false
+	}
+
+	return &goldenGate{
+		enzyme:    e,
+		site:      site,
+		spacer:    e.seqCutIndex - len(site),
+		siteRegex: regexp.MustCompile(recogRegex(site)),
+	}, true
+}
+
+// sites returns the number of recognition sites in the sequence, on either strand.
+func (gg *goldenGate) sites(seq string) int {
+	seq = strings.ToUpper(seq)
+	return len(gg.siteRegex.FindAllStringIndex(seq, -1)) + len(gg.siteRegex.FindAllStringIndex(reverseComplement(seq), -1))
+}
+
+// exclude removes fragments that have internal recognition sites. They would be
+// cleaved during assembly.
+func (gg *goldenGate) exclude(frags []*Frag) (kept []*Frag) {
+	for _, f := range frags {
+		if gg.sites(f.Seq) == 0 {
+			kept = append(kept, f)
+		}
+	}
+	return
+}
+
+// flank returns the sequence added 5' of each fragment's end: a pad, the recognition
+// site and the spacer between the recognition site and the overhang.
+func (gg *goldenGate) flank() string {
+	concrete := map[rune]byte{
+		'A': 'A', 'C': 'C', 'G': 'G', 'T': 'T',
+		'M': 'A', 'R': 'A', 'W': 'A', 'Y': 'C', 'S': 'C', 'K': 'G',
+		'H': 'A', 'D': 'A', 'V': 'A', 'B': 'C', 'N': 'A', 'X': 'A',
+	}
+
+	var site strings.Builder
+	for _, c := range gg.site {
+		site.WriteByte(concrete[c])
+	}
+
+	return goldenGatePad + site.String() + strings.Repeat("A", gg.spacer)
+}
+
+// pad extends a synthetic fragment to the minimum synthesis length with filler
+// outside its recognition sites. The filler is cut away during digestion.
+func (gg *goldenGate) pad(seq string, minLength int) string {
+	if len(seq) >= minLength {
+		return seq
+	}
+
+	padLength := minLength - len(seq)
+	for _, filler := range goldenGateFillers {
+		fill := strings.Repeat(filler, padLength/len(filler)+1)[:padLength]
+		padded := fill[:padLength/2] + seq + fill[padLength/2:]
+		if gg.sites(padded) == gg.sites(seq) {
+			return padded
+		}
+	}
+
+	return seq
+}
+
+// overhangs picks the start index of an overhang in each junction. Overhangs are unique,
+// including their reverse complements, and non-palindromic so that each fragment only
+// ligates to its neighbors. Returns false if no such set of overhangs exists.
+func (gg *goldenGate) overhangs(seq string, junctions []ggJunction) ([]int, bool) {
+	seq = strings.ToUpper(seq)
+	chosen := make([]int, len(junctions))
+	used := make(map[string]bool)
+
+	var pick func(i int) bool
+	pick = func(i int) bool {
+		if i == len(junctions) {
+			return true
+		}
+
+		for _, start := range junctions[i].candidates() {
+			if start < 0 || start+overhangLength > len(seq) {
+				continue
+			}
+
+			overhang := seq[start : start+overhangLength]
+			rc := reverseComplement(overhang)
+			if overhang == rc || used[overhang] || used[rc] || strings.Trim(overhang, "ATGC") != "" {
+				continue
+			}
+
+			used[overhang] = true
+			chosen[i] = start
+			if pick(i + 1) {
+				return true
+			}
+			delete(used, overhang)
+		}
+
+		return false
+	}
+
+	return chosen, pick(0)
+}
+
+// candidates returns start indexes in the junction's window, ordered by their
+// distance from the ideal start index.
+func (j ggJunction) candidates() (starts []int) {
+	for offset := 0; len(starts) < maxOverhangCandidates; offset++ {
+		left, right := j.ideal-offset, j.ideal+offset
+		if left < j.lo && right > j.hi {
+			break
+		}
+
+		if right <= j.hi && right >= j.lo {
+			starts = append(starts, right)
+		}
+		if offset > 0 && left >= j.lo && left <= j.hi {
+			starts = append(starts, left)
+		}
+	}
+
+	return
+}
+
+// fillGoldenGate traverses the frags in an assembly and prepares them for Golden Gate assembly.
+//
+// Adjacent fragments do not need homology. Instead, a 4bp overhang is picked at each junction
+// and every fragment is flanked by recognition sites for the Type IIS enzyme. PCR fragments get
+// the sites through their primers and synthetic fragments are ordered with them.
+func (a *assembly) fillGoldenGate(ctx context.Context, target string, gg *goldenGate, conf *config.Config) (frags []*Frag, err error) {
+	target = strings.ToUpper(target)
+	tL := len(target)
+
+	// edge case where a single Frag fills the whole target plasmid
+	if a.len() == 1 && len(a.frags[0].Seq) >= tL {
+		f := a.frags[0]
+
+		return []*Frag{
+			&Frag{
+				ID:       f.ID,
+				Seq:      strings.ToUpper(f.Seq)[0:tL],
+				fragType: circular,
+				URL:      f.URL,
+				conf:     conf,
+			},
+		}, nil
+	}
+
+	// break the assembly into parts: the fragments and the synthetic fragments between them
+	var parts []*Frag
+	for i, f := range a.frags {
+		parts = append(parts, f.copy())
+
+		next := a.mockNext(a.frags, i, target, conf)
+		synthCount := f.synthDist(next)
+		if f.fragType == synthetic && next.fragType == synthetic {
+			synthCount = 0 // already tiled by synthetic fragments
+		}
+		if synthCount == 0 {
+			continue
+		}
+
+		gapStart := f.end + 1
+		gapLength := (next.start - gapStart) / synthCount
+		for s := 0; s < synthCount; s++ {
+			start := gapStart + s*gapLength
+			end := start + gapLength - 1
+			if s == synthCount-1 {
+				end = next.start - 1
+			}
+
+			parts = append(parts, &Frag{
+				ID:       fmt.Sprintf("%s-%s-synthesis-%d", f.ID, next.ID, s+1),
+				start:    start,
+				end:      end,
+				fragType: synthetic,
+				conf:     conf,
+			})
+		}
+	}
+
+	// find the window in which each junction's overhang can be placed. PCR fragments can
+	// reach beyond their ends by embedding sequence in their primers
+	reach := func(f *Frag) int {
+		if f.fragType == synthetic {
+			return conf.SynthMaxLength()
+		}
+		return conf.PCRMaxEmbedLength
+	}
+	minLength := func(f *Frag) int {
+		if f.fragType == synthetic {
+			return overhangLength
+		}
+		return conf.PCRMinLength
+	}
+
+	junctions := make([]ggJunction, len(parts))
+	for i, left := range parts {
+		right := parts[(i+1)%len(parts)]
+		rightStart, rightEnd := right.start, right.end
+		if i == len(parts)-1 {
+			rightStart += tL
+			rightEnd += tL
+		}
+
+		lo := rightStart - reach(right)
+		if start := left.start + minLength(left); start > lo {
+			lo = start
+		}
+
+		hi := left.end + reach(left) - overhangLength + 1
+		if end := rightEnd - minLength(right) - overhangLength + 1; end < hi {
+			hi = end
+		}
+
+		if lo > hi {
+			return nil, fmt.Errorf("no room for a junction between %s and %s", left.ID, right.ID)
+		}
+
+		ideal := (left.end+rightStart)/2 - overhangLength/2
+		if ideal < lo {
+			ideal = lo
+		} else if ideal > hi {
+			ideal = hi
+		}
+
+		junctions[i] = ggJunction{lo: lo, hi: hi, ideal: ideal}
+	}
+
+	// offset the junctions so they're all positive on the repeated target sequence
+	repeated := strings.Repeat(target, 4)
+	for i := range junctions {
+		junctions[i].lo += tL
+		junctions[i].hi += tL
+		junctions[i].ideal += tL
+	}
+
+	starts, ok := gg.overhangs(repeated, junctions)
+	if !ok {
+		return nil, fmt.Errorf("failed to find %d unique, non-palindromic %s overhangs", len(junctions), gg.enzyme.name)
+	}
+
+	// each part spans from the overhang of the last junction to the end of its own overhang
+	for i, f := range parts {
+		left := starts[(i+len(starts)-1)%len(starts)] - tL
+		if i == 0 {
+			left -= tL
+		}
+		right := starts[i] - tL + overhangLength - 1
+
+		// shift the part so it starts in the first copy of the target sequence
+		shift := 0
+		for left+shift < 0 {
+			shift += tL
+		}
+		for left+shift >= tL {
+			shift -= tL
+		}
+		left += shift
+		right += shift
+		f.start += shift
+		f.end += shift
+
+		if f.fragType == synthetic {
+			f.Seq = gg.pad(gg.flank()+repeated[left:right+1]+reverseComplement(gg.flank()), conf.SynthMinLength())
+			f.start = left
+			f.end = right
+
+			vendor, err := pickVendor(f.Seq, conf)
+			if err != nil {
+				return nil, &SynthesisError{Fragment: f.ID, Err: err}
+			}
+			f.Vendor = vendor.Name
+		} else if err := f.setGoldenGatePrimers(ctx, left, right, target, gg, conf); err != nil {
+			return nil, &PrimerError{Fragment: f.ID, Err: err}
+		}
+		f.overhang = repeated[right-overhangLength+1 : right+1]
+
+		// confirm the part has only the two recognition sites added at its ends
+		prepared := f.Seq
+		if f.PCRSeq != "" {
+			prepared = f.PCRSeq
+		}
+		if sites := gg.sites(prepared); sites != 2 {
+			return nil, fmt.Errorf("%s has %d %s sites, expected 2", f.ID, sites, gg.enzyme.name)
+		}
+
+		frags = append(frags, f)
+	}
+
+	return frags, nil
+}
+
+// setGoldenGatePrimers creates primers that amplify the Frag between the left and right
+// indexes of the target (inclusive). The annealing portion of each primer is created by
+// primer3, then the primers are extended up to the overhangs and flanked by recognition sites.
+func (f *Frag) setGoldenGatePrimers(ctx context.Context, left, right int, target string, gg *goldenGate, conf *config.Config) (err error) {
+	repeated := strings.Repeat(target, 3)
+
+	pHash := fmt.Sprintf("%s%s%d%d%s", gg.enzyme.name, f.uniqueID, left, right, seqHash(target))
+	primers, err, made := loadPrimers(pHash, f, conf)
+	if err != nil {
+		return err
+	}
+	if !made {
+		// only primers that were made are kept between designs
+		primers, err = f.goldenGatePrimers(ctx, left, right, repeated, gg, conf)
+		if ctx.Err() == nil {
+			storePrimers(pHash, f, primers, err, err == nil, conf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	f.Primers = append([]Primer{}, primers...)
+	f.start = left
+	f.end = right
+	f.Seq = repeated[left : right+1]
+	f.PCRSeq = gg.flank() + f.Seq + reverseComplement(gg.flank())
+	f.fragType = pcr
+
+	return nil
+}
+
+// goldenGatePrimers runs primer3 against the portion of the Frag between left and right,
+// checks the primers, and extends them to the overhangs.
+func (f *Frag) goldenGatePrimers(ctx context.Context, left, right int, repeated string, gg *goldenGate, conf *config.Config) ([]Primer, error) {
+	start, end := f.start, f.end
+	if left > start {
+		start = left
+	}
+	if right < end {
+		end = right
+	}
+
+	if end-start+1 < conf.PCRMinLength {
+		return nil, fmt.Errorf("%s is %dbp between its overhangs, needs to be > %dbp", f.ID, end-start+1, conf.PCRMinLength)
+	}
+
+	p := newPrimer3(f, f, f, repeated[:len(repeated)/3], conf)
+
+	// fix the primers at the ends of the annealing region
+	p.in = p.settings(p.seq, start, end-start+1, 18, 20, 30, 0, 0)
+	if err := p.run(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.parse(p.seq); err != nil {
+		return nil, err
+	}
+
+	fwd, rev := f.Primers[0], f.Primers[1]
+	f.Primers = nil
+
+	if fwd.PairPenalty > conf.PCRMaxPenalty {
+		return nil, fmt.Errorf(
+			"primers have pair primer3 penalty score of %f, should be less than %f",
+			fwd.PairPenalty,
+			conf.PCRMaxPenalty,
+		)
+	}
+
+	if err := f.offtargets(ctx, []Primer{fwd, rev}, conf); err != nil {
+		return nil, err
+	}
+
+	// add the bp between the annealing portion of the primers and the overhangs, then the sites
+	fwd.Seq = gg.flank() + repeated[left:fwd.Range.start] + fwd.Seq
+	fwd.Range.start = left
+	rev.Seq = gg.flank() + reverseComplement(repeated[rev.Range.end+1:right+1]) + rev.Seq
+	rev.Range.end = right
+
+	return []Primer{fwd, rev}, nil
+}
@@ -0,0 +1,162 @@
+package repp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_newTypeIIS(t *testing.T) {
+	tests := []struct {
+		name       string
+		recog      string
+		wantOK     bool
+		wantSite   string
+		wantSpacer int
+	}{
+		{
+			"BsaI",
+			"GGTCTCN^NNNN_N",
+			true,
+			"GGTCTC",
+			1,
+		},
+		{
+			"BbsI",
+			"GAAGACNN^NNNN_N",
+			true,
+			"GAAGAC",
+			2,
+		},
+		{
+			"EcoRI cuts in its recognition site",
+			"G^AATT_C",
+			false,
+			"",
+			0,
+		},
+		{
+			"SapI leaves a 3bp overhang",
+			"GCTCTTCN^NNN_N",
+			false,
+			"",
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gg, ok := newTypeIIS(newEnzyme(tt.name, tt.recog))
+			if ok != tt.wantOK {
+				t.Fatalf("newTypeIIS() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if gg.site != tt.wantSite {
+				t.Errorf("newTypeIIS() site = %v, want %v", gg.site, tt.wantSite)
+			}
+			if gg.spacer != tt.wantSpacer {
+				t.Errorf("newTypeIIS() spacer = %v, want %v", gg.spacer, tt.wantSpacer)
+			}
+		})
+	}
+}
+
+func Test_newGoldenGate(t *testing.T) {
+	enzymes := map[string]string{
+		"EcoRI": "G^AATT_C",
+		"BsaI":  "GGTCTCN^NNNN_N",
+		"BsmBI": "CGTCTCN^NNNN_N",
+	}
+
+	gg, err := newGoldenGate("ATGCATGCGAATTCATGC", enzymes)
+	if err != nil || gg.enzyme.name != "BsaI" {
+		t.Errorf("newGoldenGate() = %v, %v, want BsaI", gg, err)
+	}
+
+	// BsaI site on the bottom strand
+	gg, err = newGoldenGate("ATGCATGCGAGACCATGC", enzymes)
+	if err != nil || gg.enzyme.name != "BsmBI" {
+		t.Errorf("newGoldenGate() = %v, %v, want BsmBI", gg, err)
+	}
+
+	// BsaI site across the zero index and a BsmBI site
+	if _, err = newGoldenGate("TCTCATCGTCTCTTGG", enzymes); err == nil {
+		t.Error("newGoldenGate() expected an error when every enzyme has a site in the target")
+	}
+
+	// a target shorter than the recognition sites
+	if _, err = newGoldenGate("ATGC", enzymes); err == nil {
+		t.Error("newGoldenGate() expected an error for a target shorter than the recognition sites")
+	}
+}
+
+func Test_goldenGate_overhangs(t *testing.T) {
+	gg, _ := newTypeIIS(newEnzyme("BsaI", "GGTCTCN^NNNN_N"))
+
+	// the ideal index of the second junction is the reverse complement of the first's
+	seq := "AAAGCTTTCCCGAAAGCATCC"
+	starts, ok := gg.overhangs(seq, []ggJunction{
+		ggJunction{lo: 0, hi: 4, ideal: 2},
+		ggJunction{lo: 8, hi: 16, ideal: 12},
+	})
+	if !ok {
+		t.Fatal("overhangs() failed to find overhangs")
+	}
+
+	used := make(map[string]bool)
+	for _, start := range starts {
+		overhang := seq[start : start+overhangLength]
+		if overhang == reverseComplement(overhang) {
+			t.Errorf("overhangs() picked palindromic overhang %s", overhang)
+		}
+		if used[overhang] || used[reverseComplement(overhang)] {
+			t.Errorf("overhangs() picked duplicate overhang %s", overhang)
+		}
+		used[overhang] = true
+	}
+
+	// only palindromes are available
+	if _, ok = gg.overhangs("GAATTC", []ggJunction{ggJunction{lo: 1, hi: 1, ideal: 1}}); ok {
+		t.Error("overhangs() should fail if the only overhang is a palindrome")
+	}
+}
+
+func Test_fillGoldenGate_synthetic(t *testing.T) {
+	c := config.New()
+	gg, _ := newTypeIIS(newEnzyme("BsaI", "GGTCTCN^NNNN_N"))
+
+	target := strings.Repeat("ATGCTAGCTAGGCTTACAGCATCGTTCAGCAATGCCTTAGCGG", 10)
+	half := len(target) / 2
+	a := assembly{
+		frags: []*Frag{
+			&Frag{ID: "1", start: 0, end: half + 20, fragType: synthetic, conf: c},
+			&Frag{ID: "2", start: half - 20, end: len(target) + 19, fragType: synthetic, conf: c},
+		},
+	}
+
+	frags, err := a.fillGoldenGate(context.Background(), target, gg, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frags) != 2 {
+		t.Fatalf("fillGoldenGate() returned %d frags, want 2", len(frags))
+	}
+
+	if frags[0].overhang == frags[1].overhang {
+		t.Errorf("fillGoldenGate() overhangs are the same: %s", frags[0].overhang)
+	}
+
+	for _, f := range frags {
+		if sites := gg.sites(f.Seq); sites != 2 {
+			t.Errorf("fillGoldenGate() %s has %d BsaI sites, want 2", f.ID, sites)
+		}
+		if len(f.Seq) < c.SyntheticMinLength {
+			t.Errorf("fillGoldenGate() %s is %dbp, shorter than the minimum synthesis length", f.ID, len(f.Seq))
+		}
+	}
+}
@@ -1,25 +1,18 @@
 package repp
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/jjtimmons/repp/config"
-	"github.com/spf13/cobra"
 )
 
-var (
-	// stderr is for logging to Stderr (without an annoying timestamp)
-	stderr = log.New(os.Stderr, "", 0)
-)
-
-// Flags contains parsed cobra Flags like "in", "out", "dbs", etc that are used by multiple commands.
+// Flags contains parsed input like "in", "out", "dbs", etc that are used by multiple commands.
 type Flags struct {
 	// the name of the file to write the input from
 	in string
@@ -27,6 +20,18 @@ type Flags struct {
 	// the name of the file to write the output to
 	out string
 
+	// format of the output: json, genbank or sbol
+	format string
+
+	// the name of the file to write an explanation of the candidate assemblies to
+	explain string
+
+	// objective that solutions are ranked by, and how many are kept per fragment count
+	objective objective
+
+	// frags are input sequences passed directly, rather than read from the in file
+	frags []*Frag
+
 	// a list of dbs to run BLAST against (their names' on the filesystem)
 	dbs []string
 
@@ -41,9 +46,73 @@ type Flags struct {
 
 	// percentage identity for finding building fragments in BLAST databases
 	identity int
+
+	// method of assembly, gibson or goldengate
+	method string
+
+	// featureDB is the features database. Read from the filesystem when needed if nil
+	featureDB *FeatureDB
+
+	// enzymeDB is the enzymes database. Read from the filesystem when needed if nil
+	enzymeDB *EnzymeDB
+
+	// conf is the configuration with the registry of databases
+	conf *config.Config
 }
 
-// inputParser contains methods for parsing flags from the input &cobra.Command.
+// Params are the parameters of a design as they're passed by a user. They're
+// resolved to Flags by ParseParams.
+type Params struct {
+	// In is the path to the input file
+	In string
+
+	// Out is the path to write the output to. Not written if empty
+	Out string
+
+	// Format of the output: json, genbank or sbol. JSON if empty
+	Format string
+
+	// Explain is the path to write a report of the candidate assemblies to. HTML
+	// if it ends with .html and JSON otherwise. Not written if empty
+	Explain string
+
+	// Objective that the solutions with each fragment count are ranked by: cost, synthesis,
+	// pcr, repositories, inventory, turnaround or weighted. The objective in the settings if empty
+	Objective string
+
+	// Solutions is the number of solutions to return for each fragment count. 1 if 0
+	Solutions int
+
+	// Frags are input sequences. The In file is not read if they're set
+	Frags []*Frag
+
+	// Dbs are the names of databases in the registry, or made by 'repp db create',
+	// or paths to local BLAST databases
+	Dbs []string
+
+	// Backbone to insert the fragments into. An entry in one of the dbs or a local file
+	Backbone string
+
+	// Enzymes to linearize the backbone with
+	Enzymes []string
+
+	// Filters are keywords for excluding fragments
+	Filters []string
+
+	// Identity is the %-identity threshold for BLAST matches
+	Identity int
+
+	// Method of assembly, gibson or goldengate. Gibson if empty
+	Method string
+
+	// FeatureDB is a preloaded features database. Read from the filesystem if nil
+	FeatureDB *FeatureDB
+
+	// EnzymeDB is a preloaded enzymes database. Read from the filesystem if nil
+	EnzymeDB *EnzymeDB
+}
+
+// inputParser contains methods for parsing user input.
 type inputParser struct{}
 
 // NewFlags makes a new flags object manually. for testing.
@@ -51,161 +120,146 @@ func NewFlags(
 	in, out, backbone, filter string,
 	enzymes, dbs []string,
 	addgene, igem, dnasu bool,
-) (*Flags, *config.Config) {
+) (*Flags, *config.Config, error) {
 	c := config.New()
 
+	p := inputParser{}
+	if strings.Contains(in, ",") {
+		in = p.parseFeatureInput(strings.Fields(in))
+	}
+
 	if addgene {
-		dbs = append(dbs, config.AddgeneDB)
+		dbs = append(dbs, "addgene")
 	}
 	if igem {
-		dbs = append(dbs, config.IGEMDB)
+		dbs = append(dbs, "igem")
 	}
 	if dnasu {
-		dbs = append(dbs, config.DNASUDB)
-	}
-
-	p := inputParser{}
-	parsedBB, bbMeta, err := p.parseBackbone(backbone, enzymes, dbs, c)
+		dbs = append(dbs, "dnasu")
+	}
+
+	flags, err := ParseParams(context.Background(), Params{
+		In:       in,
+		Out:      out,
+		Dbs:      dbs,
+		Backbone: backbone,
+		Enzymes:  enzymes,
+		Filters:  p.getFilters(filter),
+		Identity: 98,
+	}, c)
 	if err != nil {
-		stderr.Fatal(err)
+		return nil, nil, err
 	}
 
-	if strings.Contains(in, ",") {
-		in = p.parseFeatureInput(strings.Fields(in))
-	}
-
-	return &Flags{
-		in:           in,
-		out:          out,
-		dbs:          dbs,
-		backbone:     parsedBB,
-		backboneMeta: bbMeta,
-		filters:      p.getFilters(filter),
-		identity:     98,
-	}, c
+	return flags, c, nil
 }
 
-// parseCmdFlags gathers the in path, out path, etc from a cobra cmd object
-// returns Flags and a Config struct for repp.Plasmid or repp.Fragments.
-func parseCmdFlags(cmd *cobra.Command, args []string, strict bool) (*Flags, *config.Config) {
-	cmdName := strings.ToLower(cmd.Name())
-
-	var err error
-	fs := &Flags{} // parsed flags
-	p := inputParser{}
-	c := config.New()
-
-	if fs.in, err = cmd.Flags().GetString("in"); fs.in == "" || err != nil {
-		if cmdName == "features" {
-			fs.in = p.parseFeatureInput(args)
-		} else if cmdName == "sequence" && len(args) > 0 {
-			fs.in = "input.fa"
-			if err = ioutil.WriteFile(fs.in, []byte(fmt.Sprintf(">target_sequence\n%s", args[0])), 0644); err != nil {
-				stderr.Fatal(err)
-			}
-		} else if fs.in, err = p.guessInput(); strict && err != nil {
-			// check whether an input fail was specified
-			cmd.Help()
-			stderr.Fatal(err)
+// ParseParams validates Params and resolves them to Flags: databases to paths
+// on the local filesystem and the backbone to a digested Frag. The default
+// configuration is loaded if conf is nil.
+func ParseParams(ctx context.Context, params Params, conf *config.Config) (fs *Flags, err error) {
+	if conf == nil {
+		if conf, err = config.Load(""); err != nil {
+			return nil, err
 		}
 	}
 
-	if fs.out, err = cmd.Flags().GetString("out"); strict && (fs.out == "" || err != nil) {
-		fs.out = p.guessOutput(fs.in) // guess at an output name
-
-		if fs.out == "" {
-			cmd.Help()
-			stderr.Fatal("no output path")
-		}
+	p := inputParser{}
+	fs = &Flags{
+		conf:      conf,
+		in:        params.In,
+		out:       params.Out,
+		explain:   params.Explain,
+		frags:     params.Frags,
+		identity:  params.Identity,
+		featureDB: params.FeatureDB,
+		enzymeDB:  params.EnzymeDB,
 	}
 
-	addgene, err := cmd.Flags().GetBool("addgene") // use addgene db?
-	if strict && err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse addgene flag: %v", err)
+	// filters are case insensitive
+	for _, filter := range params.Filters {
+		fs.filters = append(fs.filters, p.getFilters(filter)...)
 	}
 
-	igem, err := cmd.Flags().GetBool("igem") // use igem db?
-	if strict && err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse igem flag: %v", err)
+	// set the assembly method, ignoring case and dashes, eg "Golden-Gate"
+	fs.method = strings.Replace(strings.ToLower(params.Method), "-", "", -1)
+	if fs.method == "" {
+		fs.method = methodGibson
 	}
-
-	dnasu, err := cmd.Flags().GetBool("dnasu") // use dnasu db?
-	if strict && err != nil {
-		cmd.Help()
-		stderr.Fatalf("failed to parse dnasu flag: %v", err)
+	if fs.method != methodGibson && fs.method != methodGoldenGate {
+		return nil, fmt.Errorf("unknown assembly method %s, expecting gibson or goldengate", params.Method)
 	}
 
-	dbString, err := cmd.Flags().GetString("dbs")
-	if strict && err != nil && !addgene {
-		cmd.Help()
-		stderr.Fatalf("failed to parse building fragments: %v", err)
+	if fs.objective, err = parseObjective(params.Objective, params.Solutions, conf); err != nil {
+		return nil, err
 	}
 
-	filters, err := cmd.Flags().GetString("exclude")
-	if strict && err != nil && cmdName != "fragments" {
-		cmd.Help()
-		stderr.Fatalf("failed to parse filters: %v", err)
+	fs.format = strings.ToLower(params.Format)
+	if fs.format == "" {
+		fs.format = formatJSON
 	}
-	// try to split the filter fields into a list
-	fs.filters = p.getFilters(filters)
-
-	identity, err := cmd.Flags().GetInt("identity")
-	if err != nil {
-		identity = 100 // might be something other than `repp plasmid`
+	if fs.format != formatJSON && fs.format != formatGenbank && fs.format != formatSBOL {
+		return nil, fmt.Errorf("unknown output format %s, expecting json, genbank or sbol", params.Format)
 	}
-	// set identity for blastn searching
-	fs.identity = identity
 
-	if dbString == "" && !addgene && !igem && !dnasu {
-		fmt.Println("no fragment databases chosen [-agu]: using Addgene, DNASU, and iGEM by default")
-		addgene = true
-		igem = true
-		dnasu = true
-	}
 	// read in the BLAST DB paths
-	if fs.dbs, err = p.parseDBs(dbString, addgene, igem, dnasu); err != nil || len(fs.dbs) == 0 {
-		stderr.Fatalf("failed to find any fragment databases: %v", err)
+	if fs.dbs, err = p.parseDBs(strings.Join(params.Dbs, ","), conf); err != nil {
+		return nil, fmt.Errorf("failed to find any fragment databases: %w", err)
 	}
 
-	// check if user asked for a specific backbone, confirm it exists in one of the dbs
-	backbone, _ := cmd.Flags().GetString("backbone")
-
-	// check if they also specified an enzyme
-	enzymeList, _ := cmd.Flags().GetString("enzymeList")
-	enzymes := p.parseCommaList(enzymeList)
-
 	// try to digest the backbone with the enzyme
-	fs.backbone, fs.backboneMeta, err = p.parseBackbone(backbone, enzymes, fs.dbs, c)
-	if strict && err != nil {
-		stderr.Fatal(err)
+	if fs.backbone, fs.backboneMeta, err = p.parseBackbone(ctx, params.Backbone, params.Enzymes, fs.dbs, fs.enzymeDB, conf); err != nil {
+		return nil, err
 	}
 
-	return fs, c
+	return fs, nil
 }
 
-// guessInput returns the first fasta file in the current directory. Is used
-// if the user hasn't specified an input file.
-func (p *inputParser) guessInput() (in string, err error) {
-	dir, _ := filepath.Abs(".")
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return
+// features returns the features database, reading it if it wasn't preloaded.
+func (f *Flags) features() (db *FeatureDB, err error) {
+	if f.featureDB == nil {
+		if f.featureDB, err = LoadFeatureDB(); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	return f.featureDB, nil
+}
+
+// enzymes returns the enzymes database, reading it if it wasn't preloaded.
+func (f *Flags) enzymes() (db *EnzymeDB, err error) {
+	if f.enzymeDB == nil {
+		if f.enzymeDB, err = LoadEnzymeDB(); err != nil {
+			return nil, err
 		}
+	}
 
-		ext := strings.ToUpper(filepath.Ext(file.Name()))
-		if ext == ".fa" || ext == ".fasta" {
-			return file.Name(), nil
+	return f.enzymeDB, nil
+}
+
+// NewFrag returns a Frag for an input sequence. Circular sequences are
+// assembled as plasmids by the fragments command.
+func NewFrag(id, seq string, isCircular bool) *Frag {
+	f := &Frag{ID: id, Seq: strings.ToUpper(seq), fragType: linear}
+	if isCircular {
+		f.fragType = circular
+	}
+
+	return f
+}
+
+// read returns the input sequences: those passed directly or, if there are none,
+// those in the input file.
+func (f *Flags) read(feature bool) ([]*Frag, error) {
+	if len(f.frags) > 0 {
+		var frags []*Frag
+		for _, frag := range f.frags {
+			frags = append(frags, frag.copy())
 		}
+		return frags, nil
 	}
 
-	return "", fmt.Errorf("failed: no input argument set and no fasta file found in %s", dir)
+	return read(f.in, feature)
 }
 
 // parseFeatureInput turns the arguments to the features command into a
@@ -233,34 +287,25 @@ func (p *inputParser) parseFeatureInput(args []string) (out string) {
 	return strings.Join(args, " ")
 }
 
-// guessOutput gets an outpath path from an input path (if no output path is
-// specified). It uses the same name as the input path to create an output.
-func (p *inputParser) guessOutput(in string) (out string) {
-	ext := filepath.Ext(in)
-	noExt := in[0 : len(in)-len(ext)]
-	return noExt + ".output.json"
-}
-
-// parseDBs returns a list of absolute paths to BLAST databases.
-func (p *inputParser) parseDBs(dbs string, addgene, igem, dnasu bool) (paths []string, err error) {
-	if addgene {
-		dbs += "," + config.AddgeneDB
-	}
-	if igem {
-		dbs += "," + config.IGEMDB
-	}
-	if dnasu {
-		dbs += "," + config.DNASUDB
+// parseDBs returns a list of absolute paths to BLAST databases. Databases in
+// the registry are passed by their names.
+func (p *inputParser) parseDBs(dbs string, conf *config.Config) (paths []string, err error) {
+	var dbList []string
+	for _, db := range p.parseCommaList(dbs) {
+		if d, registered := conf.Database(db, ""); registered {
+			db = d.Path
+		}
+		dbList = append(dbList, db)
 	}
 
-	if paths, err = p.dbPaths(dbs); err != nil {
+	if paths, err = p.dbPaths(strings.Join(dbList, ",")); err != nil {
 		return nil, err
 	}
 
 	// make sure all the blast databases exist in the user's FS
 	for _, path := range paths {
 		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to find a BLAST database at %s", path)
+			return nil, &MissingDatabaseError{Path: path}
 		}
 	}
 
@@ -268,11 +313,19 @@ func (p *inputParser) parseDBs(dbs string, addgene, igem, dnasu bool) (paths []s
 }
 
 // dbPaths turns a single string of comma separated BLAST dbs into a
-// slice of absolute paths to the BLAST dbs on the local fs.
+// slice of absolute paths to the BLAST dbs on the local fs. Databases made
+// by 'repp db create' can be passed by their names.
 func (p *inputParser) dbPaths(dbList string) (paths []string, err error) {
 	dbPaths := p.parseCommaList(dbList)
 
 	for _, db := range dbPaths {
+		if _, err := os.Stat(db); os.IsNotExist(err) {
+			if registered, exists := registeredDB(db); exists {
+				paths = append(paths, registered)
+				continue
+			}
+		}
+
 		absPath, err := filepath.Abs(db)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create absolute path: %v", err)
@@ -301,8 +354,10 @@ func (p *inputParser) parseCommaList(commaList string) (newList []string) {
 // parseBackbone takes a backbone, referenced by its id, and an enzyme to cleave the
 // backbone, and returns the linearized backbone as a Frag.
 func (p *inputParser) parseBackbone(
+	ctx context.Context,
 	bbName string,
 	enzymeNames, dbs []string,
+	enzymeDB *EnzymeDB,
 	c *config.Config,
 ) (f *Frag, backbone *Backbone, err error) {
 	// if no backbone was specified, return an empty Frag
@@ -311,7 +366,7 @@ func (p *inputParser) parseBackbone(
 	}
 
 	// confirm that the backbone exists in one of the dbs (or local fs) gather it as a Frag if it does
-	bbFrag, err := queryDatabases(bbName, dbs)
+	bbFrag, err := queryDatabases(ctx, bbName, dbs, c)
 	if err != nil {
 		return &Frag{}, &Backbone{}, err
 	}
@@ -324,11 +379,12 @@ func (p *inputParser) parseBackbone(
 	}
 
 	// gather the enzyme by name, err if it's unknown
-	enzymes, err := p.getEnzymes(enzymeNames)
+	enzymes, err := p.getEnzymes(enzymeNames, enzymeDB)
 	if err != nil {
 		return &Frag{}, &Backbone{}, err
 	}
 
+	bbFrag.conf = c
 	if f, backbone, err = digest(bbFrag, enzymes); err != nil {
 		return &Frag{}, &Backbone{}, err
 	}
@@ -337,16 +393,19 @@ func (p *inputParser) parseBackbone(
 }
 
 // getEnzymes return the enzyme with the name passed. errors out if there is none.
-func (p *inputParser) getEnzymes(enzymeNames []string) (enzymes []enzyme, err error) {
-	enzymeDB := NewEnzymeDB()
+// The enzymes database is read if enzymeDB is nil.
+func (p *inputParser) getEnzymes(enzymeNames []string, enzymeDB *EnzymeDB) (enzymes []enzyme, err error) {
+	if enzymeDB == nil {
+		if enzymeDB, err = LoadEnzymeDB(); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, enzymeName := range enzymeNames {
 		if cutseq, exists := enzymeDB.enzymes[enzymeName]; exists {
 			enzymes = append(enzymes, newEnzyme(enzymeName, cutseq))
 		} else {
-			return enzymes, fmt.Errorf(
-				`failed to find enzyme with name %s use "repp enzymes" for a list of recognized enzymes`,
-				enzymeName,
-			)
+			return enzymes, &UnknownEnzymeError{Name: enzymeName}
 		}
 	}
 
@@ -363,7 +422,12 @@ func (p *inputParser) getFilters(filterFlag string) []string {
 	return strings.FieldsFunc(strings.ToUpper(filterFlag), splitFunc)
 }
 
-// read a FASTA file (by its path on local FS) to a slice of Fragments.
+// Read returns the sequences in a FASTA, Genbank or SBOL file.
+func Read(path string) ([]*Frag, error) {
+	return read(path, false)
+}
+
+// read a FASTA, Genbank or SBOL file (by its path on local FS) to a slice of Fragments.
 func read(path string, feature bool) (fragments []*Frag, err error) {
 	if !filepath.IsAbs(path) {
 		path, err = filepath.Abs(path)
@@ -391,6 +455,13 @@ func read(path string, feature bool) (fragments []*Frag, err error) {
 		return readGenbank(path, file, feature)
 	}
 
+	if strings.HasSuffix(path, "jsonld") ||
+		strings.HasSuffix(path, "sbol") ||
+		strings.HasSuffix(path, "xml") ||
+		strings.HasSuffix(path, "rdf") {
+		return readSBOL(path, file, feature)
+	}
+
 	return nil, fmt.Errorf("failed to parse %s: unrecognized file type", path)
 }
 
@@ -449,83 +520,48 @@ func readFasta(path, contents string) (frags []*Frag, err error) {
 	return
 }
 
-// readGenbank parses a genbank file to fragments. Returns either fragments or parseFeatures,
-// depending on the parseFeatures parameter.
+// readGenbank parses a genbank file to fragments. Returns either each record's sequence,
+// or each record's features, depending on the parseFeatures parameter.
 func readGenbank(path, contents string, parseFeatures bool) (fragments []*Frag, err error) {
-	genbankSplit := strings.Split(contents, "ORIGIN")
-
-	if len(genbankSplit) != 2 {
-		return nil, fmt.Errorf("failed to parse %s: improperly formatted genbank file", path)
-	}
-
-	seq := strings.ToUpper(genbankSplit[1])
-	nonBpRegex := regexp.MustCompile("[^ATGC]")
-	cleanedSeq := nonBpRegex.ReplaceAllString(seq, "")
-
-	if parseFeatures {
-		// parse each feature to a fragment (misnomer)
-		splitOnFeatures := strings.Split(genbankSplit[0], "FEATURES")
-
-		if len(splitOnFeatures) < 2 {
-			return nil, fmt.Errorf("failed to parse features from %s", path)
-		}
-
-		featureSplitRegex := regexp.MustCompile("\\w+\\s+\\w+")
-		featureStrings := featureSplitRegex.Split(splitOnFeatures[1], -1)
-
-		features := []*Frag{}
-		for featureIndex, feature := range featureStrings {
-			rangeRegex := regexp.MustCompile("(\\d*)\\.\\.(\\d*)")
-			rangeIndexes := rangeRegex.FindStringSubmatch(feature)
-
-			if len(rangeIndexes) < 3 {
-				continue
-			}
-
-			start, err := strconv.Atoi(rangeIndexes[1])
-			if err != nil {
-				return nil, err
-			}
-
-			end, err := strconv.Atoi(rangeIndexes[2])
-			if err != nil {
-				return nil, err
-			}
-			featureSeq := cleanedSeq[start-1 : end] // make 0-indexed
-			featureSeq = strings.ToUpper(featureSeq)
-
-			labelRegex := regexp.MustCompile("\\/label=(.*)")
-			labelMatch := labelRegex.FindStringSubmatch(feature)
-			label := ""
-			if len(labelMatch) > 1 {
-				label = labelMatch[1]
-			} else {
-				label = strconv.Itoa(featureIndex)
+	records, err := parseGenbank(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	for _, record := range records {
+		if parseFeatures {
+			// parse each feature to a fragment (misnomer), skipping the source that spans the record
+			for _, feature := range record.features {
+				featureSeq := feature.sequence(record.seq)
+				if feature.key == "source" || featureSeq == "" {
+					continue
+				}
+
+				fragments = append(fragments, &Frag{
+					ID:  feature.name(),
+					Seq: featureSeq,
+				})
 			}
+			continue
+		}
 
-			features = append(features, &Frag{
-				ID:  label,
-				Seq: featureSeq,
-			})
+		fragType := linear
+		if record.circular {
+			fragType = circular
 		}
 
-		return features, nil
+		fragments = append(fragments, &Frag{
+			ID:       record.name,
+			Seq:      record.seq,
+			fragType: fragType,
+		})
 	}
 
-	// parse just the file's sequence
-	idRegex := regexp.MustCompile("LOCUS[ \\t]*([^ \\t]*)")
-	id := idRegex.FindString(genbankSplit[0])
-
-	if id == "" {
-		return nil, fmt.Errorf("failed to parse locus from %s", path)
+	if parseFeatures && len(fragments) < 1 {
+		return nil, fmt.Errorf("failed to parse features from %s", path)
 	}
 
-	return []*Frag{
-		&Frag{
-			ID:  id,
-			Seq: cleanedSeq,
-		},
-	}, nil
+	return fragments, nil
 }
 
 // igemBackbone returns a backbone, as it was in the database,
@@ -60,34 +60,6 @@ func Test_inputParser_dbPaths(t *testing.T) {
 	}
 }
 
-func Test_inputParser_parseOut(t *testing.T) {
-	parser := inputParser{}
-
-	type args struct {
-		in string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		wantOut string
-	}{
-		{
-			"parse relative path to neighboring output path",
-			args{
-				in: "./test_file.fa",
-			},
-			"./test_file.output.json",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if gotOut := parser.guessOutput(tt.args.in); gotOut != tt.wantOut {
-				t.Errorf("parseOut() = %v, want %v", gotOut, tt.wantOut)
-			}
-		})
-	}
-}
-
 func Test_inputParser_getFilters(t *testing.T) {
 	type args struct {
 		filterFlag string
@@ -0,0 +1,170 @@
+package repp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// inventorySuffix is appended to a BLAST db's path for the path to its inventory manifest
+const inventorySuffix = ".inventory.tsv"
+
+// inventories are the inventory manifests of BLAST dbs, keyed by the db's path. Each
+// is read when it's first needed
+var inventories = struct {
+	sync.Mutex
+	dbs map[string]map[string]*inventoryEntry
+}{dbs: make(map[string]map[string]*inventoryEntry)}
+
+// inventoryEntry is a plasmid or fragment in a local inventory, eg a freezer box.
+type inventoryEntry struct {
+	// id of the entry in the BLAST db
+	id string
+
+	// cost of the entry. Only used if hasCost
+	cost float64
+
+	// hasCost is whether the entry has a cost in the manifest
+	hasCost bool
+
+	// location of the entry, eg "freezer 2, box 4, A3"
+	location string
+
+	// concentration of the entry, eg "50 ng/uL"
+	concentration string
+
+	// stock of the entry, eg "3" tubes or "yes". Empty if it's unknown
+	stock string
+}
+
+// inStock returns whether the entry is available. Entries with an unknown stock
+// are assumed to be available.
+func (e *inventoryEntry) inStock() bool {
+	switch strings.ToLower(e.stock) {
+	case "no", "false", "n", "out":
+		return false
+	}
+
+	if stock, err := strconv.ParseFloat(e.stock, 64); err == nil {
+		return stock > 0
+	}
+
+	return true
+}
+
+// inventoryPath returns the path to the inventory manifest of a BLAST db.
+func inventoryPath(db string) string {
+	return db + inventorySuffix
+}
+
+// readInventory reads an inventory manifest. It's a TSV file with a header row that
+// names its columns: "id", and any of "cost", "location", "concentration" and "stock".
+// Lines starting with a # are comments. Entries are keyed by their IDs.
+func readInventory(path string) (map[string]*inventoryEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make(map[string]*inventoryEntry)
+	var columns map[string]int
+	line := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if columns == nil {
+			columns = make(map[string]int)
+			for i, name := range fields {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			if _, ok := columns["id"]; !ok {
+				return nil, fmt.Errorf("failed to find an id column in the header of %s", path)
+			}
+			continue
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		entry := &inventoryEntry{
+			id:            value("id"),
+			location:      value("location"),
+			concentration: value("concentration"),
+			stock:         value("stock"),
+		}
+		if entry.id == "" {
+			continue
+		}
+
+		if cost := strings.TrimPrefix(value("cost"), "$"); cost != "" {
+			if entry.cost, err = strconv.ParseFloat(cost, 64); err != nil {
+				return nil, fmt.Errorf("failed to parse the cost of %s on line %d of %s: %v", entry.id, line, path, err)
+			}
+			entry.hasCost = true
+		}
+
+		entries[entry.id] = entry
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return entries, nil
+}
+
+// dbInventory returns the inventory of a BLAST db, keyed by entry ID. It's empty
+// if the db doesn't have an inventory manifest or it can't be read.
+func dbInventory(db string) map[string]*inventoryEntry {
+	inventories.Lock()
+	defer inventories.Unlock()
+
+	if entries, read := inventories.dbs[db]; read {
+		return entries
+	}
+
+	entries, err := readInventory(inventoryPath(db))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("failed to read the inventory", "db", db, "error", err)
+		}
+		entries = make(map[string]*inventoryEntry)
+	}
+	inventories.dbs[db] = entries
+
+	return entries
+}
+
+// inventoryLookup returns the inventory entry of a db's entry or nil if it isn't in
+// the db's inventory. Circular entries are also found without their "(circular)" suffix.
+func inventoryLookup(db, entry string) *inventoryEntry {
+	if db == "" || entry == "" {
+		return nil
+	}
+
+	entries := dbInventory(db)
+	if e, ok := entries[entry]; ok {
+		return e
+	}
+
+	if trimmed := strings.TrimSuffix(entry, "(circular)"); trimmed != entry {
+		return entries[trimmed]
+	}
+
+	return nil
+}
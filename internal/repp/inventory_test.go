@@ -0,0 +1,72 @@
+package repp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_readInventory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := filepath.Join(dir, "parts.fa")
+	manifest := "# lab freezer\nid\tstock\tcost\tlocation\tconcentration\npSB1C3\t3\t$2.50\tbox 1, A1\t50 ng/uL\npUC19\t0\t\tbox 1, A2\t\nBBa_E0040\t\n"
+	if err := ioutil.WriteFile(inventoryPath(db), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readInventory(inventoryPath(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("readInventory() read %d entries, want 3", len(entries))
+	}
+
+	pSB1C3 := entries["pSB1C3"]
+	if !pSB1C3.hasCost || pSB1C3.cost != 2.5 || pSB1C3.location != "box 1, A1" || pSB1C3.concentration != "50 ng/uL" || !pSB1C3.inStock() {
+		t.Errorf("readInventory() pSB1C3 = %+v", pSB1C3)
+	}
+	if pUC19 := entries["pUC19"]; pUC19.hasCost || pUC19.inStock() {
+		t.Errorf("readInventory() pUC19 = %+v, want out of stock without a cost", pUC19)
+	}
+	if e := entries["BBa_E0040"]; !e.inStock() {
+		t.Errorf("readInventory() BBa_E0040 = %+v, want in stock with an unknown stock", e)
+	}
+
+	if e := inventoryLookup(db, "pSB1C3(circular)"); e == nil || e.id != "pSB1C3" {
+		t.Errorf("inventoryLookup() = %+v, want pSB1C3", e)
+	}
+	if e := inventoryLookup(db, "missing"); e != nil {
+		t.Errorf("inventoryLookup() = %+v, want nil", e)
+	}
+
+	// the inventory's cost replaces the free cost of a local fragment
+	f := &Frag{ID: "pSB1C3", db: db, inventory: inventoryLookup(db, "pSB1C3"), conf: config.New()}
+	if cost := f.procurementCost(); cost != 2.5 {
+		t.Errorf("procurementCost() = %f, want 2.5", cost)
+	}
+}
+
+func Test_inventoryEntry_inStock(t *testing.T) {
+	for stock, want := range map[string]bool{
+		"":      true,
+		"yes":   true,
+		"2":     true,
+		"0.5":   true,
+		"0":     false,
+		"no":    false,
+		"False": false,
+	} {
+		if got := (&inventoryEntry{stock: stock}).inStock(); got != want {
+			t.Errorf("inStock() with stock %q = %t, want %t", stock, got, want)
+		}
+	}
+}
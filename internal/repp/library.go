@@ -0,0 +1,201 @@
+package repp
+
+import "strings"
+
+// maxLibraryRounds caps the passes over the targets when planning a library
+const maxLibraryRounds = 100
+
+// libraryItem is something procured or prepared for an assembly: a repository plasmid,
+// a primer, a PCR reaction or a synthetic fragment. Items with the same key are the same
+// and only paid for once in a library.
+type libraryItem struct {
+	// key is the same for items that are interchangeable between assemblies
+	key string
+
+	// kind of item: plasmid, primer, pcr or synthetic
+	kind string
+
+	// name of the item, eg a plasmid's URL or a primer's sequence
+	name string
+
+	// cost of the item
+	cost float64
+}
+
+// libraryOption is a target's solution, broken into the items it needs.
+type libraryOption struct {
+	// overhead is the cost of the solution that isn't any item, eg the cost of a Gibson Assembly
+	overhead float64
+
+	// items needed for the solution, without duplicates
+	items []libraryItem
+}
+
+// items returns the items that must be procured or prepared to make the fragment.
+// Their costs sum to the fragment's cost.
+func (f *Frag) items() (items []libraryItem) {
+	source := f.URL
+	if source == "" {
+		source = f.ID
+	}
+
+	if f.conf == nil {
+		// without a config there's no breakdown of the fragment's cost
+		key := strings.Join([]string{f.Type, source, f.Seq, f.PCRSeq}, "|")
+		return []libraryItem{libraryItem{key: key, kind: f.Type, name: source, cost: f.Cost}}
+	}
+
+	if f.fragType != synthetic {
+		if procure := f.procurementCost(); procure > 0 {
+			items = append(items, libraryItem{key: "plasmid|" + source, kind: "plasmid", name: source, cost: procure})
+		}
+	}
+
+	if f.fragType == pcr && len(f.Primers) > 1 {
+		primers := []string{}
+		for _, p := range f.Primers[:2] {
+			primers = append(primers, p.Seq)
+			if p.Inventory != "" {
+				continue // already in stock
+			}
+			items = append(items, libraryItem{
+				key:  "primer|" + p.Seq,
+				kind: "primer",
+				name: p.Seq,
+				cost: float64(len(p.Seq)) * f.conf.CostBP,
+			})
+		}
+
+		// the same primers on the same source make the same product
+		items = append(items, libraryItem{
+			key:  "pcr|" + source + "|" + strings.Join(primers, "|"),
+			kind: "pcr",
+			name: source,
+			cost: f.conf.CostPCR,
+		})
+	} else if f.fragType == synthetic {
+		items = append(items, libraryItem{
+			key:  "synthetic|" + f.Seq,
+			kind: "synthetic",
+			name: f.ID,
+			cost: f.cost(false),
+		})
+	}
+
+	return items
+}
+
+// newLibraryOption breaks a solution into its overhead and the items it needs.
+func newLibraryOption(s Solution) libraryOption {
+	option := libraryOption{overhead: s.Cost}
+	seen := make(map[string]bool)
+	for _, f := range s.Fragments {
+		option.overhead -= f.Cost
+
+		for _, item := range f.items() {
+			if !seen[item.key] {
+				seen[item.key] = true
+				option.items = append(option.items, item)
+			}
+		}
+	}
+
+	if option.overhead < 0 {
+		option.overhead = 0
+	}
+
+	return option
+}
+
+// planLibrary picks a solution for each target that minimizes the cost of the whole
+// library, where items needed by multiple targets are only paid for once. Targets
+// without solutions are skipped and have a choice of -1.
+//
+// It starts with each target's cheapest solution and then, one target at a time,
+// switches to the solution that's cheapest given the items the other targets
+// already need. That's repeated until no target switches.
+func planLibrary(solutions [][]Solution) (choices []int, cost float64) {
+	options := make([][]libraryOption, len(solutions))
+	choices = make([]int, len(solutions))
+	for t, targetSolutions := range solutions {
+		choices[t] = -1
+		for i, s := range targetSolutions {
+			options[t] = append(options[t], newLibraryOption(s))
+			if choices[t] < 0 || s.Cost < targetSolutions[choices[t]].Cost {
+				choices[t] = i
+			}
+		}
+	}
+
+	// uses is a count of how many targets need each item
+	uses := make(map[string]int)
+	use := func(t, delta int) {
+		if choices[t] < 0 {
+			return
+		}
+		for _, item := range options[t][choices[t]].items {
+			uses[item.key] += delta
+		}
+	}
+	for t := range choices {
+		use(t, 1)
+	}
+
+	// marginal is the cost of an option given the items needed by other targets
+	marginal := func(option libraryOption) float64 {
+		c := option.overhead
+		for _, item := range option.items {
+			if uses[item.key] == 0 {
+				c += item.cost
+			}
+		}
+		return c
+	}
+
+	for round := 0; round < maxLibraryRounds; round++ {
+		switched := false
+		for t := range choices {
+			if choices[t] < 0 {
+				continue
+			}
+
+			use(t, -1)
+
+			// switch if another solution is at least a cent cheaper
+			best, bestCost := choices[t], marginal(options[t][choices[t]])
+			for i, option := range options[t] {
+				if c := marginal(option); c < bestCost-0.005 {
+					best, bestCost = i, c
+				}
+			}
+
+			if best != choices[t] {
+				switched = true
+				choices[t] = best
+			}
+			use(t, 1)
+		}
+
+		if !switched {
+			break
+		}
+	}
+
+	// the cost of the library, paying for each item once
+	paid := make(map[string]bool)
+	for t, choice := range choices {
+		if choice < 0 {
+			continue
+		}
+
+		cost += options[t][choice].overhead
+		for _, item := range options[t][choice].items {
+			if !paid[item.key] {
+				paid[item.key] = true
+				cost += item.cost
+			}
+		}
+	}
+
+	return choices, cost
+}
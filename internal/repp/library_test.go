@@ -0,0 +1,89 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// testLibraryConfig has round costs for checking library plans
+func testLibraryConfig() *config.Config {
+	return &config.Config{
+		Databases:             []config.Database{config.Database{Name: "addgene", URL: "https://www.addgene.org/{plasmid}/", Cost: 65}},
+		CostBP:                0.5,
+		CostPCR:               20,
+		SyntheticMaxLength:    1000,
+		CostSyntheticFragment: map[int]config.SynthCost{1000: config.SynthCost{Fixed: true, Cost: 10}},
+	}
+}
+
+// testPCR is a $95 PCR fragment from an Addgene plasmid
+func testPCR(id string, conf *config.Config) *Frag {
+	return &Frag{
+		ID:       id,
+		Type:     "pcr",
+		URL:      "https://www.addgene.org/1/",
+		Cost:     95,
+		Primers:  []Primer{Primer{Seq: "ATGCATGCAT"}, Primer{Seq: "GGCCGGCCGG"}},
+		fragType: pcr,
+		conf:     conf,
+	}
+}
+
+// testSynthetic is a $10 synthetic fragment
+func testSynthetic(id, seq string, conf *config.Config) *Frag {
+	return &Frag{ID: id, Type: "synthetic", Seq: seq, Cost: 10, fragType: synthetic, conf: conf}
+}
+
+func Test_Frag_items(t *testing.T) {
+	conf := testLibraryConfig()
+
+	for _, f := range []*Frag{testPCR("pcr", conf), testSynthetic("synth", "ATGC", conf)} {
+		total := 0.0
+		for _, item := range f.items() {
+			total += item.cost
+		}
+
+		if total != f.cost(true) {
+			t.Errorf("items() of %s cost %.2f, want %.2f", f.ID, total, f.cost(true))
+		}
+	}
+
+	if items := testPCR("pcr", conf).items(); len(items) != 4 {
+		t.Errorf("items() = %v, want a plasmid, two primers and a PCR reaction", items)
+	}
+}
+
+func Test_planLibrary(t *testing.T) {
+	conf := testLibraryConfig()
+
+	solutions := [][]Solution{
+		[]Solution{
+			// cheapest on its own, $50 of assembly overhead
+			Solution{Count: 1, Cost: 60, Fragments: []*Frag{testSynthetic("a-1", "GGCC", conf)}},
+			Solution{Count: 1, Cost: 95, Fragments: []*Frag{testPCR("a-2", conf)}},
+		},
+		nil, // failed target
+		[]Solution{
+			Solution{Count: 1, Cost: 95, Fragments: []*Frag{testPCR("c-1", conf)}},
+		},
+	}
+
+	choices, cost := planLibrary(solutions)
+
+	if choices[0] != 1 || choices[1] != -1 || choices[2] != 0 {
+		t.Errorf("planLibrary() choices = %v, want [1 -1 0]", choices)
+	}
+	if cost != 95 {
+		t.Errorf("planLibrary() cost = %.2f, want 95", cost)
+	}
+
+	// targets that share nothing keep their cheapest solutions
+	choices, cost = planLibrary([][]Solution{
+		solutions[0],
+		[]Solution{Solution{Count: 1, Cost: 60, Fragments: []*Frag{testSynthetic("b-1", "TTAA", conf)}}},
+	})
+	if choices[0] != 0 || choices[1] != 0 || cost != 120 {
+		t.Errorf("planLibrary() = %v, %.2f, want [0 0] and 120", choices, cost)
+	}
+}
@@ -0,0 +1,60 @@
+package repp
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logger is where warnings and, at the debug level, the progress of designs are logged.
+// It's a text logger to stderr by default.
+var logger = slog.New(newLogHandler(os.Stderr, "text", slog.LevelInfo))
+
+// SetLogger sets the logger that warnings and debug messages are logged to.
+// It shouldn't be called while designs are running.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// NewLogger returns a logger that writes to w in a format, text or json.
+// Debug messages are logged if verbose.
+func NewLogger(w io.Writer, format string, verbose bool) (*slog.Logger, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("unknown log format %s, expecting text or json", format)
+	}
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	return slog.New(newLogHandler(w, format, level)), nil
+}
+
+// newLogHandler returns a handler for the log format. Text logs are without a timestamp.
+func newLogHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	if format == "json" {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	}
+
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+// LogError logs an error, with its code, at the error level.
+func LogError(err error) {
+	if code := ErrorCode(err); code != "" {
+		logger.Error(err.Error(), "code", code)
+		return
+	}
+
+	logger.Error(err.Error())
+}
@@ -0,0 +1,77 @@
+package repp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// logBuffer holds the JSON logs of a test
+type logBuffer struct {
+	bytes.Buffer
+}
+
+// records returns each of the logs decoded
+func (b *logBuffer) records() (records []map[string]interface{}) {
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		record := make(map[string]interface{})
+		if json.Unmarshal([]byte(line), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	return
+}
+
+// tempLogger swaps the logger for a verbose JSON one until the test ends
+func tempLogger(t *testing.T) *logBuffer {
+	logs := &logBuffer{}
+	l, err := NewLogger(logs, "json", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := logger
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(old) })
+
+	return logs
+}
+
+func Test_NewLogger(t *testing.T) {
+	var out bytes.Buffer
+	l, err := NewLogger(&out, "text", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Debug("hidden")
+	l.Warn("shown", "db", "addgene")
+	if got := out.String(); got != "level=WARN msg=shown db=addgene\n" {
+		t.Errorf("NewLogger() logged %q", got)
+	}
+
+	if _, err := NewLogger(&out, "xml", false); err == nil {
+		t.Error("NewLogger() accepted an unknown format")
+	}
+}
+
+func Test_LogError(t *testing.T) {
+	logs := tempLogger(t)
+
+	LogError(fmt.Errorf("failed to find any fragment databases: %w", &MissingDatabaseError{Path: "/dbs/addgene"}))
+	LogError(errors.New("failed"))
+
+	records := logs.records()
+	if len(records) != 2 {
+		t.Fatalf("LogError() logged %d records, want 2", len(records))
+	}
+	if records[0]["level"] != "ERROR" || records[0]["code"] != "missing_database" || records[0]["msg"] != "failed to find any fragment databases: failed to find a BLAST database at /dbs/addgene" {
+		t.Errorf("LogError() logged %v", records[0])
+	}
+	if _, coded := records[1]["code"]; coded {
+		t.Errorf("LogError() logged a code for an error without one: %v", records[1])
+	}
+}
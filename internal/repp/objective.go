@@ -0,0 +1,195 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+const (
+	// objectiveCost ranks solutions by their cost
+	objectiveCost = "cost"
+
+	// objectiveSynthesis ranks solutions by their synthesized bp
+	objectiveSynthesis = "synthesis"
+
+	// objectivePCR ranks solutions by their PCR reactions
+	objectivePCR = "pcr"
+
+	// objectiveRepositories ranks solutions by the repositories their fragments are ordered from
+	objectiveRepositories = "repositories"
+
+	// objectiveInventory ranks solutions by their in-house fragments, most first
+	objectiveInventory = "inventory"
+
+	// objectiveTurnaround ranks solutions by the days to their plasmid
+	objectiveTurnaround = "turnaround"
+
+	// objectiveWeighted ranks solutions by a weighted sum of the other objectives
+	objectiveWeighted = "weighted"
+)
+
+// Scores of a solution by each objective, other than its cost.
+type Scores struct {
+	// SynthesizedBP is the number of bp in the solution's synthetic fragments
+	SynthesizedBP int `json:"synthesizedBP"`
+
+	// PCRReactions is the number of fragments that are PCR'ed
+	PCRReactions int `json:"pcrReactions"`
+
+	// Repositories is the number of repositories that fragments are ordered from
+	Repositories int `json:"repositories"`
+
+	// InHouse is the number of fragments on hand: from internal databases, local
+	// databases outside the registry, or an inventory
+	InHouse int `json:"inHouse"`
+
+	// Days is the estimated number of days from ordering the fragments and primers
+	// to a verified plasmid
+	Days float64 `json:"days"`
+
+	// Weighted is the sum of the scores, and cost, times their weights in the settings
+	Weighted float64 `json:"weighted"`
+}
+
+// objective is what the solutions with each fragment count are ranked by.
+type objective struct {
+	// name of the objective: cost, synthesis, pcr, repositories, inventory, turnaround or weighted
+	name string
+
+	// solutions is the number of solutions kept for each fragment count
+	solutions int
+}
+
+// parseObjective returns the objective of a design, by name and with the number of
+// solutions to keep per fragment count. It's the objective in the settings if name is
+// empty and one solution is kept if solutions is 0.
+func parseObjective(name string, solutions int, conf *config.Config) (objective, error) {
+	if name == "" {
+		name = conf.Objective
+	}
+	name = strings.ToLower(name)
+	if name == "" {
+		name = objectiveCost
+	}
+
+	switch name {
+	case objectiveCost, objectiveSynthesis, objectivePCR, objectiveRepositories, objectiveInventory, objectiveTurnaround, objectiveWeighted:
+	default:
+		return objective{}, fmt.Errorf("unknown objective %s, expecting cost, synthesis, pcr, repositories, inventory, turnaround or weighted", name)
+	}
+
+	if solutions < 0 {
+		return objective{}, fmt.Errorf("invalid number of solutions %d, expecting at least 1", solutions)
+	}
+	if solutions == 0 {
+		solutions = 1
+	}
+
+	return objective{name: name, solutions: solutions}, nil
+}
+
+// keep returns the number of solutions kept for each fragment count.
+func (o objective) keep() int {
+	if o.solutions < 1 {
+		return 1
+	}
+	return o.solutions
+}
+
+// costOnly returns whether solutions are ranked by cost alone. Only then can assemblies
+// be skipped when their estimated cost is more than that of the filled solutions.
+func (o objective) costOnly() bool {
+	return o.name == "" || o.name == objectiveCost
+}
+
+// score returns the score of a filled assembly by the objective, lower is better.
+func (o objective) score(cost float64, s Scores) float64 {
+	switch o.name {
+	case objectiveSynthesis:
+		return float64(s.SynthesizedBP)
+	case objectivePCR:
+		return float64(s.PCRReactions)
+	case objectiveRepositories:
+		return float64(s.Repositories)
+	case objectiveInventory:
+		return -float64(s.InHouse)
+	case objectiveTurnaround:
+		return s.Days
+	case objectiveWeighted:
+		return s.Weighted
+	default:
+		return cost
+	}
+}
+
+// label returns the name of what the objective scores, for explanations.
+func (o objective) label() string {
+	switch o.name {
+	case objectiveSynthesis:
+		return "synthesized bp"
+	case objectivePCR:
+		return "number of PCR reactions"
+	case objectiveRepositories:
+		return "number of repositories"
+	case objectiveInventory:
+		return "number of in-house fragments"
+	case objectiveTurnaround:
+		return "days to the plasmid"
+	case objectiveWeighted:
+		return "weighted score"
+	default:
+		return "cost"
+	}
+}
+
+// format returns a score by the objective as it's explained.
+func (o objective) format(score float64) string {
+	switch o.name {
+	case objectiveInventory:
+		return fmt.Sprintf("%.0f", -score)
+	case objectiveTurnaround:
+		return fmt.Sprintf("%.1f", score)
+	case objectiveWeighted:
+		return fmt.Sprintf("%.2f", score)
+	case objectiveSynthesis, objectivePCR, objectiveRepositories:
+		return fmt.Sprintf("%.0f", score)
+	default:
+		return fmt.Sprintf("$%.2f", score)
+	}
+}
+
+// newScores returns the scores of a filled assembly by each objective. Its turnaround
+// is that of a Golden Gate assembly if gg is not nil and of a Gibson Assembly otherwise.
+func newScores(frags []*Frag, gg *goldenGate, conf *config.Config) (s Scores) {
+	repositories := make(map[string]bool)
+	for _, f := range frags {
+		switch f.fragType {
+		case synthetic:
+			s.SynthesizedBP += len(f.Seq)
+			continue
+		case pcr:
+			s.PCRReactions++
+		}
+
+		d, registered := conf.Database(f.db, f.URL)
+		if f.inventory != nil || !registered || d.Internal {
+			s.InHouse++
+		} else {
+			repositories[d.Name] = true
+		}
+	}
+	s.Repositories = len(repositories)
+	s.Days = turnaround(frags, gg, conf)
+
+	w := conf.ObjectiveWeights
+	s.Weighted = w.Cost*fragsCost(frags) +
+		w.Synthesis*float64(s.SynthesizedBP) +
+		w.PCR*float64(s.PCRReactions) +
+		w.Repositories*float64(s.Repositories) -
+		w.Inventory*float64(s.InHouse) +
+		w.Turnaround*s.Days
+
+	return s
+}
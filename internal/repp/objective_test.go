@@ -0,0 +1,134 @@
+package repp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_parseObjective(t *testing.T) {
+	c := &config.Config{Objective: "pcr"}
+
+	tests := []struct {
+		name      string
+		objective string
+		solutions int
+		want      objective
+		wantErr   bool
+	}{
+		{"settings", "", 0, objective{name: objectivePCR, solutions: 1}, false},
+		{"case insensitive", "Inventory", 3, objective{name: objectiveInventory, solutions: 3}, false},
+		{"unknown", "fastest", 1, objective{}, true},
+		{"negative solutions", "cost", -1, objective{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseObjective(tt.objective, tt.solutions, c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseObjective() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseObjective() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := parseObjective("", 0, &config.Config{}); got.name != objectiveCost {
+		t.Errorf("parseObjective() = %+v, want cost without one in the settings", got)
+	}
+}
+
+func Test_newScores(t *testing.T) {
+	c := config.New()
+	c.Databases = []config.Database{
+		config.Database{Name: "addgene", Path: "addgene", Cost: 65},
+		config.Database{Name: "lab", Path: "lab", Internal: true},
+	}
+	c.ObjectiveWeights = config.ObjectiveWeights{Synthesis: 1, Repositories: 10, Inventory: 1, Turnaround: 2}
+	c.LeadTimeSyntheticFragment = 5
+	c.DaysPCR = 1
+	c.DaysGibson = 4
+
+	frags := []*Frag{
+		&Frag{ID: "1", Seq: strings.Repeat("A", 200), fragType: synthetic, conf: c},
+		&Frag{ID: "2", fragType: pcr, db: "addgene", conf: c},
+		&Frag{ID: "3", fragType: pcr, db: "lab", conf: c},
+		&Frag{ID: "4", fragType: circular, db: "/tmp/local", conf: c},
+	}
+
+	want := Scores{SynthesizedBP: 200, PCRReactions: 2, Repositories: 1, InHouse: 2, Days: 9, Weighted: 226}
+	if got := newScores(frags, nil, c); got != want {
+		t.Errorf("newScores() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_fillAssemblies_objective(t *testing.T) {
+	c := config.New()
+	c.Databases = []config.Database{
+		config.Database{Name: "addgene", Path: "addgene", Cost: 65, LeadTime: 7},
+		config.Database{Name: "lab", Path: "lab", Cost: 100, Internal: true},
+	}
+	c.ObjectiveWeights = config.ObjectiveWeights{Cost: 1, Inventory: 50}
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	// a plasmid that's on hand but costs more than one from a repository
+	lab := &Frag{ID: "lab", Seq: target, fragType: circular, db: "lab", conf: c}
+	repository := &Frag{ID: "repository", Seq: target, fragType: circular, db: "addgene", conf: c}
+	counts, countToAssemblies := groupAssembliesByCount([]assembly{
+		assembly{frags: []*Frag{lab}, cost: 0},
+		assembly{frags: []*Frag{repository}, cost: 1},
+	})
+
+	tests := []struct {
+		objective string
+		want      string
+	}{
+		{objectiveCost, "repository"},
+		{objectiveRepositories, "lab"},
+		{objectiveInventory, "lab"},
+		{objectiveTurnaround, "lab"},
+		{objectiveWeighted, "lab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.objective, func(t *testing.T) {
+			solutions := fillAssemblies(context.Background(), target, counts, countToAssemblies, nil, objective{name: tt.objective, solutions: 1}, c)
+			if len(solutions) != 1 || solutions[0][0].ID != tt.want {
+				t.Errorf("fillAssemblies() = %v, want %s", solutions, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fillAssemblies_solutions(t *testing.T) {
+	c := config.New()
+	target := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
+
+	// assemblies that cost the same once filled, the first few are kept
+	var assemblies []assembly
+	for i := 0; i < 10; i++ {
+		f := &Frag{ID: fmt.Sprintf("frag%d", i), Seq: target, fragType: circular, conf: c}
+		assemblies = append(assemblies, assembly{frags: []*Frag{f}, cost: float64(i)})
+	}
+	counts, countToAssemblies := groupAssembliesByCount(assemblies)
+
+	e := &Explanation{}
+	ctx := withExplanation(context.Background(), e)
+	solutions := fillAssemblies(ctx, target, counts, countToAssemblies, nil, objective{name: objectiveCost, solutions: 3}, c)
+	if len(solutions) != 3 {
+		t.Fatalf("fillAssemblies() = %v, want 3 solutions", solutions)
+	}
+	for i, s := range solutions {
+		if want := fmt.Sprintf("frag%d", i); s[0].ID != want {
+			t.Errorf("fillAssemblies() solution %d = %s, want %s", i, s[0].ID, want)
+		}
+	}
+
+	for i, got := range e.Candidates {
+		if wantSolution := i < 3; (got.Outcome == outcomeSolution) != wantSolution {
+			t.Errorf("fillAssemblies() explained %+v, want solution %v", got, wantSolution)
+		}
+	}
+}
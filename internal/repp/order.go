@@ -0,0 +1,281 @@
+package repp
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+const (
+	// orderTube is the layout of order sheets with a tube per oligo or fragment
+	orderTube = "tube"
+
+	// orderPlate is the layout of order sheets in 96-well plates
+	orderPlate = "plate"
+
+	// orderCSV is the format of order sheets as a CSV file per sheet
+	orderCSV = "csv"
+
+	// orderXLSX is the format of order sheets as an Excel workbook
+	orderXLSX = "xlsx"
+)
+
+// OrderItem is a primer or synthetic fragment to order.
+type OrderItem struct {
+	// Name of the item, from the configured name template
+	Name string `json:"name"`
+
+	// Seq of the item
+	Seq string `json:"seq"`
+
+	// Scale of the item's synthesis, eg 25nm
+	Scale string `json:"scale,omitempty"`
+
+	// Purification of the item, eg STD
+	Purification string `json:"purification,omitempty"`
+
+	// Vendor that a synthetic fragment is ordered from
+	Vendor string `json:"vendor,omitempty"`
+
+	// Targets are the plasmids that use the item
+	Targets []string `json:"targets"`
+}
+
+// Order is the primers and synthetic fragments to order for the solutions of one
+// or more designs. Identical sequences are ordered once.
+type Order struct {
+	// Primers to order
+	Primers []OrderItem `json:"primers"`
+
+	// Synthetics are the synthetic fragments to order
+	Synthetics []OrderItem `json:"synthetics"`
+
+	// conf is the configuration of names, scales and purifications
+	conf *config.Config
+
+	// names are the names already given to items
+	names map[string]bool
+}
+
+// NewOrder returns an empty order with names, scales and purifications from conf.
+func NewOrder(conf *config.Config) *Order {
+	return &Order{conf: conf, names: make(map[string]bool)}
+}
+
+// ReadOrder returns the order for the design output, or batch of outputs, in a JSON
+// file. Batches order every target's chosen solution, otherwise the solution, 1-indexed,
+// is ordered.
+func ReadOrder(path string, solution int, conf *config.Config) (*Order, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch Batch
+	if err := json.Unmarshal(contents, &batch); err != nil {
+		return nil, fmt.Errorf("failed to parse the output in %s: %v", path, err)
+	}
+
+	order := NewOrder(conf)
+	if len(batch.Targets) == 0 {
+		out, err := ReadOutput(path)
+		if err != nil {
+			return nil, err
+		}
+		return order, order.Add(out, solution)
+	}
+
+	for _, bt := range batch.Targets {
+		if bt.Solution < 0 || bt.Error != "" {
+			continue // failed to design
+		}
+
+		out := bt.Output
+		if out == nil && bt.File != "" {
+			file := bt.File
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				// relative to the batch rather than to where it was designed
+				file = filepath.Join(filepath.Dir(path), filepath.Base(bt.File))
+			}
+			if out, err = ReadOutput(file); err != nil {
+				return nil, fmt.Errorf("failed to read the output of %s: %v", bt.Target, err)
+			}
+		}
+		if out == nil {
+			continue
+		}
+
+		if err := order.Add(out, bt.Solution+1); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Add adds the primers and synthetic fragments of one of the output's solutions,
+// 1-indexed, to the order.
+func (o *Order) Add(out *Output, solution int) error {
+	if solution < 1 || solution > len(out.Solutions) {
+		return fmt.Errorf("failed to find solution %d, %s has %d solutions", solution, out.Target, len(out.Solutions))
+	}
+
+	for _, f := range out.Solutions[solution-1].Fragments {
+		switch f.Type {
+		case pcr.String():
+			for _, p := range f.Primers {
+				if p.Inventory != "" {
+					continue // already in stock
+				}
+				o.Primers = o.add(o.Primers, out.Target, p.Seq, "", o.conf.OrderPrimerName, o.conf.OrderPrimerScale, o.conf.OrderPrimerPurification)
+			}
+		case synthetic.String():
+			o.Synthetics = o.add(o.Synthetics, out.Target, f.Seq, f.Vendor, o.conf.OrderSyntheticName, o.conf.OrderSyntheticScale, o.conf.OrderSyntheticPurification)
+		}
+	}
+
+	return nil
+}
+
+// add adds a sequence to the items if it isn't already in them. Otherwise the target
+// is added to the existing item's targets.
+func (o *Order) add(items []OrderItem, target, seq, vendor, name, scale, purification string) []OrderItem {
+	seq = strings.ToUpper(seq)
+	for i, item := range items {
+		if item.Seq != seq {
+			continue
+		}
+		for _, t := range item.Targets {
+			if t == target {
+				return items
+			}
+		}
+		items[i].Targets = append(items[i].Targets, target)
+		return items
+	}
+
+	return append(items, OrderItem{
+		Name:         o.name(name, target, len(items)+1),
+		Seq:          seq,
+		Scale:        scale,
+		Purification: purification,
+		Vendor:       vendor,
+		Targets:      []string{target},
+	})
+}
+
+// name returns a unique name from the template. {target} is replaced by the target
+// and {n} by the item's number. Names are suffixed with a count if they're taken.
+func (o *Order) name(template, target string, n int) string {
+	if template == "" {
+		template = "{target}_{n}"
+	}
+
+	target = unsafeFilename.ReplaceAllString(target, "_")
+	name := strings.NewReplacer("{target}", target, "{n}", strconv.Itoa(n)).Replace(template)
+	unique := name
+	for i := 2; o.names[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	o.names[unique] = true
+
+	return unique
+}
+
+// Write writes the order's sheets beside the stem in the format, csv or xlsx, and
+// layout, tube or plate. CSV sheets are written to "<stem>.primers.csv" and
+// "<stem>.synthetics.csv" and an Excel workbook to "<stem>.xlsx". The names of the
+// written files are returned.
+func (o *Order) Write(stem, format, layout string) ([]string, error) {
+	if layout != orderTube && layout != orderPlate {
+		return nil, fmt.Errorf("unknown order layout %s, expecting tube or plate", layout)
+	}
+
+	var sheets []xlsxSheet
+	if len(o.Primers) > 0 {
+		sheets = append(sheets, xlsxSheet{"primers", orderSheet(o.Primers, layout)})
+	}
+	if len(o.Synthetics) > 0 {
+		sheets = append(sheets, xlsxSheet{"synthetics", orderSheet(o.Synthetics, layout)})
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("failed to write an order, there are no primers or synthetic fragments to order")
+	}
+
+	switch format {
+	case orderCSV:
+		var filenames []string
+		for _, sheet := range sheets {
+			filename := stem + "." + sheet.name + ".csv"
+			if err := writeCSV(filename, sheet.rows); err != nil {
+				return nil, err
+			}
+			filenames = append(filenames, filename)
+		}
+		return filenames, nil
+	case orderXLSX:
+		filename := stem + ".xlsx"
+		return []string{filename}, writeXLSX(filename, sheets)
+	default:
+		return nil, fmt.Errorf("unknown order format %s, expecting csv or xlsx", format)
+	}
+}
+
+// orderSheet returns the rows of an order sheet, with a header, in the layout.
+// Plates are filled by column, A1 through H1 then A2, and a new plate is started
+// after H12.
+func orderSheet(items []OrderItem, layout string) [][]string {
+	// synthetic fragments name their vendor, if any does
+	vendors := false
+	for _, item := range items {
+		vendors = vendors || item.Vendor != ""
+	}
+
+	header := []string{"Name", "Sequence", "Scale", "Purification"}
+	if vendors {
+		header = append(header, "Vendor")
+	}
+	if layout == orderPlate {
+		header = append([]string{"Plate", "Well Position"}, header...)
+	}
+
+	rows := [][]string{header}
+	for i, item := range items {
+		row := []string{item.Name, item.Seq, item.Scale, item.Purification}
+		if vendors {
+			row = append(row, item.Vendor)
+		}
+		if layout == orderPlate {
+			well := i % 96
+			plate := fmt.Sprintf("Plate %d", i/96+1)
+			position := fmt.Sprintf("%c%d", 'A'+well%8, well/8+1)
+			row = append([]string{plate, position}, row...)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// writeCSV writes the rows to a CSV file.
+func writeCSV(filename string, rows [][]string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package repp
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func testOrderConfig() *config.Config {
+	return &config.Config{
+		OrderPrimerName:         "{target}_P{n}",
+		OrderPrimerScale:        "25nm",
+		OrderPrimerPurification: "STD",
+		OrderSyntheticName:      "{target}_F{n}",
+	}
+}
+
+func Test_Order_Add(t *testing.T) {
+	first := testProtocolOutput()
+	second := testProtocolOutput()
+	second.Target = "second target"
+
+	// the second target shares the forward primer and has its own reverse primer
+	seq := strings.ToUpper(testPlasmid)
+	second.Solutions[0].Fragments[0].Primers[1].Seq = reverseComplement(seq[310:330])
+
+	order := NewOrder(testOrderConfig())
+	if err := order.Add(first, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := order.Add(second, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := order.Add(first, 2); err == nil {
+		t.Error("Order.Add() should fail for a solution that isn't in the output")
+	}
+
+	var names []string
+	for _, p := range order.Primers {
+		names = append(names, p.Name)
+	}
+	if want := []string{"target_P1", "target_P2", "second_target_P3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Order.Add() primers = %v, want %v", names, want)
+	}
+	if want := []string{"target", "second target"}; !reflect.DeepEqual(order.Primers[0].Targets, want) {
+		t.Errorf("Order.Add() shared primer targets = %v, want %v", order.Primers[0].Targets, want)
+	}
+	if order.Primers[0].Scale != "25nm" || order.Primers[0].Purification != "STD" {
+		t.Errorf("Order.Add() primer = %+v, want 25nm and STD", order.Primers[0])
+	}
+
+	if len(order.Synthetics) != 1 || order.Synthetics[0].Name != "target_F1" || len(order.Synthetics[0].Targets) != 2 {
+		t.Errorf("Order.Add() synthetics = %+v, want one shared fragment", order.Synthetics)
+	}
+}
+
+func Test_orderSheet(t *testing.T) {
+	items := make([]OrderItem, 100)
+	rows := orderSheet(items, orderPlate)
+
+	wells := map[int]string{
+		1:   "Plate 1 A1",
+		2:   "Plate 1 B1",
+		9:   "Plate 1 A2",
+		96:  "Plate 1 H12",
+		97:  "Plate 2 A1",
+		100: "Plate 2 D1",
+	}
+	for row, want := range wells {
+		if got := rows[row][0] + " " + rows[row][1]; got != want {
+			t.Errorf("orderSheet() row %d = %s, want %s", row, got, want)
+		}
+	}
+
+	if tube := orderSheet(items, orderTube); tube[0][0] != "Name" {
+		t.Errorf("orderSheet() tube header = %v", tube[0])
+	}
+
+	items[1].Vendor = "twist"
+	if vendors := orderSheet(items, orderTube); vendors[0][4] != "Vendor" || vendors[2][4] != "twist" {
+		t.Errorf("orderSheet() with vendors = %v, want a vendor column", vendors[:3])
+	}
+}
+
+func Test_Order_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "order-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	order := NewOrder(testOrderConfig())
+	if err := order.Add(testProtocolOutput(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	stem := filepath.Join(dir, "plasmid.order")
+	files, err := order.Write(stem, orderCSV, orderTube)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{stem + ".primers.csv", stem + ".synthetics.csv"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("Order.Write() = %v, want %v", files, want)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][0] != "target_P1" || rows[1][1] != order.Primers[0].Seq {
+		t.Errorf("Order.Write() primers = %v", rows)
+	}
+
+	files, err = order.Write(stem, orderXLSX, orderPlate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workbook, err := zip.OpenReader(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer workbook.Close()
+
+	parts := map[string]string{}
+	for _, file := range workbook.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, _ := ioutil.ReadAll(r)
+		r.Close()
+		parts[file.Name] = string(contents)
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="synthetics" sheetId="2" r:id="rId2"/>`) {
+		t.Errorf("Order.Write() workbook = %s", parts["xl/workbook.xml"])
+	}
+	if sheet := parts["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">A1</t></is></c>`) {
+		t.Errorf("Order.Write() primers sheet = %s", sheet)
+	}
+
+	if _, err := NewOrder(testOrderConfig()).Write(stem, orderCSV, orderTube); err == nil {
+		t.Error("Order.Write() should fail without anything to order")
+	}
+}
+
+func Test_xlsxColumn(t *testing.T) {
+	for c, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(c); got != want {
+			t.Errorf("xlsxColumn(%d) = %s, want %s", c, got, want)
+		}
+	}
+}
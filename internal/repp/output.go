@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -12,6 +13,17 @@ import (
 	"github.com/jjtimmons/repp/config"
 )
 
+const (
+	// formatJSON is the default output format, the Output as JSON
+	formatJSON = "json"
+
+	// formatGenbank is an output format with a Genbank file per solution
+	formatGenbank = "genbank"
+
+	// formatSBOL is an output format with an SBOL3 document, as JSON-LD, of the solutions
+	formatSBOL = "sbol"
+)
+
 // Solution is a single solution to build up the target plasmid.
 type Solution struct {
 	// Count is the number of fragments in this solution
@@ -20,8 +32,14 @@ type Solution struct {
 	// Cost estimated from the primer and sequence lengths
 	Cost float64 `json:"cost"`
 
+	// Scores of the solution by the other objectives it can be ranked by
+	Scores Scores `json:"scores"`
+
 	// Fragments used to build this solution
 	Fragments []*Frag `json:"fragments"`
+
+	// Overhangs between the fragments of a Golden Gate assembly, 5' to 3' on the target
+	Overhangs []string `json:"overhangs,omitempty"`
 }
 
 // Output is a struct containing design results for the assembly.
@@ -49,19 +67,23 @@ type Output struct {
 
 	// Backbone is the user linearized a backbone fragment
 	Backbone *Backbone `json:"backbone,omitempty"`
+
+	// Enzyme is the Type IIS enzyme used for Golden Gate assembly
+	Enzyme string `json:"enzyme,omitempty"`
 }
 
-// writeJSON turns a list of solutions into a Solution object and writes to the filename requested.
-func writeJSON(
-	filename,
+// newOutput turns a list of solutions into an Output object.
+// The solutions are priced as Golden Gate assemblies if gg is not nil.
+func newOutput(
 	targetName,
 	targetSeq string,
 	assemblies [][]*Frag,
 	insertSeqLength int,
 	seconds float64,
 	backbone *Backbone,
+	gg *goldenGate,
 	conf *config.Config,
-) (output []byte, err error) {
+) (out *Output, err error) {
 	// store save time, using same format as log.Println https://golang.org/pkg/log/#Println
 	t := time.Now() // https://gobyexample.com/time-formatting-parsing
 	time := fmt.Sprintf(
@@ -75,14 +97,20 @@ func writeJSON(
 	// calculate final cost of the assembly and fragment count
 	solutions := []Solution{}
 	for _, assembly := range assemblies {
+		scores := newScores(assembly, gg, conf)
 		assemblyCost := 0.0
 		assemblyFragmentIDs := make(map[string]bool)
-		gibson := false // whether it will be assembled via Gibson assembly
-		hasPCR := false // whether there will be a batch PCR
+		assembled := false // whether it will be assembled via Gibson or Golden Gate assembly
+		hasPCR := false    // whether there will be a batch PCR
+		var overhangs []string
 
 		for _, f := range assembly {
 			if f.fragType != linear && f.fragType != circular {
-				gibson = true
+				assembled = true
+			}
+
+			if f.overhang != "" {
+				overhangs = append(overhangs, f.overhang)
 			}
 
 			if f.fragType == pcr {
@@ -92,7 +120,12 @@ func writeJSON(
 			f.Type = f.fragType.String() // freeze fragment type
 
 			if f.URL == "" && f.fragType != synthetic {
-				f.URL = parseURL(f.ID, f.db)
+				f.URL = parseURL(f.ID, f.db, conf)
+			}
+
+			if f.inventory != nil && f.fragType != synthetic {
+				f.Location = f.inventory.location
+				f.Concentration = f.inventory.concentration
 			}
 
 			if f.URL != "" {
@@ -117,7 +150,9 @@ func writeJSON(
 			assemblyCost += f.Cost
 		}
 
-		if gibson {
+		if assembled && gg != nil {
+			assemblyCost += conf.CostGoldenGate
+		} else if assembled {
 			assemblyCost += conf.CostGibson + conf.CostTimeGibson
 		}
 
@@ -133,12 +168,15 @@ func writeJSON(
 		solutions = append(solutions, Solution{
 			Count:     len(assembly),
 			Cost:      solutionCost,
+			Scores:    scores,
 			Fragments: assembly,
+			Overhangs: overhangs,
 		})
 	}
 
-	// sort solutions in increasing fragment count order
-	sort.Slice(solutions, func(i, j int) bool {
+	// sort solutions in increasing fragment count order, keeping the order by objective
+	// of those with the same count
+	sort.SliceStable(solutions, func(i, j int) bool {
 		return solutions[i].Count < solutions[j].Count
 	})
 
@@ -160,7 +198,7 @@ func writeJSON(
 		backbone = nil
 	}
 
-	out := Output{
+	out = &Output{
 		Time:      time,
 		Target:    targetName,
 		TargetSeq: strings.ToUpper(targetSeq),
@@ -171,74 +209,302 @@ func writeJSON(
 		// InsertSynthesisCost: insertSynthCost,
 	}
 
-	output, err = json.MarshalIndent(out, "", "  ")
+	if gg != nil {
+		out.Enzyme = gg.enzyme.name
+	}
+
+	return out, nil
+}
+
+// writeJSON writes the output to the filename requested.
+func writeJSON(filename string, out interface{}) error {
+	output, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
-		return output, fmt.Errorf("failed to serialize output: %v", err)
+		return fmt.Errorf("failed to serialize output: %v", err)
 	}
 
 	if err = ioutil.WriteFile(filename, output, 0666); err != nil {
-		return output, fmt.Errorf("failed to write the output: %v", err)
+		return fmt.Errorf("failed to write the output: %v", err)
 	}
 
-	return output, nil
+	return nil
 }
 
-// writeGenbank writes a slice of fragments/features to a genbank output file.
-func writeGenbank(filename, name, seq string, frags []*Frag, feats []match) {
-	// header row
-	d := time.Now().Local()
-	h1 := fmt.Sprintf("LOCUS       %s", name)
-	h2 := fmt.Sprintf("%d bp DNA      circular      %s\n", len(seq), strings.ToUpper(d.Format("02-Jan-2006")))
-	space := strings.Repeat(" ", 81-len(h1+h2))
-	header := h1 + space + h2
-
-	// feature rows
-	var fsb strings.Builder
-	fsb.WriteString("DEFINITION  .\nACCESSION   .\nFEATURES             Location/Qualifiers\n")
+// writeOutput writes the output to the flags' out path in the flags' format. Genbank
+// outputs are written as a file per solution beside the out path, eg "designs.output.json"
+// to "designs.output.1.gb" and "designs.output.2.gb". SBOL outputs are written to a
+// JSON-LD file beside the out path, eg "designs.output.jsonld".
+func writeOutput(flags *Flags, out *Output) error {
+	stem := strings.TrimSuffix(flags.out, filepath.Ext(flags.out))
+	switch flags.format {
+	case formatGenbank:
+		for i, s := range out.Solutions {
+			filename := fmt.Sprintf("%s.%d.gb", stem, i+1)
+			if err := writeGenbank(filename, solutionRecord(out, i, s)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatSBOL:
+		return writeJSON(stem+".jsonld", sbolOutput(out))
+	default:
+		return writeJSON(flags.out, out)
+	}
+}
+
+// writeGenbank writes a record to a genbank output file.
+func writeGenbank(filename string, record genbankRecord) error {
+	if err := ioutil.WriteFile(filename, []byte(record.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write the genbank file: %v", err)
+	}
+
+	return nil
+}
+
+// annotatedRecord returns a circular record of a sequence annotated with features.
+func annotatedRecord(name, seq string, feats []match) genbankRecord {
+	record := genbankRecord{name: name, circular: true, seq: strings.ToUpper(seq)}
 	for _, m := range feats {
-		cS := ""
-		cE := ""
-		if !m.forward {
-			cS = "complement("
-			cE = ")"
+		record.features = append(record.features, genbankFeature{
+			key:        "misc_feature",
+			spans:      []span{span{start: m.queryStart % len(seq), end: m.queryEnd % len(seq), forward: m.forward}},
+			qualifiers: []qualifier{qualifier{"label", m.entry}},
+		})
+	}
+
+	return record
+}
+
+// solutionRecord returns a record of the plasmid assembled by a solution. It's annotated
+// with each fragment and its source, primer binding sites, the junctions between
+// fragments and the sites where the backbone was cut.
+func solutionRecord(out *Output, index int, s Solution) genbankRecord {
+	seq := strings.ToUpper(out.TargetSeq)
+	record := genbankRecord{
+		name:     filepath.Base(out.Target),
+		circular: true,
+		seq:      seq,
+		definition: fmt.Sprintf(
+			"%s, solution %d: %d fragments, $%.2f",
+			out.Target, index+1, s.Count, s.Cost,
+		),
+	}
+	if out.Enzyme != "" {
+		record.definition += fmt.Sprintf(", Golden Gate assembly with %s", out.Enzyme)
+	}
+
+	// the span of each fragment on the plasmid
+	spans := make([]span, len(s.Fragments))
+	for i, f := range s.Fragments {
+		spans[i] = fragSpan(f, seq)
+	}
+
+	for i, f := range s.Fragments {
+		name := fmt.Sprintf("fragment %d", i+1)
+		source := f.URL
+		if source == "" {
+			source = f.ID
 		}
 
-		s := (m.queryStart + 1) % len(seq)
-		e := (m.queryEnd + 1) % len(seq)
+		note := fmt.Sprintf("%s fragment", f.Type)
+		if f.Type == synthetic.String() {
+			note = "synthetic fragment"
+			if f.Vendor != "" {
+				note += " from " + f.Vendor
+			}
+		} else if source != "" {
+			note += " from " + source
+		}
+		note += fmt.Sprintf(", $%.2f", f.Cost)
+
+		record.features = append(record.features, genbankFeature{
+			key:        "misc_feature",
+			spans:      []span{spans[i]},
+			qualifiers: []qualifier{qualifier{"label", name}, qualifier{"note", note}},
+		})
+
+		// where the primers anneal
+		for _, p := range f.Primers {
+			near := spans[i].start
+			direction := "FWD"
+			if !p.Strand {
+				near = spans[i].end
+				direction = "REV"
+			}
 
-		if s == 0 {
-			s = len(seq)
+			if bind, ok := primerSpan(p, seq, near); ok {
+				record.features = append(record.features, genbankFeature{
+					key:   "primer_bind",
+					spans: []span{bind},
+					qualifiers: []qualifier{
+						qualifier{"label", fmt.Sprintf("%s %s primer", name, direction)},
+						qualifier{"note", fmt.Sprintf("5'-%s-3', Tm %.1f", p.Seq, p.Tm)},
+					},
+				})
+			}
 		}
-		if e == 0 {
-			e = len(seq)
+
+		// the sequence shared with the next fragment
+		if len(s.Fragments) > 1 {
+			next := (i + 1) % len(s.Fragments)
+			if junction, ok := overlap(spans[i], spans[next], len(seq)); ok {
+				note := fmt.Sprintf("homology between fragments %d and %d", i+1, next+1)
+				if f.overhang != "" {
+					note = fmt.Sprintf("%s overhang between fragments %d and %d", f.overhang, i+1, next+1)
+				}
+
+				record.features = append(record.features, genbankFeature{
+					key:   "misc_feature",
+					spans: []span{junction},
+					qualifiers: []qualifier{
+						qualifier{"label", fmt.Sprintf("junction %d-%d", i+1, next+1)},
+						qualifier{"note", note},
+					},
+				})
+			}
 		}
 
-		fsb.WriteString(
-			fmt.Sprintf("     misc_feature    %s%d..%d%s\n", cS, s, e, cE) +
-				fmt.Sprintf("                     /label=\"%s\"\n", m.entry),
-		)
+		// the ends of the linearized backbone
+		if bb := out.Backbone; bb != nil && len(bb.Enzymes) > 0 && f.Backbone {
+			ends := []struct {
+				index  int
+				enzyme string
+			}{
+				{spans[i].start, bb.Enzymes[0]},
+				{spans[i].end, bb.Enzymes[len(bb.Enzymes)-1]},
+			}
+			for _, end := range ends {
+				record.features = append(record.features, genbankFeature{
+					key:   "misc_feature",
+					spans: []span{span{start: end.index, end: end.index, forward: true}},
+					qualifiers: []qualifier{
+						qualifier{"label", end.enzyme + " cut site"},
+						qualifier{"note", fmt.Sprintf("end of the backbone linearized with %s", end.enzyme)},
+					},
+				})
+			}
+		}
 	}
 
-	// origin row
-	var ori strings.Builder
-	ori.WriteString("ORIGIN\n")
-	for i := 0; i < len(seq); i += 60 {
-		n := strconv.Itoa(i + 1)
-		ori.WriteString(strings.Repeat(" ", 9-len(n)) + n)
-		for s := i; s < i+60 && s < len(seq); s += 10 {
-			e := s + 10
-			if e > len(seq) {
-				e = len(seq)
-			}
-			ori.WriteString(fmt.Sprintf(" %s", seq[s:e]))
+	return record
+}
+
+// fragSpan returns the span of a fragment on the assembled plasmid. The fragment's sequence
+// is found in the plasmid nearest to the fragment's start, falling back to its start and end.
+func fragSpan(f *Frag, seq string) span {
+	whole := span{start: 0, end: len(seq) - 1, forward: true}
+	if len(seq) < 1 {
+		return whole
+	}
+
+	for _, fragSeq := range []string{f.PCRSeq, f.Seq} {
+		fragSeq = strings.ToUpper(fragSeq)
+		if fragSeq == "" {
+			continue
+		}
+		if len(fragSeq) >= len(seq) {
+			return whole
+		}
+		if start, ok := nearestIndex(seq, fragSeq, f.start); ok {
+			return span{start: start, end: (start + len(fragSeq) - 1) % len(seq), forward: true}
 		}
-		ori.WriteString("\n")
 	}
-	ori.WriteString("//\n")
 
-	gb := strings.Join([]string{header, fsb.String(), ori.String()}, "")
-	err := ioutil.WriteFile(filename, []byte(gb), 0644)
-	if err != nil {
-		stderr.Fatalln(err)
+	if f.end-f.start+1 >= len(seq) {
+		return whole
+	}
+	return span{start: mod(f.start, len(seq)), end: mod(f.end, len(seq)), forward: true}
+}
+
+// primerSpan returns the span where a primer anneals to the plasmid, nearest to an index.
+// The annealing sequence is the primer's 3' end and the bp before it that match the plasmid.
+func primerSpan(p Primer, seq string, near int) (span, bool) {
+	const minAnneal = 15
+
+	primer := strings.ToUpper(p.Seq)
+	if len(primer) < minAnneal || len(seq) < len(primer) {
+		return span{}, false
+	}
+
+	// indexes are on the middle copy of the plasmid so they can extend past its ends
+	tripled := seq + seq + seq
+	l := len(seq)
+
+	if p.Strand {
+		i, ok := nearestIndex(seq, primer[len(primer)-minAnneal:], near)
+		if !ok {
+			return span{}, false
+		}
+
+		// extend the annealing sequence towards the primer's 5' end
+		start, end := i+l, i+l+minAnneal-1
+		for j := len(primer) - minAnneal - 1; j >= 0 && tripled[start-1] == primer[j]; j-- {
+			start--
+		}
+		return span{start: start % l, end: end % l, forward: true}, true
+	}
+
+	// on the bottom strand, the primer's 3' end is its leftmost base on the plasmid
+	primer = reverseComplement(primer)
+	i, ok := nearestIndex(seq, primer[:minAnneal], near-len(primer))
+	if !ok {
+		return span{}, false
+	}
+
+	start, end := i+l, i+l+minAnneal-1
+	for j := minAnneal; j < len(primer) && tripled[end+1] == primer[j]; j++ {
+		end++
+	}
+	return span{start: start % l, end: end % l, forward: false}, true
+}
+
+// nearestIndex returns the index of the substring in the circular sequence that's nearest an index.
+func nearestIndex(seq, sub string, near int) (int, bool) {
+	if sub == "" || len(sub) > len(seq) {
+		return 0, false
+	}
+
+	near = mod(near, len(seq))
+	doubled := seq + seq[:len(sub)-1]
+
+	best, bestDistance := -1, len(seq)
+	for offset := 0; ; {
+		i := strings.Index(doubled[offset:], sub)
+		if i < 0 {
+			break
+		}
+		i += offset
+
+		distance := mod(i-near, len(seq))
+		if other := len(seq) - distance; other < distance {
+			distance = other
+		}
+		if distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+		offset = i + 1
+	}
+
+	return best, best >= 0
+}
+
+// overlap returns the span shared by two spans on a circular sequence, where the
+// second starts before the first ends.
+func overlap(first, second span, length int) (span, bool) {
+	size := mod(first.end-second.start, length) + 1
+	if size >= spanLength(first, length) || size >= spanLength(second, length) {
+		return span{}, false
 	}
+
+	return span{start: second.start, end: first.end, forward: true}, true
+}
+
+// spanLength returns the number of bp in a span on a circular sequence.
+func spanLength(s span, length int) int {
+	return mod(s.end-s.start, length) + 1
+}
+
+// mod returns the non-negative remainder of a divided by b.
+func mod(a, b int) int {
+	return ((a % b) + b) % b
 }
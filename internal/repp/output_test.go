@@ -1,50 +1,146 @@
 package repp
 
 import (
+	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+// testPlasmid is a plasmid sequence for writing Genbank files
+const testPlasmid = "aattgtgagcggataacaattgacattgtgagcggataacaagatactgagcacatactagagaaagaggagaaatactagatggtgagcaagggcgaggagctgttcaccggggtggtgcccatcctggtcgagctggacggcgacgtaaacggccacaagttcagcgtgtccggcgagggcgagggcgatgccacctacggcaagctgaccctgaagttcatctgcaccaccggcaagctgcccgtgccctggcccaccctcgtgaccaccttcggctacggcctgcaatgcttcgcccgctaccccgaccacatgaagctgcacgacttcttcaagtccgccatgcccgaaggctacgtccaggagcgcaccatcttcttcaaggacgacggcaactacaagacccgcgccgaggtgaagttcgagggcgacaccctggtgaaccgcatcgagctgaagggcatcgacttcaaggaggacggcaacatcctggggcacaagctggagtacaactacaacagccacaacgtctatatcatggccgacaagcagaagaacggcatcaaggtgaacttcaagatccgccacaacatcgaggacggcagcgtgcagctcgccgaccactaccagcagaacacccccatcggcgacggccccgtgctgctgcccgacaaccactacctgagctaccagtccgccctgagcaaagaccccaacgagaagcgcgatcacatggtcctgctggagttcgtgaccgccgccgggatcactctcggcatggacgagctgtacaagaggcctgctgcaaacgacgaaaactacgctttagtagcttaataatactagagtcacactggctcaccttcgggtgggcctttctgcgtttatatactagagagagaatataaaaagccagattattaatccggcttttttattattt"
+
 func Test_writeGenbank(t *testing.T) {
-	type args struct {
-		filename string
-		name     string
-		seq      string
-		frags    []*Frag
-		feats    []match
-	}
-	tests := []struct {
-		name string
-		args args
-	}{
-		{
-			"include forward and reverse fields",
-			args{
-				filepath.Join("..", "..", "test", "output", "writeGenbank.gb"),
-				"mock part",
-				"aattgtgagcggataacaattgacattgtgagcggataacaagatactgagcacatactagagaaagaggagaaatactagatggtgagcaagggcgaggagctgttcaccggggtggtgcccatcctggtcgagctggacggcgacgtaaacggccacaagttcagcgtgtccggcgagggcgagggcgatgccacctacggcaagctgaccctgaagttcatctgcaccaccggcaagctgcccgtgccctggcccaccctcgtgaccaccttcggctacggcctgcaatgcttcgcccgctaccccgaccacatgaagctgcacgacttcttcaagtccgccatgcccgaaggctacgtccaggagcgcaccatcttcttcaaggacgacggcaactacaagacccgcgccgaggtgaagttcgagggcgacaccctggtgaaccgcatcgagctgaagggcatcgacttcaaggaggacggcaacatcctggggcacaagctggagtacaactacaacagccacaacgtctatatcatggccgacaagcagaagaacggcatcaaggtgaacttcaagatccgccacaacatcgaggacggcagcgtgcagctcgccgaccactaccagcagaacacccccatcggcgacggccccgtgctgctgcccgacaaccactacctgagctaccagtccgccctgagcaaagaccccaacgagaagcgcgatcacatggtcctgctggagttcgtgaccgccgccgggatcactctcggcatggacgagctgtacaagaggcctgctgcaaacgacgaaaactacgctttagtagcttaataatactagagtcacactggctcaccttcgggtgggcctttctgcgtttatatactagagagagaatataaaaagccagattattaatccggcttttttattattt",
-				[]*Frag{},
-				[]match{
-					match{
-						entry:      "feature 1",
-						queryStart: 0,
-						queryEnd:   10,
-						forward:    true,
-					},
-					match{
-						entry:      "feature 2",
-						queryStart: 15,
-						queryEnd:   20,
-						forward:    false,
-					},
+	filename := filepath.Join("..", "..", "test", "output", "writeGenbank.gb")
+	feats := []match{
+		match{
+			entry:      "feature 1",
+			queryStart: 0,
+			queryEnd:   10,
+			forward:    true,
+		},
+		match{
+			entry:      "feature 2",
+			queryStart: 15,
+			queryEnd:   20,
+			forward:    false,
+		},
+	}
+
+	if err := writeGenbank(filename, annotatedRecord("mock part", testPlasmid, feats)); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// it's read back as it was written
+	records, err := parseGenbank(string(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].name != "mock_part" || records[0].seq != strings.ToUpper(testPlasmid) {
+		t.Fatalf("writeGenbank() wrote %+v", records)
+	}
+	if f := records[0].features[1]; f.name() != "feature 2" || f.location != "complement(16..21)" {
+		t.Errorf("writeGenbank() wrote feature %s at %s", f.name(), f.location)
+	}
+}
+
+func Test_solutionRecord(t *testing.T) {
+	seq := strings.ToUpper(testPlasmid)
+	out := &Output{Target: "target", TargetSeq: seq}
+	solution := Solution{
+		Count: 2,
+		Cost:  100,
+		Fragments: []*Frag{
+			&Frag{
+				Type:   "pcr",
+				URL:    "https://www.addgene.org/1/",
+				PCRSeq: seq[:500],
+				Primers: []Primer{
+					Primer{Seq: seq[:20], Strand: true},
+					Primer{Seq: reverseComplement(seq[480:500]), Strand: false},
 				},
+				start: 0,
+				end:   499,
+			},
+			&Frag{
+				Type:  "synthetic",
+				Seq:   seq[470:] + seq[:30],
+				start: 470,
+				end:   len(seq) + 29,
 			},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			writeGenbank(tt.args.filename, tt.args.name, tt.args.seq, tt.args.frags, tt.args.feats)
-		})
+	record := solutionRecord(out, 0, solution)
+
+	want := map[string]string{
+		"fragment 1":            "1..500",
+		"fragment 1 FWD primer": "1..20",
+		"fragment 1 REV primer": "complement(481..500)",
+		"junction 1-2":          "471..500",
+		"fragment 2":            fmt.Sprintf("join(471..%d,1..30)", len(seq)),
+		"junction 2-1":          "1..30",
+	}
+	for _, f := range record.features {
+		location := formatLocation(f.spans, len(seq))
+		if wantLocation, ok := want[f.name()]; !ok || location != wantLocation {
+			t.Errorf("solutionRecord() has %s at %s, want %s", f.name(), location, wantLocation)
+		}
+		delete(want, f.name())
+	}
+	for name := range want {
+		t.Errorf("solutionRecord() is missing %s", name)
+	}
+
+	if note, _ := record.features[0].qualifier("note"); note != "pcr fragment from https://www.addgene.org/1/, $0.00" {
+		t.Errorf("solutionRecord() fragment note = %s", note)
+	}
+}
+
+func Test_solutionRecord_localBackbone(t *testing.T) {
+	seq := strings.ToUpper(testPlasmid)
+	out := &Output{
+		Target:    "target",
+		TargetSeq: seq,
+		Backbone:  &Backbone{Seq: seq, Enzymes: []string{"EcoRI"}, Cutsites: []int{0}, Strands: []bool{true}},
+	}
+	solution := Solution{
+		Count: 2,
+		Cost:  100,
+		Fragments: []*Frag{
+			&Frag{
+				ID:       "backbone.fa",
+				Type:     "linear",
+				Seq:      seq[:500],
+				Backbone: true,
+				start:    0,
+				end:      499,
+			},
+			&Frag{
+				Type:  "synthetic",
+				Seq:   seq[470:] + seq[:30],
+				start: 470,
+				end:   len(seq) + 29,
+			},
+		},
+	}
+
+	record := solutionRecord(out, 0, solution)
+
+	var cutsites []string
+	for _, f := range record.features {
+		if f.name() == "EcoRI cut site" {
+			cutsites = append(cutsites, formatLocation(f.spans, len(seq)))
+		}
+	}
+	if want := []string{"1", "500"}; !reflect.DeepEqual(cutsites, want) {
+		t.Errorf("solutionRecord() has cut sites at %v, want %v", cutsites, want)
 	}
 }
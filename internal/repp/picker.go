@@ -0,0 +1,448 @@
+package repp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pickPrimers picks primers like primer3_core, for the input tags that repp uses, and
+// returns primer3's output tags for them. Primers are penalized by their distance from
+// the optimal size and Tm and pairs by the sum of their primers' penalties, primer3's
+// defaults. Tms are from primerTm and hairpins and dimers from thal, both in primer3's
+// default conditions.
+//
+// Primers are fixed in place with pick_cloning_primers, SEQUENCE_FORCE_LEFT_START and
+// SEQUENCE_FORCE_RIGHT_START or picked from SEQUENCE_PRIMER_PAIR_OK_REGION_LIST. Primers
+// can be specified with SEQUENCE_PRIMER and SEQUENCE_PRIMER_REVCOMP.
+func pickPrimers(in map[string]string) map[string]string {
+	s, err := newPickSettings(in)
+	if err != nil {
+		return map[string]string{"PRIMER_ERROR": err.Error()}
+	}
+
+	pairs := s.pairs()
+
+	out := map[string]string{
+		"PRIMER_LEFT_NUM_RETURNED":     strconv.Itoa(len(pairs)),
+		"PRIMER_RIGHT_NUM_RETURNED":    strconv.Itoa(len(pairs)),
+		"PRIMER_INTERNAL_NUM_RETURNED": "0",
+		"PRIMER_PAIR_NUM_RETURNED":     strconv.Itoa(len(pairs)),
+	}
+	for i, pair := range pairs {
+		left, right := pair[0], pair[1]
+		s.check(left)
+		s.check(right)
+
+		out[fmt.Sprintf("PRIMER_PAIR_%d_PENALTY", i)] = fmt.Sprintf("%f", left.penalty+right.penalty)
+		out[fmt.Sprintf("PRIMER_PAIR_%d_COMPL_ANY_TH", i)] = fmt.Sprintf("%.2f", pairAny(left, right))
+		out[fmt.Sprintf("PRIMER_PAIR_%d_COMPL_END_TH", i)] = fmt.Sprintf("%.2f", pairEnd(left, right))
+		out[fmt.Sprintf("PRIMER_PAIR_%d_PRODUCT_SIZE", i)] = strconv.Itoa(right.pos - left.pos + 1)
+
+		for side, c := range map[string]*pickCandidate{"LEFT": left, "RIGHT": right} {
+			out[fmt.Sprintf("PRIMER_%s_%d_SEQUENCE", side, i)] = c.seq
+			out[fmt.Sprintf("PRIMER_%s_%d", side, i)] = fmt.Sprintf("%d,%d", c.pos, len(c.seq))
+			out[fmt.Sprintf("PRIMER_%s_%d_PENALTY", side, i)] = fmt.Sprintf("%f", c.penalty)
+			out[fmt.Sprintf("PRIMER_%s_%d_TM", side, i)] = fmt.Sprintf("%.3f", c.tm)
+			out[fmt.Sprintf("PRIMER_%s_%d_GC_PERCENT", side, i)] = fmt.Sprintf("%.3f", c.gc)
+			out[fmt.Sprintf("PRIMER_%s_%d_SELF_ANY_TH", side, i)] = fmt.Sprintf("%.2f", c.selfAny)
+			out[fmt.Sprintf("PRIMER_%s_%d_SELF_END_TH", side, i)] = fmt.Sprintf("%.2f", c.selfEnd)
+			out[fmt.Sprintf("PRIMER_%s_%d_HAIRPIN_TH", side, i)] = fmt.Sprintf("%.2f", c.hairpin)
+		}
+	}
+
+	return out
+}
+
+// pickSettings are the settings of pickPrimers, from primer3's input tags
+type pickSettings struct {
+	template string
+
+	// the first bp and length of the template that primers can be in
+	includedStart, includedLength int
+
+	// whether to keep primers that break the constraints if they're fixed in place
+	pickAnyway bool
+
+	numReturn int
+
+	minSize, optSize, maxSize int
+	minTm, optTm, maxTm       float64
+	minGC, maxGC              float64
+	maxPolyX                  int
+
+	// the highest melting temperatures of hairpins and dimers
+	maxHairpin, maxSelfAny, maxSelfEnd, maxPairAny, maxPairEnd float64
+
+	// the ranges of product sizes
+	productSizes [][2]int
+
+	// regions where the left and right primers can be, see parseOkRegions
+	okRegions [][4]int
+
+	// the first bp of the left primer and the last bp of the right one, or -1
+	forceLeft, forceRight int
+
+	// primers that have to be used, the right one is as it's ordered
+	left, right string
+}
+
+// newPickSettings reads the settings from the input tags, with primer3's defaults
+func newPickSettings(in map[string]string) (*pickSettings, error) {
+	s := &pickSettings{
+		template:   strings.ToUpper(in["SEQUENCE_TEMPLATE"]),
+		pickAnyway: in["PRIMER_PICK_ANYWAY"] == "1",
+		forceLeft:  -1,
+		forceRight: -1,
+		left:       strings.ToUpper(in["SEQUENCE_PRIMER"]),
+		right:      strings.ToUpper(in["SEQUENCE_PRIMER_REVCOMP"]),
+	}
+	if s.template == "" {
+		return nil, fmt.Errorf("missing SEQUENCE_TEMPLATE")
+	}
+
+	var err error
+	integer := func(key string, def int) int {
+		val, ok := in[key]
+		if !ok || err != nil {
+			return def
+		}
+		var i int
+		if i, err = strconv.Atoi(strings.TrimSpace(val)); err != nil {
+			err = fmt.Errorf("illegal value for %s: %s", key, val)
+		}
+		return i
+	}
+	float := func(key string, def float64) float64 {
+		val, ok := in[key]
+		if !ok || err != nil {
+			return def
+		}
+		var f float64
+		if f, err = strconv.ParseFloat(strings.TrimSpace(val), 64); err != nil {
+			err = fmt.Errorf("illegal value for %s: %s", key, val)
+		}
+		return f
+	}
+
+	s.numReturn = integer("PRIMER_NUM_RETURN", 5)
+	s.minSize = integer("PRIMER_MIN_SIZE", 18)
+	s.optSize = integer("PRIMER_OPT_SIZE", 20)
+	s.maxSize = integer("PRIMER_MAX_SIZE", 27)
+	s.minTm = float("PRIMER_MIN_TM", 57)
+	s.optTm = float("PRIMER_OPT_TM", 60)
+	s.maxTm = float("PRIMER_MAX_TM", 63)
+	s.minGC = float("PRIMER_MIN_GC", 20)
+	s.maxGC = float("PRIMER_MAX_GC", 80)
+	s.maxPolyX = integer("PRIMER_MAX_POLY_X", 5)
+	s.maxHairpin = float("PRIMER_MAX_HAIRPIN_TH", 47)
+	s.maxSelfAny = float("PRIMER_MAX_SELF_ANY_TH", 47)
+	s.maxSelfEnd = float("PRIMER_MAX_SELF_END_TH", 47)
+	s.maxPairAny = float("PRIMER_PAIR_MAX_COMPL_ANY_TH", 47)
+	s.maxPairEnd = float("PRIMER_PAIR_MAX_COMPL_END_TH", 47)
+	s.forceLeft = integer("SEQUENCE_FORCE_LEFT_START", -1)
+	s.forceRight = integer("SEQUENCE_FORCE_RIGHT_START", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	s.includedStart, s.includedLength = 0, len(s.template)
+	if included, ok := in["SEQUENCE_INCLUDED_REGION"]; ok {
+		region := strings.Split(included, ",")
+		if len(region) != 2 {
+			return nil, fmt.Errorf("illegal value for SEQUENCE_INCLUDED_REGION: %s", included)
+		}
+		s.includedStart, _ = strconv.Atoi(strings.TrimSpace(region[0]))
+		s.includedLength, _ = strconv.Atoi(strings.TrimSpace(region[1]))
+	}
+	if s.includedStart < 0 || s.includedLength < 1 || s.includedStart+s.includedLength > len(s.template) {
+		return nil, fmt.Errorf("SEQUENCE_INCLUDED_REGION isn't in the template")
+	}
+
+	if s.productSizes, err = parseProductSizes(in["PRIMER_PRODUCT_SIZE_RANGE"]); err != nil {
+		return nil, err
+	}
+
+	switch in["PRIMER_TASK"] {
+	case "pick_cloning_primers":
+		// primers are at the ends of the included region
+		s.forceLeft = s.includedStart
+		s.forceRight = s.includedStart + s.includedLength - 1
+		s.okRegions = [][4]int{{-1, -1, -1, -1}}
+	case "", "generic":
+		if s.okRegions, err = parseOkRegions(in["SEQUENCE_PRIMER_PAIR_OK_REGION_LIST"]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PRIMER_TASK: %s", in["PRIMER_TASK"])
+	}
+
+	return s, nil
+}
+
+// parseProductSizes parses ranges of product sizes like "100-300 400-500"
+func parseProductSizes(sizes string) ([][2]int, error) {
+	if strings.TrimSpace(sizes) == "" {
+		return [][2]int{{100, 300}}, nil // primer3's default
+	}
+
+	var ranges [][2]int
+	for _, r := range strings.Fields(sizes) {
+		bounds := strings.Split(r, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("illegal value for PRIMER_PRODUCT_SIZE_RANGE: %s", sizes)
+		}
+		min, err1 := strconv.Atoi(bounds[0])
+		max, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil || min > max {
+			return nil, fmt.Errorf("illegal value for PRIMER_PRODUCT_SIZE_RANGE: %s", sizes)
+		}
+		ranges = append(ranges, [2]int{min, max})
+	}
+	return ranges, nil
+}
+
+// parseOkRegions parses regions like "left start,left length,right start,right length ;".
+// Missing values, where either primer can be anywhere, are -1
+func parseOkRegions(regions string) ([][4]int, error) {
+	var okRegions [][4]int
+	for _, region := range strings.Split(regions, ";") {
+		if strings.TrimSpace(region) == "" {
+			continue
+		}
+
+		values := strings.Split(region, ",")
+		if len(values) != 4 {
+			return nil, fmt.Errorf("illegal value for SEQUENCE_PRIMER_PAIR_OK_REGION_LIST: %s", regions)
+		}
+
+		var okRegion [4]int
+		for i, val := range values {
+			okRegion[i] = -1
+			if val = strings.TrimSpace(val); val != "" {
+				var err error
+				if okRegion[i], err = strconv.Atoi(val); err != nil {
+					return nil, fmt.Errorf("illegal value for SEQUENCE_PRIMER_PAIR_OK_REGION_LIST: %s", regions)
+				}
+			}
+		}
+		okRegions = append(okRegions, okRegion)
+	}
+
+	if len(okRegions) == 0 {
+		okRegions = [][4]int{{-1, -1, -1, -1}}
+	}
+	return okRegions, nil
+}
+
+// pickCandidate is a primer that might be picked
+type pickCandidate struct {
+	seq string
+
+	// pos is the first bp of a left primer or the last bp of a right primer in the template
+	pos int
+
+	tm, gc, penalty float64
+
+	// fixed is whether the primer is fixed in place or specified. With PRIMER_PICK_ANYWAY
+	// it's kept even if it breaks the constraints
+	fixed bool
+
+	// checked is whether its hairpin and self dimers have been calculated, and ok
+	// whether they're beneath the maximums
+	checked, ok               bool
+	hairpin, selfAny, selfEnd float64
+}
+
+// pairs returns the pairs of primers with the lowest penalties, up to numReturn of them
+func (s *pickSettings) pairs() (pairs [][2]*pickCandidate) {
+	type scored struct {
+		left, right *pickCandidate
+		penalty     float64
+	}
+	var best []scored
+
+	for _, region := range s.okRegions {
+		lefts := s.candidates(true, region[0], region[1])
+		rights := s.candidates(false, region[2], region[3])
+
+		for _, left := range lefts {
+			for _, right := range rights {
+				penalty := left.penalty + right.penalty
+				if len(best) == s.numReturn && !penaltyLess(penalty, best[len(best)-1].penalty) {
+					break // rights are sorted by penalty, none are better
+				}
+
+				if !s.productSizeOk(right.pos-left.pos+1) || !s.check(left) || !s.check(right) {
+					continue
+				}
+				if pairAny(left, right) > s.maxPairAny || pairEnd(left, right) > s.maxPairEnd {
+					if !(s.pickAnyway && left.fixed && right.fixed) {
+						continue
+					}
+				}
+
+				best = append(best, scored{left, right, penalty})
+				sort.SliceStable(best, func(i, j int) bool { return penaltyLess(best[i].penalty, best[j].penalty) })
+				if len(best) > s.numReturn {
+					best = best[:s.numReturn]
+				}
+			}
+		}
+	}
+
+	for _, b := range best {
+		pairs = append(pairs, [2]*pickCandidate{b.left, b.right})
+	}
+	return pairs
+}
+
+// candidates returns the left or right primers that can be in a region, sorted by their
+// penalty. A region with a start of -1 is the included region.
+func (s *pickSettings) candidates(left bool, regionStart, regionLength int) (candidates []*pickCandidate) {
+	start, end := s.includedStart, s.includedStart+s.includedLength-1
+	if regionStart >= 0 {
+		if regionStart > start {
+			start = regionStart
+		}
+		if last := regionStart + regionLength - 1; last < end {
+			end = last
+		}
+	}
+
+	add := func(seq string, pos int, fixed bool) {
+		c := &pickCandidate{seq: seq, pos: pos, fixed: fixed}
+		if !s.oligoOk(c) && !(fixed && s.pickAnyway) {
+			return
+		}
+		candidates = append(candidates, c)
+	}
+
+	specified, force := s.left, s.forceLeft
+	if !left {
+		specified, force = s.right, s.forceRight
+	}
+
+	if specified != "" {
+		// the specified primer wherever it binds in the region
+		site := specified
+		if !left {
+			site = reverseComplement(specified)
+		}
+		for i := start; i+len(site)-1 <= end; i++ {
+			if s.template[i:i+len(site)] != site {
+				continue
+			}
+
+			pos := i
+			if !left {
+				pos = i + len(site) - 1
+			}
+			if force < 0 || force == pos {
+				add(specified, pos, true)
+			}
+		}
+	} else {
+		for size := s.minSize; size <= s.maxSize; size++ {
+			for first := start; first+size-1 <= end; first++ {
+				pos, seq := first, s.template[first:first+size]
+				if !left {
+					pos, seq = first+size-1, reverseComplement(seq)
+				}
+				if force >= 0 && force != pos {
+					continue
+				}
+				add(seq, pos, force >= 0)
+			}
+		}
+	}
+
+	// like primer3, ties go to the primers nearest the middle of the template
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if penaltyLess(a.penalty, b.penalty) || penaltyLess(b.penalty, a.penalty) {
+			return penaltyLess(a.penalty, b.penalty)
+		}
+		if left {
+			return a.pos > b.pos
+		}
+		return a.pos < b.pos
+	})
+	return candidates
+}
+
+// oligoOk calculates the Tm, GC and penalty of a primer and returns whether they, its
+// size and its sequence are within the constraints
+func (s *pickSettings) oligoOk(c *pickCandidate) bool {
+	c.tm = primerTm(c.seq)
+	c.gc = gcPercent(c.seq)
+	c.penalty = math.Abs(c.tm-s.optTm) + math.Abs(float64(len(c.seq)-s.optSize))
+
+	if len(c.seq) < s.minSize || len(c.seq) > s.maxSize {
+		return false
+	}
+	if c.tm < s.minTm || c.tm > s.maxTm || c.gc < s.minGC || c.gc > s.maxGC {
+		return false
+	}
+	if strings.ContainsAny(c.seq, "N") {
+		return false
+	}
+
+	run := 1
+	for i := 1; i < len(c.seq); i++ {
+		if c.seq[i] == c.seq[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > s.maxPolyX {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check calculates a primer's hairpin and self dimers, once, and returns whether
+// they're beneath the maximums. Like primer3, melting temperatures are at least 0
+func (s *pickSettings) check(c *pickCandidate) bool {
+	if !c.checked {
+		c.checked = true
+		c.hairpin = math.Max(0, thalHairpin(c.seq, primer3Conditions))
+		c.selfAny = math.Max(0, thalDimer(c.seq, c.seq, false, primer3Conditions))
+		c.selfEnd = math.Max(0, thalDimer(c.seq, c.seq, true, primer3Conditions))
+		c.ok = c.hairpin <= s.maxHairpin && c.selfAny <= s.maxSelfAny && c.selfEnd <= s.maxSelfEnd
+	}
+	return c.ok || (c.fixed && s.pickAnyway)
+}
+
+// penaltyLess returns whether penalty a is less than b. Penalties within rounding error
+// of one another are ties
+func penaltyLess(a, b float64) bool {
+	return a < b-1e-9
+}
+
+// productSizeOk returns whether a product's size is in one of the ranges
+func (s *pickSettings) productSizeOk(size int) bool {
+	for _, r := range s.productSizes {
+		if size >= r[0] && size <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// pairAny is the melting temperature of the most stable duplex between two primers
+func pairAny(left, right *pickCandidate) float64 {
+	return math.Max(0, thalDimer(left.seq, right.seq, false, primer3Conditions))
+}
+
+// pairEnd is the melting temperature of the most stable duplex with the end of either primer
+func pairEnd(left, right *pickCandidate) float64 {
+	return math.Max(
+		math.Max(0, thalDimer(left.seq, right.seq, true, primer3Conditions)),
+		thalDimer(right.seq, left.seq, true, primer3Conditions),
+	)
+}
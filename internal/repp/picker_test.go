@@ -0,0 +1,140 @@
+package repp
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+// pickTemplate is the template of the primer picking tests
+const pickTemplate = "GGGGGAACGCTGAAGATCTCTTCTTCTCATGACTGAACTCGCGAGGGTCGTGATGTCGGTTCCTTCAAAGGTTAAAGAACAAAGGCTTACTGTGCGCAGAGGAACGCCCATTTAGCGGCTGGCGTCTTGAATCCTCGGTCCCCCTTGTCTTTCCAGATTAATCCATTTCCCTCATTCACGAGCTTACCAAGTCAACATTGGTATATGAATGCGACCTTGAAGAGGCCGCTTAAAAATGGCAGTGGTTGAT"
+
+// pickInputs are primer3 input tags like the ones from primer3.settings
+var pickInputs = []map[string]string{
+	{
+		"SEQUENCE_TEMPLATE":         pickTemplate,
+		"PRIMER_TASK":               "pick_cloning_primers",
+		"PRIMER_PICK_ANYWAY":        "1",
+		"PRIMER_NUM_RETURN":         "1",
+		"SEQUENCE_INCLUDED_REGION":  "10,200",
+		"PRIMER_PRODUCT_SIZE_RANGE": "200-200",
+		"PRIMER_MIN_SIZE":           "18",
+		"PRIMER_OPT_SIZE":           "20",
+		"PRIMER_MAX_SIZE":           "30",
+		"PRIMER_MIN_TM":             "47.0",
+		"PRIMER_MAX_TM":             "73.0",
+	},
+	{
+		"SEQUENCE_TEMPLATE":                   pickTemplate,
+		"PRIMER_TASK":                         "generic",
+		"PRIMER_NUM_RETURN":                   "1",
+		"SEQUENCE_PRIMER_PAIR_OK_REGION_LIST": "0,60,180,60 ;",
+		"PRIMER_PRODUCT_SIZE_RANGE":           "120-240",
+	},
+	{
+		"SEQUENCE_TEMPLATE":                   pickTemplate,
+		"PRIMER_TASK":                         "generic",
+		"PRIMER_PICK_ANYWAY":                  "1",
+		"PRIMER_NUM_RETURN":                   "1",
+		"SEQUENCE_PRIMER":                     "GAACGCTGAAGATCTCTTCTTCTC",
+		"SEQUENCE_PRIMER_PAIR_OK_REGION_LIST": ",,180,60 ;",
+		"PRIMER_PRODUCT_SIZE_RANGE":           "120-240",
+	},
+}
+
+func Test_pickPrimers(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]string
+		want map[string]string
+	}{
+		{
+			"pick cloning primers at the ends of the included region",
+			pickInputs[0],
+			map[string]string{
+				"PRIMER_PAIR_NUM_RETURNED":   "1",
+				"PRIMER_LEFT_0":              "10,26",
+				"PRIMER_LEFT_0_SEQUENCE":     "TGAAGATCTCTTCTTCTCATGACTGA",
+				"PRIMER_RIGHT_0":             "209,25",
+				"PRIMER_RIGHT_0_SEQUENCE":    "ATTCATATACCAATGTTGACTTGGT",
+				"PRIMER_PAIR_0_PENALTY":      "14.860992",
+				"PRIMER_PAIR_0_PRODUCT_SIZE": "200",
+				"PRIMER_LEFT_0_TM":           "59.337",
+				"PRIMER_LEFT_0_HAIRPIN_TH":   "53.47",
+			},
+		},
+		{
+			"pick primers in ok regions",
+			pickInputs[1],
+			map[string]string{
+				"PRIMER_PAIR_NUM_RETURNED":   "1",
+				"PRIMER_LEFT_0":              "26,20",
+				"PRIMER_LEFT_0_SEQUENCE":     "TCATGACTGAACTCGCGAGG",
+				"PRIMER_RIGHT_0":             "239,20",
+				"PRIMER_RIGHT_0_SEQUENCE":    "GCCATTTTTAAGCGGCCTCT",
+				"PRIMER_PAIR_0_PENALTY":      "0.988885",
+				"PRIMER_PAIR_0_PRODUCT_SIZE": "214",
+				"PRIMER_LEFT_0_SELF_ANY_TH":  "36.93",
+			},
+		},
+		{
+			"pick a primer to pair with a specified one",
+			pickInputs[2],
+			map[string]string{
+				"PRIMER_PAIR_NUM_RETURNED":   "1",
+				"PRIMER_LEFT_0":              "4,24",
+				"PRIMER_LEFT_0_SEQUENCE":     "GAACGCTGAAGATCTCTTCTTCTC",
+				"PRIMER_RIGHT_0_SEQUENCE":    "GCCATTTTTAAGCGGCCTCT",
+				"PRIMER_PAIR_0_PRODUCT_SIZE": "236",
+			},
+		},
+		{
+			"fail without a template",
+			map[string]string{"PRIMER_TASK": "generic"},
+			map[string]string{"PRIMER_ERROR": "missing SEQUENCE_TEMPLATE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickPrimers(tt.in)
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("pickPrimers() %s = %s, want %s", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+// Test_nativeThermo_primer3 checks native primer picking against primer3_core, if it's in PATH
+func Test_nativeThermo_primer3(t *testing.T) {
+	if _, err := exec.LookPath("primer3_core"); err != nil {
+		t.Skip("no primer3_core executable in PATH")
+	}
+
+	for _, in := range pickInputs {
+		want, err := primer3Thermo{}.pick(context.Background(), in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := nativeThermo{}.pick(context.Background(), in)
+
+		for _, key := range []string{
+			"PRIMER_PAIR_NUM_RETURNED",
+			"PRIMER_LEFT_0",
+			"PRIMER_LEFT_0_SEQUENCE",
+			"PRIMER_RIGHT_0",
+			"PRIMER_RIGHT_0_SEQUENCE",
+			"PRIMER_PAIR_0_PENALTY",
+			"PRIMER_LEFT_0_TM",
+			"PRIMER_RIGHT_0_TM",
+			"PRIMER_LEFT_0_HAIRPIN_TH",
+			"PRIMER_PAIR_0_COMPL_ANY_TH",
+			"PRIMER_PAIR_0_COMPL_END_TH",
+		} {
+			if got[key] != want[key] {
+				t.Errorf("pick() %s = %s, primer3_core = %s", key, got[key], want[key])
+			}
+		}
+	}
+}
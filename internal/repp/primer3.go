@@ -2,11 +2,9 @@ package repp
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"math"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -27,37 +25,34 @@ type primer3 struct {
 	// the target sequence
 	seq string
 
-	// input file
-	in *os.File
+	// input tags
+	in map[string]string
 
-	// output file
-	out *os.File
+	// output tags
+	out map[string]string
 
-	// path to primer3 executable
-	primer3Path string
+	// thermo picks the primers
+	thermo thermo
 
-	// path to primer3 config folder (with trailing separator)
-	primer3ConfDir string
+	// left is the FWD primer from the inventory, if one binds where the PCR starts
+	left *Primer
+
+	// right is the REV primer from the inventory, if one binds where the PCR ends
+	right *Primer
 }
 
 // newPrimer3 creates a primer3 struct from a fragment
 func newPrimer3(last, this, next *Frag, seq string, conf *config.Config) primer3 {
-	in, _ := ioutil.TempFile("", "primer3-in-*")
-	out, _ := ioutil.TempFile("", "primer3-out-*")
-
 	return primer3{
-		f:              this,
-		last:           last,
-		next:           next,
-		seq:            strings.ToUpper(seq),
-		in:             in,
-		out:            out,
-		primer3Path:    "primer3_core",
-		primer3ConfDir: config.Primer3Config,
+		f:      this,
+		last:   last,
+		next:   next,
+		seq:    strings.ToUpper(seq),
+		thermo: newThermo(conf),
 	}
 }
 
-// input makes a primer3 input settings file and writes it to the filesystem
+// input makes the primer3 input settings
 //
 // the primers on this Frag should account for creating homology
 // against the last Frag and the next Frag if there isn't enough
@@ -73,6 +68,9 @@ func (p *primer3) input(minHomology, maxHomology, maxEmbedLength, minLength, pcr
 	addLeft = p.bpToAdd(p.last, p.f)
 	addRight = p.bpToAdd(p.f, p.next)
 
+	// use primers from the inventory if they bind where the PCR starts or ends
+	p.left, p.right = primerInventory().bind(p.f, p.seq, addLeft, addRight, p.f.conf)
+
 	start := p.f.start
 	length := p.f.end - start + 1
 
@@ -94,10 +92,24 @@ func (p *primer3) input(minHomology, maxHomology, maxEmbedLength, minLength, pcr
 		rightBuffer = 0
 	}
 
+	// primers from the inventory are fixed in place. If one is too long for primer3
+	// to design around, primer3 picks both
+	if p.left != nil {
+		leftBuffer = 0
+	}
+	if p.right != nil {
+		rightBuffer = 0
+	}
+	if p.left != nil && p.right != nil {
+		return // primer3 isn't needed
+	}
+	if (p.left != nil && len(p.left.Seq) > primerMax) || (p.right != nil && len(p.right.Seq) > primerMax) {
+		p.left, p.right = nil, nil
+	}
+
 	// create the settings map from all instructions
-	file, err := p.settings(
+	p.in = p.settings(
 		p.seq,
-		p.primer3ConfDir,
 		start,
 		length,
 		primerMin,
@@ -106,13 +118,6 @@ func (p *primer3) input(minHomology, maxHomology, maxEmbedLength, minLength, pcr
 		leftBuffer,
 		rightBuffer,
 	)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	if _, err := p.in.Write(file); err != nil {
-		return 0, 0, fmt.Errorf("failed to write primer3 input file %v: ", err)
-	}
 
 	return
 }
@@ -190,30 +195,29 @@ func (p *primer3) buffer(dist, minHomology, maxEmbedLength, pcrBuffer int) (buff
 	return 0
 }
 
-// settingsMap returns a new settings map for the primer3 config files
+// settings returns a new settings map of primer3 input tags
 // can either use pick_cloning_primers mode, if the start and end primers' locations
 // are fixed, or pick_primer_list mode if we're letting the primers shift and allowing
 // primer3 to pick the best ones. One side may be free to move and the other not
 func (p *primer3) settings(
-	seq, p3conf string,
+	seq string,
 	start, length, primerMin, primerOpt, primerMax, leftBuffer, rightBuffer int,
-) (file []byte, err error) {
+) map[string]string {
 	// see primer3 manual or /vendor/primer3-2.4.0/settings_files/p3_th_settings.txt
 	settings := map[string]string{
-		"SEQUENCE_ID":                          p.f.ID,
-		"PRIMER_THERMODYNAMIC_PARAMETERS_PATH": p3conf,
-		"PRIMER_NUM_RETURN":                    "1",
-		"PRIMER_PICK_ANYWAY":                   "1",
-		"SEQUENCE_TEMPLATE":                    seq + seq,               // TODO
-		"PRIMER_MIN_SIZE":                      strconv.Itoa(primerMin), // default 18
-		"PRIMER_OPT_SIZE":                      strconv.Itoa(primerOpt),
-		"PRIMER_MAX_SIZE":                      strconv.Itoa(primerMax),
-		"PRIMER_EXPLAIN_FLAG":                  "1",
-		"PRIMER_MIN_TM":                        "47.0",                                              // defaults to 57.0
-		"PRIMER_MAX_TM":                        "73.0",                                              // defaults to 63.0
-		"PRIMER_MAX_HAIRPIN_TH":                fmt.Sprintf("%f", p.f.conf.FragmentsMaxHairpinMelt), // defaults to 47.0
-		"PRIMER_MAX_POLY_X":                    "7",                                                 // defaults to 5
-		"PRIMER_PAIR_MAX_COMPL_ANY":            "13.0",                                              // defaults to 8.00
+		"SEQUENCE_ID":               p.f.ID,
+		"PRIMER_NUM_RETURN":         "1",
+		"PRIMER_PICK_ANYWAY":        "1",
+		"SEQUENCE_TEMPLATE":         seq + seq,               // TODO
+		"PRIMER_MIN_SIZE":           strconv.Itoa(primerMin), // default 18
+		"PRIMER_OPT_SIZE":           strconv.Itoa(primerOpt),
+		"PRIMER_MAX_SIZE":           strconv.Itoa(primerMax),
+		"PRIMER_EXPLAIN_FLAG":       "1",
+		"PRIMER_MIN_TM":             "47.0",                                              // defaults to 57.0
+		"PRIMER_MAX_TM":             "73.0",                                              // defaults to 63.0
+		"PRIMER_MAX_HAIRPIN_TH":     fmt.Sprintf("%f", p.f.conf.FragmentsMaxHairpinMelt), // defaults to 47.0
+		"PRIMER_MAX_POLY_X":         "7",                                                 // defaults to 5
+		"PRIMER_PAIR_MAX_COMPL_ANY": "13.0",                                              // defaults to 8.00
 	}
 
 	// if there is room to optimize, we let primer3 pick the best primers available
@@ -256,29 +260,20 @@ func (p *primer3) settings(
 		settings["PRIMER_PRODUCT_SIZE_RANGE"] = fmt.Sprintf("%d-%d", length, length)
 	}
 
-	var fileBuffer bytes.Buffer
-	for key, val := range settings {
-		fmt.Fprintf(&fileBuffer, "%s=%s\n", key, val)
+	// design around a primer from the inventory
+	if p.left != nil {
+		settings["SEQUENCE_PRIMER"] = p.left.Seq
+	}
+	if p.right != nil {
+		settings["SEQUENCE_PRIMER_REVCOMP"] = p.right.Seq
 	}
-	fileBuffer.WriteString("=") // required at file's end
 
-	return fileBuffer.Bytes(), nil
+	return settings
 }
 
-// run the primer3 executable against the input file
-func (p *primer3) run() (err error) {
-	p3Cmd := exec.Command(
-		p.primer3Path,
-		p.in.Name(),
-		"-output", p.out.Name(),
-		"-strict_tags",
-	)
-
-	// execute primer3 and wait on it to finish
-	if output, err := p3Cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to execute primer3 on input file %s: %s: %v", p.in.Name(), string(output), err)
-	}
-
+// run primer3 against the input settings
+func (p *primer3) run(ctx context.Context) (err error) {
+	p.out, err = p.thermo.pick(ctx, p.in)
 	return
 }
 
@@ -286,24 +281,8 @@ func (p *primer3) run() (err error) {
 //
 // target is the target sequence we're building for. We need it to modulo the primer ranges
 func (p *primer3) parse(target string) (err error) {
-	// delete the temporary input and output primer3 files
-	defer os.Remove(p.in.Name())
-	defer os.Remove(p.out.Name())
-
-	fileBytes, err := ioutil.ReadFile(p.out.Name())
-	if err != nil {
-		return
-	}
-	file := string(fileBytes)
-
-	// read in results into map, they're all 1:1
-	results := make(map[string]string)
-	for _, line := range strings.Split(file, "\n") {
-		keyVal := strings.Split(line, "=")
-		if len(keyVal) > 1 {
-			results[strings.TrimSpace(keyVal[0])] = strings.TrimSpace(keyVal[1])
-		}
-	}
+	results := p.out
+	file := primer3Tags(p.in)
 
 	if p3Warnings := results["PRIMER_WARNING"]; p3Warnings != "" {
 		return fmt.Errorf("warnings executing primer3: %s", p3Warnings)
@@ -358,48 +337,39 @@ func (p *primer3) parse(target string) (err error) {
 		parsePrimer("RIGHT"),
 	}
 
+	// the primer from the inventory that primer3 designed around
+	if p.left != nil && p.f.Primers[0].Seq == p.left.Seq {
+		p.f.Primers[0].Inventory = p.left.Inventory
+	}
+	if p.right != nil && p.f.Primers[1].Seq == p.right.Seq {
+		p.f.Primers[1].Inventory = p.right.Inventory
+	}
+
 	return
 }
 
 // hairpin finds the melting temperature of a hairpin in a sequence
 // returns 0 if there is none
-func hairpin(seq string, conf *config.Config) (melt float64) {
+func hairpin(ctx context.Context, seq string, conf *config.Config) (melt float64, err error) {
 	// if it's longer than 60bp (max for ntthal) find the max between
 	// the start and end of the sequence
 	if len(seq) > 60 {
-		startHairpin := hairpin(seq[:60], conf)
-		endHairpin := hairpin(seq[len(seq)-60:], conf)
+		startHairpin, err := hairpin(ctx, seq[:60], conf)
+		if err != nil {
+			return 0, err
+		}
+		endHairpin, err := hairpin(ctx, seq[len(seq)-60:], conf)
+		if err != nil {
+			return 0, err
+		}
 
 		if startHairpin > endHairpin {
-			return startHairpin
+			return startHairpin, nil
 		}
-		return endHairpin
-	}
-
-	// see nnthal (no parameters) help. within primer3 distribution
-	ntthalCmd := exec.Command(
-		"ntthal",
-		"-a", "HAIRPIN",
-		"-r",       // temperature only
-		"-t", "50", // gibson assembly is at 50 degrees
-		"-s1", seq,
-		"-path", config.Primer3Config,
-	)
-
-	ntthalOut, err := ntthalCmd.CombinedOutput()
-	if err != nil {
-		stderr.Printf("failed to execute ntthal: -s1 %s -path %s", seq, config.Primer3Config)
-		stderr.Fatal(err)
-	}
-
-	ntthalOutString := string(ntthalOut)
-	temp, err := strconv.ParseFloat(strings.TrimSpace(ntthalOutString), 64)
-	if err != nil {
-		stderr.Printf("failed to parse ntthal: -s1 %s -path %s", seq, config.Primer3Config)
-		stderr.Fatalln(err)
+		return endHairpin, nil
 	}
 
-	return temp
+	return newThermo(conf).hairpin(ctx, seq)
 }
 
 // reverseComplement returns the reverse complement of a sequence
@@ -411,6 +381,7 @@ func reverseComplement(seq string) string {
 		'T': 'A',
 		'G': 'C',
 		'C': 'G',
+		'N': 'N',
 		'^': '_',
 		'_': '^',
 	}
@@ -1,8 +1,8 @@
 package repp
 
 import (
+	"context"
 	"math"
-	"os"
 	"reflect"
 	"testing"
 
@@ -11,15 +11,10 @@ import (
 
 func Test_primer3_shrink(t *testing.T) {
 	type fields struct {
-		n      *Frag
-		last   *Frag
-		next   *Frag
-		seq    string
-		in     *os.File
-		out    *os.File
-		p3Path string
-		p3Conf string
-		p3Dir  string
+		n    *Frag
+		last *Frag
+		next *Frag
+		seq  string
 	}
 	type args struct {
 		last        *Frag
@@ -64,14 +59,10 @@ func Test_primer3_shrink(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &primer3{
-				f:              tt.fields.n,
-				last:           tt.fields.last,
-				next:           tt.fields.next,
-				seq:            tt.fields.seq,
-				in:             tt.fields.in,
-				out:            tt.fields.out,
-				primer3Path:    tt.fields.p3Path,
-				primer3ConfDir: tt.fields.p3Conf,
+				f:    tt.fields.n,
+				last: tt.fields.last,
+				next: tt.fields.next,
+				seq:  tt.fields.seq,
 			}
 			if got := p.shrink(tt.args.last, tt.args.n, tt.args.next, tt.args.maxHomology, tt.args.minLength); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("primer3.shrink() = %v, want %v", got, tt.want)
@@ -225,6 +216,7 @@ func Test_mutatePrimers(t *testing.T) {
 							end:   20,
 						},
 						Strand: true,
+						added:  5,
 					},
 					Primer{
 						Seq: "AAGAATCGCCGTAGTA", //  rev comp TACTACGGCGATTCTT
@@ -233,6 +225,7 @@ func Test_mutatePrimers(t *testing.T) {
 							end:   45,
 						},
 						Strand: false,
+						added:  6,
 					},
 				},
 			},
@@ -375,7 +368,11 @@ func Test_hairpin(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotMelt := hairpin(tt.args.seq, tt.args.conf); math.Abs(gotMelt-tt.wantMelt) > 1 {
+			gotMelt, err := hairpin(context.Background(), tt.args.seq, tt.args.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(gotMelt-tt.wantMelt) > 1 {
 				t.Errorf("hairpin() = %v, want %v", gotMelt, tt.wantMelt)
 			}
 		})
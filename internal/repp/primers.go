@@ -0,0 +1,359 @@
+package repp
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/spf13/cobra"
+)
+
+// minInventoryAnneal is the fewest bp of a primer from the inventory that must anneal to a template
+const minInventoryAnneal = 15
+
+// inventory is the primer inventory used in designs. Read when it's first needed
+var inventory struct {
+	once sync.Once
+	db   *PrimerDB
+}
+
+// PrimerDB is the inventory of primers in stock. Designs use primers in it before
+// making new ones.
+type PrimerDB struct {
+	// primers is a map between a primer's name and its sequence
+	primers map[string]string
+
+	// names is a map between a primer's sequence and its name
+	names map[string]string
+}
+
+// newPrimerDB returns a primer inventory with the primers, mapped from name to sequence.
+func newPrimerDB(primers map[string]string) *PrimerDB {
+	db := &PrimerDB{primers: make(map[string]string), names: make(map[string]string)}
+	for name, seq := range primers {
+		db.set(name, seq)
+	}
+	return db
+}
+
+// LoadPrimerDB returns a new copy of the primer inventory or an error if it can't be
+// read. The inventory is empty if it doesn't exist yet.
+func LoadPrimerDB() (*PrimerDB, error) {
+	primers := make(map[string]string)
+
+	primerFile, err := os.Open(config.PrimerDB)
+	if os.IsNotExist(err) {
+		return newPrimerDB(primers), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// https://golang.org/pkg/bufio/#example_Scanner_lines
+	scanner := bufio.NewScanner(primerFile)
+	for scanner.Scan() {
+		columns := strings.Split(scanner.Text(), "	")
+		if len(columns) > 1 {
+			primers[columns[0]] = columns[1] // primer name = primer seq
+		}
+	}
+
+	if err := primerFile.Close(); err != nil {
+		return nil, err
+	}
+
+	return newPrimerDB(primers), nil
+}
+
+// primerInventory returns the primer inventory used in designs. It's empty if it
+// can't be read.
+func primerInventory() *PrimerDB {
+	inventory.once.Do(func() {
+		db, err := LoadPrimerDB()
+		if err != nil {
+			logger.Warn("failed to read the primer inventory, making new primers", "error", err)
+			db = newPrimerDB(nil)
+		}
+		inventory.db = db
+	})
+
+	return inventory.db
+}
+
+// set adds or updates a primer in memory.
+func (p *PrimerDB) set(name, seq string) {
+	if old, exists := p.primers[name]; exists {
+		delete(p.names, old)
+	}
+
+	seq = strings.ToUpper(seq)
+	p.primers[name] = seq
+	p.names[seq] = name
+}
+
+// remove deletes a primer from memory.
+func (p *PrimerDB) remove(name string) {
+	delete(p.names, p.primers[name])
+	delete(p.primers, name)
+}
+
+// ReadCmd logs primers that are similar in name, or identical in sequence, to the
+// one requested. All the primers are logged if none is requested.
+func (p *PrimerDB) ReadCmd(cmd *cobra.Command, args []string) error {
+	// from https://golang.org/pkg/text/tabwriter/
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	if len(args) < 1 {
+		writeMatches(w, p.primers, "the primer inventory is empty")
+		return w.Flush()
+	}
+
+	name := strings.Join(args, " ")
+	writeMatches(w, p.Find(name), fmt.Sprintf("failed to find any primers for %s", name))
+	return w.Flush()
+}
+
+// Find returns the primers, mapped to their sequences, with names similar to name.
+// A primer with name as its sequence, or an exact match, is returned alone. Otherwise
+// the primers containing the name are returned or, if there are fewer than three,
+// they're returned with those beneath a levenshtein distance cutoff.
+func (p *PrimerDB) Find(name string) map[string]string {
+	if primerName, exists := p.names[strings.ToUpper(name)]; exists {
+		return map[string]string{primerName: p.primers[primerName]}
+	}
+
+	if seq, exists := p.primers[name]; exists {
+		return map[string]string{name: seq}
+	}
+
+	ldCutoff := len(name) / 3
+	if 1 > ldCutoff {
+		ldCutoff = 1
+	}
+	containing := make(map[string]string)
+	lowDistance := make(map[string]string)
+
+	for pName, pSeq := range p.primers {
+		if strings.Contains(pName, name) {
+			containing[pName] = pSeq
+		} else if len(pName) > ldCutoff && ld(name, pName, true) <= ldCutoff {
+			lowDistance[pName] = pSeq
+		}
+	}
+
+	if len(containing) < 3 {
+		for pName, pSeq := range containing {
+			lowDistance[pName] = pSeq
+		}
+		return lowDistance
+	}
+
+	return containing
+}
+
+// SetCmd sets the primer's seq in the inventory (or adds it if it isn't in the inventory).
+// With an input file, each of the file's sequences is set, by its ID.
+func (p *PrimerDB) SetCmd(cmd *cobra.Command, args []string) error {
+	primers := make(map[string]string)
+	if in, _ := cmd.Flags().GetString("in"); in != "" {
+		frags, err := read(in, false)
+		if err != nil {
+			return err
+		}
+		for _, f := range frags {
+			primers[f.ID] = f.Seq
+		}
+	} else if len(args) < 2 {
+		cmd.Help()
+		return fmt.Errorf("expecting two args: a primer's name and sequence")
+	} else {
+		primers[strings.Join(args[:len(args)-1], " ")] = args[len(args)-1]
+	}
+
+	invalidChars := regexp.MustCompile("[^ATGC]")
+	for name, seq := range primers {
+		if seq = strings.ToUpper(seq); invalidChars.MatchString(seq) {
+			return fmt.Errorf("%s is not a valid primer sequence, expecting only A, T, G and C", seq)
+		}
+
+		if old, exists := p.primers[name]; exists && old != strings.ToUpper(seq) {
+			fmt.Printf("updated %s in the primer inventory\n", name)
+		}
+		p.set(name, seq)
+	}
+
+	if err := p.write(); err != nil {
+		return err
+	}
+
+	if len(primers) > 1 {
+		fmt.Printf("set %d primers in the primer inventory\n", len(primers))
+	}
+
+	return nil
+}
+
+// DeleteCmd deletes the primer from the inventory.
+func (p *PrimerDB) DeleteCmd(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		cmd.Help()
+		return fmt.Errorf("no primer name passed")
+	}
+
+	name := strings.Join(args, " ")
+	if _, contained := p.primers[name]; !contained {
+		return fmt.Errorf("failed to find %s in the primer inventory", name)
+	}
+
+	p.remove(name)
+	if err := p.write(); err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted %s from the primer inventory\n", name)
+	return nil
+}
+
+// write writes the primers, sorted by name, to the inventory file.
+func (p *PrimerDB) write() error {
+	names := []string{}
+	for name := range p.primers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var output strings.Builder
+	for _, name := range names {
+		output.WriteString(fmt.Sprintf("%s	%s\n", name, p.primers[name]))
+	}
+
+	return ioutil.WriteFile(config.PrimerDB, []byte(output.String()), 0644)
+}
+
+// bind returns primers from the inventory that bind a Frag's template where its PCR
+// needs to start and end. The PCR starts addLeft bp before the Frag's start and ends
+// addRight bp after its end, so a primer from the inventory has to match the target
+// exactly from there, with at least minInventoryAnneal bp and the minimum Tm annealing
+// to the template. Either primer is nil if there isn't one in the inventory.
+//
+// The returned primers are only the annealing portions, as made by primer3. The rest
+// is added back by mutatePrimers.
+func (p *PrimerDB) bind(f *Frag, seq string, addLeft, addRight int, conf *config.Config) (left, right *Primer) {
+	if len(p.primers) == 0 {
+		return nil, nil
+	}
+
+	sl := len(seq)
+	seq = strings.ToUpper(seq + seq + seq + seq)
+	start := f.start - addLeft + sl // first bp of the PCR product
+	end := f.end + addRight + sl    // last bp of the PCR product
+	if start < 0 || end >= len(seq) || start >= end {
+		return nil, nil
+	}
+
+	// the longest primer from the inventory that binds on each side
+	for primerSeq, name := range p.names {
+		l := len(primerSeq)
+		if l > end-start+1 {
+			continue
+		}
+
+		if seq[start:start+l] == primerSeq && l-addLeft >= minInventoryAnneal && (left == nil || l-addLeft > len(left.Seq)) {
+			anneal := primerSeq[addLeft:]
+			if tm := primerTm(anneal); tm >= conf.PCRInventoryMinTm {
+				left = &Primer{
+					Seq:       anneal,
+					Strand:    true,
+					Tm:        tm,
+					GC:        gcPercent(anneal),
+					Inventory: name,
+					Range:     ranged{start: f.start, end: f.start + len(anneal)},
+				}
+			}
+		}
+
+		if reverseComplement(seq[end-l+1:end+1]) == primerSeq && l-addRight >= minInventoryAnneal && (right == nil || l-addRight > len(right.Seq)) {
+			anneal := primerSeq[addRight:]
+			if tm := primerTm(anneal); tm >= conf.PCRInventoryMinTm {
+				right = &Primer{
+					Seq:       anneal,
+					Strand:    false,
+					Tm:        tm,
+					GC:        gcPercent(anneal),
+					Inventory: name,
+					Range:     ranged{start: f.end - len(anneal), end: f.end},
+				}
+			}
+		}
+	}
+
+	return left, right
+}
+
+// nearestNeighbor are the enthalpies (kcal/mol) and entropies (cal/K/mol) of DNA
+// duplex formation for each pair of neighboring bases, SantaLucia 1998
+var nearestNeighbor = map[string][2]float64{
+	"AA": {-7.9, -22.2}, "TT": {-7.9, -22.2},
+	"AT": {-7.2, -20.4},
+	"TA": {-7.2, -21.3},
+	"CA": {-8.5, -22.7}, "TG": {-8.5, -22.7},
+	"GT": {-8.4, -22.4}, "AC": {-8.4, -22.4},
+	"CT": {-7.8, -21.0}, "AG": {-7.8, -21.0},
+	"GA": {-8.2, -22.2}, "TC": {-8.2, -22.2},
+	"CG": {-10.6, -27.2},
+	"GC": {-9.8, -24.4},
+	"GG": {-8.0, -19.9}, "CC": {-8.0, -19.9},
+}
+
+// primerTm returns the melting temperature of a primer with its perfect complement,
+// by the nearest neighbor method of SantaLucia 1998, in primer3's default conditions:
+// 50 mM monovalent salt, 1.5 mM Mg2+, 0.6 mM dNTPs and 50 nM primer.
+func primerTm(seq string) float64 {
+	seq = strings.ToUpper(seq)
+	if len(seq) < 2 {
+		return 0
+	}
+
+	var dh, ds float64
+	for _, end := range []byte{seq[0], seq[len(seq)-1]} {
+		if end == 'G' || end == 'C' {
+			dh, ds = dh+0.1, ds-2.8
+		} else {
+			dh, ds = dh+2.3, ds+4.1
+		}
+	}
+	for i := 0; i < len(seq)-1; i++ {
+		params := nearestNeighbor[seq[i:i+2]]
+		dh += params[0]
+		ds += params[1]
+	}
+
+	// salt correction with divalent cations as monovalent equivalents, von Ahsen 2001
+	sodium := (50 + 120*math.Sqrt(1.5-0.6)) / 1000
+	ds += 0.368 * float64(len(seq)-1) * math.Log(sodium)
+
+	oligo := 50e-9
+	if seq == reverseComplement(seq) {
+		ds -= 1.4
+	} else {
+		oligo /= 4
+	}
+
+	return dh*1000/(ds+1.987*math.Log(oligo)) - 273.15
+}
+
+// gcPercent returns the percentage of a sequence that's G or C.
+func gcPercent(seq string) float64 {
+	if len(seq) == 0 {
+		return 0
+	}
+	gc := strings.Count(strings.ToUpper(seq), "G") + strings.Count(strings.ToUpper(seq), "C")
+	return 100 * float64(gc) / float64(len(seq))
+}
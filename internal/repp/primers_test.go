@@ -0,0 +1,89 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_PrimerDB_bind(t *testing.T) {
+	seq := strings.ToUpper(testPlasmid)
+	conf := &config.Config{PCRInventoryMinTm: 50, CostBP: 0.6, CostPCR: 0.27}
+
+	db := newPrimerDB(map[string]string{
+		"fwd":          seq[95:122],
+		"rev":          reverseComplement(seq[380:401]),
+		"elsewhere":    seq[200:222],
+		"short anneal": seq[390:401], // 11 bp
+		"shorter fwd":  seq[95:117],
+	})
+
+	f := &Frag{ID: "template", start: 100, end: 400, conf: conf}
+	left, right := db.bind(f, seq, 5, 0, conf)
+	if left == nil || left.Inventory != "fwd" || left.Seq != seq[100:122] {
+		t.Fatalf("bind() left = %+v, want the annealing portion of fwd", left)
+	}
+	if right == nil || right.Inventory != "rev" || right.Seq != reverseComplement(seq[380:401]) {
+		t.Fatalf("bind() right = %+v, want rev", right)
+	}
+	if left.Tm < 50 || right.Tm < 50 {
+		t.Errorf("bind() Tms = %.1f, %.1f, want at least 50", left.Tm, right.Tm)
+	}
+
+	// the tail of the inventory primer is added back, so it's ordered as it is in stock
+	f.Primers = []Primer{*left, *right}
+	mutatePrimers(f, seq, 5, 0)
+	if f.Primers[0].Seq != seq[95:122] || f.PCRSeq != seq[95:401] {
+		t.Errorf("mutatePrimers() = %s, %s, want the inventory primer and the PCR from 95 to 400", f.Primers[0].Seq, f.PCRSeq)
+	}
+
+	// primers from the inventory are free
+	f.fragType = pcr
+	if cost := f.cost(false); cost != conf.CostPCR {
+		t.Errorf("cost() = %.2f, want only the PCR reaction", cost)
+	}
+
+	// nothing binds where the PCR needs to start without the homology tail
+	if left, _ := db.bind(f, seq, 12, 0, conf); left != nil {
+		t.Errorf("bind() left = %+v, want nil", left)
+	}
+}
+
+func Test_PrimerDB_Find(t *testing.T) {
+	db := newPrimerDB(map[string]string{
+		"oJT101": "GTTGACAATTAATCATCGGCATAG",
+		"oJT102": "CTATGCCGATGATTAATTGTCAAC",
+		"oXY1":   "ATGCATGCATGCATGCATGC",
+	})
+
+	if found := db.Find("gttgacaattaatcatcggcatag"); len(found) != 1 || found["oJT101"] == "" {
+		t.Errorf("Find() by sequence = %v, want oJT101", found)
+	}
+	if found := db.Find("oJT10"); len(found) != 2 {
+		t.Errorf("Find() = %v, want oJT101 and oJT102", found)
+	}
+
+	db.remove("oJT101")
+	if found := db.Find("GTTGACAATTAATCATCGGCATAG"); found["oJT101"] != "" {
+		t.Errorf("Find() = %v after removing oJT101", found)
+	}
+}
+
+func Test_primerTm(t *testing.T) {
+	tests := []struct {
+		seq      string
+		min, max float64
+	}{
+		{"GTTGACAATTAATCATCGGCATAG", 55, 63},
+		{"ATGCATGCATGCATGCATGC", 55, 63},
+		{"GCGGCCGCGGCGCCGCGG", 70, 85},
+		{"ATATTATAATTATATTAT", 25, 40},
+		{"AGCGGATAACAATTTCACACAGGA", 58, 63},
+	}
+	for _, tt := range tests {
+		if tm := primerTm(tt.seq); tm < tt.min || tm > tt.max {
+			t.Errorf("primerTm(%s) = %.1f, want between %.0f and %.0f", tt.seq, tm, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package repp
+
+import (
+	"context"
+	"sync"
+)
+
+// Progress is how far a design has gotten.
+type Progress struct {
+	// Stage of the design: blast, build or fill
+	Stage string `json:"stage"`
+
+	// Fragments is the number of fragments found in the databases
+	Fragments int `json:"fragments"`
+
+	// Assemblies is the number of assemblies built from the fragments
+	Assemblies int `json:"assemblies"`
+
+	// Filled is the number of assemblies filled with primers and synthetic fragments
+	Filled int `json:"filled"`
+}
+
+// progressKey is the key of a design's progress in its context.
+type progressKey struct{}
+
+// progressReporter passes a design's progress to a callback as it changes.
+type progressReporter struct {
+	mu sync.Mutex
+
+	// progress so far
+	progress Progress
+
+	// report is called with the progress after each change
+	report func(Progress)
+}
+
+// WithProgress returns a context that reports the progress of the design it's passed to.
+// report is called, one at a time, after each change. The progress of designs in a batch
+// isn't reported.
+func WithProgress(ctx context.Context, report func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, &progressReporter{report: report})
+}
+
+// withoutProgress returns a context that doesn't report progress.
+func withoutProgress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, progressKey{}, (*progressReporter)(nil))
+}
+
+// reportProgress updates the progress of the design, if its context reports it.
+func reportProgress(ctx context.Context, update func(p *Progress)) {
+	r, _ := ctx.Value(progressKey{}).(*progressReporter)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	update(&r.progress)
+	r.report(r.progress)
+}
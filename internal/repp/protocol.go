@@ -0,0 +1,363 @@
+package repp
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// bpMass is the average mass of a base pair of double-stranded DNA, in g/mol
+	bpMass = 650.0
+
+	// vectorPmol is the amount of the vector in an assembly, in pmol
+	vectorPmol = 0.05
+
+	// annealingOffset is how far below the lower Tm of a primer pair to anneal, in Celsius
+	annealingOffset = 3.0
+)
+
+// Protocol is a step-by-step protocol for making a solution at the bench.
+type Protocol struct {
+	// Target is the name of the plasmid being made
+	Target string `json:"target"`
+
+	// Solution is the number of the solution in the output, 1-indexed
+	Solution int `json:"solution"`
+
+	// Steps of the protocol, in order
+	Steps []ProtocolStep `json:"steps"`
+}
+
+// ProtocolStep is a single step of a Protocol, eg running the PCR reactions.
+type ProtocolStep struct {
+	// Action of the step, eg "Run PCR reactions"
+	Action string `json:"action"`
+
+	// Notes on the step, eg its reaction conditions
+	Notes string `json:"notes,omitempty"`
+
+	// Items are the things ordered, or reactions run, in the step
+	Items []ProtocolItem `json:"items"`
+}
+
+// ProtocolItem is a single thing ordered, or reaction run, in a ProtocolStep.
+type ProtocolItem struct {
+	// Name of the item, eg "fragment 1"
+	Name string `json:"name"`
+
+	// Source of the item, eg a repository URL, the template of a PCR or a synthesis vendor
+	Source string `json:"source,omitempty"`
+
+	// Reagents of the item, eg a pair of primers or enzymes
+	Reagents string `json:"reagents,omitempty"`
+
+	// Size of the item or its product in bp
+	Size int `json:"size,omitempty"`
+
+	// Temperature of the reaction in Celsius, eg a PCR's annealing temperature
+	Temperature float64 `json:"temperature,omitempty"`
+
+	// Amount of the item to use, eg in an assembly
+	Amount string `json:"amount,omitempty"`
+
+	// Seq of the item, eg a primer or synthetic fragment
+	Seq string `json:"seq,omitempty"`
+}
+
+// ReadOutput reads a design's output from a JSON file.
+func ReadOutput(path string) (*Output, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Output{}
+	if err := json.Unmarshal(contents, out); err != nil {
+		return nil, fmt.Errorf("failed to parse the output in %s: %v", path, err)
+	}
+
+	return out, nil
+}
+
+// NewProtocol returns the protocol for making one of the output's solutions. The
+// solution is 1-indexed, as it's numbered for users.
+func NewProtocol(out *Output, solution int) (*Protocol, error) {
+	if solution < 1 || solution > len(out.Solutions) {
+		return nil, fmt.Errorf("failed to find solution %d, %s has %d solutions", solution, out.Target, len(out.Solutions))
+	}
+	s := out.Solutions[solution-1]
+
+	protocol := &Protocol{Target: out.Target, Solution: solution}
+	plasmids := ProtocolStep{Action: "Order plasmids", Notes: "Order the plasmids from their repositories."}
+	primers := ProtocolStep{Action: "Order primers"}
+	synthetics := ProtocolStep{Action: "Order synthetic fragments"}
+	pcrs := ProtocolStep{
+		Action: "Run PCR reactions",
+		Notes: fmt.Sprintf(
+			"Amplify each fragment from its template. Anneal %.0fC below the lower Tm of the primer pair, then gel or column purify the products.",
+			annealingOffset,
+		),
+	}
+
+	orderedPlasmids := make(map[string]bool)
+	orderedPrimers := make(map[string]string)
+	var assembled []ProtocolItem
+	backbone := -1
+
+	for i, f := range s.Fragments {
+		name := fmt.Sprintf("fragment %d", i+1)
+		source := f.URL
+		if source == "" {
+			source = f.ID
+		}
+
+		// repository plasmids, whether used as they are or as a PCR template
+		if f.URL != "" && f.Type != synthetic.String() && !orderedPlasmids[f.URL] {
+			orderedPlasmids[f.URL] = true
+			plasmids.Items = append(plasmids.Items, ProtocolItem{Name: source, Source: f.URL})
+		}
+
+		size := len(f.Seq)
+		switch f.Type {
+		case pcr.String():
+			if f.PCRSeq != "" {
+				size = len(f.PCRSeq)
+			}
+
+			var primerNames []string
+			tm := math.MaxFloat64
+			for _, p := range f.Primers {
+				direction := "FWD"
+				if !p.Strand {
+					direction = "REV"
+				}
+
+				// primers shared between reactions are only ordered once and
+				// those from the inventory aren't ordered
+				primerName, ordered := orderedPrimers[p.Seq]
+				if p.Inventory != "" {
+					primerName = p.Inventory + " (inventory)"
+				} else if !ordered {
+					primerName = fmt.Sprintf("%s %s", name, direction)
+					orderedPrimers[p.Seq] = primerName
+					primers.Items = append(primers.Items, ProtocolItem{
+						Name:        primerName,
+						Size:        len(p.Seq),
+						Temperature: round(p.Tm, 1),
+						Seq:         p.Seq,
+					})
+				}
+				primerNames = append(primerNames, primerName)
+				tm = math.Min(tm, p.Tm)
+			}
+
+			item := ProtocolItem{Name: name, Source: source, Reagents: strings.Join(primerNames, ", "), Size: size}
+			if len(f.Primers) > 0 && tm > annealingOffset {
+				item.Temperature = round(tm-annealingOffset, 1)
+			}
+			pcrs.Items = append(pcrs.Items, item)
+		case synthetic.String():
+			synthetics.Items = append(synthetics.Items, ProtocolItem{Name: name, Source: f.Vendor, Size: size, Seq: f.Seq})
+		}
+
+		if out.Backbone != nil && f.Backbone {
+			backbone = len(assembled)
+		}
+		assembled = append(assembled, ProtocolItem{Name: name, Source: source, Size: size})
+	}
+
+	for _, step := range []ProtocolStep{plasmids, primers, synthetics} {
+		if len(step.Items) > 0 {
+			protocol.Steps = append(protocol.Steps, step)
+		}
+	}
+
+	// the backbone is linearized before the assembly
+	if backbone >= 0 && len(out.Backbone.Enzymes) > 0 {
+		protocol.Steps = append(protocol.Steps, ProtocolStep{
+			Action: "Digest the backbone",
+			Notes:  "Digest the backbone and gel purify the linearized band.",
+			Items: []ProtocolItem{ProtocolItem{
+				Name:     assembled[backbone].Name,
+				Source:   out.Backbone.URL,
+				Reagents: strings.Join(out.Backbone.Enzymes, ", "),
+				Size:     assembled[backbone].Size,
+			}},
+		})
+	}
+
+	if len(pcrs.Items) > 0 {
+		protocol.Steps = append(protocol.Steps, pcrs)
+	}
+
+	if len(assembled) > 1 {
+		protocol.Steps = append(protocol.Steps, assemblyStep(out, assembled, backbone))
+	}
+
+	return protocol, nil
+}
+
+// assemblyStep returns the step that assembles the fragments, with the amount of each.
+//
+// The vector, the backbone or else the largest fragment, is 0.05 pmol. With up to three
+// fragments, the others are at a 2:1 molar ratio to the vector. With more, all are equimolar.
+func assemblyStep(out *Output, fragments []ProtocolItem, vector int) ProtocolStep {
+	if vector < 0 {
+		vector = 0
+		for i, f := range fragments {
+			if f.Size > fragments[vector].Size {
+				vector = i
+			}
+		}
+	}
+
+	insertRatio := 2.0
+	if len(fragments) > 3 {
+		insertRatio = 1.0
+	}
+
+	step := ProtocolStep{
+		Action: "Assemble the fragments with Gibson Assembly",
+		Notes:  "Mix the fragments with a Gibson Assembly master mix and incubate at 50C for 15-60 minutes.",
+	}
+	if out.Enzyme != "" {
+		step.Action = "Assemble the fragments with Golden Gate Assembly"
+		step.Notes = fmt.Sprintf(
+			"Mix the fragments with %s and T4 DNA ligase. Cycle between 37C and 16C, 5 minutes each, 30 times, then heat inactivate at 60C for 5 minutes.",
+			out.Enzyme,
+		)
+	}
+
+	for i := range fragments {
+		f := &fragments[i]
+		ratio := insertRatio
+		if i == vector {
+			ratio = 1.0
+		}
+
+		pmol := vectorPmol * ratio
+		ng := pmol * float64(f.Size) * bpMass / 1000
+		f.Amount = fmt.Sprintf("%.3f pmol, %.1f ng", pmol, ng)
+		if i == vector {
+			f.Amount += " (vector)"
+		} else if ratio > 1 {
+			f.Amount += " (2:1 to vector)"
+		}
+	}
+	step.Items = fragments
+
+	return step
+}
+
+// round rounds a float to a number of decimal places.
+func round(f float64, places int) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'f', places, 64), 64)
+	return rounded
+}
+
+// protocolColumns are the columns of a protocol's items, with their values
+var protocolColumns = []struct {
+	name  string
+	value func(ProtocolItem) string
+}{
+	{"item", func(i ProtocolItem) string { return i.Name }},
+	{"source", func(i ProtocolItem) string { return i.Source }},
+	{"reagents", func(i ProtocolItem) string { return i.Reagents }},
+	{"size (bp)", func(i ProtocolItem) string { return itoa(i.Size) }},
+	{"temperature (C)", func(i ProtocolItem) string { return ftoa(i.Temperature) }},
+	{"amount", func(i ProtocolItem) string { return i.Amount }},
+	{"sequence", func(i ProtocolItem) string { return i.Seq }},
+}
+
+// itoa formats an int, or an empty string if it's zero.
+func itoa(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+// ftoa formats a float to a decimal place, or an empty string if it's zero.
+func ftoa(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
+
+// Markdown writes the protocol as Markdown, a numbered section and table per step.
+func (p *Protocol) Markdown(w io.Writer) error {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s, solution %d\n", p.Target, p.Solution))
+
+	for i, step := range p.Steps {
+		md.WriteString(fmt.Sprintf("\n## %d. %s\n\n", i+1, step.Action))
+		if step.Notes != "" {
+			md.WriteString(step.Notes + "\n\n")
+		}
+
+		// only the columns with values in the step
+		var columns []int
+		for c, column := range protocolColumns {
+			for _, item := range step.Items {
+				if column.value(item) != "" {
+					columns = append(columns, c)
+					break
+				}
+			}
+		}
+
+		var header, divider []string
+		for _, c := range columns {
+			header = append(header, protocolColumns[c].name)
+			divider = append(divider, "---")
+		}
+		md.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		md.WriteString("| " + strings.Join(divider, " | ") + " |\n")
+
+		for _, item := range step.Items {
+			var row []string
+			for _, c := range columns {
+				row = append(row, strings.Replace(protocolColumns[c].value(item), "|", `\|`, -1))
+			}
+			md.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+
+	_, err := io.WriteString(w, md.String())
+	return err
+}
+
+// CSV writes the protocol as CSV, a row per item with its step.
+func (p *Protocol) CSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"step", "action"}
+	for _, column := range protocolColumns {
+		header = append(header, column.name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for i, step := range p.Steps {
+		for _, item := range step.Items {
+			row := []string{strconv.Itoa(i + 1), step.Action}
+			for _, column := range protocolColumns {
+				row = append(row, column.value(item))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,190 @@
+package repp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func testProtocolOutput() *Output {
+	seq := strings.ToUpper(testPlasmid)
+	return &Output{
+		Target:    "target",
+		TargetSeq: seq,
+		Backbone: &Backbone{
+			URL:     "https://www.addgene.org/2/",
+			Enzymes: []string{"EcoRI", "PstI"},
+		},
+		Solutions: []Solution{
+			Solution{
+				Count: 3,
+				Cost:  100,
+				Fragments: []*Frag{
+					&Frag{
+						Type:   "pcr",
+						URL:    "https://www.addgene.org/1/",
+						Seq:    seq[:300],
+						PCRSeq: seq[:320],
+						Primers: []Primer{
+							Primer{Seq: seq[:20], Strand: true, Tm: 60.2},
+							Primer{Seq: reverseComplement(seq[300:320]), Strand: false, Tm: 58.4},
+						},
+					},
+					&Frag{
+						Type: "synthetic",
+						Seq:  seq[300:500],
+					},
+					&Frag{
+						Type:     "linear",
+						URL:      "https://www.addgene.org/2/",
+						Seq:      seq[480:] + seq[:20],
+						Backbone: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_NewProtocol(t *testing.T) {
+	out := testProtocolOutput()
+
+	if _, err := NewProtocol(out, 2); err == nil {
+		t.Error("NewProtocol() should fail for a solution that isn't in the output")
+	}
+
+	protocol, err := NewProtocol(out, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	for _, step := range protocol.Steps {
+		actions = append(actions, step.Action)
+	}
+	wantActions := []string{
+		"Order plasmids",
+		"Order primers",
+		"Order synthetic fragments",
+		"Digest the backbone",
+		"Run PCR reactions",
+		"Assemble the fragments with Gibson Assembly",
+	}
+	if strings.Join(actions, ", ") != strings.Join(wantActions, ", ") {
+		t.Fatalf("NewProtocol() steps = %v, want %v", actions, wantActions)
+	}
+
+	if plasmids := protocol.Steps[0].Items; len(plasmids) != 2 {
+		t.Errorf("NewProtocol() orders %d plasmids, want 2", len(plasmids))
+	}
+
+	if digest := protocol.Steps[3].Items[0]; digest.Name != "fragment 3" || digest.Reagents != "EcoRI, PstI" {
+		t.Errorf("NewProtocol() digests %+v, want the backbone with EcoRI and PstI", digest)
+	}
+
+	reaction := protocol.Steps[4].Items[0]
+	if reaction.Source != "https://www.addgene.org/1/" || reaction.Size != 320 || reaction.Temperature != 55.4 {
+		t.Errorf("NewProtocol() PCR = %+v, want a 320 bp product annealed at 55.4C", reaction)
+	}
+	if reaction.Reagents != "fragment 1 FWD, fragment 1 REV" {
+		t.Errorf("NewProtocol() PCR primers = %s", reaction.Reagents)
+	}
+
+	// the backbone is the vector, the inserts are at twice its molarity
+	assembly := protocol.Steps[5].Items
+	if !strings.HasPrefix(assembly[2].Amount, "0.050 pmol, 15.8 ng (vector)") {
+		t.Errorf("NewProtocol() vector amount = %s", assembly[2].Amount)
+	}
+	if !strings.HasPrefix(assembly[0].Amount, "0.100 pmol, 20.8 ng") {
+		t.Errorf("NewProtocol() insert amount = %s", assembly[0].Amount)
+	}
+}
+
+func Test_NewProtocol_localBackbone(t *testing.T) {
+	// steps returns the items of a protocol's steps by their action
+	steps := func(protocol *Protocol) map[string][]ProtocolItem {
+		items := map[string][]ProtocolItem{}
+		for _, step := range protocol.Steps {
+			items[step.Action] = step.Items
+		}
+		return items
+	}
+
+	out := testProtocolOutput()
+	out.Backbone.URL = ""
+	out.Solutions[0].Fragments[2].URL = ""
+
+	protocol, err := NewProtocol(out, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the synthetic fragment, also without a URL, isn't the backbone
+	if digest := steps(protocol)["Digest the backbone"]; len(digest) != 1 || digest[0].Name != "fragment 3" {
+		t.Errorf("NewProtocol() digests %+v, want the backbone", digest)
+	}
+
+	// without the backbone in the solution, nothing is digested
+	out.Solutions[0].Fragments[2].URL = "https://www.addgene.org/3/"
+	out.Solutions[0].Fragments[2].Backbone = false
+
+	if protocol, err = NewProtocol(out, 1); err != nil {
+		t.Fatal(err)
+	}
+	if digest, ok := steps(protocol)["Digest the backbone"]; ok {
+		t.Errorf("NewProtocol() digests %+v without the backbone", digest)
+	}
+}
+
+func Test_Protocol_Markdown(t *testing.T) {
+	protocol, err := NewProtocol(testProtocolOutput(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var md bytes.Buffer
+	if err := protocol.Markdown(&md); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# target, solution 1\n",
+		"## 4. Digest the backbone\n",
+		"| item | source | reagents | size (bp) | temperature (C) |\n",
+		"| fragment 1 | https://www.addgene.org/1/ | fragment 1 FWD, fragment 1 REV | 320 | 55.4 |\n",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown() is missing %q in:\n%s", want, md.String())
+		}
+	}
+}
+
+func Test_Protocol_CSV(t *testing.T) {
+	protocol, err := NewProtocol(testProtocolOutput(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := protocol.CSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a header and a row per item
+	items := 0
+	for _, step := range protocol.Steps {
+		items += len(step.Items)
+	}
+	if len(rows) != items+1 {
+		t.Fatalf("CSV() wrote %d rows, want %d", len(rows), items+1)
+	}
+	if rows[0][0] != "step" || rows[1][0] != "1" || rows[len(rows)-1][1] != protocol.Steps[len(protocol.Steps)-1].Action {
+		t.Errorf("CSV() rows = %v", rows)
+	}
+}
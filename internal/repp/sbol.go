@@ -0,0 +1,633 @@
+package repp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sbolNS is the namespace of SBOL3 classes and properties
+	sbolNS = "http://sbols.org/v3#"
+
+	// provNS is the namespace of the W3C provenance ontology
+	provNS = "http://www.w3.org/ns/prov#"
+
+	// rdfNS is the namespace of RDF's syntax
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+	// sbolNamespace is the namespace of the objects in SBOL documents written by repp
+	sbolNamespace = "https://github.com/jjtimmons/repp"
+)
+
+// ontology terms used in SBOL documents, by identifiers.org IRI
+const (
+	sboDNA          = "https://identifiers.org/SBO:0000251"
+	sboReaction     = "https://identifiers.org/SBO:0000176"
+	sboReactant     = "https://identifiers.org/SBO:0000010"
+	soCircular      = "https://identifiers.org/SO:0000988"
+	soLinear        = "https://identifiers.org/SO:0000987"
+	soPlasmid       = "https://identifiers.org/SO:0000637"
+	soPCRProduct    = "https://identifiers.org/SO:0000006"
+	soSynthetic     = "https://identifiers.org/SO:0000351"
+	soRegion        = "https://identifiers.org/SO:0000804"
+	soPrimer        = "https://identifiers.org/SO:0000112"
+	soPrimerBinding = "https://identifiers.org/SO:0005850"
+	soForward       = "https://identifiers.org/SO:0001030"
+	soReverse       = "https://identifiers.org/SO:0001031"
+	edamDNA         = "https://identifiers.org/edam:format_1207"
+)
+
+// sbolObject is an object in an SBOL document: its identity, types and properties.
+// Types and properties are by their local names, eg "Component" and "hasSequence".
+type sbolObject struct {
+	// id is the object's IRI
+	id string
+
+	// types of the object
+	types []string
+
+	// props maps each property to its values, IRIs or literals
+	props map[string][]string
+}
+
+// is returns whether the object is of the type.
+func (o *sbolObject) is(t string) bool {
+	for _, ot := range o.types {
+		if ot == t {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the first value of a property.
+func (o *sbolObject) value(prop string) string {
+	if values := o.props[prop]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// has returns whether a property has a value with the suffix, eg "SO:0000988".
+func (o *sbolObject) has(prop, suffix string) bool {
+	for _, v := range o.props[prop] {
+		if strings.HasSuffix(v, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// name returns the object's name, falling back to its displayId and then its IRI.
+func (o *sbolObject) name() string {
+	if name := o.value("name"); name != "" {
+		return name
+	}
+	if displayID := o.value("displayId"); displayID != "" {
+		return displayID
+	}
+	return localName(o.id)
+}
+
+// sbolDocument is the objects of an SBOL document in the order they were read.
+type sbolDocument struct {
+	objects []*sbolObject
+	byID    map[string]*sbolObject
+}
+
+// object returns the object with the IRI, adding it if it's new.
+func (d *sbolDocument) object(id string) *sbolObject {
+	if o, ok := d.byID[id]; ok {
+		return o
+	}
+
+	o := &sbolObject{id: id, props: make(map[string][]string)}
+	d.objects = append(d.objects, o)
+	d.byID[id] = o
+	return o
+}
+
+// localName returns the last part of an IRI or compact IRI, eg "displayId" in
+// "http://sbols.org/v3#displayId" or "sbol:displayId".
+func localName(iri string) string {
+	if i := strings.LastIndex(iri, "#"); i >= 0 {
+		return iri[i+1:]
+	}
+	if i := strings.LastIndex(iri, "/"); i >= 0 {
+		return iri[i+1:]
+	}
+	if i := strings.LastIndex(iri, ":"); i >= 0 {
+		return iri[i+1:]
+	}
+	return iri
+}
+
+// parseSBOL parses an SBOL document serialized as JSON-LD or RDF/XML.
+func parseSBOL(contents string) (*sbolDocument, error) {
+	doc := &sbolDocument{byID: make(map[string]*sbolObject)}
+
+	contents = strings.TrimSpace(strings.TrimPrefix(contents, "\ufeff"))
+	if strings.HasPrefix(contents, "{") || strings.HasPrefix(contents, "[") {
+		return doc, parseJSONLD(doc, contents)
+	}
+	if strings.HasPrefix(contents, "<") {
+		return doc, parseRDFXML(doc, contents)
+	}
+
+	return nil, fmt.Errorf("unrecognized SBOL serialization, expecting JSON-LD or RDF/XML")
+}
+
+// parseJSONLD parses the nodes of a JSON-LD document to SBOL objects.
+func parseJSONLD(doc *sbolDocument, contents string) error {
+	var root interface{}
+	if err := json.Unmarshal([]byte(contents), &root); err != nil {
+		return fmt.Errorf("failed to parse JSON-LD: %v", err)
+	}
+
+	blank := 0
+	var node func(n map[string]interface{}) string
+	node = func(n map[string]interface{}) string {
+		id, _ := n["@id"].(string)
+		if id == "" {
+			blank++
+			id = fmt.Sprintf("_:b%d", blank)
+		}
+		o := doc.object(id)
+
+		for key, value := range n {
+			switch key {
+			case "@id", "@context":
+				continue
+			case "@graph":
+				jsonldGraph(value, node)
+				continue
+			case "@type":
+				for _, t := range jsonldList(value) {
+					if s, ok := t.(string); ok {
+						o.types = append(o.types, localName(s))
+					}
+				}
+				continue
+			}
+
+			prop := localName(key)
+			for _, v := range jsonldList(value) {
+				switch v := v.(type) {
+				case string:
+					o.props[prop] = append(o.props[prop], v)
+				case float64, bool:
+					o.props[prop] = append(o.props[prop], jsonldLiteral(v))
+				case map[string]interface{}:
+					if literal, ok := v["@value"]; ok {
+						o.props[prop] = append(o.props[prop], jsonldLiteral(literal))
+					} else {
+						o.props[prop] = append(o.props[prop], node(v))
+					}
+				}
+			}
+		}
+
+		return id
+	}
+
+	// the top-level nodes are added first so they're in the order of the document
+	var nodes []interface{}
+	if r, ok := root.(map[string]interface{}); ok && r["@graph"] != nil {
+		nodes = jsonldList(r["@graph"])
+	} else {
+		nodes = jsonldList(root)
+	}
+	for _, n := range nodes {
+		if n, ok := n.(map[string]interface{}); ok {
+			if id, ok := n["@id"].(string); ok {
+				doc.object(id)
+			}
+		}
+	}
+
+	jsonldGraph(nodes, node)
+	return nil
+}
+
+// jsonldGraph calls node on each of the nodes in a JSON-LD document or graph.
+func jsonldGraph(value interface{}, node func(map[string]interface{}) string) {
+	for _, v := range jsonldList(value) {
+		if n, ok := v.(map[string]interface{}); ok {
+			node(n)
+		}
+	}
+}
+
+// jsonldLiteral returns a JSON-LD literal as a string.
+func jsonldLiteral(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonldList returns a JSON-LD value as a list of values.
+func jsonldList(value interface{}) []interface{} {
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	return []interface{}{value}
+}
+
+// parseRDFXML parses the nodes of an RDF/XML document to SBOL objects. Nodes may be
+// top-level or nested in the properties of other nodes.
+func parseRDFXML(doc *sbolDocument, contents string) error {
+	decoder := xml.NewDecoder(strings.NewReader(contents))
+	blank := 0
+
+	attr := func(e xml.StartElement, space, local string) string {
+		for _, a := range e.Attr {
+			if a.Name.Space == space && a.Name.Local == local {
+				return a.Value
+			}
+		}
+		return ""
+	}
+
+	// node parses a node element through its end
+	var node func(e xml.StartElement) (string, error)
+	node = func(e xml.StartElement) (string, error) {
+		id := attr(e, rdfNS, "about")
+		if id == "" {
+			blank++
+			id = fmt.Sprintf("_:b%d", blank)
+		}
+		o := doc.object(id)
+		if !(e.Name.Space == rdfNS && e.Name.Local == "Description") {
+			o.types = append(o.types, e.Name.Local)
+		}
+
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return "", fmt.Errorf("failed to parse RDF/XML: %v", err)
+			}
+
+			switch t := token.(type) {
+			case xml.EndElement:
+				return id, nil
+			case xml.StartElement:
+				// a property of the node
+				prop := t.Name.Local
+				if resource := attr(t, rdfNS, "resource"); resource != "" {
+					if prop == "type" && t.Name.Space == rdfNS {
+						o.types = append(o.types, localName(resource))
+					} else {
+						o.props[prop] = append(o.props[prop], resource)
+					}
+					if err := decoder.Skip(); err != nil {
+						return "", err
+					}
+					continue
+				}
+
+				var text strings.Builder
+				for done := false; !done; {
+					token, err := decoder.Token()
+					if err != nil {
+						return "", fmt.Errorf("failed to parse RDF/XML: %v", err)
+					}
+
+					switch v := token.(type) {
+					case xml.CharData:
+						text.Write(v)
+					case xml.StartElement:
+						nested, err := node(v)
+						if err != nil {
+							return "", err
+						}
+						o.props[prop] = append(o.props[prop], nested)
+						text.Reset()
+					case xml.EndElement:
+						done = true
+					}
+				}
+				if literal := strings.TrimSpace(text.String()); literal != "" {
+					o.props[prop] = append(o.props[prop], literal)
+				}
+			}
+		}
+	}
+
+	// the nodes are the children of the rdf:RDF root
+	inRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse RDF/XML: %v", err)
+		}
+
+		if e, ok := token.(xml.StartElement); ok {
+			if !inRoot && e.Name.Space == rdfNS && e.Name.Local == "RDF" {
+				inRoot = true
+				continue
+			}
+			if _, err := node(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readSBOL parses an SBOL document to fragments. Returns either the Components with
+// sequences, or the located features of Components, depending on the parseFeatures parameter.
+func readSBOL(path, contents string, parseFeatures bool) (fragments []*Frag, err error) {
+	doc, err := parseSBOL(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	// seq returns the sequence of an object with a hasSequence property
+	seq := func(o *sbolObject) string {
+		for _, id := range o.props["hasSequence"] {
+			if s, ok := doc.byID[id]; ok && s.value("elements") != "" {
+				return strings.ToUpper(strings.Join(strings.Fields(s.value("elements")), ""))
+			}
+		}
+		return ""
+	}
+
+	var components []*Frag
+	var features []*Frag
+	for _, o := range doc.objects {
+		if !o.is("Component") {
+			continue
+		}
+
+		componentSeq := seq(o)
+		if componentSeq == "" {
+			continue
+		}
+
+		fragType := linear
+		if o.has("type", "SO:0000988") {
+			fragType = circular
+		}
+		components = append(components, &Frag{ID: o.name(), Seq: componentSeq, fragType: fragType})
+
+		// the features of the component with locations on its sequence
+		for _, featureID := range o.props["hasFeature"] {
+			feature, ok := doc.byID[featureID]
+			if !ok {
+				continue
+			}
+
+			name := feature.name()
+			if instance, ok := doc.byID[feature.value("instanceOf")]; ok {
+				name = instance.name()
+			}
+
+			var featureSeq strings.Builder
+			for _, locationID := range feature.props["hasLocation"] {
+				location, ok := doc.byID[locationID]
+				if !ok || !location.is("Range") {
+					continue
+				}
+
+				start, err1 := strconv.Atoi(location.value("start"))
+				end, err2 := strconv.Atoi(location.value("end"))
+				if err1 != nil || err2 != nil || start < 1 || end > len(componentSeq) || start > end {
+					return nil, fmt.Errorf("failed to parse %s: invalid range of %s", path, name)
+				}
+
+				rangeSeq := componentSeq[start-1 : end]
+				if location.has("orientation", "SO:0001031") || location.has("orientation", "reverseComplement") {
+					rangeSeq = reverseComplement(rangeSeq)
+				}
+				featureSeq.WriteString(rangeSeq)
+			}
+
+			if featureSeq.Len() > 0 {
+				features = append(features, &Frag{ID: name, Seq: featureSeq.String()})
+			}
+		}
+	}
+
+	if parseFeatures && len(features) > 0 {
+		return features, nil
+	}
+
+	// without located features, each component is a feature
+	if len(components) < 1 {
+		return nil, fmt.Errorf("failed to parse a Component with a sequence from %s", path)
+	}
+
+	return components, nil
+}
+
+// invalidDisplayID matches characters that aren't allowed in an SBOL displayId
+var invalidDisplayID = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// displayID returns a valid SBOL displayId for a name.
+func displayID(name string) string {
+	id := strings.Trim(invalidDisplayID.ReplaceAllString(name, "_"), "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "_" + id
+	}
+	return id
+}
+
+// sbolWriter builds the graph of a JSON-LD SBOL document.
+type sbolWriter struct {
+	graph []map[string]interface{}
+}
+
+// sbolIRI is a reference to another object or term in a JSON-LD document
+func sbolIRI(id string) map[string]interface{} {
+	return map[string]interface{}{"@id": id}
+}
+
+// topLevel adds a top-level object to the document and returns it.
+func (w *sbolWriter) topLevel(t, displayID string) map[string]interface{} {
+	o := map[string]interface{}{
+		"@id":               sbolNamespace + "/" + displayID,
+		"@type":             "sbol:" + t,
+		"sbol:displayId":    displayID,
+		"sbol:hasNamespace": sbolIRI(sbolNamespace),
+	}
+	w.graph = append(w.graph, o)
+	return o
+}
+
+// sbolChild returns an object that's owned by another, eg a Range of a SubComponent.
+func sbolChild(parent map[string]interface{}, t, displayID string) map[string]interface{} {
+	return map[string]interface{}{
+		"@id":            parent["@id"].(string) + "/" + displayID,
+		"@type":          "sbol:" + t,
+		"sbol:displayId": displayID,
+	}
+}
+
+// component adds a DNA Component, and its Sequence, to the document.
+func (w *sbolWriter) component(displayID, seq string, roles ...string) map[string]interface{} {
+	s := w.topLevel("Sequence", displayID+"_seq")
+	s["sbol:elements"] = strings.ToLower(seq)
+	s["sbol:encoding"] = sbolIRI(edamDNA)
+
+	c := w.topLevel("Component", displayID)
+	c["sbol:type"] = []interface{}{sbolIRI(sboDNA)}
+	c["sbol:hasSequence"] = sbolIRI(s["@id"].(string))
+	var roleIRIs []interface{}
+	for _, role := range roles {
+		roleIRIs = append(roleIRIs, sbolIRI(role))
+	}
+	c["sbol:role"] = roleIRIs
+	return c
+}
+
+// addType adds a type to a Component, eg its topology.
+func addType(c map[string]interface{}, t string) {
+	c["sbol:type"] = append(c["sbol:type"].([]interface{}), sbolIRI(t))
+}
+
+// sbolRanges returns the Ranges of a span on a sequence, split in two if it crosses the origin.
+func sbolRanges(parent map[string]interface{}, s span, length int, seqID string) (locations []interface{}) {
+	orientation := soForward
+	if !s.forward {
+		orientation = soReverse
+	}
+
+	bounds := [][]int{[]int{s.start, s.end}}
+	if s.start > s.end {
+		bounds = [][]int{[]int{s.start, length - 1}, []int{0, s.end}}
+	}
+
+	for i, b := range bounds {
+		r := sbolChild(parent, "Range", fmt.Sprintf("range%d", i+1))
+		r["sbol:start"] = b[0] + 1
+		r["sbol:end"] = b[1] + 1
+		r["sbol:orientation"] = sbolIRI(orientation)
+		r["sbol:hasSequence"] = sbolIRI(seqID)
+		locations = append(locations, r)
+	}
+	return locations
+}
+
+// sbolOutput returns an SBOL3 document, as JSON-LD, of the output. The target is a
+// Component and so is each solution's plasmid. A solution's fragments are Components
+// with their provenance, included in its plasmid as SubComponents that are the
+// participants of an assembly Interaction. Primers are Components, their binding sites
+// SequenceFeatures of the fragments.
+func sbolOutput(out *Output) map[string]interface{} {
+	w := &sbolWriter{}
+	seq := strings.ToUpper(out.TargetSeq)
+	targetID := displayID(out.Target)
+
+	method := "Gibson Assembly"
+	if out.Enzyme != "" {
+		method = "Golden Gate Assembly with " + out.Enzyme
+	}
+
+	target := w.component(targetID, seq, soPlasmid)
+	addType(target, soCircular)
+	target["sbol:name"] = out.Target
+	target["sbol:description"] = "target plasmid"
+
+	for i, s := range out.Solutions {
+		solutionID := fmt.Sprintf("%s_solution_%d", targetID, i+1)
+		plasmid := w.component(solutionID, seq, soPlasmid)
+		addType(plasmid, soCircular)
+		plasmid["sbol:description"] = fmt.Sprintf("solution %d: %d fragments, $%.2f", i+1, s.Count, s.Cost)
+		plasmid["prov:wasDerivedFrom"] = sbolIRI(target["@id"].(string))
+		plasmidSeqID := plasmid["sbol:hasSequence"].(map[string]interface{})["@id"].(string)
+
+		interaction := sbolChild(plasmid, "Interaction", "assembly")
+		interaction["sbol:type"] = sbolIRI(sboReaction)
+		interaction["sbol:description"] = method
+
+		var subComponents, participations []interface{}
+		for j, f := range s.Fragments {
+			fragment := sbolFragment(w, fmt.Sprintf("%s_fragment_%d", solutionID, j+1), f)
+
+			sub := sbolChild(plasmid, "SubComponent", fmt.Sprintf("fragment_%d", j+1))
+			sub["sbol:instanceOf"] = sbolIRI(fragment["@id"].(string))
+			sub["sbol:hasLocation"] = sbolRanges(sub, fragSpan(f, seq), len(seq), plasmidSeqID)
+			subComponents = append(subComponents, sub)
+
+			participation := sbolChild(interaction, "Participation", fmt.Sprintf("fragment_%d", j+1))
+			participation["sbol:role"] = sbolIRI(sboReactant)
+			participation["sbol:participant"] = sbolIRI(sub["@id"].(string))
+			participations = append(participations, participation)
+		}
+
+		interaction["sbol:hasParticipation"] = participations
+		plasmid["sbol:hasFeature"] = subComponents
+		plasmid["sbol:hasInteraction"] = []interface{}{interaction}
+	}
+
+	return map[string]interface{}{
+		"@context": map[string]interface{}{
+			"sbol": sbolNS,
+			"prov": provNS,
+		},
+		"@graph": w.graph,
+	}
+}
+
+// sbolFragment adds a fragment, and its primers, to the document as Components.
+func sbolFragment(w *sbolWriter, id string, f *Frag) map[string]interface{} {
+	fragSeq := f.PCRSeq
+	if fragSeq == "" {
+		fragSeq = f.Seq
+	}
+
+	role := soRegion
+	switch f.Type {
+	case pcr.String():
+		role = soPCRProduct
+	case synthetic.String():
+		role = soSynthetic
+	case circular.String():
+		role = soPlasmid
+	}
+
+	fragment := w.component(id, fragSeq, role)
+	addType(fragment, soLinear)
+	fragment["sbol:description"] = fmt.Sprintf("%s fragment, $%.2f", f.Type, f.Cost)
+	if f.URL != "" {
+		fragment["prov:wasDerivedFrom"] = sbolIRI(f.URL)
+	}
+	if f.ID != "" {
+		fragment["sbol:name"] = f.ID
+	}
+	fragSeqID := fragment["sbol:hasSequence"].(map[string]interface{})["@id"].(string)
+
+	var bindingSites []interface{}
+	for _, p := range f.Primers {
+		direction, s := "fwd", span{start: 0, end: len(p.Seq) - 1, forward: true}
+		if !p.Strand {
+			direction, s = "rev", span{start: len(fragSeq) - len(p.Seq), end: len(fragSeq) - 1, forward: false}
+		}
+
+		primer := w.component(fmt.Sprintf("%s_primer_%s", id, direction), p.Seq, soPrimer)
+		addType(primer, soLinear)
+		primer["sbol:description"] = fmt.Sprintf("%s primer, Tm %.1f", direction, p.Tm)
+
+		if s.start < 0 || len(p.Seq) < 1 {
+			continue
+		}
+		site := sbolChild(fragment, "SequenceFeature", "primer_binding_site_"+direction)
+		site["sbol:role"] = []interface{}{sbolIRI(soPrimerBinding)}
+		site["sbol:description"] = "binding site of " + primer["sbol:displayId"].(string)
+		site["sbol:hasLocation"] = sbolRanges(site, s, len(fragSeq), fragSeqID)
+		bindingSites = append(bindingSites, site)
+	}
+	if len(bindingSites) > 0 {
+		fragment["sbol:hasFeature"] = bindingSites
+	}
+
+	return fragment
+}
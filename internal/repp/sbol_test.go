@@ -0,0 +1,176 @@
+package repp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSBOLXML = `<?xml version="1.0" encoding="utf-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:sbol="http://sbols.org/v3#">
+  <sbol:Component rdf:about="https://example.org/device">
+    <sbol:displayId>device</sbol:displayId>
+    <sbol:hasNamespace rdf:resource="https://example.org"/>
+    <sbol:type rdf:resource="https://identifiers.org/SBO:0000251"/>
+    <sbol:type rdf:resource="https://identifiers.org/SO:0000988"/>
+    <sbol:hasSequence rdf:resource="https://example.org/device_seq"/>
+    <sbol:hasFeature>
+      <sbol:SubComponent rdf:about="https://example.org/device/promoter">
+        <sbol:displayId>promoter</sbol:displayId>
+        <sbol:instanceOf rdf:resource="https://example.org/pTet"/>
+        <sbol:hasLocation>
+          <sbol:Range rdf:about="https://example.org/device/promoter/range1">
+            <sbol:start>1</sbol:start>
+            <sbol:end>4</sbol:end>
+            <sbol:orientation rdf:resource="https://identifiers.org/SO:0001030"/>
+            <sbol:hasSequence rdf:resource="https://example.org/device_seq"/>
+          </sbol:Range>
+        </sbol:hasLocation>
+      </sbol:SubComponent>
+    </sbol:hasFeature>
+    <sbol:hasFeature>
+      <sbol:SequenceFeature rdf:about="https://example.org/device/cds">
+        <sbol:displayId>cds</sbol:displayId>
+        <sbol:name>GFP</sbol:name>
+        <sbol:hasLocation>
+          <sbol:Range rdf:about="https://example.org/device/cds/range1">
+            <sbol:start>5</sbol:start>
+            <sbol:end>8</sbol:end>
+            <sbol:orientation rdf:resource="https://identifiers.org/SO:0001031"/>
+            <sbol:hasSequence rdf:resource="https://example.org/device_seq"/>
+          </sbol:Range>
+        </sbol:hasLocation>
+      </sbol:SequenceFeature>
+    </sbol:hasFeature>
+  </sbol:Component>
+  <sbol:Component rdf:about="https://example.org/pTet">
+    <sbol:displayId>pTet</sbol:displayId>
+  </sbol:Component>
+  <sbol:Sequence rdf:about="https://example.org/device_seq">
+    <sbol:displayId>device_seq</sbol:displayId>
+    <sbol:elements>atgcaaaacc</sbol:elements>
+    <sbol:encoding rdf:resource="https://identifiers.org/edam:format_1207"/>
+  </sbol:Sequence>
+</rdf:RDF>
+`
+
+func Test_readSBOL_xml(t *testing.T) {
+	frags, err := readSBOL("test.xml", testSBOLXML, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frags) != 1 || frags[0].ID != "device" || frags[0].Seq != "ATGCAAAACC" || frags[0].fragType != circular {
+		t.Errorf("readSBOL() = %+v, want the circular device", frags)
+	}
+
+	features, err := readSBOL("test.xml", testSBOLXML, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 {
+		t.Fatalf("readSBOL() parsed %d features, want 2", len(features))
+	}
+	if features[0].ID != "pTet" || features[0].Seq != "ATGC" {
+		t.Errorf("readSBOL() sub-component = %+v, want pTet", features[0])
+	}
+	if features[1].ID != "GFP" || features[1].Seq != "TTTT" {
+		t.Errorf("readSBOL() sequence feature = %+v, want the reverse complement of GFP", features[1])
+	}
+}
+
+func Test_sbolOutput(t *testing.T) {
+	seq := strings.ToUpper(testPlasmid)
+	out := &Output{
+		Target:    "target plasmid",
+		TargetSeq: seq,
+		Solutions: []Solution{
+			Solution{
+				Count: 2,
+				Cost:  100,
+				Fragments: []*Frag{
+					&Frag{
+						Type:   "pcr",
+						URL:    "https://www.addgene.org/1/",
+						PCRSeq: seq[:500],
+						Primers: []Primer{
+							Primer{Seq: seq[:20], Strand: true},
+							Primer{Seq: reverseComplement(seq[480:500]), Strand: false},
+						},
+					},
+					&Frag{
+						Type: "synthetic",
+						Seq:  seq[470:] + seq[:30],
+					},
+				},
+			},
+		},
+	}
+
+	contents, err := json.Marshal(sbolOutput(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := parseSBOL(string(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the target, solution, two fragments and two primers
+	components := map[string]*sbolObject{}
+	for _, o := range doc.objects {
+		if o.is("Component") {
+			components[o.value("displayId")] = o
+		}
+	}
+	if len(components) != 6 {
+		t.Fatalf("sbolOutput() has %d components, want 6", len(components))
+	}
+
+	fragment := components["target_plasmid_solution_1_fragment_1"]
+	if fragment == nil || fragment.value("wasDerivedFrom") != "https://www.addgene.org/1/" || !fragment.has("role", "SO:0000006") {
+		t.Errorf("sbolOutput() fragment = %+v, want a PCR product from Addgene", fragment)
+	}
+	if _, ok := components["target_plasmid_solution_1_fragment_1_primer_rev"]; !ok {
+		t.Error("sbolOutput() is missing the reverse primer")
+	}
+
+	// the synthetic fragment crosses the origin, it's in two ranges
+	sub := doc.byID[sbolNamespace+"/target_plasmid_solution_1/fragment_2"]
+	if sub == nil || len(sub.props["hasLocation"]) != 2 {
+		t.Fatalf("sbolOutput() sub-component = %+v, want two ranges", sub)
+	}
+
+	// it's read back with its features
+	features, err := readSBOL("test.jsonld", string(contents), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFeatures := map[string]string{
+		"target_plasmid_solution_1_fragment_1": seq[:500],
+		"target_plasmid_solution_1_fragment_2": seq[470:] + seq[:30],
+		"primer_binding_site_fwd":              seq[:20],
+		"primer_binding_site_rev":              reverseComplement(seq[480:500]),
+	}
+	for _, f := range features {
+		if want, ok := wantFeatures[f.ID]; !ok || f.Seq != want {
+			t.Errorf("readSBOL() feature %s = %s, want %s", f.ID, f.Seq, want)
+		}
+	}
+	if len(features) != len(wantFeatures) {
+		t.Errorf("readSBOL() read %d features, want %d", len(features), len(wantFeatures))
+	}
+}
+
+func Test_displayID(t *testing.T) {
+	for name, want := range map[string]string{
+		"target plasmid":   "target_plasmid",
+		"BBa_K123000":      "BBa_K123000",
+		"113726(circular)": "_113726_circular",
+		"":                 "_",
+	} {
+		if got := displayID(name); got != want {
+			t.Errorf("displayID(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -1,35 +1,58 @@
 package repp
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/jjtimmons/repp/config"
-	"github.com/spf13/cobra"
 )
 
-// SequenceFindCmd is for BLAST'ing a sequence against the dbs and finding matches
-func SequenceFindCmd(cmd *cobra.Command, args []string) {
-	if len(args) < 1 {
-		cmd.Help()
-		stderr.Fatalln("\nno sequence passed.")
+// Match is a BLAST match between a query sequence and an entry in a database.
+type Match struct {
+	// Entry is the name of the matched entry in the database
+	Entry string `json:"entry"`
+
+	// QueryStart is the start index of the match on the query sequence
+	QueryStart int `json:"queryStart"`
+
+	// QueryEnd is the end index of the match on the query sequence
+	QueryEnd int `json:"queryEnd"`
+
+	// SubjectStart is the start index of the match on the entry's sequence
+	SubjectStart int `json:"subjectStart"`
+
+	// SubjectEnd is the end index of the match on the entry's sequence
+	SubjectEnd int `json:"subjectEnd"`
+
+	// DB is the database with the entry
+	DB string `json:"db"`
+
+	// URL of the entry, eg its Addgene page
+	URL string `json:"url,omitempty"`
+}
+
+// FindSequence BLASTs a sequence against the dbs and returns its matches,
+// largest first.
+func FindSequence(ctx context.Context, seq string, flags *Flags) ([]Match, error) {
+	if seq == "" {
+		return nil, fmt.Errorf("no sequence passed")
 	}
-	seq := args[0]
 
-	flags, _ := parseCmdFlags(cmd, args, false)
-	tw := blastWriter()
-	matches, err := blast("find_cmd", seq, true, flags.dbs, flags.filters, flags.identity, tw)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matches, err := blast(ctx, "find_cmd", seq, true, flags.dbs, flags.filters, flags.identity, flags.conf)
 	if err != nil {
-		stderr.Fatalln(err)
+		return nil, err
 	}
 
 	if len(matches) == 0 {
-		stderr.Fatalln("no matches found")
+		return nil, fmt.Errorf("no matches found")
 	}
 
 	// sort so the largest matches are first
@@ -42,9 +65,8 @@ func SequenceFindCmd(cmd *cobra.Command, args []string) {
 		return m.entry + strconv.Itoa(m.subjectStart) + strconv.Itoa(m.subjectEnd)
 	}
 
+	var found []Match
 	seenIds := make(map[string]bool)
-	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
-	fmt.Fprintf(writer, "entry\tqstart\tqend\tsstart\tsend\tdatabase\tURL\t\n")
 	for _, m := range matches {
 		if _, seen := seenIds[key(m)]; seen {
 			continue
@@ -54,47 +76,73 @@ func SequenceFindCmd(cmd *cobra.Command, args []string) {
 			continue
 		}
 
-		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", m.entry, m.queryStart, m.queryEnd, m.subjectStart, m.subjectEnd, m.db, parseURL(m.entry, m.db))
+		found = append(found, Match{
+			Entry:        m.entry,
+			QueryStart:   m.queryStart,
+			QueryEnd:     m.queryEnd,
+			SubjectStart: m.subjectStart,
+			SubjectEnd:   m.subjectEnd,
+			DB:           m.db,
+			URL:          parseURL(m.entry, m.db, flags.conf),
+		})
 		seenIds[key(m)] = true
 	}
-	writer.Flush()
-}
 
-// SequenceCmd takes a cobra command (with its flags) and runs plasmid.
-func SequenceCmd(cmd *cobra.Command, args []string) {
-	Sequence(parseCmdFlags(cmd, args, true))
+	return found, nil
 }
 
 // Sequence is for running an end to end plasmid design using a target sequence.
-func Sequence(flags *Flags, conf *config.Config) [][]*Frag {
+// The output is written to the out path of the flags, if set.
+//
+// If the context is cancelled or times out while assemblies are being filled, the best
+// solutions found so far are written and returned with the context's error.
+//
+// If the flags have an explain path, a report of the candidate assemblies is written to
+// it, even if the design fails.
+func Sequence(ctx context.Context, flags *Flags, conf *config.Config) (*Output, error) {
 	start := time.Now()
 
-	insert, target, solutions, err := sequence(flags, conf) // build up the assemblies that make the sequence
-	if err != nil {
-		stderr.Fatalln(err)
+	ctx, explanation := explain(ctx, flags)
+	insert, target, solutions, gg, err := sequence(ctx, flags, conf) // build up the assemblies that make the sequence
+	if explanation != nil {
+		explanation.Target = target.ID
+		if err := explanation.write(flags.explain); err != nil {
+			return nil, err
+		}
 	}
+	if err != nil && len(solutions) == 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err() // the error was from a step that was cut short
+		}
+		return nil, err
+	}
+	stopped := err // the context's error if the design stopped early with solutions
 
-	// write the results to a file
 	elapsed := time.Since(start)
-	_, err = writeJSON(
-		flags.out,
+	out, err := newOutput(
 		target.ID,
 		target.Seq,
 		solutions,
 		len(insert.Seq),
 		elapsed.Seconds(),
 		flags.backboneMeta,
+		gg,
 		conf,
 	)
 	if err != nil {
-		stderr.Fatalln(err)
+		return nil, err
 	}
 
-	if conf.Verbose {
-		fmt.Printf("%s\n\n", elapsed)
+	// write the results to a file
+	if flags.out != "" {
+		if err = writeOutput(flags, out); err != nil {
+			return nil, err
+		}
 	}
 
-	return solutions
+	logger.Debug("designed", "target", target.ID, "elapsed", elapsed)
+
+	return out, stopped
 }
 
 // sequence builds a plasmid cost optimization
@@ -120,26 +168,27 @@ func Sequence(flags *Flags, conf *config.Config) [][]*Frag {
 // "fill-in" the nodes. Create primers on the Frag if it's a PCR Frag
 // or create a sequence to be synthesized if it's a synthetic fragment.
 // Error out and repeat the build stage if a Frag fails to be filled
-func sequence(input *Flags, conf *config.Config) (insert, target *Frag, solutions [][]*Frag, err error) {
+//
+// If the assembly method is Golden Gate, a Type IIS enzyme without sites in
+// the target is chosen first and returned for writing the output
+func sequence(ctx context.Context, input *Flags, conf *config.Config) (insert, target *Frag, solutions [][]*Frag, gg *goldenGate, err error) {
 	// read the target sequence (the first in the slice is used)
-	fragments, err := read(input.in, false)
+	fragments, err := input.read(false)
 	if err != nil {
-		return &Frag{}, &Frag{}, nil, fmt.Errorf("failed to read target sequence from %s: %v", input.in, err)
+		return &Frag{}, &Frag{}, nil, nil, fmt.Errorf("failed to read target sequence from %s: %v", input.in, err)
 	}
 
 	if len(fragments) > 1 {
-		stderr.Printf(
-			"warning: %d fragments were in %s. Only targeting the sequence of the first: %s\n",
-			len(fragments),
-			input.in,
-			fragments[0].ID,
+		logger.Warn(
+			"only targeting the sequence of the first fragment, design all of them with --batch",
+			"fragments", len(fragments),
+			"in", input.in,
+			"target", fragments[0].ID,
 		)
 	}
 
 	target = fragments[0]
-	if conf.Verbose {
-		fmt.Printf("Building %s\n", target.ID)
-	}
+	logger.Debug("building", "target", target.ID)
 
 	// if a backbone was specified, add it to the sequence of the target frag
 	insert = target.copy() // store a copy for logging later
@@ -147,25 +196,42 @@ func sequence(input *Flags, conf *config.Config) (insert, target *Frag, solution
 		target.Seq += input.backbone.Seq
 	}
 
-	// get all the matches against the target plasmid
-	tw := blastWriter()
-	matches, err := blast(target.ID, target.Seq, true, input.dbs, input.filters, input.identity, tw)
-	if conf.Verbose {
-		tw.Flush()
+	// pick the enzyme for Golden Gate assembly, it can't cut the target
+	if input.method == methodGoldenGate {
+		enzymeDB, err := input.enzymes()
+		if err != nil {
+			return &Frag{}, &Frag{}, nil, nil, err
+		}
+
+		if gg, err = newGoldenGate(target.Seq, enzymeDB.enzymes); err != nil {
+			return &Frag{}, &Frag{}, nil, nil, fmt.Errorf("failed to prepare %s for Golden Gate assembly: %w", target.ID, err)
+		}
+
+		logger.Debug("picked the Golden Gate enzyme", "enzyme", gg.enzyme.name)
 	}
+
+	if len(input.dbs) == 0 {
+		return &Frag{}, &Frag{}, nil, nil, fmt.Errorf("no fragment databases chosen")
+	}
+
+	// get all the matches against the target plasmid
+	reportProgress(ctx, func(p *Progress) { p.Stage = "blast" })
+	matches, err := blast(ctx, target.ID, target.Seq, true, input.dbs, input.filters, input.identity, conf)
 	if err != nil {
 		dbMessage := strings.Join(input.dbs, ", ")
-		return &Frag{}, &Frag{}, nil, fmt.Errorf("failed to blast %s against the dbs %s: %v", target.ID, dbMessage, err)
+		return &Frag{}, &Frag{}, nil, nil, fmt.Errorf("failed to blast %s against the dbs %s: %w", target.ID, dbMessage, err)
 	}
 
 	// keep only "proper" arcs (non-self-contained)
 	matches = cull(matches, len(target.Seq), conf.PCRMinLength, 1)
-	if conf.Verbose {
-		fmt.Printf("%d matches after culling\n", len(matches)/2)
-	}
+	logger.Debug("culled matches", "matches", len(matches)/2)
 
 	// map fragment Matches to nodes
 	frags := newFrags(matches, conf)
+	if gg != nil {
+		// fragments with internal recognition sites would be cleaved during assembly
+		frags = gg.exclude(frags)
+	}
 
 	if input.backbone.ID != "" {
 		// add the backbone in as fragment (copy twice across zero index)
@@ -186,15 +252,34 @@ func sequence(input *Flags, conf *config.Config) (insert, target *Frag, solution
 		})
 	}
 
+	if err = ctx.Err(); err != nil {
+		return &Frag{}, &Frag{}, nil, nil, err
+	}
+
 	// build up a slice of assemblies that could, within the upper-limit on
 	// fragment count, be assembled to make the target plasmid
-	assemblies := createAssemblies(frags, target.Seq, len(target.Seq), false, conf)
+	reportProgress(ctx, func(p *Progress) {
+		p.Stage = "build"
+		p.Fragments = len(frags)
+	})
+	assemblies, err := createAssemblies(ctx, frags, target.Seq, len(target.Seq), false, gg, conf)
+	if err != nil {
+		return &Frag{}, &Frag{}, nil, nil, err
+	}
 
 	// build up a map from fragment count to a sorted list of assemblies with that number
 	assemblyCounts, countToAssemblies := groupAssembliesByCount(assemblies)
 
-	// fill in pareto optimal assembly solutions
-	solutions = fillAssemblies(target.Seq, assemblyCounts, countToAssemblies, conf)
+	// fill in pareto optimal assembly solutions. If the design is cancelled or times out
+	// while they're filled, the solutions found so far are returned with the context's error
+	reportProgress(ctx, func(p *Progress) {
+		p.Stage = "fill"
+		p.Assemblies = len(assemblies)
+	})
+	solutions = fillAssemblies(ctx, target.Seq, assemblyCounts, countToAssemblies, gg, input.objective, conf)
+	if err = ctx.Err(); err != nil && len(solutions) == 0 {
+		return &Frag{}, &Frag{}, nil, nil, err
+	}
 
-	return insert, target, solutions, nil
+	return insert, target, solutions, gg, err
 }
@@ -1,6 +1,7 @@
 package repp
 
 import (
+	"context"
 	"path"
 	"testing"
 )
@@ -8,7 +9,7 @@ import (
 // if an input fragment being built is exactly the same as one in a DB, it should be used
 // as is and without PCR or any preparation
 func Test_sequence(t *testing.T) {
-	fs, c := NewFlags(
+	fs, c, err := NewFlags(
 		path.Join("..", "..", "test", "input", "BBa_K1649003.fa"),
 		path.Join("..", "..", "test", "output", "BBa_K1649003.json"),
 		"pSB1A3",
@@ -19,10 +20,16 @@ func Test_sequence(t *testing.T) {
 		true,
 		false,
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	results := Sequence(fs, c) // use addgene database
+	out, err := Sequence(context.Background(), fs, c) // use addgene database
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if len(results) < 1 {
+	if len(out.Solutions) < 1 {
 		t.Fail()
 	}
 }
@@ -0,0 +1,904 @@
+package repp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// This is a port of primer3's thermodynamic alignment, thal, that ntthal and primer3_core
+// use for the melting temperatures of hairpins and dimers. Structures are found by dynamic
+// programming over the nearest-neighbor parameters of SantaLucia and Hicks (2004), from
+// primer3's primer3_config directory. The quirks of thal are kept so the temperatures
+// match ntthal's.
+
+const (
+	// thalMaxLoop is the longest bulge or internal loop considered
+	thalMaxLoop = 30
+
+	// thalMinHairpinLoop is the shortest hairpin loop
+	thalMinHairpinLoop = 3
+
+	// below thalMinEntropyCutoff, the entropy of a structure is replaced by thalMinEntropy
+	thalMinEntropyCutoff = -2500.0
+	thalMinEntropy       = -3224.0
+
+	// thalGasConstant is in cal/K/mol
+	thalGasConstant = 1.9872
+
+	thalAbsoluteZero = -273.15
+
+	// the entropy penalty of asymmetric internal loops, per bp of asymmetry
+	thalLoopAsymmetryS = -300 / 310.15
+
+	// the penalties of terminal AT pairs
+	thalATPenaltyH = 2200.0
+	thalATPenaltyS = 6.9
+)
+
+var thalInf = math.Inf(1)
+
+// thalConditions are the salt and oligo concentrations and temperature of an alignment
+type thalConditions struct {
+	// mv is the concentration of monovalent cations in mM
+	mv float64
+
+	// dv is the concentration of divalent cations in mM
+	dv float64
+
+	// dntp is the concentration of dNTPs in mM
+	dntp float64
+
+	// dna is the concentration of oligos in nM
+	dna float64
+
+	// temp is the temperature, in °C, at which structures are compared
+	temp float64
+}
+
+// ntthalConditions are ntthal's defaults
+var ntthalConditions = thalConditions{mv: 50, dna: 50, temp: 37}
+
+// primer3Conditions are primer3_core's defaults, used when picking primers
+var primer3Conditions = thalConditions{mv: 50, dv: 1.5, dntp: 0.6, dna: 50, temp: 37}
+
+// saltCorrection is the entropy correction for the salt conditions, per pair.
+// Divalent cations are converted to monovalent equivalents, von Ahsen 2001
+func (c thalConditions) saltCorrection() float64 {
+	dntp := c.dntp
+	if c.dv <= 0 {
+		dntp = c.dv
+	}
+	return 0.368 * math.Log((c.mv+120*math.Sqrt(c.dv-dntp))/1000)
+}
+
+// thalTable4 is a table of parameters indexed by four bases. 5'-ab-3' / 3'-cd-5'
+type thalTable4 [5][5][5][5]float64
+
+// thalTable3 is a table of dangling end parameters indexed by [top][bottom][dangle]
+type thalTable3 [5][5][5]float64
+
+// thalParams are thal's nearest-neighbor parameters. Entropies are in cal/K/mol
+// and enthalpies in cal/mol. Bases are indexed A, C, G, T and N
+type thalParams struct {
+	stackS, stackH     thalTable4
+	stackmmS, stackmmH thalTable4
+	tstackS, tstackH   thalTable4
+	tstack2S, tstack2H thalTable4
+
+	dangle3S, dangle3H thalTable3
+	dangle5S, dangle5H thalTable3
+
+	interiorS, interiorH [thalMaxLoop]float64
+	bulgeS, bulgeH       [thalMaxLoop]float64
+	hairpinS, hairpinH   [thalMaxLoop]float64
+
+	triloopS, triloopH     map[string]float64
+	tetraloopS, tetraloopH map[string]float64
+}
+
+// thalTables are the parsed parameters, read once from thalParameterFiles
+var thalTables = struct {
+	sync.Once
+	params *thalParams
+}{}
+
+// thalParameters returns thal's nearest-neighbor parameters
+func thalParameters() *thalParams {
+	thalTables.Do(func() {
+		thalTables.params = parseThalParams(thalParameterFiles)
+	})
+	return thalTables.params
+}
+
+// parseThalParams reads the parameters from the contents of primer3_config's files
+func parseThalParams(files map[string]string) *thalParams {
+	values := func(name string) []float64 {
+		var vals []float64
+		for _, field := range strings.Fields(files[name]) {
+			vals = append(vals, thalValue(field))
+		}
+		return vals
+	}
+
+	// the file's values are for ACGT, parameters with an N are missing
+	table4 := func(t *thalTable4, vals []float64, entropy bool) {
+		for a := range t {
+			for b := range t[a] {
+				for c := range t[a][b] {
+					for d := range t[a][b][c] {
+						t[a][b][c][d] = thalInf
+						if entropy {
+							t[a][b][c][d] = -1
+						}
+					}
+				}
+			}
+		}
+		for n, v := range vals {
+			t[n/64][n/16%4][n/4%4][n%4] = v
+		}
+	}
+	table3 := func(t *thalTable3, vals []float64, entropy bool) {
+		for a := range t {
+			for b := range t[a] {
+				for c := range t[a][b] {
+					t[a][b][c] = thalInf
+					if entropy {
+						t[a][b][c] = -1
+					}
+				}
+			}
+		}
+		for n, v := range vals {
+			t[n/16][n/4%4][n%4] = v
+		}
+	}
+	loops := func(name string) map[string]float64 {
+		m := make(map[string]float64)
+		for _, line := range strings.Split(files[name], "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 {
+				m[fields[0]] = thalValue(fields[1])
+			}
+		}
+		return m
+	}
+
+	p := &thalParams{}
+	table4(&p.stackS, values("stack.ds"), true)
+	table4(&p.stackH, values("stack.dh"), false)
+	table4(&p.stackmmS, values("stackmm.ds"), true)
+	table4(&p.stackmmH, values("stackmm.dh"), false)
+	table4(&p.tstackS, values("tstack_tm_inf.ds"), true)
+	table4(&p.tstackH, values("tstack.dh"), false)
+	table4(&p.tstack2S, values("tstack2.ds"), true)
+	table4(&p.tstack2H, values("tstack2.dh"), false)
+
+	// the first 64 dangles are on 3' ends, the last 64 on 5' ends
+	danglesS, danglesH := values("dangle.ds"), values("dangle.dh")
+	table3(&p.dangle3S, danglesS[:64], true)
+	table3(&p.dangle3H, danglesH[:64], false)
+	table3(&p.dangle5S, danglesS[64:], true)
+	table3(&p.dangle5H, danglesH[64:], false)
+
+	// each line is the loop size then its interior, bulge and hairpin loop parameters
+	loopsS, loopsH := values("loops.ds"), values("loops.dh")
+	for k := 0; k < thalMaxLoop; k++ {
+		p.interiorS[k], p.bulgeS[k], p.hairpinS[k] = loopsS[4*k+1], loopsS[4*k+2], loopsS[4*k+3]
+		p.interiorH[k], p.bulgeH[k], p.hairpinH[k] = loopsH[4*k+1], loopsH[4*k+2], loopsH[4*k+3]
+	}
+
+	p.triloopS, p.triloopH = loops("triloop.ds"), loops("triloop.dh")
+	p.tetraloopS, p.tetraloopH = loops("tetraloop.ds"), loops("tetraloop.dh")
+
+	return p
+}
+
+// thalValue parses a parameter, "inf" is an infinite one
+func thalValue(field string) float64 {
+	if field == "inf" {
+		return thalInf
+	}
+	v, _ := strconv.ParseFloat(field, 64)
+	return v
+}
+
+// thal is the state of one alignment. The most stable structures closed by each pair of
+// bases (i, j) are in dH and dS. Both sequences are 1-based and padded with Ns
+type thal struct {
+	p *thalParams
+
+	s1, s2     []int
+	len1, len2 int
+
+	// seq is the sequence of a hairpin, for its tri and tetraloops
+	seq string
+
+	dH, dS []float64
+	pairs  []int
+
+	initH, initS, rc float64
+
+	// temp is in K
+	temp float64
+
+	salt float64
+
+	// the most stable structures of the hairpin's exterior loop up to each base
+	hend, send []float64
+	nend       []int
+}
+
+// newThal returns an alignment between sequences of the two lengths
+func newThal(len1, len2 int, c thalConditions) *thal {
+	n := (len1 + 2) * (len2 + 2)
+	return &thal{
+		p:     thalParameters(),
+		len1:  len1,
+		len2:  len2,
+		dH:    make([]float64, n),
+		dS:    make([]float64, n),
+		pairs: make([]int, n),
+		temp:  c.temp - thalAbsoluteZero,
+		salt:  c.saltCorrection(),
+	}
+}
+
+// thalEncode returns the 1-based indexes of a sequence's bases, reversed if
+// reverse is true, and padded with an N on either end
+func thalEncode(seq string, reverse bool) []int {
+	n := len(seq)
+	encoded := make([]int, n+2)
+	encoded[0], encoded[n+1] = 4, 4
+	for i := 0; i < n; i++ {
+		b := 4
+		switch seq[i] {
+		case 'A':
+			b = 0
+		case 'C':
+			b = 1
+		case 'G':
+			b = 2
+		case 'T':
+			b = 3
+		}
+
+		if reverse {
+			encoded[n-i] = b
+		} else {
+			encoded[i+1] = b
+		}
+	}
+	return encoded
+}
+
+// thalFinite returns whether a value is finite
+func thalFinite(v float64) bool {
+	return !math.IsInf(v, 0)
+}
+
+// thalEqual returns whether two values are equal enough for a traceback
+func thalEqual(a, b float64) bool {
+	if !thalFinite(a) || !thalFinite(b) {
+		return !thalFinite(a) && !thalFinite(b)
+	}
+	return math.Abs(a-b) < 1e-5
+}
+
+// thalPair returns whether two bases pair
+func thalPair(a, b int) bool {
+	return a < 4 && b < 4 && a+b == 3
+}
+
+// atPenaltyS is the entropy penalty of a terminal AT pair
+func atPenaltyS(a, b int) float64 {
+	if (a == 0 && b == 3) || (a == 3 && b == 0) {
+		return thalATPenaltyS
+	}
+	return 0
+}
+
+// atPenaltyH is the enthalpy penalty of a terminal AT pair
+func atPenaltyH(a, b int) float64 {
+	if (a == 0 && b == 3) || (a == 3 && b == 0) {
+		return thalATPenaltyH
+	}
+	return 0
+}
+
+func (t *thal) at(i, j int) int { return i*(t.len2+2) + j }
+
+func (t *thal) H(i, j int) float64 { return t.dH[t.at(i, j)] }
+
+func (t *thal) S(i, j int) float64 { return t.dS[t.at(i, j)] }
+
+func (t *thal) set(i, j int, s, h float64, pairs int) {
+	k := t.at(i, j)
+	t.dS[k], t.dH[k], t.pairs[k] = s, h, pairs
+}
+
+// tm is the melting temperature, in K, of a structure without the salt correction
+func (t *thal) tm(h, s float64) float64 {
+	return (h + t.initH) / (s + t.initS + t.rc)
+}
+
+// thalDimer returns the melting temperature, in °C, of the most stable duplex between
+// two sequences. If end1, it's the most stable duplex with the 3' end of seq1, like
+// ntthal's END1. It's 0 if there isn't one.
+func thalDimer(seq1, seq2 string, end1 bool, c thalConditions) float64 {
+	seq1, seq2 = strings.ToUpper(seq1), strings.ToUpper(seq2)
+	if seq1 == "" || seq2 == "" {
+		return 0
+	}
+
+	t := newThal(len(seq1), len(seq2), c)
+	t.s1, t.s2 = thalEncode(seq1, false), thalEncode(seq2, true)
+	t.initH, t.initS = 200, -5.7
+	t.rc = thalGasConstant * math.Log(c.dna/4000000000.0)
+	if seq1 == reverseComplement(seq1) && seq2 == reverseComplement(seq2) {
+		t.rc = thalGasConstant * math.Log(c.dna/1000000000.0)
+	}
+
+	for i := 1; i <= t.len1; i++ {
+		for j := 1; j <= t.len2; j++ {
+			if thalPair(t.s1[i], t.s2[j]) {
+				t.set(i, j, thalMinEntropy, 0, 0)
+			} else {
+				t.set(i, j, -1, thalInf, 0)
+			}
+		}
+	}
+	t.fillDimer()
+
+	// the end of the duplex with the highest melting temperature
+	bestI, bestJ, bestTm := 0, 0, math.Inf(-1)
+	from := 1
+	if end1 {
+		from = t.len1
+	}
+	for i := from; i <= t.len1; i++ {
+		for j := 1; j <= t.len2; j++ {
+			rs, rh := t.rsh(i, j)
+			if tm := t.tm(t.H(i, j)+rh, t.S(i, j)+rs); tm > bestTm {
+				bestI, bestJ, bestTm = i, j, tm
+			}
+		}
+	}
+	if bestI == 0 || !thalFinite(bestTm) {
+		return 0
+	}
+
+	rs, rh := t.rsh(bestI, bestJ)
+	dH := t.H(bestI, bestJ) + rh + t.initH
+	dS := t.S(bestI, bestJ) + rs + t.initS
+	pairs := t.pairs[t.at(bestI, bestJ)]
+
+	return dH/(dS+float64(pairs-1)*t.salt+t.rc) + thalAbsoluteZero
+}
+
+// fillDimer finds the most stable duplex ending in each pair (i, j)
+func (t *thal) fillDimer() {
+	for i := 1; i <= t.len1; i++ {
+		for j := 1; j <= t.len2; j++ {
+			if !thalFinite(t.H(i, j)) {
+				continue
+			}
+
+			if s, h := t.lsh(i, j); thalFinite(h) {
+				t.set(i, j, s, h, 1)
+			}
+
+			if i == 1 || j == 1 {
+				continue
+			}
+
+			t.maxTM(i, j)
+
+			// bulges and internal loops before the pair
+			for d := 3; d <= thalMaxLoop+2; d++ {
+				ii := i - 1
+				jj := -ii - d + (j + i)
+				if jj < 1 {
+					ii -= 1 - jj
+					jj = 1
+				}
+
+				for ; ii > 0 && jj < j; ii, jj = ii-1, jj+1 {
+					if !thalFinite(t.H(ii, jj)) {
+						continue
+					}
+
+					s, h, ok := t.bulgeInternal(ii, jj, i, j)
+					if !ok || !thalFinite(h) {
+						continue
+					}
+					if s < thalMinEntropyCutoff {
+						s, h = thalMinEntropy, 0
+					}
+					t.set(i, j, s, h, t.pairs[t.at(ii, jj)]+1)
+				}
+			}
+		}
+	}
+}
+
+// lsh returns the entropy and enthalpy of a duplex's start at (i, j), with its terminal
+// mismatch or dangling ends
+func (t *thal) lsh(i, j int) (float64, float64) {
+	p, s1, s2 := t.p, t.s1, t.s2
+	return t.helixEnd(s1[i], s2[j],
+		p.tstack2S[s2[j]][s2[j-1]][s1[i]][s1[i-1]], p.tstack2H[s2[j]][s2[j-1]][s1[i]][s1[i-1]],
+		p.dangle3S[s2[j]][s1[i]][s2[j-1]], p.dangle3H[s2[j]][s1[i]][s2[j-1]],
+		p.dangle5S[s2[j]][s1[i]][s1[i-1]], p.dangle5H[s2[j]][s1[i]][s1[i-1]],
+	)
+}
+
+// rsh returns the entropy and enthalpy of a duplex's end at (i, j), with its terminal
+// mismatch or dangling ends
+func (t *thal) rsh(i, j int) (float64, float64) {
+	p, s1, s2 := t.p, t.s1, t.s2
+	return t.helixEnd(s1[i], s2[j],
+		p.tstack2S[s1[i]][s1[i+1]][s2[j]][s2[j+1]], p.tstack2H[s1[i]][s1[i+1]][s2[j]][s2[j+1]],
+		p.dangle3S[s1[i]][s2[j]][s1[i+1]], p.dangle3H[s1[i]][s2[j]][s1[i+1]],
+		p.dangle5S[s1[i]][s2[j]][s2[j+1]], p.dangle5H[s1[i]][s2[j]][s2[j+1]],
+	)
+}
+
+// helixEnd returns the most stable of a terminal mismatch, dangling ends, or neither at
+// the end of a helix with the pair (a, b)
+func (t *thal) helixEnd(a, b int, mmS, mmH, d3S, d3H, d5S, d5H float64) (float64, float64) {
+	if !thalPair(a, b) {
+		return -1, thalInf
+	}
+
+	atS, atH := atPenaltyS(a, b), atPenaltyH(a, b)
+
+	// a terminal mismatch
+	S1, H1 := atS+mmS, atH+mmH
+	G1 := H1 - t.temp*S1
+	if !thalFinite(H1) || G1 > 0 {
+		S1, H1, G1 = -1, thalInf, 1
+	}
+	T1 := t.tm(H1, S1)
+
+	// or dangling ends
+	dangles := true
+	var S2, H2 float64
+	switch {
+	case thalFinite(d3H) && thalFinite(d5H):
+		S2, H2 = atS+d3S+d5S, atH+d3H+d5H
+	case thalFinite(d3H):
+		S2, H2 = atS+d3S, atH+d3H
+	case thalFinite(d5H):
+		S2, H2 = atS+d5S, atH+d5H
+	default:
+		dangles = false
+	}
+	if dangles {
+		if !thalFinite(H2) {
+			S2, H2 = -1, thalInf
+		}
+		T2 := t.tm(H2, S2)
+		if !thalFinite(H1) || G1 >= 0 || T1 < T2 {
+			S1, H1, T1 = S2, H2, T2
+		}
+	}
+
+	// or neither
+	if !thalFinite(H1) || T1 < t.tm(atH, atS) {
+		return atS, atH
+	}
+	return S1, H1
+}
+
+// maxTM compares the duplex ending at (i, j) with the stack on the one ending at (i-1, j-1)
+func (t *thal) maxTM(i, j int) {
+	p, s1, s2 := t.p, t.s1, t.s2
+
+	S0, H0, n0 := t.S(i, j), t.H(i, j), t.pairs[t.at(i, j)]
+	T0 := t.tm(H0, S0)
+
+	S1, H1 := -1.0, thalInf
+	stackH := p.stackH[s1[i-1]][s1[i]][s2[j-1]][s2[j]]
+	if thalFinite(t.H(i-1, j-1)) && thalFinite(stackH) {
+		S1 = t.S(i-1, j-1) + p.stackS[s1[i-1]][s1[i]][s2[j-1]][s2[j]]
+		H1 = t.H(i-1, j-1) + stackH
+	}
+	T1 := t.tm(H1, S1)
+
+	if S1 < thalMinEntropyCutoff {
+		S1, H1 = thalMinEntropy, 0
+	}
+	if S0 < thalMinEntropyCutoff {
+		S0, H0 = thalMinEntropy, 0
+	}
+
+	if T1 > T0 {
+		t.set(i, j, S1, H1, t.pairs[t.at(i-1, j-1)]+1)
+	} else {
+		t.set(i, j, S0, H0, n0)
+	}
+}
+
+// bulgeInternal returns the duplex ending at (ii, jj) with a bulge or internal loop after
+// the one ending at (i, j), and whether it's more stable than the current one
+func (t *thal) bulgeInternal(i, j, ii, jj int) (float64, float64, bool) {
+	p, s1, s2 := t.p, t.s1, t.s2
+	l1, l2 := ii-i-1, jj-j-1
+	loop := l1 + l2 - 1
+
+	var S, H float64
+	switch {
+	case (l1 == 0 && l2 > 0) || (l2 == 0 && l1 > 0):
+		if l1 == 1 || l2 == 1 {
+			S = p.bulgeS[loop] + p.stackS[s1[i]][s1[ii]][s2[j]][s2[jj]]
+			H = p.bulgeH[loop] + p.stackH[s1[i]][s1[ii]][s2[j]][s2[jj]]
+		} else {
+			S = p.bulgeS[loop] + atPenaltyS(s1[i], s2[j]) + atPenaltyS(s1[ii], s2[jj])
+			H = p.bulgeH[loop] + atPenaltyH(s1[i], s2[j]) + atPenaltyH(s1[ii], s2[jj])
+		}
+	case l1 == 1 && l2 == 1:
+		S = p.stackmmS[s1[i]][s1[i+1]][s2[j]][s2[j+1]] + p.stackmmS[s2[jj]][s2[jj-1]][s1[ii]][s1[ii-1]]
+		H = p.stackmmH[s1[i]][s1[i+1]][s2[j]][s2[j+1]] + p.stackmmH[s2[jj]][s2[jj-1]][s1[ii]][s1[ii-1]]
+	default:
+		S = p.interiorS[loop] + p.tstackS[s1[i]][s1[i+1]][s2[j]][s2[j+1]] + p.tstackS[s2[jj]][s2[jj-1]][s1[ii]][s1[ii-1]] +
+			thalLoopAsymmetryS*math.Abs(float64(l1-l2))
+		H = p.interiorH[loop] + p.tstackH[s1[i]][s1[i+1]][s2[j]][s2[j+1]] + p.tstackH[s2[jj]][s2[jj-1]][s1[ii]][s1[ii-1]]
+	}
+
+	S += t.S(i, j)
+	H += t.H(i, j)
+	if !thalFinite(H) {
+		return -1, thalInf, false
+	}
+
+	return S, H, t.tm(H, S) > t.tm(t.H(ii, jj), t.S(ii, jj))
+}
+
+// thalHairpin returns the melting temperature, in °C, of the most stable hairpin of
+// a sequence. It's 0 if there isn't one that's stable at the conditions' temperature.
+func thalHairpin(seq string, c thalConditions) float64 {
+	seq = strings.ToUpper(seq)
+	n := len(seq)
+	if n < thalMinHairpinLoop+2 {
+		return 0
+	}
+
+	t := newThal(n, n, c)
+	t.seq = seq
+	t.s1 = thalEncode(seq, false)
+	t.s2 = t.s1
+	t.initS = -0.00000000001
+
+	for i := 1; i <= n; i++ {
+		for j := i; j <= n; j++ {
+			if j-i < thalMinHairpinLoop+1 || !thalPair(t.s1[i], t.s1[j]) {
+				t.set(i, j, -1, thalInf, 0)
+			} else {
+				t.set(i, j, thalMinEntropy, 0, 0)
+			}
+		}
+	}
+	t.fillHairpin()
+	t.fillExterior()
+
+	if !thalFinite(t.hend[n]) || t.nend[n] == 0 {
+		return 0
+	}
+
+	pairs := t.tracebackHairpin()
+	return t.hend[n]/(t.send[n]+float64(pairs-1)*t.salt) + thalAbsoluteZero
+}
+
+// fillHairpin finds the most stable structure closed by each pair (i, j)
+func (t *thal) fillHairpin() {
+	for j := 2; j <= t.len1; j++ {
+		for i := j - thalMinHairpinLoop - 1; i >= 1; i-- {
+			if !thalFinite(t.H(i, j)) {
+				continue
+			}
+
+			t.maxTMHairpin(i, j)
+			t.bulgeInternalHairpins(i, j)
+
+			pairs := t.pairs[t.at(i, j)]
+			S, H := t.hairpinLoop(i, j)
+			if S != t.S(i, j) || H != t.H(i, j) {
+				pairs = 1
+			}
+			if thalFinite(H) {
+				if S < thalMinEntropyCutoff {
+					S, H = thalMinEntropy, 0
+				}
+				t.set(i, j, S, H, pairs)
+			}
+		}
+	}
+}
+
+// maxTMHairpin compares the structure closed by (i, j) with the stack on the one
+// closed by (i+1, j-1)
+func (t *thal) maxTMHairpin(i, j int) {
+	p, s := t.p, t.s1
+
+	S0, H0, n0 := t.S(i, j), t.H(i, j), t.pairs[t.at(i, j)]
+	T0 := t.tm(H0, S0)
+
+	S1, H1 := -1.0, thalInf
+	if thalFinite(H0) {
+		S1 = t.S(i+1, j-1) + p.stackS[s[i]][s[i+1]][s[j]][s[j-1]]
+		H1 = t.H(i+1, j-1) + p.stackH[s[i]][s[i+1]][s[j]][s[j-1]]
+	}
+	T1 := t.tm(H1, S1)
+
+	if S1 < thalMinEntropyCutoff {
+		S1, H1 = thalMinEntropy, 0
+	}
+	if S0 < thalMinEntropyCutoff {
+		S0, H0 = thalMinEntropy, 0
+	}
+
+	if T1 > T0 {
+		t.set(i, j, S1, H1, t.pairs[t.at(i+1, j-1)]+1)
+	} else {
+		t.set(i, j, S0, H0, n0)
+	}
+}
+
+// bulgeInternalHairpins compares the structure closed by (i, j) with those that close
+// a bulge or internal loop around a structure closed by (ii, jj)
+func (t *thal) bulgeInternalHairpins(i, j int) {
+	for d := j - i - 3; d >= thalMinHairpinLoop+1 && d >= j-i-2-thalMaxLoop; d-- {
+		for ii := i + 1; ii < j-d && ii <= t.len1; ii++ {
+			jj := d + ii
+			if !thalFinite(t.H(ii, jj)) || !thalFinite(t.H(i, j)) {
+				continue
+			}
+
+			S, H := t.loopTerms(i, j, ii, jj)
+			S += t.S(ii, jj)
+			H += t.H(ii, jj)
+			if !thalFinite(H) || (H > 0 && S > 0) || t.tm(H, S) <= t.tm(t.H(i, j), t.S(i, j)) {
+				continue
+			}
+
+			if S < thalMinEntropyCutoff {
+				S, H = thalMinEntropy, 0
+			}
+			t.set(i, j, S, H, t.pairs[t.at(ii, jj)]+1)
+		}
+	}
+}
+
+// loopTerms returns the entropy and enthalpy of a bulge or internal loop between
+// the pairs (i, j) and (ii, jj) of a hairpin
+func (t *thal) loopTerms(i, j, ii, jj int) (float64, float64) {
+	p, s := t.p, t.s1
+	l1, l2 := ii-i-1, j-jj-1
+	if l1+l2 > thalMaxLoop {
+		return -1, thalInf
+	}
+	loop := l1 + l2 - 1
+
+	switch {
+	case (l1 == 0 && l2 > 0) || (l2 == 0 && l1 > 0):
+		if l1 == 1 || l2 == 1 {
+			return p.bulgeS[loop] + p.stackS[s[i]][s[ii]][s[j]][s[jj]],
+				p.bulgeH[loop] + p.stackH[s[i]][s[ii]][s[j]][s[jj]]
+		}
+		return p.bulgeS[loop] + atPenaltyS(s[i], s[j]) + atPenaltyS(s[ii], s[jj]),
+			p.bulgeH[loop] + atPenaltyH(s[i], s[j]) + atPenaltyH(s[ii], s[jj])
+	case l1 == 1 && l2 == 1:
+		return p.stackmmS[s[i]][s[i+1]][s[j]][s[j-1]] + p.stackmmS[s[jj]][s[jj+1]][s[ii]][s[ii-1]],
+			p.stackmmH[s[i]][s[i+1]][s[j]][s[j-1]] + p.stackmmH[s[jj]][s[jj+1]][s[ii]][s[ii-1]]
+	default:
+		return p.interiorS[loop] + p.tstackS[s[i]][s[i+1]][s[j]][s[j-1]] + p.tstackS[s[jj]][s[jj+1]][s[ii]][s[ii-1]] +
+				thalLoopAsymmetryS*math.Abs(float64(l1-l2)),
+			p.interiorH[loop] + p.tstackH[s[i]][s[i+1]][s[j]][s[j-1]] + p.tstackH[s[jj]][s[jj+1]][s[ii]][s[ii-1]]
+	}
+}
+
+// hairpinTerms returns the entropy and enthalpy of a hairpin loop closed by (i, j)
+func (t *thal) hairpinTerms(i, j int) (float64, float64) {
+	p, s := t.p, t.s1
+	loop := j - i - 1
+	if loop < thalMinHairpinLoop {
+		return -1, thalInf
+	}
+
+	k := loop - 1
+	if k >= thalMaxLoop {
+		k = thalMaxLoop - 1
+	}
+	S, H := p.hairpinS[k], p.hairpinH[k]
+
+	if loop > 3 {
+		S += p.tstack2S[s[i]][s[i+1]][s[j]][s[j-1]]
+		H += p.tstack2H[s[i]][s[i+1]][s[j]][s[j-1]]
+	} else {
+		S += atPenaltyS(s[i], s[j])
+		H += atPenaltyH(s[i], s[j])
+	}
+
+	if loop == 3 {
+		S += t.p.triloopS[t.seq[i-1:i+4]]
+		H += t.p.triloopH[t.seq[i-1:i+4]]
+	} else if loop == 4 {
+		S += t.p.tetraloopS[t.seq[i-1:i+5]]
+		H += t.p.tetraloopH[t.seq[i-1:i+5]]
+	}
+
+	if !thalFinite(H) {
+		return -1, thalInf
+	}
+	return S, H
+}
+
+// hairpinLoop returns the more stable of the current structure closed by (i, j) and a
+// hairpin loop closed by it
+func (t *thal) hairpinLoop(i, j int) (float64, float64) {
+	S, H := t.hairpinTerms(i, j)
+	if H > 0 && S > 0 && (t.H(i, j) <= 0 || t.S(i, j) <= 0) {
+		S, H = -1, thalInf
+	}
+
+	if t.tm(t.H(i, j), t.S(i, j)) > t.tm(H, S) {
+		return t.S(i, j), t.H(i, j)
+	}
+	return S, H
+}
+
+// fillExterior finds the most stable structures of the exterior loop up to each base
+func (t *thal) fillExterior() {
+	n := t.len1
+	t.hend, t.send, t.nend = make([]float64, n+1), make([]float64, n+1), make([]int, n+1)
+	t.hend[0], t.hend[1], t.send[0], t.send[1] = thalInf, thalInf, -1, -1
+	for i := 2; i <= n; i++ {
+		t.hend[i], t.send[i] = 0, thalMinEntropy
+	}
+
+	for i := 2; i <= n; i++ {
+		bestS, bestH, bestN := t.send[i-1], t.hend[i-1], t.nend[i-1]
+		bestTm := t.tm(bestH, bestS)
+		found := false
+		for _, ends := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			S, H, pairs := t.exteriorEnd(i, ends[0], ends[1])
+			if tm := t.tm(H, S); tm >= bestTm {
+				bestS, bestH, bestN, bestTm = S, H, pairs, tm
+				found = true
+			}
+		}
+
+		// a structure has to be stable at the temperature
+		if found && bestH-t.temp*bestS >= 0 {
+			bestS, bestH, bestN = t.send[i-1], t.hend[i-1], t.nend[i-1]
+		}
+		t.hend[i], t.send[i], t.nend[i] = bestH, bestS, bestN
+	}
+}
+
+// exteriorEnd returns the most stable exterior loop up to base i that ends in a pair
+// (k+1+dangle5, i-dangle3), with dangling ends if dangle5 or dangle3 are 1
+func (t *thal) exteriorEnd(i, dangle5, dangle3 int) (float64, float64, int) {
+	bestS, bestH, bestN := -1.0, thalInf, 0
+	bestTm := math.Inf(-1)
+	for k := 0; k <= i-thalMinHairpinLoop-2-dangle5-dangle3; k++ {
+		x, y, dS, dH := t.exteriorTerms(k, i, dangle5, dangle3)
+
+		S, H := dS+t.S(x, y), dH+t.H(x, y)
+		pairs := t.pairs[t.at(x, y)]
+		if t.tm(t.hend[k], t.send[k]) >= t.tm(0, 0) {
+			S += t.send[k]
+			H += t.hend[k]
+			pairs += t.nend[k]
+		}
+		if !thalFinite(H) || H > 0 || S > 0 {
+			S, H = -1, thalInf
+		}
+
+		if tm := t.tm(H, S); bestTm < tm && S > thalMinEntropyCutoff {
+			bestS, bestH, bestN, bestTm = S, H, pairs, tm
+		}
+	}
+	return bestS, bestH, bestN
+}
+
+// exteriorTerms returns the pair at the end of an exterior loop, see exteriorEnd, and
+// the entropy and enthalpy of its AT penalty and dangling ends
+func (t *thal) exteriorTerms(k, i, dangle5, dangle3 int) (x, y int, S, H float64) {
+	p, s := t.p, t.s1
+	x, y = k+1+dangle5, i-dangle3
+	S, H = atPenaltyS(s[x], s[y]), atPenaltyH(s[x], s[y])
+
+	switch {
+	case dangle5 == 1 && dangle3 == 1:
+		S += p.tstack2S[s[y]][s[i]][s[x]][s[k+1]]
+		H += p.tstack2H[s[y]][s[i]][s[x]][s[k+1]]
+	case dangle5 == 1:
+		S += p.dangle5S[s[y]][s[x]][s[k+1]]
+		H += p.dangle5H[s[y]][s[x]][s[k+1]]
+	case dangle3 == 1:
+		S += p.dangle3S[s[y]][s[x]][s[i]]
+		H += p.dangle3H[s[y]][s[x]][s[i]]
+	}
+	return
+}
+
+// tracebackHairpin returns the number of pairs in the most stable hairpin, for the salt
+// correction. Like thal's traceback, the pair at the 3' end of the sequence isn't counted
+func (t *thal) tracebackHairpin() int {
+	type step struct {
+		i, j     int
+		exterior bool
+	}
+
+	paired := make([]bool, t.len1+1)
+	stack := []step{{t.len1, 0, true}}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		i, j := top.i, top.j
+
+		if top.exterior {
+			for i > 0 && thalEqual(t.send[i], t.send[i-1]) && thalEqual(t.hend[i], t.hend[i-1]) {
+				i--
+			}
+			if i == 0 {
+				continue
+			}
+
+		ends:
+			for _, ends := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				for k := 0; k <= i-thalMinHairpinLoop-2-ends[0]-ends[1]; k++ {
+					x, y, dS, dH := t.exteriorTerms(k, i, ends[0], ends[1])
+					if thalEqual(t.send[i], dS+t.S(x, y)) && thalEqual(t.hend[i], dH+t.H(x, y)) {
+						stack = append(stack, step{x, y, false})
+						break ends
+					}
+					if thalEqual(t.send[i], t.send[k]+dS+t.S(x, y)) && thalEqual(t.hend[i], t.hend[k]+dH+t.H(x, y)) {
+						stack = append(stack, step{x, y, false}, step{k, 0, true})
+						break ends
+					}
+				}
+			}
+			continue
+		}
+
+		paired[i], paired[j] = true, true
+
+		// a stack
+		p, s := t.p, t.s1
+		if thalEqual(t.S(i, j), p.stackS[s[i]][s[i+1]][s[j]][s[j-1]]+t.S(i+1, j-1)) &&
+			thalEqual(t.H(i, j), p.stackH[s[i]][s[i+1]][s[j]][s[j-1]]+t.H(i+1, j-1)) {
+			stack = append(stack, step{i + 1, j - 1, false})
+			continue
+		}
+
+		// a hairpin loop
+		if S, H := t.hairpinTerms(i, j); thalEqual(t.S(i, j), S) && thalEqual(t.H(i, j), H) {
+			continue
+		}
+
+		// or a bulge or internal loop
+	loops:
+		for d := j - i - 3; d >= thalMinHairpinLoop+1 && d >= j-i-2-thalMaxLoop; d-- {
+			for ii := i + 1; ii < j-d; ii++ {
+				jj := d + ii
+				S, H := t.loopTerms(i, j, ii, jj)
+				if thalEqual(t.S(i, j), S+t.S(ii, jj)) && thalEqual(t.H(i, j), H+t.H(ii, jj)) {
+					stack = append(stack, step{ii, jj, false})
+					break loops
+				}
+			}
+		}
+	}
+
+	pairs := 0
+	for k := 1; k < t.len1; k++ {
+		if paired[k] {
+			pairs++
+		}
+	}
+	return pairs / 2
+}
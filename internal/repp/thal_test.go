@@ -0,0 +1,140 @@
+package repp
+
+import (
+	"context"
+	"math"
+	"os/exec"
+	"testing"
+)
+
+func Test_thalHairpin(t *testing.T) {
+	type args struct {
+		seq string
+		c   thalConditions
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantMelt float64
+	}{
+		{
+			"find hairpin at 50 degrees",
+			args{
+				"TGTGCACTCATCATCATCATCGGGGGGGGGGGGTGAACACTATCCCCCCCCCCCCCCA",
+				hairpinConditions,
+			},
+			75.100793,
+		},
+		{
+			"find hairpin with divalent cations",
+			args{
+				"CAACGGCATACGAGTTTGCC",
+				primer3Conditions,
+			},
+			48.636149,
+		},
+		{
+			"return 0 when no hairpin found",
+			args{
+				"TGTGcactcatcatcCCCA",
+				hairpinConditions,
+			},
+			0.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotMelt := thalHairpin(tt.args.seq, tt.args.c); math.Abs(gotMelt-tt.wantMelt) > 0.01 {
+				t.Errorf("thalHairpin() = %v, want %v", gotMelt, tt.wantMelt)
+			}
+		})
+	}
+}
+
+func Test_thalDimer(t *testing.T) {
+	type args struct {
+		seq1 string
+		seq2 string
+		end1 bool
+		c    thalConditions
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantMelt float64
+	}{
+		{
+			"find dimer with the end of a primer",
+			args{
+				"GTAAAACGACGGCCAGT",
+				"ACTGGCCGTCGTTTTAC",
+				true,
+				ntthalConditions,
+			},
+			49.087511,
+		},
+		{
+			"find a weak dimer beneath freezing",
+			args{
+				"GAAGAAACTGAGCAGAGGTCACGT",
+				"GAGATCAACGGGCAGTGACT",
+				true,
+				ntthalConditions,
+			},
+			-17.706198,
+		},
+		{
+			"find dimer of a palindromic primer with itself",
+			args{
+				"AGCTAGCTAGCT",
+				"AGCTAGCTAGCT",
+				false,
+				primer3Conditions,
+			},
+			40.553305,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotMelt := thalDimer(tt.args.seq1, tt.args.seq2, tt.args.end1, tt.args.c); math.Abs(gotMelt-tt.wantMelt) > 0.01 {
+				t.Errorf("thalDimer() = %v, want %v", gotMelt, tt.wantMelt)
+			}
+		})
+	}
+}
+
+// Test_nativeThermo_ntthal checks native hairpins and dimers against ntthal, if it's in PATH
+func Test_nativeThermo_ntthal(t *testing.T) {
+	if _, err := exec.LookPath("ntthal"); err != nil {
+		t.Skip("no ntthal executable in PATH")
+	}
+
+	seqs := []string{
+		"TGTGCACTCATCATCATCATCGGGGGGGGGGGGTGAACACTATCCCCCCCCCCCCCCA",
+		"GTAAAACGACGGCCAGT",
+		"CAGTCAATCTTTCACAAATTTTGT",
+		"TACAGCTTCATGTGCATGTTCT",
+		"GCGCTGGAAACAGTACAGC",
+		"TCCCTCCGTCATGCGACG",
+	}
+
+	for _, seq := range seqs {
+		want, err := primer3Thermo{}.hairpin(context.Background(), seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := (nativeThermo{}).hairpin(context.Background(), seq); math.Abs(got-want) > 0.01 {
+			t.Errorf("hairpin(%s) = %v, ntthal = %v", seq, got, want)
+		}
+
+		for _, ectopic := range seqs {
+			want, err := primer3Thermo{}.dimer(context.Background(), seq, ectopic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := (nativeThermo{}).dimer(context.Background(), seq, ectopic); math.Abs(got-want) > 0.01 {
+				t.Errorf("dimer(%s, %s) = %v, ntthal = %v", seq, ectopic, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package repp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// thermo calculates the melting temperatures of hairpins and dimers and picks primers.
+type thermo interface {
+	// hairpin returns the melting temperature of a sequence's most stable hairpin at 50°C,
+	// the temperature of Gibson Assembly. It's 0 if there's none
+	hairpin(ctx context.Context, seq string) (float64, error)
+
+	// dimer returns the melting temperature of the most stable duplex between the 3' end
+	// of a primer and an ectopic binding site
+	dimer(ctx context.Context, primer, ectopic string) (float64, error)
+
+	// pick picks primers with primer3's input tags and returns primer3's output tags
+	pick(ctx context.Context, in map[string]string) (map[string]string, error)
+}
+
+// newThermo returns the thermodynamics backend in the settings. native is the default.
+func newThermo(conf *config.Config) thermo {
+	if conf != nil && conf.Thermo == "primer3" {
+		return primer3Thermo{}
+	}
+	return nativeThermo{}
+}
+
+// nativeThermo is thermodynamics in Go, without primer3. Hairpins and dimers are
+// from a port of primer3's thal and primers are picked like primer3 with repp's settings.
+type nativeThermo struct{}
+
+// hairpinConditions are ntthal's defaults at the temperature of Gibson Assembly
+var hairpinConditions = thalConditions{mv: 50, dna: 50, temp: 50}
+
+// hairpin finds the most stable hairpin in the sequence.
+func (nativeThermo) hairpin(_ context.Context, seq string) (float64, error) {
+	return thalHairpin(seq, hairpinConditions), nil
+}
+
+// dimer finds the most stable duplex with the end of the primer.
+func (nativeThermo) dimer(_ context.Context, primer, ectopic string) (float64, error) {
+	return thalDimer(primer, ectopic, true, ntthalConditions), nil
+}
+
+// pick picks primers in Go, see pickPrimers.
+func (nativeThermo) pick(_ context.Context, in map[string]string) (map[string]string, error) {
+	return pickPrimers(in), nil
+}
+
+// primer3Thermo is thermodynamics from primer3's ntthal and primer3_core executables.
+type primer3Thermo struct{}
+
+// hairpin runs ntthal to find the most stable hairpin in the sequence.
+func (primer3Thermo) hairpin(ctx context.Context, seq string) (float64, error) {
+	// see nnthal (no parameters) help. within primer3 distribution
+	return ntthal(
+		ctx,
+		"-a", "HAIRPIN",
+		"-r",       // temperature only
+		"-t", "50", // gibson assembly is at 50 degrees
+		"-s1", seq,
+		"-path", config.Primer3Config,
+	)
+}
+
+// dimer runs ntthal to find the most stable duplex with the end of the primer.
+func (primer3Thermo) dimer(ctx context.Context, primer, ectopic string) (float64, error) {
+	return ntthal(
+		ctx,
+		"-a", "END1", // end of primer sequence
+		"-s1", primer,
+		"-s2", ectopic,
+		"-path", config.Primer3Config,
+		"-r", // temperature only
+	)
+}
+
+// pick runs primer3_core on an input file of the tags.
+func (primer3Thermo) pick(ctx context.Context, in map[string]string) (map[string]string, error) {
+	inFile, err := ioutil.TempFile("", "primer3-in-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(inFile.Name())
+
+	outFile, err := ioutil.TempFile("", "primer3-out-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(outFile.Name())
+
+	tags := make(map[string]string)
+	for key, val := range in {
+		tags[key] = val
+	}
+	tags["PRIMER_THERMODYNAMIC_PARAMETERS_PATH"] = config.Primer3Config
+
+	file := primer3Tags(tags) + "=" // required at file's end
+	if _, err := inFile.WriteString(file); err != nil {
+		return nil, fmt.Errorf("failed to write primer3 input file %v: ", err)
+	}
+
+	p3Cmd := exec.CommandContext(
+		ctx,
+		"primer3_core",
+		inFile.Name(),
+		"-output", outFile.Name(),
+		"-strict_tags",
+	)
+
+	// execute primer3 and wait on it to finish
+	if output, err := p3Cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to execute primer3 on input file %s: %s: %v", inFile.Name(), string(output), err)
+	}
+
+	fileBytes, err := ioutil.ReadFile(outFile.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	// read in results into map, they're all 1:1
+	results := make(map[string]string)
+	for _, line := range strings.Split(string(fileBytes), "\n") {
+		keyVal := strings.Split(line, "=")
+		if len(keyVal) > 1 {
+			results[strings.TrimSpace(keyVal[0])] = strings.TrimSpace(keyVal[1])
+		}
+	}
+
+	return results, nil
+}
+
+// ntthal runs ntthal with the args and returns the melting temperature it prints
+func ntthal(ctx context.Context, args ...string) (float64, error) {
+	ntthalCmd := exec.CommandContext(ctx, "ntthal", args...)
+
+	ntthalOut, err := ntthalCmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute ntthal: %s: %v", strings.Join(ntthalCmd.Args, " "), err)
+	}
+
+	temp, err := strconv.ParseFloat(strings.TrimSpace(string(ntthalOut)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ntthal: %s: %v", strings.Join(ntthalCmd.Args, " "), err)
+	}
+
+	return temp, nil
+}
+
+// primer3Tags returns primer3 tags as the lines of a Boulder-IO record, sorted by key
+func primer3Tags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buffer bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&buffer, "%s=%s\n", key, tags[key])
+	}
+	return buffer.String()
+}
@@ -0,0 +1,66 @@
+package repp
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// turnaround returns the estimated number of days from ordering a filled assembly's
+// fragments and primers to a verified plasmid. Everything is ordered at once. Each PCR
+// starts when its template and primers arrive, in rounds of at most conf.PCRMaxParallel
+// reactions, and the fragments are assembled, with Golden Gate assembly if gg is not nil,
+// once they're all ready.
+func turnaround(frags []*Frag, gg *goldenGate, conf *config.Config) float64 {
+	ready := 0.0            // when all the fragments are ready to be assembled
+	assembled := false      // whether the fragments are assembled, rather than used as is
+	var pcrStarts []float64 // when the template and primers of each PCR arrive
+
+	for _, f := range frags {
+		switch f.fragType {
+		case synthetic:
+			assembled = true
+			leadTime := conf.LeadTimeSyntheticFragment
+			if v, picked := conf.SynthVendor(f.Vendor); picked && v.LeadTime > 0 {
+				leadTime = v.LeadTime
+			}
+			ready = math.Max(ready, leadTime)
+		case pcr:
+			assembled = true
+			start := f.leadTime()
+			for _, p := range f.Primers {
+				if p.Inventory == "" {
+					start = math.Max(start, conf.LeadTimePrimer)
+				}
+			}
+			pcrStarts = append(pcrStarts, start)
+		default:
+			ready = math.Max(ready, f.leadTime())
+		}
+	}
+
+	// run the PCRs in rounds, in the order that they can start
+	round := conf.PCRMaxParallel
+	if round < 1 {
+		round = len(pcrStarts)
+	}
+	sort.Float64s(pcrStarts)
+	pcrsDone := 0.0
+	for i := 0; i < len(pcrStarts); i += round {
+		last := i + round - 1
+		if last >= len(pcrStarts) {
+			last = len(pcrStarts) - 1
+		}
+		pcrsDone = math.Max(pcrsDone, pcrStarts[last]) + conf.DaysPCR
+	}
+	ready = math.Max(ready, pcrsDone)
+
+	if !assembled {
+		return ready
+	}
+	if gg != nil {
+		return ready + conf.DaysGoldenGate
+	}
+	return ready + conf.DaysGibson
+}
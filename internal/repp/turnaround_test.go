@@ -0,0 +1,79 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_turnaround(t *testing.T) {
+	c := &config.Config{
+		Databases: []config.Database{
+			config.Database{Name: "addgene", Path: "addgene", LeadTime: 7},
+			config.Database{Name: "lab", Path: "lab", LeadTime: 30, Internal: true},
+		},
+		DaysPCR:                   1,
+		LeadTimePrimer:            2,
+		DaysGibson:                4,
+		DaysGoldenGate:            3,
+		LeadTimeSyntheticFragment: 5,
+	}
+
+	newPrimers := []Primer{Primer{Seq: "ATGC"}, Primer{Seq: "GCAT"}}
+	stockPrimers := []Primer{Primer{Seq: "ATGC", Inventory: "p1"}, Primer{Seq: "GCAT", Inventory: "p2"}}
+	frag := func(db string, t fragType, primers []Primer) *Frag {
+		return &Frag{db: db, fragType: t, Primers: primers, conf: c}
+	}
+
+	tests := []struct {
+		name        string
+		frags       []*Frag
+		gg          *goldenGate
+		maxParallel int
+		want        float64
+	}{
+		{
+			"plasmid from a repository, not assembled",
+			[]*Frag{frag("addgene", circular, nil)},
+			nil,
+			0,
+			7,
+		},
+		{
+			"PCR waits for its template, synthesis in parallel",
+			[]*Frag{frag("addgene", pcr, newPrimers), frag("", synthetic, nil)},
+			nil,
+			0,
+			12, // 7 days to the template, 1 to PCR and 4 to assemble
+		},
+		{
+			"internal template and primers in stock",
+			[]*Frag{frag("lab", pcr, stockPrimers), frag("lab", pcr, stockPrimers)},
+			nil,
+			0,
+			5,
+		},
+		{
+			"Golden Gate assembly",
+			[]*Frag{frag("lab", pcr, stockPrimers), frag("", synthetic, nil)},
+			&goldenGate{},
+			0,
+			8,
+		},
+		{
+			"PCRs in rounds",
+			[]*Frag{frag("lab", pcr, newPrimers), frag("lab", pcr, newPrimers), frag("lab", pcr, newPrimers)},
+			nil,
+			2,
+			8, // primers in 2 days, 2 rounds of PCR and 4 days to assemble
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.PCRMaxParallel = tt.maxParallel
+			if got := turnaround(tt.frags, tt.gg, c); got != tt.want {
+				t.Errorf("turnaround() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
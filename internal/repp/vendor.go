@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+// pickVendor returns the cheapest vendor that can synthesize the sequence. If none can,
+// the error has the reason each can't.
+func pickVendor(seq string, conf *config.Config) (vendor config.Vendor, err error) {
+	var reasons []string
+	cheapest := math.Inf(1)
+	for _, v := range conf.SynthVendors() {
+		if err := checkVendor(v, seq); err != nil {
+			if v.Name != "" {
+				err = fmt.Errorf("%s: %v", v.Name, err)
+			}
+			reasons = append(reasons, err.Error())
+			continue
+		}
+
+		if cost := v.SynthFragmentCost(len(seq)); cost < cheapest {
+			vendor, cheapest = v, cost
+		}
+	}
+
+	if math.IsInf(cheapest, 1) {
+		return config.Vendor{}, errors.New(strings.Join(reasons, "; "))
+	}
+	return vendor, nil
+}
+
+// checkVendor returns why the vendor can't synthesize the sequence, or nil if it can.
+func checkVendor(v config.Vendor, seq string) error {
+	seq = strings.ToUpper(seq)
+
+	if len(seq) < v.MinLength {
+		return fmt.Errorf("it's %dbp, less than the min of %dbp", len(seq), v.MinLength)
+	}
+	if v.MaxLength > 0 && len(seq) > v.MaxLength {
+		return fmt.Errorf("it's %dbp, more than the max of %dbp", len(seq), v.MaxLength)
+	}
+	if v.SynthFragmentCost(len(seq)) >= math.MaxInt32 {
+		return fmt.Errorf("it's %dbp, longer than any of the vendor's prices", len(seq))
+	}
+
+	// GC content of each window, counted as it slides along the sequence
+	if v.MinGC > 0 || v.MaxGC > 0 {
+		window := v.GCWindow
+		if window <= 0 || window > len(seq) {
+			window = len(seq)
+		}
+
+		isGC := func(b byte) int {
+			if b == 'G' || b == 'C' {
+				return 1
+			}
+			return 0
+		}
+		gc := 0
+		for i := 0; i < len(seq); i++ {
+			gc += isGC(seq[i])
+			if i >= window {
+				gc -= isGC(seq[i-window])
+			}
+			if i < window-1 {
+				continue
+			}
+
+			percent := 100 * float64(gc) / float64(window)
+			if v.MinGC > 0 && percent < v.MinGC {
+				return fmt.Errorf("its GC content is %.0f%% in bp %d-%d, less than the min of %.0f%%", percent, i-window+2, i+1, v.MinGC)
+			}
+			if v.MaxGC > 0 && percent > v.MaxGC {
+				return fmt.Errorf("its GC content is %.0f%% in bp %d-%d, more than the max of %.0f%%", percent, i-window+2, i+1, v.MaxGC)
+			}
+		}
+	}
+
+	if v.MaxHomopolymer > 0 {
+		run := 0
+		for i := 0; i < len(seq); i++ {
+			if i > 0 && seq[i] == seq[i-1] {
+				run++
+			} else {
+				run = 1
+			}
+			if run > v.MaxHomopolymer {
+				return fmt.Errorf("it has a run of more than %d %c's at bp %d", v.MaxHomopolymer, seq[i], i+1)
+			}
+		}
+	}
+
+	// a repeat longer than the max has a subsequence one longer than the max twice
+	if k := v.MaxRepeat + 1; v.MaxRepeat > 0 && len(seq) >= k {
+		seen := make(map[string]bool)
+		for i := 0; i+k <= len(seq); i++ {
+			if seen[seq[i:i+k]] {
+				return fmt.Errorf("it has a repeat of more than %dbp: %s", v.MaxRepeat, seq[i:i+k])
+			}
+			seen[seq[i:i+k]] = true
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_checkVendor(t *testing.T) {
+	v := config.Vendor{
+		MinLength:      20,
+		MaxLength:      100,
+		FragmentCost:   map[int]config.SynthCost{100: config.SynthCost{Fixed: true, Cost: 50}},
+		MinGC:          25,
+		MaxGC:          75,
+		GCWindow:       20,
+		MaxHomopolymer: 5,
+		MaxRepeat:      10,
+	}
+
+	tests := []struct {
+		name    string
+		seq     string
+		wantErr string
+	}{
+		{"synthesizable", "ATGCGTACCTAGGATCCGTTAACGGTCAGT", ""},
+		{"too short", "ATGCATGC", "less than the min"},
+		{"too long", strings.Repeat("ATGCATGCAT", 11), "more than the max"},
+		{"low GC window", "ATGCGTACCTAGGATCCGTTAATATATTATATAATATAT", "less than the min of 25%"},
+		{"high GC window", "ATGCGTACCTAGGATCCGTTGCGGCGCCGCGGCCGCGCGC", "more than the max of 75%"},
+		{"homopolymer", "ATGCGTACCTAGGGGGGATCCGTTAACGGTCAGT", "run of more than 5 G's"},
+		{"repeat", "ATGCGTACCTAGGATCCGTTAATGCGTACCTAGCA", "repeat of more than 10bp: ATGCGTACCTA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVendor(v, tt.seq)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkVendor() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkVendor() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_pickVendor(t *testing.T) {
+	c := &config.Config{
+		Vendors: []config.Vendor{
+			config.Vendor{
+				Name:         "idt",
+				MaxLength:    3000,
+				FragmentCost: map[int]config.SynthCost{3000: config.SynthCost{Fixed: true, Cost: 100}},
+			},
+			config.Vendor{
+				Name:           "twist",
+				MaxLength:      1800,
+				MaxHomopolymer: 5,
+				FragmentCost:   map[int]config.SynthCost{1800: config.SynthCost{Fixed: true, Cost: 50}},
+			},
+		},
+	}
+
+	seq := "ATGCGTACCTAGGATCCGTTAACGGTCAGT"
+	if v, err := pickVendor(seq, c); err != nil || v.Name != "twist" {
+		t.Errorf("pickVendor() = %+v, %v, want the cheaper twist", v, err)
+	}
+
+	homopolymer := "ATGCGTACCTAGGGGGGATCCGTTAACGGTCAGT"
+	if v, err := pickVendor(homopolymer, c); err != nil || v.Name != "idt" {
+		t.Errorf("pickVendor() = %+v, %v, want idt for a homopolymer twist can't make", v, err)
+	}
+
+	_, err := pickVendor(strings.Repeat("ATGCATGCAT", 301), c)
+	if err == nil || !strings.Contains(err.Error(), "idt: ") || !strings.Contains(err.Error(), "; twist: ") {
+		t.Errorf("pickVendor() error = %v, want why each vendor can't make it", err)
+	}
+}
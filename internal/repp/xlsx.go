@@ -0,0 +1,124 @@
+package repp
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// xlsxSheet is a named sheet of rows in an Excel workbook.
+type xlsxSheet struct {
+	name string
+	rows [][]string
+}
+
+// xlsxPart is a file in the zip archive of an Excel workbook.
+type xlsxPart struct {
+	name     string
+	contents []byte
+}
+
+const (
+	xlsxMain          = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPackageRels   = "http://schemas.openxmlformats.org/package/2006/relationships"
+	xlsxContentTypes  = "http://schemas.openxmlformats.org/package/2006/content-types"
+	xmlHeader         = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+// writeXLSX writes the sheets to an Excel (Office Open XML) workbook. Every cell
+// is an inline string, so the workbook has no shared strings or styles.
+func writeXLSX(filename string, sheets []xlsxSheet) error {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+
+	var types, workbook, rels bytes.Buffer
+	types.WriteString(xmlHeader + `<Types xmlns="` + xlsxContentTypes + `">`)
+	types.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	types.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	types.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xmlHeader + `<workbook xmlns="` + xlsxMain + `" xmlns:r="` + xlsxRelationships + `"><sheets>`)
+	rels.WriteString(xmlHeader + `<Relationships xmlns="` + xlsxPackageRels + `">`)
+
+	var worksheets []xlsxPart
+	for i, sheet := range sheets {
+		id := strconv.Itoa(i + 1)
+		types.WriteString(`<Override PartName="/xl/worksheets/sheet` + id + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		workbook.WriteString(`<sheet name="` + xmlEscape(sheet.name) + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + id + `" Type="` + xlsxRelationships + `/worksheet" Target="worksheets/sheet` + id + `.xml"/>`)
+		worksheets = append(worksheets, xlsxPart{"xl/worksheets/sheet" + id + ".xml", xlsxWorksheet(sheet.rows)})
+	}
+
+	types.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	// the content types come first, as some readers expect
+	parts := append([]xlsxPart{
+		xlsxPart{"[Content_Types].xml", types.Bytes()},
+		xlsxPart{"_rels/.rels", []byte(xmlHeader + `<Relationships xmlns="` + xlsxPackageRels + `"><Relationship Id="rId1" Type="` + xlsxRelationships + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`)},
+		xlsxPart{"xl/workbook.xml", workbook.Bytes()},
+		xlsxPart{"xl/_rels/workbook.xml.rels", rels.Bytes()},
+	}, worksheets...)
+
+	for _, part := range parts {
+		w, err := z.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to write %s to %s: %v", part.name, filename, err)
+		}
+		if _, err := w.Write(part.contents); err != nil {
+			return fmt.Errorf("failed to write %s to %s: %v", part.name, filename, err)
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0666); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+
+	return nil
+}
+
+// xlsxWorksheet returns the XML of a worksheet with the rows.
+func xlsxWorksheet(rows [][]string) []byte {
+	var sheet bytes.Buffer
+	sheet.WriteString(xmlHeader + `<worksheet xmlns="` + xlsxMain + `"><sheetData>`)
+	for r, row := range rows {
+		ref := strconv.Itoa(r + 1)
+		sheet.WriteString(`<row r="` + ref + `">`)
+		for c, value := range row {
+			if value == "" {
+				continue
+			}
+			sheet.WriteString(`<c r="` + xlsxColumn(c) + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			sheet.WriteString(xmlEscape(value))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	return sheet.Bytes()
+}
+
+// xlsxColumn returns the letters of a 0-indexed column, eg 0 to A and 26 to AA.
+func xlsxColumn(c int) string {
+	column := ""
+	for c++; c > 0; c = (c - 1) / 26 {
+		column = string(rune('A'+(c-1)%26)) + column
+	}
+	return column
+}
+
+// xmlEscape escapes text for an XML element or attribute.
+func xmlEscape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jjtimmons/repp/pkg/repp"
+)
+
+const (
+	// maxQueued is the number of jobs that can wait to run before new ones are refused
+	maxQueued = 256
+
+	// jobTTL is how long a finished job is kept for its status to be read
+	jobTTL = time.Hour
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	// Queued jobs are waiting for an earlier job to finish
+	Queued Status = "queued"
+
+	// Running jobs are being designed
+	Running Status = "running"
+
+	// Done jobs finished with an Output
+	Done Status = "done"
+
+	// Failed jobs finished with an error
+	Failed Status = "failed"
+
+	// Cancelled jobs were cancelled before they finished
+	Cancelled Status = "cancelled"
+)
+
+// Job is a design that's run in the background. Its Output is set once it's Done.
+type Job struct {
+	// ID of the job, for reading its status
+	ID string `json:"id"`
+
+	// Kind of design: sequence, features or fragments
+	Kind string `json:"kind"`
+
+	// Status of the job
+	Status Status `json:"status"`
+
+	// Error of a Failed job
+	Error string `json:"error,omitempty"`
+
+	// ErrorCode is the kind of the Error, eg "missing_database", if it has one
+	ErrorCode string `json:"errorCode,omitempty"`
+
+	// Output of a Done job or, with the best solutions found before it stopped, of a
+	// Cancelled job
+	Output *repp.Output `json:"output,omitempty"`
+
+	// Progress of a Running job
+	Progress *repp.Progress `json:"progress,omitempty"`
+
+	// Submitted is when the job was queued
+	Submitted time.Time `json:"submitted"`
+
+	// Started is when the job started running
+	Started *time.Time `json:"started,omitempty"`
+
+	// Finished is when the job finished or was cancelled
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// design runs a design to completion or until its context is cancelled.
+type design func(ctx context.Context) (*repp.Output, error)
+
+// job is a Job with the design it runs.
+type job struct {
+	Job
+
+	// run is the job's design
+	run design
+
+	// ctx is the context of the design, cancelled with cancel
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobQueue runs jobs, one at a time, in the order they're submitted. Each design
+// already fills its assemblies in parallel, across every CPU.
+type jobQueue struct {
+	// mu guards the jobs and their statuses
+	mu sync.Mutex
+
+	// jobs by their ID
+	jobs map[string]*job
+
+	// queue of jobs waiting to run
+	queue chan *job
+
+	// ttl is how long a finished job is kept
+	ttl time.Duration
+
+	// ctx is the parent of every job's context, cancelled on close
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newJobQueue returns a jobQueue and starts running its jobs.
+func newJobQueue() *jobQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &jobQueue{
+		jobs:   make(map[string]*job),
+		queue:  make(chan *job, maxQueued),
+		ttl:    jobTTL,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go q.work()
+
+	return q
+}
+
+// submit queues a design and returns its job.
+func (q *jobQueue) submit(kind string, run design) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	j := &job{
+		Job:    Job{ID: id, Kind: kind, Status: Queued, Submitted: time.Now()},
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.prune()
+
+	select {
+	case q.queue <- j:
+		q.jobs[id] = j
+		return j.Job, nil
+	default:
+		cancel()
+		return Job{}, fmt.Errorf("failed to queue the design: %d designs are already queued", maxQueued)
+	}
+}
+
+// get returns the job with the ID passed.
+func (q *jobQueue) get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+
+	return j.Job, true
+}
+
+// cancelJob stops the job with the ID passed. A queued job is cancelled immediately.
+// A running job is cancelled once its design returns.
+func (q *jobQueue) cancelJob(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+
+	if j.Status == Queued {
+		j.finish(Cancelled)
+	}
+	j.cancel()
+
+	return j.Job, true
+}
+
+// close cancels every queued and running job and stops the queue.
+func (q *jobQueue) close() {
+	q.cancel()
+}
+
+// work runs the queued jobs until the queue is closed.
+func (q *jobQueue) work() {
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case j := <-q.queue:
+			q.runJob(j)
+		}
+	}
+}
+
+// runJob runs a job's design and records its result.
+func (q *jobQueue) runJob(j *job) {
+	q.mu.Lock()
+	if j.Status != Queued {
+		q.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	j.Status = Running
+	j.Started = &started
+	q.mu.Unlock()
+
+	ctx := repp.WithProgress(j.ctx, func(p repp.Progress) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		j.Progress = &p
+	})
+	out, err := j.run(ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j.Progress = nil
+	switch {
+	case j.ctx.Err() != nil:
+		j.Output = out
+		j.finish(Cancelled)
+	case err != nil:
+		j.Error = err.Error()
+		j.ErrorCode = repp.ErrorCode(err)
+		j.finish(Failed)
+	default:
+		j.Output = out
+		j.finish(Done)
+	}
+	j.cancel()
+}
+
+// prune removes jobs that finished more than the ttl ago. The lock must be held.
+func (q *jobQueue) prune() {
+	cutoff := time.Now().Add(-q.ttl)
+	for id, j := range q.jobs {
+		if j.Finished != nil && j.Finished.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// finish sets the job's final status.
+func (j *job) finish(status Status) {
+	finished := time.Now()
+	j.Status = status
+	j.Finished = &finished
+}
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to create a job ID: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
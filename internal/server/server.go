@@ -0,0 +1,376 @@
+// Package server serves repp's designs as JSON over HTTP.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjtimmons/repp/pkg/repp"
+)
+
+// maxBodySize is the largest request body accepted, in bytes
+const maxBodySize = 32 << 20
+
+// Server is an http.Handler for repp's endpoints. Request bodies are the requests of
+// pkg/repp, eg SequenceRequest, and mirror the flags of the commands. Outputs are only
+// returned in responses, so requests with "out" or "explain" paths are rejected. An "in"
+// path is read from the server's input directory, and rejected if it doesn't have one.
+// Features, backbones and fragment names can be files in the input directory too, but
+// not other paths on the server, and "dbs" are passed by name.
+//
+//	POST   /make/sequence  SequenceRequest      202, Job
+//	POST   /make/features  FeaturesRequest      202, Job
+//	POST   /make/fragments FragmentsRequest     202, Job
+//	GET    /jobs/{id}                           200, Job, with its Output once it's done
+//	DELETE /jobs/{id}                           200, Job, after cancelling it
+//	POST   /annotate       AnnotateRequest      200, AnnotateResponse
+//	POST   /find/fragment  FindFragmentRequest  200, FoundFragment
+//	POST   /find/sequence  FindSequenceRequest  200, []Match
+//	GET    /find/feature?name=                  200, feature names to sequences
+//	GET    /find/enzyme?name=                   200, enzyme names to recognition sequences
+//
+// Designs, from the make endpoints, can take minutes so they're run as jobs in the
+// background. Errors are returned as {"error": "..."}.
+type Server struct {
+	// designer makes the designs, with the configuration and databases loaded at startup
+	designer *repp.Designer
+
+	// inputDir is the directory that "in" paths are read from. Empty if they're rejected
+	inputDir string
+
+	// jobs are the designs from the make endpoints
+	jobs *jobQueue
+
+	// mux routes requests to their handler
+	mux *http.ServeMux
+}
+
+// New returns a Server that makes designs with designer. Requests' "in" paths are
+// read from inputDir, and rejected if it's empty.
+func New(designer *repp.Designer, inputDir string) *Server {
+	s := &Server{
+		designer: designer,
+		inputDir: inputDir,
+		jobs:     newJobQueue(),
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/make/sequence", s.makeSequence)
+	s.mux.HandleFunc("/make/features", s.makeFeatures)
+	s.mux.HandleFunc("/make/fragments", s.makeFragments)
+	s.mux.HandleFunc("/jobs/", s.job)
+	s.mux.HandleFunc("/annotate", s.annotate)
+	s.mux.HandleFunc("/find/fragment", s.findFragment)
+	s.mux.HandleFunc("/find/sequence", s.findSequence)
+	s.mux.HandleFunc("/find/feature", s.findFeature)
+	s.mux.HandleFunc("/find/enzyme", s.findEnzyme)
+
+	return s
+}
+
+// ServeHTTP routes a request to its endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close cancels all queued and running jobs.
+func (s *Server) Close() {
+	s.jobs.close()
+}
+
+// makeSequence queues a design of a plasmid from its target sequence.
+func (s *Server) makeSequence(w http.ResponseWriter, r *http.Request) {
+	var req repp.SequenceRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !s.paths(w, &req.In, req.Out, req.Explain) ||
+		!s.names(w, &req.Backbone) || !databases(w, req.Dbs) {
+		return
+	}
+
+	s.submit(w, "sequence", func(ctx context.Context) (*repp.Output, error) {
+		return s.designer.Sequence(ctx, req)
+	})
+}
+
+// makeFeatures queues a design of a plasmid from its features.
+func (s *Server) makeFeatures(w http.ResponseWriter, r *http.Request) {
+	var req repp.FeaturesRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !s.paths(w, &req.In, req.Out, req.Explain) ||
+		!s.names(w, append(pointers(req.Features), &req.Backbone)...) || !databases(w, req.Dbs) {
+		return
+	}
+
+	s.submit(w, "features", func(ctx context.Context) (*repp.Output, error) {
+		return s.designer.Features(ctx, req)
+	})
+}
+
+// makeFragments queues a design of a plasmid from its fragments.
+func (s *Server) makeFragments(w http.ResponseWriter, r *http.Request) {
+	var req repp.FragmentsRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !s.paths(w, &req.In, req.Out) ||
+		!s.names(w, &req.Backbone) || !databases(w, req.Dbs) {
+		return
+	}
+
+	s.submit(w, "fragments", func(ctx context.Context) (*repp.Output, error) {
+		return s.designer.Fragments(ctx, req)
+	})
+}
+
+// submit queues a design and responds with its job.
+func (s *Server) submit(w http.ResponseWriter, kind string, run design) {
+	j, err := s.jobs.submit(kind, run)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, j)
+}
+
+// job responds with the status of a job or cancels it.
+func (s *Server) job(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet, http.MethodDelete) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+
+	var j Job
+	var ok bool
+	if r.Method == http.MethodDelete {
+		j, ok = s.jobs.cancelJob(id)
+	} else {
+		j, ok = s.jobs.get(id)
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("failed to find job %s", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, j)
+}
+
+// annotate responds with the features in a plasmid.
+func (s *Server) annotate(w http.ResponseWriter, r *http.Request) {
+	var req repp.AnnotateRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !s.paths(w, &req.In, req.Out) || !databases(w, req.Dbs) {
+		return
+	}
+
+	annotated, err := s.designer.Annotate(r.Context(), req)
+	respond(w, annotated, err)
+}
+
+// findFragment responds with a fragment, found by its name in the dbs.
+func (s *Server) findFragment(w http.ResponseWriter, r *http.Request) {
+	var req repp.FindFragmentRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !s.names(w, &req.Name) || !databases(w, req.Dbs) {
+		return
+	}
+
+	frag, err := s.designer.FindFragment(r.Context(), req)
+	respond(w, frag, err)
+}
+
+// findSequence responds with a sequence's BLAST matches in the dbs.
+func (s *Server) findSequence(w http.ResponseWriter, r *http.Request) {
+	var req repp.FindSequenceRequest
+	if !allow(w, r, http.MethodPost) || !decode(w, r, &req) || !databases(w, req.Dbs) {
+		return
+	}
+
+	matches, err := s.designer.FindSequence(r.Context(), req)
+	respond(w, matches, err)
+}
+
+// findFeature responds with the features with names similar to the name parameter.
+func (s *Server) findFeature(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	features, err := s.designer.FindFeature(r.URL.Query().Get("name"))
+	respond(w, features, err)
+}
+
+// findEnzyme responds with the enzymes with names similar to the name parameter.
+func (s *Server) findEnzyme(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	enzymes, err := s.designer.FindEnzyme(r.URL.Query().Get("name"))
+	respond(w, enzymes, err)
+}
+
+// allow checks that the request's method is one of those passed. If it isn't,
+// it responds with a 405 and returns false.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// decode reads the request's JSON body into req. If it can't, it responds
+// with a 400 and returns false.
+func decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// paths checks the paths of a request. Outputs are returned in the response, so any
+// outputs to write are rejected. The input path, if any, is resolved in the server's
+// input directory and can't leave it. If a path is rejected, it responds with a 400
+// and returns false.
+func (s *Server) paths(w http.ResponseWriter, in *string, outputs ...string) bool {
+	for _, out := range outputs {
+		if out != "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to accept output path %s, outputs are returned in the response", out))
+			return false
+		}
+	}
+
+	if *in == "" {
+		return true
+	}
+	if s.inputDir == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to accept input path %s, the server has no input directory so sequences have to be in the request", *in))
+		return false
+	}
+
+	resolved, err := resolve(s.inputDir, *in)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	*in = resolved
+	return true
+}
+
+// names checks values that name features, database entries or backbones. Each is read
+// as a file if one is at its path, so a value that's a file in the server's input
+// directory is replaced by its path there. A value that's another local path is
+// rejected with a 400 and it returns false.
+func (s *Server) names(w http.ResponseWriter, values ...*string) bool {
+	for _, value := range values {
+		if *value == "" {
+			continue
+		}
+
+		if s.inputDir != "" {
+			if resolved, err := resolve(s.inputDir, *value); err == nil {
+				*value = resolved
+				continue
+			}
+		}
+
+		if local(*value) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to accept %s, it's a local path outside the server's input directory", *value))
+			return false
+		}
+	}
+	return true
+}
+
+// databases checks that the dbs of a request are names, eg of databases in the settings'
+// registry, rather than paths on the server. If one isn't, it responds with a 400 and
+// returns false.
+func databases(w http.ResponseWriter, dbs []string) bool {
+	for _, db := range dbs {
+		for _, name := range strings.Split(db, ",") {
+			if local(strings.TrimSpace(name)) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("failed to accept database %s, databases are passed by name", name))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// local returns whether a value is a path on the server: it has a path separator, is
+// relative to the working directory like "..", or is a file in the working directory.
+func local(value string) bool {
+	if value == "" {
+		return false
+	}
+	if strings.ContainsAny(value, `/\`) || value == "." || value == ".." {
+		return true
+	}
+
+	_, err := os.Stat(value)
+	return err == nil
+}
+
+// pointers returns pointers to each of the values, so they can be replaced.
+func pointers(values []string) (ptrs []*string) {
+	for i := range values {
+		ptrs = append(ptrs, &values[i])
+	}
+	return
+}
+
+// resolve returns the path of a file in the directory. Symlinks are followed and the
+// path can't leave the directory.
+func resolve(dir, path string) (string, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the input directory %s: %v", dir, err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.Clean("/"+path)))
+	if err != nil {
+		return "", fmt.Errorf("failed to find input file %s: %v", path, err)
+	}
+
+	if rel, err := filepath.Rel(dir, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("failed to accept input path %s, it's outside the input directory", path)
+	}
+	return resolved, nil
+}
+
+// respond writes the result of a request, or its error, as JSON.
+func respond(w http.ResponseWriter, result interface{}, err error) {
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writeError writes an error, and its code if it has one, as JSON.
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := map[string]string{"error": err.Error()}
+	if code := repp.ErrorCode(err); code != "" {
+		body["errorCode"] = code
+	}
+	writeJSON(w, status, body)
+}
+
+// writeJSON writes v as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/pkg/repp"
+)
+
+func newTestServer(t *testing.T) *Server {
+	designer, err := repp.NewDesigner(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(designer, "")
+	t.Cleanup(s.Close)
+	return s
+}
+
+// wait polls a job until it's finished.
+func wait(t *testing.T, q *jobQueue, id string) Job {
+	for i := 0; i < 500; i++ {
+		if j, _ := q.get(id); j.Finished != nil {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("job %s never finished", id)
+	return Job{}
+}
+
+func Test_jobQueue(t *testing.T) {
+	q := newJobQueue()
+	defer q.close()
+
+	// a job that runs until it's cancelled, blocking those after it
+	started := make(chan struct{})
+	blocking, _ := q.submit("sequence", func(ctx context.Context) (*repp.Output, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	done, _ := q.submit("features", func(ctx context.Context) (*repp.Output, error) {
+		return &repp.Output{Target: "target"}, nil
+	})
+	failed, _ := q.submit("fragments", func(ctx context.Context) (*repp.Output, error) {
+		return nil, errors.New("failed to design")
+	})
+	queued, _ := q.submit("sequence", func(ctx context.Context) (*repp.Output, error) {
+		t.Error("cancelled job was run")
+		return nil, nil
+	})
+
+	<-started
+	if j, _ := q.get(blocking.ID); j.Status != Running {
+		t.Errorf("job status = %s, want %s", j.Status, Running)
+	}
+	if j, _ := q.get(done.ID); j.Status != Queued {
+		t.Errorf("job status = %s, want %s", j.Status, Queued)
+	}
+
+	if j, _ := q.cancelJob(queued.ID); j.Status != Cancelled {
+		t.Errorf("cancelled queued job status = %s, want %s", j.Status, Cancelled)
+	}
+	q.cancelJob(blocking.ID)
+
+	if j := wait(t, q, blocking.ID); j.Status != Cancelled {
+		t.Errorf("cancelled running job status = %s, want %s", j.Status, Cancelled)
+	}
+	if j := wait(t, q, done.ID); j.Status != Done || j.Output == nil || j.Output.Target != "target" {
+		t.Errorf("job = %+v, want it done with its output", j)
+	}
+	if j := wait(t, q, failed.ID); j.Status != Failed || j.Error != "failed to design" {
+		t.Errorf("job = %+v, want it failed with its error", j)
+	}
+
+	// finished jobs are removed after the ttl
+	q.ttl = 0
+	q.submit("sequence", func(ctx context.Context) (*repp.Output, error) { return nil, nil })
+	if _, ok := q.get(done.ID); ok {
+		t.Error("finished job was not pruned")
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"queue a design",
+			http.MethodPost,
+			"/make/sequence",
+			`{"seq": "ATGC"}`,
+			http.StatusAccepted,
+			`"status":"queued"`,
+		},
+		{
+			"reject a malformed request",
+			http.MethodPost,
+			"/make/features",
+			`{"features": "p10 promoter"}`,
+			http.StatusBadRequest,
+			`"error":"failed to parse request`,
+		},
+		{
+			"reject unknown fields",
+			http.MethodPost,
+			"/make/fragments",
+			`{"fragment": []}`,
+			http.StatusBadRequest,
+			`unknown field`,
+		},
+		{
+			"reject the wrong method",
+			http.MethodGet,
+			"/make/sequence",
+			"",
+			http.StatusMethodNotAllowed,
+			`"error":"method GET not allowed"`,
+		},
+		{
+			"unknown job",
+			http.MethodGet,
+			"/jobs/123",
+			"",
+			http.StatusNotFound,
+			`"error":"failed to find job 123"`,
+		},
+		{
+			"find an enzyme",
+			http.MethodGet,
+			"/find/enzyme?name=BsaI",
+			"",
+			http.StatusOK,
+			`{"BsaI":"GGTCTCN^NNNN_N"}`,
+		},
+		{
+			"fail to annotate without a sequence",
+			http.MethodPost,
+			"/annotate",
+			`{}`,
+			http.StatusUnprocessableEntity,
+			`"error":"must pass a file with a plasmid sequence`,
+		},
+		{
+			"reject an output path",
+			http.MethodPost,
+			"/make/sequence",
+			`{"seq": "ATGC", "out": "/etc/cron.d/repp"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept output path /etc/cron.d/repp`,
+		},
+		{
+			"reject an explanation path",
+			http.MethodPost,
+			"/make/features",
+			`{"features": ["p10"], "explain": "/tmp/explain.html"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept output path /tmp/explain.html`,
+		},
+		{
+			"reject an input path without an input directory",
+			http.MethodPost,
+			"/annotate",
+			`{"in": "/etc/passwd"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept input path /etc/passwd`,
+		},
+		{
+			"reject a relative feature path",
+			http.MethodPost,
+			"/make/features",
+			`{"features": ["p10", "../secret.fa"]}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept ../secret.fa`,
+		},
+		{
+			"reject an absolute feature path",
+			http.MethodPost,
+			"/make/features",
+			`{"features": ["/etc/passwd"]}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept /etc/passwd`,
+		},
+		{
+			"reject a relative backbone path",
+			http.MethodPost,
+			"/make/sequence",
+			`{"seq": "ATGC", "backbone": "../backbone.fa", "enzymes": ["EcoRI"]}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept ../backbone.fa`,
+		},
+		{
+			"reject an absolute backbone path",
+			http.MethodPost,
+			"/make/fragments",
+			`{"backbone": "/etc/passwd"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept /etc/passwd`,
+		},
+		{
+			"reject a relative fragment path",
+			http.MethodPost,
+			"/find/fragment",
+			`{"name": "../secret.fa"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept ../secret.fa`,
+		},
+		{
+			"reject an absolute fragment path",
+			http.MethodPost,
+			"/find/fragment",
+			`{"name": "/etc/passwd"}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept /etc/passwd`,
+		},
+		{
+			"reject a relative database path",
+			http.MethodPost,
+			"/find/sequence",
+			`{"seq": "ATGC", "dbs": ["addgene", "../db"]}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept database ../db`,
+		},
+		{
+			"reject an absolute database path",
+			http.MethodPost,
+			"/make/sequence",
+			`{"seq": "ATGC", "dbs": ["/data/blast/db"]}`,
+			http.StatusBadRequest,
+			`"error":"failed to accept database /data/blast/db`,
+		},
+		{
+			"fail to find a fragment without a name",
+			http.MethodPost,
+			"/find/fragment",
+			`{}`,
+			http.StatusUnprocessableEntity,
+			`"error":"no fragment name passed"`,
+		},
+		{
+			"fail to find a sequence without a sequence",
+			http.MethodPost,
+			"/find/sequence",
+			`{}`,
+			http.StatusUnprocessableEntity,
+			`"error":"no sequence passed"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("%s %s body = %s, want it to contain %s", tt.method, tt.target, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServer_job(t *testing.T) {
+	s := newTestServer(t)
+
+	// a design without fragment databases fails
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/make/sequence", strings.NewReader(`{"seq": "ATGC"}`)))
+
+	var j Job
+	if err := json.NewDecoder(w.Body).Decode(&j); err != nil {
+		t.Fatal(err)
+	}
+	if location := w.Header().Get("Location"); location != "/jobs/"+j.ID {
+		t.Errorf("Location = %s, want /jobs/%s", location, j.ID)
+	}
+
+	wait(t, s.jobs, j.ID)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+j.ID, nil))
+	if err := json.NewDecoder(w.Body).Decode(&j); err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != Failed || !strings.Contains(j.Error, "no fragment databases chosen") {
+		t.Errorf("job = %+v, want it failed without fragment databases", j)
+	}
+}
+
+func Test_resolve(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "plasmid.fa"), []byte(">plasmid\nATGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(outside, "secret.fa"), []byte(">secret\nATGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.fa"), filepath.Join(dir, "link.fa")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"plasmid.fa", false},
+		{"/plasmid.fa", false},
+		{"../" + filepath.Base(outside) + "/secret.fa", true},
+		{"link.fa", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := resolve(dir, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() = %s, %v, wantErr %v", got, err, tt.wantErr)
+			}
+			if err == nil && filepath.Base(got) != "plasmid.fa" {
+				t.Errorf("resolve() = %s, want plasmid.fa in %s", got, dir)
+			}
+		})
+	}
+}
+
+func TestServer_names(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "backbone.fa"), []byte(">backbone\nATGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{inputDir: dir}
+
+	// a file in the input directory is read from there, names are left as is
+	backbone, name := "backbone.fa", "pSB1C3"
+	w := httptest.NewRecorder()
+	if !s.names(w, &backbone, &name) {
+		t.Fatalf("names() rejected %s", w.Body.String())
+	}
+	if want, _ := filepath.EvalSymlinks(filepath.Join(dir, "backbone.fa")); backbone != want || name != "pSB1C3" {
+		t.Errorf("names() = %s, %s, want %s, pSB1C3", backbone, name, want)
+	}
+
+	for _, path := range []string{"../secret.fa", "/etc/passwd"} {
+		if s.names(httptest.NewRecorder(), &path) {
+			t.Errorf("names() accepted %s", path)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package repp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// AnnotateRequest is a request to annotate a plasmid's features.
+type AnnotateRequest struct {
+	Databases
+
+	// In is a path to a FASTA, Genbank or SBOL file with the plasmid. Unused if Seq is set
+	In string `json:"in,omitempty"`
+
+	// Name of the plasmid
+	Name string `json:"name,omitempty"`
+
+	// Seq is the plasmid's sequence
+	Seq string `json:"seq,omitempty"`
+
+	// Out is a path to write the annotated plasmid to, as Genbank. Not written if empty
+	Out string `json:"out,omitempty"`
+
+	// Exclude are keywords for excluding features
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Identity is the %-identity threshold for BLAST matches. 96 if unset
+	Identity int `json:"identity,omitempty"`
+
+	// Cull is whether to remove features enclosed in others. True if unset
+	Cull *bool `json:"cull,omitempty"`
+}
+
+// AnnotateResponse is an annotated plasmid.
+type AnnotateResponse struct {
+	// Name of the plasmid
+	Name string `json:"name"`
+
+	// Seq is the plasmid's sequence
+	Seq string `json:"seq"`
+
+	// Features found in the plasmid
+	Features []Feature `json:"features"`
+}
+
+// Annotate finds features in a plasmid. Features are from the features database
+// unless dbs are requested, in which case their entries are used as features.
+func Annotate(ctx context.Context, req AnnotateRequest) (*AnnotateResponse, error) {
+	return (&Designer{}).Annotate(ctx, req)
+}
+
+// Annotate finds features in a plasmid, with the designer's configuration. See Annotate.
+func (d *Designer) Annotate(ctx context.Context, req AnnotateRequest) (*AnnotateResponse, error) {
+	if req.Seq == "" && req.In == "" {
+		return nil, fmt.Errorf("must pass a file with a plasmid sequence or the plasmid sequence")
+	}
+
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.In = req.In
+	params.Filters = req.Exclude
+	params.Identity = identity(req.Identity, 96)
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	name, seq := req.Name, req.Seq
+	if seq == "" && req.In != "" {
+		plasmids, err := repp.Read(req.In)
+		if err != nil {
+			return nil, err
+		}
+		name, seq = plasmids[0].ID, plasmids[0].Seq
+	}
+
+	cull := req.Cull == nil || *req.Cull
+	features, err := repp.Annotate(ctx, name, seq, flags, cull)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Out != "" {
+		if err := repp.WriteGenbank(req.Out, name, seq, features); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", req.Out, err)
+		}
+	}
+
+	return &AnnotateResponse{Name: name, Seq: seq, Features: features}, nil
+}
@@ -0,0 +1,11 @@
+package repp
+
+import (
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// ClearCache removes the BLAST, primer and off-target results cached between designs
+// and returns the number of bytes removed.
+func ClearCache() (int64, error) {
+	return repp.ClearCache()
+}
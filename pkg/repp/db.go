@@ -0,0 +1,40 @@
+package repp
+
+import (
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// DB is a BLAST database of fragments made from FASTA and Genbank files. It's
+// registered by its name, so it can be passed to Databases.Dbs without its path.
+type DB = repp.DB
+
+// DBEntry is a single plasmid or fragment in a DB.
+type DBEntry = repp.DBEntry
+
+// CreateDB makes a new database, with the name, from the sequences in FASTA and
+// Genbank files. It needs makeblastdb, unless it's only used by the native aligner.
+func CreateDB(name string, files []string) (*DB, error) {
+	return repp.CreateDB(name, files)
+}
+
+// AddToDB adds the sequences in FASTA and Genbank files to a database. Entries with
+// the same ID as one in the database replace it.
+func AddToDB(name string, files []string) (*DB, error) {
+	return repp.AddToDB(name, files)
+}
+
+// RemoveFromDB removes entries, by ID, from a database or, if no IDs are passed, the
+// whole database.
+func RemoveFromDB(name string, ids []string) (*DB, error) {
+	return repp.RemoveFromDB(name, ids)
+}
+
+// ListDBs returns the databases made with CreateDB.
+func ListDBs() ([]*DB, error) {
+	return repp.ListDBs()
+}
+
+// ReadDB returns a database made with CreateDB by its name.
+func ReadDB(name string) (*DB, error) {
+	return repp.ReadDB(name)
+}
@@ -0,0 +1,83 @@
+package repp
+
+import (
+	"fmt"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// Designer makes designs with a configuration and features and enzymes databases
+// that are loaded once, rather than on every design. It's for long-running services.
+//
+// The zero Designer uses the default configuration and reads the databases when
+// they're needed, like the package-level functions.
+type Designer struct {
+	// conf is the configuration of designs. The default configuration if nil
+	conf *config.Config
+
+	// features is the features database. Read when needed if nil
+	features *repp.FeatureDB
+
+	// enzymes is the enzymes database. Read when needed if nil
+	enzymes *repp.EnzymeDB
+}
+
+// NewDesigner reads the features and enzymes databases for designs with conf.
+// The default configuration is used if conf is nil.
+func NewDesigner(conf *config.Config) (*Designer, error) {
+	if conf == nil {
+		var err error
+		if conf, err = config.Load(""); err != nil {
+			return nil, err
+		}
+	}
+
+	features, err := repp.LoadFeatureDB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the features database: %v", err)
+	}
+
+	enzymes, err := repp.LoadEnzymeDB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the enzymes database: %v", err)
+	}
+
+	return &Designer{conf: conf, features: features, enzymes: enzymes}, nil
+}
+
+// FindFeature returns the features, mapped to their sequences, with names similar
+// to name. All features are returned if name is empty.
+func (d *Designer) FindFeature(name string) (map[string]string, error) {
+	db := d.features
+	if db == nil {
+		var err error
+		if db, err = repp.LoadFeatureDB(); err != nil {
+			return nil, err
+		}
+	}
+
+	return db.Find(name), nil
+}
+
+// FindEnzyme returns the enzymes, mapped to their recognition sequences, with names
+// similar to name. All enzymes are returned if name is empty.
+func (d *Designer) FindEnzyme(name string) (map[string]string, error) {
+	db := d.enzymes
+	if db == nil {
+		var err error
+		if db, err = repp.LoadEnzymeDB(); err != nil {
+			return nil, err
+		}
+	}
+
+	return db.Find(name), nil
+}
+
+// params returns the parameters shared by all designs with the designer's databases.
+func (d *Designer) params(dbs Databases) repp.Params {
+	params := dbs.params()
+	params.FeatureDB = d.features
+	params.EnzymeDB = d.enzymes
+	return params
+}
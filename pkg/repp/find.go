@@ -0,0 +1,127 @@
+package repp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// FindFragmentRequest is a request to find a fragment by its name.
+type FindFragmentRequest struct {
+	Databases
+
+	// Name of the fragment in the dbs
+	Name string `json:"name"`
+}
+
+// FindSequenceRequest is a request to find a sequence's matches in the dbs.
+type FindSequenceRequest struct {
+	Databases
+
+	// Seq to find matches for
+	Seq string `json:"seq"`
+
+	// Exclude are keywords for excluding fragments
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Identity is the %-identity threshold for BLAST matches. 100 if unset
+	Identity int `json:"identity,omitempty"`
+}
+
+// FoundFragment is a fragment found in one of the dbs.
+type FoundFragment struct {
+	// ID of the fragment
+	ID string `json:"id"`
+
+	// DB that the fragment was found in
+	DB string `json:"db"`
+
+	// URL of the fragment, eg its Addgene page
+	URL string `json:"url,omitempty"`
+
+	// Seq of the fragment
+	Seq string `json:"seq"`
+}
+
+// FindFragment finds a fragment, by its name, in the dbs.
+func FindFragment(ctx context.Context, req FindFragmentRequest) (*FoundFragment, error) {
+	return (&Designer{}).FindFragment(ctx, req)
+}
+
+// FindFragment finds a fragment, by its name, in the dbs of the designer's configuration.
+func (d *Designer) FindFragment(ctx context.Context, req FindFragmentRequest) (*FoundFragment, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("no fragment name passed")
+	}
+
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := repp.ParseParams(ctx, d.params(req.Databases), conf)
+	if err != nil {
+		return nil, err
+	}
+
+	frag, db, err := repp.FindFragment(ctx, req.Name, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FoundFragment{ID: req.Name, DB: db, URL: frag.URL, Seq: frag.Seq}, nil
+}
+
+// FindSequence finds a sequence's BLAST matches in the dbs, largest first.
+func FindSequence(ctx context.Context, req FindSequenceRequest) ([]Match, error) {
+	return (&Designer{}).FindSequence(ctx, req)
+}
+
+// FindSequence finds a sequence's BLAST matches in the dbs of the designer's
+// configuration, largest first.
+func (d *Designer) FindSequence(ctx context.Context, req FindSequenceRequest) ([]Match, error) {
+	if req.Seq == "" {
+		return nil, fmt.Errorf("no sequence passed")
+	}
+
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.Filters = req.Exclude
+	params.Identity = identity(req.Identity, 100)
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return repp.FindSequence(ctx, req.Seq, flags)
+}
+
+// FindFeature returns the features, mapped to their sequences, with names similar
+// to name. All features are returned if name is empty.
+func FindFeature(name string) (map[string]string, error) {
+	return (&Designer{}).FindFeature(name)
+}
+
+// FindEnzyme returns the enzymes, mapped to their recognition sequences, with names
+// similar to name. All enzymes are returned if name is empty.
+func FindEnzyme(name string) (map[string]string, error) {
+	return (&Designer{}).FindEnzyme(name)
+}
+
+// FindPrimer returns the primers in the primer inventory, mapped to their sequences,
+// with names similar to name or with name as their sequence. All primers are returned
+// if name is empty.
+func FindPrimer(name string) (map[string]string, error) {
+	db, err := repp.LoadPrimerDB()
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Find(name), nil
+}
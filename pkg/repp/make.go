@@ -0,0 +1,331 @@
+package repp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// SequenceRequest is a request to build a plasmid from its target sequence.
+type SequenceRequest struct {
+	Databases
+
+	// In is a path to a FASTA, Genbank or SBOL file with the target sequence. Unused if Seq is set
+	In string `json:"in,omitempty"`
+
+	// Name of the target sequence
+	Name string `json:"name,omitempty"`
+
+	// Seq is the target sequence
+	Seq string `json:"seq,omitempty"`
+
+	// Out is a path to write the output to. Not written if empty
+	Out string `json:"out,omitempty"`
+
+	// Format of the output: json, genbank or sbol. In genbank, each solution is written
+	// to its own file beside Out, eg "plasmid.output.1.gb". In sbol, an SBOL3 document
+	// is written as JSON-LD beside Out, eg "plasmid.output.jsonld". JSON if unset
+	Format string `json:"format,omitempty"`
+
+	// Explain is a path to write a report of the candidate assemblies to, with the reason
+	// each failed or was pruned. HTML if it ends with .html and JSON otherwise. Not written if empty
+	Explain string `json:"explain,omitempty"`
+
+	// Backbone to insert the fragments into. An entry in one of the dbs or a local file
+	Backbone string `json:"backbone,omitempty"`
+
+	// Enzymes to linearize the backbone with
+	Enzymes []string `json:"enzymes,omitempty"`
+
+	// Exclude are keywords for excluding fragments
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Identity is the %-identity threshold for BLAST matches. 98 if unset
+	Identity int `json:"identity,omitempty"`
+
+	// Method of assembly: gibson or goldengate. Gibson if unset
+	Method string `json:"method,omitempty"`
+
+	// Objective that the solutions with each fragment count are ranked by: cost, synthesis,
+	// pcr, repositories, inventory, turnaround or weighted. The objective in the settings if unset
+	Objective string `json:"objective,omitempty"`
+
+	// Solutions is the number of solutions to return for each fragment count. 1 if unset
+	Solutions int `json:"solutions,omitempty"`
+}
+
+// SequenceBatchRequest is a request to build a plasmid for each of many target sequences.
+type SequenceBatchRequest struct {
+	Databases
+
+	// In is a path to a multi-FASTA file with the target sequences. Unused if Targets is set
+	In string `json:"in,omitempty"`
+
+	// Targets are the target sequences
+	Targets []Fragment `json:"targets,omitempty"`
+
+	// Out is a path to write the JSON batch to. Not written if empty
+	Out string `json:"out,omitempty"`
+
+	// Format of the targets' outputs: json, genbank or sbol. In genbank, each target's
+	// chosen solution is written to its own file beside Out. In sbol, each target's output
+	// is written to its own SBOL3 document beside Out. JSON if unset
+	Format string `json:"format,omitempty"`
+
+	// Split is whether to write each target's output to its own file beside Out
+	Split bool `json:"split,omitempty"`
+
+	// Parallel is the number of targets to design at a time. 1 if unset
+	Parallel int `json:"parallel,omitempty"`
+
+	// Backbone to insert the fragments into. An entry in one of the dbs or a local file
+	Backbone string `json:"backbone,omitempty"`
+
+	// Enzymes to linearize the backbone with
+	Enzymes []string `json:"enzymes,omitempty"`
+
+	// Exclude are keywords for excluding fragments
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Identity is the %-identity threshold for BLAST matches. 98 if unset
+	Identity int `json:"identity,omitempty"`
+
+	// Method of assembly: gibson or goldengate. Gibson if unset
+	Method string `json:"method,omitempty"`
+
+	// Objective that the solutions with each fragment count are ranked by: cost, synthesis,
+	// pcr, repositories, inventory, turnaround or weighted. The objective in the settings if unset
+	Objective string `json:"objective,omitempty"`
+
+	// Solutions is the number of solutions to return for each fragment count. 1 if unset
+	Solutions int `json:"solutions,omitempty"`
+}
+
+// FeaturesRequest is a request to build a plasmid from its constituent features.
+type FeaturesRequest struct {
+	Databases
+
+	// Features are names of features in the features database or entries in the dbs.
+	// A feature is reverse complemented with a ":rev" suffix, eg "mEGFP:rev"
+	Features []string `json:"features,omitempty"`
+
+	// In is a path to a FASTA, Genbank or SBOL file with the features. Unused if Features is set
+	In string `json:"in,omitempty"`
+
+	// Out is a path to write the output to. Not written if empty
+	Out string `json:"out,omitempty"`
+
+	// Format of the output: json, genbank or sbol. In genbank, each solution is written
+	// to its own file beside Out, eg "plasmid.output.1.gb". In sbol, an SBOL3 document
+	// is written as JSON-LD beside Out, eg "plasmid.output.jsonld". JSON if unset
+	Format string `json:"format,omitempty"`
+
+	// Explain is a path to write a report of the candidate assemblies to, with the reason
+	// each failed or was pruned. HTML if it ends with .html and JSON otherwise. Not written if empty
+	Explain string `json:"explain,omitempty"`
+
+	// Backbone to insert the fragments into. An entry in one of the dbs or a local file
+	Backbone string `json:"backbone,omitempty"`
+
+	// Enzymes to linearize the backbone with
+	Enzymes []string `json:"enzymes,omitempty"`
+
+	// Exclude are keywords for excluding fragments
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Identity is the %-identity threshold for BLAST matches. 98 if unset
+	Identity int `json:"identity,omitempty"`
+
+	// Objective that the solutions with each fragment count are ranked by: cost, synthesis,
+	// pcr, repositories, inventory, turnaround or weighted. The objective in the settings if unset
+	Objective string `json:"objective,omitempty"`
+
+	// Solutions is the number of solutions to return for each fragment count. 1 if unset
+	Solutions int `json:"solutions,omitempty"`
+}
+
+// FragmentsRequest is a request to build a plasmid from its constituent fragments.
+type FragmentsRequest struct {
+	Databases
+
+	// Fragments to assemble, in order
+	Fragments []Fragment `json:"fragments,omitempty"`
+
+	// In is a path to a FASTA, Genbank or SBOL file with the fragments. Unused if Fragments is set
+	In string `json:"in,omitempty"`
+
+	// Out is a path to write the output to. Not written if empty
+	Out string `json:"out,omitempty"`
+
+	// Format of the output: json, genbank or sbol. In genbank, each solution is written
+	// to its own file beside Out, eg "plasmid.output.1.gb". In sbol, an SBOL3 document
+	// is written as JSON-LD beside Out, eg "plasmid.output.jsonld". JSON if unset
+	Format string `json:"format,omitempty"`
+
+	// Backbone to insert the fragments into. An entry in one of the dbs or a local file
+	Backbone string `json:"backbone,omitempty"`
+
+	// Enzymes to linearize the backbone with
+	Enzymes []string `json:"enzymes,omitempty"`
+}
+
+// Sequence builds a plasmid from its target sequence using a combination of existing
+// and synthesized fragments. The default configuration is used if conf is nil.
+//
+// If ctx is cancelled or times out while assemblies are being filled, the best solutions
+// found so far are written and returned along with ctx's error.
+func Sequence(ctx context.Context, req SequenceRequest, conf *config.Config) (*Output, error) {
+	return (&Designer{conf: conf}).Sequence(ctx, req)
+}
+
+// SequenceBatch builds a plasmid for each target sequence. The targets are planned
+// together as a library: each target's solution is chosen to minimize the cost of the
+// library, where plasmids, primers, PCR products and synthetic fragments used by multiple
+// targets are paid for once. Targets that fail to design are recorded in the Batch rather
+// than returned as errors. The default configuration is used if conf is nil.
+//
+// If ctx is cancelled or times out, the targets designed so far, with the best solutions
+// found for them, are written and returned along with ctx's error.
+func SequenceBatch(ctx context.Context, req SequenceBatchRequest, conf *config.Config) (*Batch, error) {
+	return (&Designer{conf: conf}).SequenceBatch(ctx, req)
+}
+
+// Features builds a plasmid with all the features requested. The default configuration
+// is used if conf is nil. Like Sequence, the best solutions found so far are returned if
+// ctx is cancelled or times out while assemblies are being filled.
+func Features(ctx context.Context, req FeaturesRequest, conf *config.Config) (*Output, error) {
+	return (&Designer{conf: conf}).Features(ctx, req)
+}
+
+// Fragments prepares a list of fragments for assembly. Fragments are checked for
+// homology with their neighbors and prepared for assembly with PCR. The default
+// configuration is used if conf is nil.
+func Fragments(ctx context.Context, req FragmentsRequest, conf *config.Config) (*Output, error) {
+	return (&Designer{conf: conf}).Fragments(ctx, req)
+}
+
+// Sequence builds a plasmid from its target sequence. See Sequence.
+func (d *Designer) Sequence(ctx context.Context, req SequenceRequest) (*Output, error) {
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.In = req.In
+	params.Out = req.Out
+	params.Format = req.Format
+	params.Explain = req.Explain
+	params.Backbone = req.Backbone
+	params.Enzymes = req.Enzymes
+	params.Filters = req.Exclude
+	params.Identity = identity(req.Identity, 98)
+	params.Method = req.Method
+	params.Objective = req.Objective
+	params.Solutions = req.Solutions
+	if req.Seq != "" {
+		name := req.Name
+		if name == "" {
+			name = "target_sequence"
+		}
+		params.Frags = frags([]Fragment{Fragment{ID: name, Seq: req.Seq}})
+	}
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return repp.Sequence(ctx, flags, conf)
+}
+
+// SequenceBatch builds a plasmid for each target sequence. See SequenceBatch.
+func (d *Designer) SequenceBatch(ctx context.Context, req SequenceBatchRequest) (*Batch, error) {
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.In = req.In
+	params.Out = req.Out
+	params.Format = req.Format
+	params.Frags = frags(req.Targets)
+	params.Backbone = req.Backbone
+	params.Enzymes = req.Enzymes
+	params.Filters = req.Exclude
+	params.Identity = identity(req.Identity, 98)
+	params.Method = req.Method
+	params.Objective = req.Objective
+	params.Solutions = req.Solutions
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return repp.SequenceBatch(ctx, flags, conf, req.Parallel, req.Split)
+}
+
+// Features builds a plasmid with all the features requested. See Features.
+func (d *Designer) Features(ctx context.Context, req FeaturesRequest) (*Output, error) {
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.In = req.In
+	if len(req.Features) > 0 {
+		params.In = strings.Join(req.Features, ",")
+	}
+	params.Out = req.Out
+	params.Format = req.Format
+	params.Explain = req.Explain
+	params.Backbone = req.Backbone
+	params.Enzymes = req.Enzymes
+	params.Filters = req.Exclude
+	params.Identity = identity(req.Identity, 98)
+	params.Objective = req.Objective
+	params.Solutions = req.Solutions
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return repp.Features(ctx, flags, conf)
+}
+
+// Fragments prepares a list of fragments for assembly. See Fragments.
+func (d *Designer) Fragments(ctx context.Context, req FragmentsRequest) (*Output, error) {
+	conf, err := setup(ctx, d.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.params(req.Databases)
+	params.In = req.In
+	params.Out = req.Out
+	params.Format = req.Format
+	params.Frags = frags(req.Fragments)
+	params.Backbone = req.Backbone
+	params.Enzymes = req.Enzymes
+
+	flags, err := repp.ParseParams(ctx, params, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return repp.Fragments(ctx, flags, conf)
+}
+
+// identity returns the requested %-identity or the default if it's unset.
+func identity(requested, fallback int) int {
+	if requested == 0 {
+		return fallback
+	}
+	return requested
+}
@@ -0,0 +1,90 @@
+package repp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// Order is the primers and synthetic fragments to order for designs.
+type Order = repp.Order
+
+// OrderItem is a primer or synthetic fragment to order.
+type OrderItem = repp.OrderItem
+
+// OrderRequest is a request to write vendor order sheets for the primers and
+// synthetic fragments of a design, or of a batch of designs.
+type OrderRequest struct {
+	// In is a path to the JSON output of a design or a batch
+	In string `json:"in"`
+
+	// Solution to order, 1-indexed. Unused for batches, which order each target's
+	// chosen solution. 1 if unset
+	Solution int `json:"solution,omitempty"`
+
+	// Out is the stem of the order sheets' paths, eg "plasmid.order" to
+	// "plasmid.order.primers.csv". Beside In if unset
+	Out string `json:"out,omitempty"`
+
+	// Format of the order sheets: csv or xlsx. The configured format if unset
+	Format string `json:"format,omitempty"`
+
+	// Layout of the order sheets: tube or plate. The configured layout if unset
+	Layout string `json:"layout,omitempty"`
+}
+
+// OrderResponse is the order and the sheets it was written to.
+type OrderResponse struct {
+	// Primers to order
+	Primers []OrderItem `json:"primers"`
+
+	// Synthetics are the synthetic fragments to order
+	Synthetics []OrderItem `json:"synthetics"`
+
+	// Files are the order sheets
+	Files []string `json:"files"`
+}
+
+// WriteOrder writes order sheets for the primers and synthetic fragments of a design's
+// solution or of every target in a batch. Identical sequences are ordered once and
+// items are named with the configured templates. The default configuration is used
+// if conf is nil.
+func WriteOrder(ctx context.Context, req OrderRequest, conf *config.Config) (*OrderResponse, error) {
+	conf, err := setup(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	solution := req.Solution
+	if solution == 0 {
+		solution = 1
+	}
+
+	order, err := repp.ReadOrder(req.In, solution, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	stem := req.Out
+	if stem == "" {
+		stem = strings.TrimSuffix(req.In, filepath.Ext(req.In)) + ".order"
+	}
+	format := req.Format
+	if format == "" {
+		format = conf.OrderFormat
+	}
+	layout := req.Layout
+	if layout == "" {
+		layout = conf.OrderLayout
+	}
+
+	files, err := order.Write(stem, format, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderResponse{Primers: order.Primers, Synthetics: order.Synthetics, Files: files}, nil
+}
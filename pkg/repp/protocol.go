@@ -0,0 +1,26 @@
+package repp
+
+import (
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// Protocol is a step-by-step protocol for making a solution at the bench.
+type Protocol = repp.Protocol
+
+// ProtocolStep is a single step of a Protocol, eg running the PCR reactions.
+type ProtocolStep = repp.ProtocolStep
+
+// ProtocolItem is a single thing ordered, or reaction run, in a ProtocolStep.
+type ProtocolItem = repp.ProtocolItem
+
+// ReadOutput reads the output of a design from a JSON file.
+func ReadOutput(path string) (*Output, error) {
+	return repp.ReadOutput(path)
+}
+
+// NewProtocol returns the protocol for making one of an output's solutions: the
+// plasmids, primers and synthetic fragments to order, the digestion of the backbone,
+// the PCR reactions and the assembly. The solution is 1-indexed.
+func NewProtocol(out *Output, solution int) (*Protocol, error) {
+	return repp.NewProtocol(out, solution)
+}
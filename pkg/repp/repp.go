@@ -0,0 +1,165 @@
+// Package repp is the public API for designing plasmids with repp.
+//
+// Each design takes a context, for cancellation, a request that mirrors the flags of its
+// command line counterpart, and an optional configuration. Errors are returned rather than
+// logged, so the package can be embedded in long-running services. Warnings are logged to
+// stderr unless another logger is set with SetLogger.
+package repp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/jjtimmons/repp/config"
+	"github.com/jjtimmons/repp/internal/repp"
+)
+
+// Output is a struct containing design results for the assembly.
+type Output = repp.Output
+
+// Solution is a single solution to build up the target plasmid.
+type Solution = repp.Solution
+
+// Scores of a solution by each objective, other than its cost.
+type Scores = repp.Scores
+
+// Batch is the designs of many target sequences.
+type Batch = repp.Batch
+
+// BatchTarget is the design of a single target in a Batch.
+type BatchTarget = repp.BatchTarget
+
+// SharedItem is a plasmid, primer, PCR product or synthetic fragment used by
+// multiple targets in a Batch.
+type SharedItem = repp.SharedItem
+
+// Frag is a single building block stretch of DNA for assembly.
+type Frag = repp.Frag
+
+// Primer is a single Primer used to create a PCR fragment.
+type Primer = repp.Primer
+
+// Backbone is a linearized backbone that fragments are inserted into.
+type Backbone = repp.Backbone
+
+// Feature is a feature found in a sequence by Annotate.
+type Feature = repp.Feature
+
+// Match is a BLAST match between a query sequence and an entry in a database.
+type Match = repp.Match
+
+// Progress is how far a design has gotten.
+type Progress = repp.Progress
+
+// WithProgress returns a context that reports the progress of the design it's passed
+// to. report is called, one at a time, after each change. The progress of the designs
+// in a batch isn't reported.
+func WithProgress(ctx context.Context, report func(Progress)) context.Context {
+	return repp.WithProgress(ctx, report)
+}
+
+// Explanation is a report of every candidate assembly considered in a design and why
+// it was, or wasn't, one of the solutions.
+type Explanation = repp.Explanation
+
+// Candidate is an assembly considered in a design.
+type Candidate = repp.Candidate
+
+// MissingDatabaseError is returned when a fragment database isn't in the filesystem.
+type MissingDatabaseError = repp.MissingDatabaseError
+
+// NoCutsiteError is returned when a backbone isn't cut by the enzymes it's linearized with.
+type NoCutsiteError = repp.NoCutsiteError
+
+// PrimerError is returned when primers can't be made to PCR a fragment.
+type PrimerError = repp.PrimerError
+
+// SynthesisError is returned when no vendor can synthesize a fragment.
+type SynthesisError = repp.SynthesisError
+
+// UnknownEnzymeError is returned for an enzyme name that isn't in the enzymes database.
+type UnknownEnzymeError = repp.UnknownEnzymeError
+
+// ErrorCode returns a short code for the kind of an error, eg "missing_database" or
+// "timeout". It's empty for errors without a kind.
+func ErrorCode(err error) string {
+	return repp.ErrorCode(err)
+}
+
+// NewLogger returns a logger that writes to w in a format, text or json.
+// Debug messages are logged if verbose.
+func NewLogger(w io.Writer, format string, verbose bool) (*slog.Logger, error) {
+	return repp.NewLogger(w, format, verbose)
+}
+
+// SetLogger sets the logger that warnings and debug messages are logged to. It's a
+// text logger to stderr by default. It shouldn't be called while designs are running.
+func SetLogger(l *slog.Logger) {
+	repp.SetLogger(l)
+}
+
+// Databases are the sources of building fragments in a design.
+type Databases struct {
+	// Dbs are the names of databases in the settings' registry, paths to local
+	// BLAST databases or the names of those made with CreateDB
+	Dbs []string `json:"dbs,omitempty"`
+
+	// Addgene is whether to use the Addgene repository, same as "addgene" in Dbs
+	Addgene bool `json:"addgene,omitempty"`
+
+	// IGEM is whether to use the iGEM repository, same as "igem" in Dbs
+	IGEM bool `json:"igem,omitempty"`
+
+	// DNASU is whether to use the DNASU repository, same as "dnasu" in Dbs
+	DNASU bool `json:"dnasu,omitempty"`
+}
+
+// Fragment is an input sequence.
+type Fragment struct {
+	// ID of the fragment, eg its name in a FASTA file
+	ID string `json:"id"`
+
+	// Seq of the fragment
+	Seq string `json:"seq"`
+
+	// Circular is whether the fragment is a plasmid
+	Circular bool `json:"circular,omitempty"`
+}
+
+// params returns the parameters shared by all designs.
+func (d Databases) params() repp.Params {
+	dbs := append([]string{}, d.Dbs...)
+	if d.Addgene {
+		dbs = append(dbs, "addgene")
+	}
+	if d.IGEM {
+		dbs = append(dbs, "igem")
+	}
+	if d.DNASU {
+		dbs = append(dbs, "dnasu")
+	}
+	return repp.Params{Dbs: dbs}
+}
+
+// frags converts input fragments to Frags.
+func frags(fragments []Fragment) (frags []*Frag) {
+	for _, f := range fragments {
+		frags = append(frags, repp.NewFrag(f.ID, f.Seq, f.Circular))
+	}
+	return
+}
+
+// setup checks that the context hasn't been cancelled and loads the default
+// configuration if one wasn't passed.
+func setup(ctx context.Context, conf *config.Config) (*config.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if conf != nil {
+		return conf, nil
+	}
+
+	return config.Load("")
+}
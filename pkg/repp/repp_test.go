@@ -0,0 +1,46 @@
+package repp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jjtimmons/repp/config"
+)
+
+func Test_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Sequence(ctx, SequenceRequest{Seq: "ATGC"}, &config.Config{}); err != context.Canceled {
+		t.Errorf("Sequence() err = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := Annotate(ctx, AnnotateRequest{Seq: "ATGC"}); err != context.Canceled {
+		t.Errorf("Annotate() err = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := FindSequence(ctx, FindSequenceRequest{Seq: "ATGC"}); err != context.Canceled {
+		t.Errorf("FindSequence() err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func Test_frags(t *testing.T) {
+	got := frags([]Fragment{
+		Fragment{ID: "a", Seq: "atgc"},
+		Fragment{ID: "b", Seq: "GGCC", Circular: true},
+	})
+
+	if len(got) != 2 || got[0].Seq != "ATGC" || got[1].ID != "b" {
+		t.Errorf("frags() = %v", got)
+	}
+}
+
+func Test_identity(t *testing.T) {
+	if got := identity(0, 98); got != 98 {
+		t.Errorf("identity() = %d, want 98", got)
+	}
+
+	if got := identity(90, 98); got != 90 {
+		t.Errorf("identity() = %d, want 90", got)
+	}
+}